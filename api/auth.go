@@ -151,6 +151,20 @@ func (s *Server) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// deleted employees keep their refresh token until it expires, but may not use it
+	active, statusCode, errResp := s.store.IsEmployeeActive(&uid)
+	if errResp.Error != "" {
+		if err := jsonutil.Render(w, statusCode, errResp); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if !active {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
 	sessionToken, errResp := s.session.CreateSessionToken(&uid)
 	if errResp.Error != "" {
 		if err := jsonutil.Render(w, http.StatusInternalServerError, err); err != nil {
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"github.com/Tus1688/library-management-api/bookutil"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+)
+
+func (s *Server) GetAuthor(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, errParam := pageParams(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	filter := types.AuthorFilter{Search: r.URL.Query().Get("search"), Cursor: cursor, Limit: limit}
+
+	authors, statusCode, err := s.store.GetAuthor(&filter)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, authors)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) GetAuthorById(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	author, statusCode, err := s.store.GetAuthorById(&id)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", etag(author.Version))
+	errResp := jsonutil.Render(w, http.StatusOK, author)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) GetAuthorBooks(w http.ResponseWriter, r *http.Request) {
+	filter, errParam := bookFilter(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// an unknown author is a 404 rather than an empty page
+	filter.AuthorId = chi.URLParam(r, "id")
+	if _, statusCode, err := s.store.GetAuthorById(&filter.AuthorId); err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	books, statusCode, err := s.store.GetBook(&filter)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	withCovers(books.Items)
+
+	errResp := jsonutil.Render(w, http.StatusOK, books)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) CreateAuthor(w http.ResponseWriter, r *http.Request) {
+	var req types.CreateAuthor
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := bookutil.NormalizeAuthor(&req); err != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: err.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	authorId, statusCode, err := s.store.CreateAuthor(&req)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusCreated, authorId)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) UpdateAuthor(w http.ResponseWriter, r *http.Request) {
+	var req types.UpdateAuthor
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := bookutil.NormalizeAuthor(&req.CreateAuthor); err != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: err.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	version, statusCode, err := s.store.UpdateAuthor(&req, ifMatchVersion(r))
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) DeleteAuthor(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	statusCode, err := s.store.DeleteAuthor(&id)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
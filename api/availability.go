@@ -0,0 +1,70 @@
+package api
+
+import (
+	"github.com/Tus1688/library-management-api/availability"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+// availabilityMaxAge lets browsers and proxies in front of the catalog keep an answer as long as the server does
+var availabilityMaxAge = "public, max-age=" + strconv.Itoa(int(availability.CacheTTL.Seconds()))
+
+func (s *Server) GetBookAvailability(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	books, statusCode, err := s.availability.Get([]string{id})
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if len(books) == 0 {
+		if err := jsonutil.Render(w, http.StatusNotFound, types.Err{Error: "book not found"}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", availabilityMaxAge)
+	errResp := jsonutil.Render(w, http.StatusOK, books[0])
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) GetAvailability(w http.ResponseWriter, r *http.Request) {
+	ids := r.URL.Query()["id"]
+	if len(ids) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if len(ids) > availability.MaxBooks {
+		errMsg := types.Err{Error: "at most " + strconv.Itoa(availability.MaxBooks) + " books at a time"}
+		if err := jsonutil.Render(w, http.StatusBadRequest, errMsg); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	books, statusCode, err := s.availability.Get(ids)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", availabilityMaxAge)
+	errResp := jsonutil.Render(w, http.StatusOK, books)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -1,18 +1,27 @@
 package api
 
 import (
+	"errors"
+	"github.com/Tus1688/library-management-api/bookutil"
+	"github.com/Tus1688/library-management-api/dataio"
 	"github.com/Tus1688/library-management-api/jsonutil"
 	"github.com/Tus1688/library-management-api/types"
+	"github.com/go-chi/chi/v5"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 func (s *Server) GetBook(w http.ResponseWriter, r *http.Request) {
-	searchQuery := r.URL.Query().Get("search")
-	lastId, _ := strconv.Atoi(r.URL.Query().Get("last_id"))
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	filter, errParam := bookFilter(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
 
-	books, statusCode, err := s.store.GetBook(&searchQuery, &lastId, &limit)
+	books, statusCode, err := s.store.GetBook(&filter)
 	if err.Error != "" {
 		err := jsonutil.Render(w, statusCode, err)
 		if err != nil {
@@ -20,6 +29,7 @@ func (s *Server) GetBook(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	withCovers(books.Items)
 
 	errResp := jsonutil.Render(w, http.StatusOK, books)
 	if errResp != nil {
@@ -27,6 +37,75 @@ func (s *Server) GetBook(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) GetDashboardBook(w http.ResponseWriter, r *http.Request) {
+	filter, errParam := bookFilter(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	filter.IncludeDeleted, _ = strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+
+	books, statusCode, err := s.store.GetBook(&filter)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	withCovers(books.Items)
+
+	errResp := jsonutil.Render(w, http.StatusOK, books)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) GetBookById(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	book, statusCode, err := s.store.GetBookById(&id, false)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// the public catalog must not leak who is currently borrowing the book
+	book.CurrentBooking = nil
+
+	book.Cover = coverUrls(book.CoverKey)
+	w.Header().Set("ETag", etag(book.Version))
+	errResp := jsonutil.Render(w, http.StatusOK, book)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) GetBookDetail(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	book, statusCode, err := s.store.GetBookById(&id, true)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	book.Cover = coverUrls(book.CoverKey)
+	w.Header().Set("ETag", etag(book.Version))
+	errResp := jsonutil.Render(w, http.StatusOK, book)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
 func (s *Server) CreateBook(w http.ResponseWriter, r *http.Request) {
 	var req types.CreateBook
 	if err := jsonutil.ShouldBind(r, &req); err != nil {
@@ -34,6 +113,31 @@ func (s *Server) CreateBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := bookutil.NormalizeMetadata(&req.BookMetadata); err != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: err.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	authors, errAuthors := bookutil.NormalizeBookAuthors(req.Authors)
+	if errAuthors != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errAuthors.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	req.Authors = authors
+
+	categoryIds, tags, errClassification := bookutil.NormalizeClassification(req.CategoryIds, req.Tags)
+	if errClassification != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errClassification.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	req.CategoryIds, req.Tags = categoryIds, tags
+
 	bookId, statusCode, err := s.store.CreateBook(&req)
 	if err.Error != "" {
 		err := jsonutil.Render(w, statusCode, err)
@@ -75,7 +179,84 @@ func (s *Server) UpdateBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	statusCode, err := s.store.UpdateBook(&req)
+	if err := bookutil.NormalizeMetadata(&req.BookMetadata); err != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: err.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	authors, errAuthors := bookutil.NormalizeBookAuthors(req.Authors)
+	if errAuthors != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errAuthors.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	req.Authors = authors
+
+	categoryIds, tags, errClassification := bookutil.NormalizeClassification(req.CategoryIds, req.Tags)
+	if errClassification != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errClassification.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	req.CategoryIds, req.Tags = categoryIds, tags
+
+	version, statusCode, err := s.store.UpdateBook(&req, ifMatchVersion(r))
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) PatchBook(w http.ResponseWriter, r *http.Request) {
+	if !isMergePatch(r) {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req types.PatchBook
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: err.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := bookutil.NormalizePatch(&req); err != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: err.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+
+	version, statusCode, err := s.store.PatchBook(&id, ifMatchVersion(r), &req)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) RestoreBook(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	statusCode, err := s.store.RestoreBook(&id)
 	if err.Error != "" {
 		err := jsonutil.Render(w, statusCode, err)
 		if err != nil {
@@ -86,3 +267,96 @@ func (s *Server) UpdateBook(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+func (s *Server) ImportBooks(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = dataio.FormatFromContentType(r.Header.Get("Content-Type"))
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	r.Body = http.MaxBytesReader(w, r.Body, dataio.MaxImportSize)
+	defer r.Body.Close()
+	reader, errReader := dataio.NewBookReader(r.Body, format)
+	if errReader != nil {
+		statusCode := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(errReader, &maxBytesErr) {
+			statusCode = http.StatusRequestEntityTooLarge
+		}
+
+		if err := jsonutil.Render(w, statusCode, types.Err{Error: errReader.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// a failed import still reports the rows committed before it stopped
+	report, statusCode, err := dataio.ImportBooks(s.store, reader, dataio.DefaultBatchSize, dryRun)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, report)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, report)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// bookFilter reads the query parameters shared by every endpoint that lists books.
+// Parameters:
+// - r: the incoming request.
+// Returns the parsed filter and an error if a parameter is malformed.
+func bookFilter(r *http.Request) (types.BookFilter, error) {
+	cursor, limit, err := pageParams(r)
+	if err != nil {
+		return types.BookFilter{}, err
+	}
+
+	query := r.URL.Query()
+	filter := types.BookFilter{
+		Ids:       bookutil.NormalizeIds(query["id"]),
+		Search:    query.Get("search"),
+		Publisher: query.Get("publisher"),
+		Language:  strings.ToLower(query.Get("language")),
+		Subject:   query.Get("subject"),
+		AuthorId:  query.Get("author_id"),
+		Cursor:    cursor,
+		Limit:     limit,
+	}
+
+	if raw := query.Get("isbn"); raw != "" {
+		if filter.Isbn, err = bookutil.NormalizeIsbn(raw); err != nil {
+			return types.BookFilter{}, err
+		}
+	}
+
+	if raw := query.Get("category_id"); raw != "" {
+		filter.CategoryId = raw
+		filter.Descendants, _ = strconv.ParseBool(query.Get("include_descendants"))
+	}
+
+	if raw := query["tag"]; len(raw) > 0 {
+		if filter.Tags, err = bookutil.NormalizeTags(raw); err != nil {
+			return types.BookFilter{}, err
+		}
+	}
+
+	if raw := query.Get("year_from"); raw != "" {
+		if filter.YearFrom, err = strconv.Atoi(raw); err != nil {
+			return types.BookFilter{}, errors.New("invalid year_from")
+		}
+	}
+
+	if raw := query.Get("year_to"); raw != "" {
+		if filter.YearTo, err = strconv.Atoi(raw); err != nil {
+			return types.BookFilter{}, errors.New("invalid year_to")
+		}
+	}
+
+	return filter, nil
+}
@@ -2,9 +2,10 @@ package api
 
 import (
 	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/patronutil"
 	"github.com/Tus1688/library-management-api/types"
+	"github.com/go-chi/chi/v5"
 	"net/http"
-	"strconv"
 )
 
 func (s *Server) CreateBooking(w http.ResponseWriter, r *http.Request) {
@@ -14,6 +15,15 @@ func (s *Server) CreateBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	email, errEmail := patronutil.NormalizeEmail(req.CustomerEmail)
+	if errEmail != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errEmail.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	req.CustomerEmail = email
+
 	uid := r.Context().Value("uid").(string)
 
 	bookingId, statusCode, err := s.store.CreateBooking(&uid, &req)
@@ -51,10 +61,20 @@ func (s *Server) ReturnBook(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) GetBooking(w http.ResponseWriter, r *http.Request) {
-	lastId, _ := strconv.Atoi(r.URL.Query().Get("last_id"))
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	cursor, limit, errParam := pageParams(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	filter := types.BookingFilter{
+		Cursor: cursor,
+		Limit:  limit,
+	}
 
-	bookings, statusCode, err := s.store.GetBooking(&lastId, &limit)
+	bookings, statusCode, err := s.store.GetBooking(&filter)
 	if err.Error != "" {
 		err := jsonutil.Render(w, statusCode, err)
 		if err != nil {
@@ -68,3 +88,22 @@ func (s *Server) GetBooking(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
+
+func (s *Server) GetBookingById(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	booking, statusCode, err := s.store.GetBookingById(&id)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	booking.Book.Cover = coverUrls(booking.Book.CoverKey)
+
+	errResp := jsonutil.Render(w, http.StatusOK, booking)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"github.com/Tus1688/library-management-api/bookutil"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+func (s *Server) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, statusCode, err := s.store.GetCategoryTree()
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, tree)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var req types.CreateCategory
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req.ParentId, req.Code, req.Name = strings.TrimSpace(req.ParentId), strings.TrimSpace(req.Code),
+		strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	categoryId, statusCode, err := s.store.CreateCategory(&req)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusCreated, categoryId)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	var req types.UpdateCategory
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req.ParentId, req.Code, req.Name = strings.TrimSpace(req.ParentId), strings.TrimSpace(req.Code),
+		strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	version, statusCode, err := s.store.UpdateCategory(&req, ifMatchVersion(r))
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	statusCode, err := s.store.DeleteCategory(&id)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) GetTag(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, errParam := pageParams(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	filter := types.TagFilter{Search: r.URL.Query().Get("search"), Cursor: cursor, Limit: limit}
+
+	tags, statusCode, err := s.store.GetTag(&filter)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, tags)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	var req types.UpdateTag
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	tags, errTag := bookutil.NormalizeTags([]string{req.Name})
+	if errTag != nil || len(tags) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req.Name = tags[0]
+
+	statusCode, err := s.store.UpdateTag(&req)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) MergeTags(w http.ResponseWriter, r *http.Request) {
+	var req types.MergeTags
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// merging a tag into itself is a no-op rather than a deletion of the target
+	req.TargetId = strings.ToLower(strings.TrimSpace(req.TargetId))
+	req.SourceIds = slices.DeleteFunc(bookutil.NormalizeIds(req.SourceIds), func(id string) bool {
+		return id == req.TargetId
+	})
+	if len(req.SourceIds) == 0 {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: "nothing to merge"}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	statusCode, err := s.store.MergeTags(&req)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	statusCode, err := s.store.DeleteTag(&id)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/patronutil"
+	"github.com/Tus1688/library-management-api/types"
+	"net/http"
+)
+
+func (s *Server) Scan(w http.ResponseWriter, r *http.Request) {
+	var req types.ScanRequest
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := patronutil.NormalizeScan(&req); err != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: err.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	uid := r.Context().Value("uid").(string)
+
+	result, statusCode, err := s.store.ScanItems(&uid, &req)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, result)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
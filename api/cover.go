@@ -0,0 +1,204 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/blob"
+	"github.com/Tus1688/library-management-api/coverutil"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/go-chi/chi/v5"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// coverPath is the route prefix GetCover is mounted on.
+const coverPath = "/api/v1/collections/cover/"
+
+func (s *Server) UploadCover(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	// leave room for the multipart envelope around the file
+	r.Body = http.MaxBytesReader(w, r.Body, coverutil.MaxUploadSize+1<<20)
+	file, _, errForm := r.FormFile("cover")
+	if errForm != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(errForm, &maxBytesErr) {
+			if err := jsonutil.Render(w, http.StatusRequestEntityTooLarge,
+				types.Err{Error: coverutil.ErrTooLarge.Error()}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: "cover file is required"}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	defer file.Close()
+
+	data, errRead := io.ReadAll(io.LimitReader(file, coverutil.MaxUploadSize+1))
+	if errRead != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	cover, errCover := coverutil.Process(data)
+	if errCover != nil {
+		statusCode := http.StatusBadRequest
+		switch {
+		case errors.Is(errCover, coverutil.ErrTooLarge):
+			statusCode = http.StatusRequestEntityTooLarge
+		case errors.Is(errCover, coverutil.ErrUnsupportedType):
+			statusCode = http.StatusUnsupportedMediaType
+		}
+
+		if err := jsonutil.Render(w, statusCode, types.Err{Error: errCover.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	coverKey := id + "/" + cover.Hash
+	for _, size := range coverutil.Sizes {
+		err := s.blobs.Put(r.Context(), coverutil.Key(coverKey, size.Name), bytes.NewReader(cover.Images[size.Name]))
+		if err != nil {
+			s.deleteCover(coverKey)
+			if errors.Is(err, blob.ErrInvalidKey) {
+				if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: "invalid id"}); err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+				return
+			}
+
+			if err := jsonutil.Render(w, http.StatusInternalServerError,
+				types.Err{Error: "unable to store cover"}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	previous, statusCode, err := s.store.SetBookCover(&id, coverKey)
+	if err.Error != "" {
+		s.deleteCover(coverKey)
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// uploading the same file twice yields the same key, which must not be deleted
+	if previous != "" && previous != coverKey {
+		s.deleteCover(previous)
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, coverUrls(coverKey))
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) DeleteCover(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	previous, statusCode, err := s.store.SetBookCover(&id, "")
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if previous != "" {
+		s.deleteCover(previous)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) GetCover(w http.ResponseWriter, r *http.Request) {
+	hash, size := chi.URLParam(r, "hash"), chi.URLParam(r, "size")
+	if !coverutil.IsSize(size) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// the hash in the URL changes with every upload, so a cover URL always points to the same image
+	tag := `"` + hash + "-" + size + `"`
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", tag)
+	match := r.Header.Get("If-None-Match")
+	if strings.Contains(match, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	content, info, err := s.blobs.Get(r.Context(), coverutil.Key(chi.URLParam(r, "book")+"/"+hash, size))
+	if err != nil {
+		w.Header().Del("Cache-Control")
+		w.Header().Del("ETag")
+		if errors.Is(err, blob.ErrNotFound) || errors.Is(err, blob.ErrInvalidKey) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	// * only matches a cover that exists
+	if match == "*" {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", coverutil.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	w.Header().Set("Last-Modified", info.ModTime.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		log.Print("unable to send cover: ", err)
+	}
+}
+
+// deleteCover removes every size of a cover from the blob store.
+// It runs after the response is decided, so failures are only logged and leave an unreferenced file behind.
+func (s *Server) deleteCover(coverKey string) {
+	for _, size := range coverutil.Sizes {
+		if err := s.blobs.Delete(context.Background(), coverutil.Key(coverKey, size.Name)); err != nil {
+			log.Print("unable to delete cover: ", err)
+		}
+	}
+}
+
+// coverUrls builds the URLs of every size of a cover.
+// Parameters:
+// - coverKey: the key stored with the book.
+// Returns the URLs, or nil if the book has no cover.
+func coverUrls(coverKey string) *types.BookCover {
+	if coverKey == "" {
+		return nil
+	}
+
+	return &types.BookCover{
+		Small:  coverPath + coverKey + "/small",
+		Medium: coverPath + coverKey + "/medium",
+		Large:  coverPath + coverKey + "/large",
+	}
+}
+
+// withCovers fills in the cover URLs of every book of a list.
+func withCovers(books []types.ListBook) {
+	for i := range books {
+		books[i].Cover = coverUrls(books[i].CoverKey)
+	}
+}
@@ -3,7 +3,9 @@ package api
 import (
 	"github.com/Tus1688/library-management-api/jsonutil"
 	"github.com/Tus1688/library-management-api/types"
+	"github.com/go-chi/chi/v5"
 	"net/http"
+	"strconv"
 )
 
 func (s *Server) CreateEmployee(w http.ResponseWriter, r *http.Request) {
@@ -28,8 +30,41 @@ func (s *Server) CreateEmployee(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) GetEmployee(w http.ResponseWriter, _ *http.Request) {
-	employee, statusCode, err := s.store.GetEmployee()
+func (s *Server) GetEmployee(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, errParam := pageParams(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+	filter := types.EmployeeFilter{
+		IncludeDeleted: includeDeleted,
+		Cursor:         cursor,
+		Limit:          limit,
+	}
+
+	employee, statusCode, err := s.store.GetEmployee(&filter)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, employee)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) GetEmployeeById(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	employee, statusCode, err := s.store.GetEmployeeById(&id)
 	if err.Error != "" {
 		err := jsonutil.Render(w, statusCode, err)
 		if err != nil {
@@ -38,12 +73,42 @@ func (s *Server) GetEmployee(w http.ResponseWriter, _ *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", etag(employee.Version))
 	errResp := jsonutil.Render(w, http.StatusOK, employee)
 	if errResp != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
 
+func (s *Server) PatchEmployee(w http.ResponseWriter, r *http.Request) {
+	if !isMergePatch(r) {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req types.PatchEmployee
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: err.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+
+	version, statusCode, err := s.store.PatchEmployee(&id, ifMatchVersion(r), &req)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusOK)
+}
+
 func (s *Server) DeleteEmployee(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("id")
 	if id == "" {
@@ -64,3 +129,18 @@ func (s *Server) DeleteEmployee(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+func (s *Server) RestoreEmployee(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	statusCode, err := s.store.RestoreEmployee(&id)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag formats a row version as a strong entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion reads the row version the client based its changes on from the If-Match header.
+// Parameters:
+// - r: the incoming request.
+// Returns 0 if the header is absent or "*", and -1 (which never matches) if it is not one of our entity tags.
+func ifMatchVersion(r *http.Request) int {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0
+	}
+
+	header = strings.TrimPrefix(header, "W/")
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 {
+		return -1
+	}
+
+	return version
+}
+
+// isMergePatch reports whether the request body is declared as a JSON merge patch (or plain JSON).
+func isMergePatch(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/merge-patch+json" || mediaType == "application/json"
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"github.com/Tus1688/library-management-api/dataio"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (s *Server) Export(w http.ResponseWriter, r *http.Request) {
+	resource := chi.URLParam(r, "resource")
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = dataio.FormatCSV
+	}
+	includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+
+	w.Header().Set("Content-Type", dataio.ContentType(format))
+	w.Header().Set("Content-Disposition",
+		`attachment; filename="`+resource+"-"+time.Now().Format("20060102")+"."+dataio.Extension(format)+`"`)
+
+	var rows, statusCode int
+	var err types.Err
+	switch resource {
+	case "books":
+		filter, errParam := bookFilter(r)
+		if errParam != nil {
+			rows, statusCode, err = 0, http.StatusBadRequest, types.Err{Error: errParam.Error()}
+			break
+		}
+		filter.IncludeDeleted = includeDeleted
+		rows, statusCode, err = dataio.ExportBooks(s.store, &filter, w, format)
+	case "bookings":
+		filter := types.BookingFilter{}
+		rows, statusCode, err = dataio.ExportBookings(s.store, &filter, w, format)
+	case "employees":
+		filter := types.EmployeeFilter{IncludeDeleted: includeDeleted}
+		rows, statusCode, err = dataio.ExportEmployees(s.store, &filter, w, format)
+	default:
+		rows, statusCode, err = 0, http.StatusNotFound, types.Err{Error: "unknown export"}
+	}
+
+	if err.Error != "" {
+		// once rows have been streamed the status line is gone, the only honest option left is to cut the download
+		if rows > 0 {
+			panic(http.ErrAbortHandler)
+		}
+
+		w.Header().Del("Content-Disposition")
+		if err := jsonutil.Render(w, statusCode, err); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"net/http"
+)
+
+func (s *Server) GetJob(w http.ResponseWriter, r *http.Request) {
+	errResp := jsonutil.Render(w, http.StatusOK, s.jobs.Jobs())
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) GetJobRun(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, errParam := pageParams(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	query := r.URL.Query()
+	filter := types.JobRunFilter{
+		Job:    query.Get("job"),
+		Status: query.Get("status"),
+		Cursor: cursor,
+		Limit:  limit,
+	}
+
+	switch filter.Status {
+	case "", types.JobRunning, types.JobSucceeded, types.JobFailed:
+	default:
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: "invalid status"}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	runs, statusCode, err := s.store.GetJobRun(&filter)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, runs)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
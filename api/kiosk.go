@@ -0,0 +1,198 @@
+package api
+
+import (
+	"github.com/Tus1688/library-management-api/authutil"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/patronutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strings"
+)
+
+func (s *Server) GetKiosk(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, errParam := pageParams(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	filter := types.KioskFilter{Cursor: cursor, Limit: limit}
+
+	kiosks, statusCode, err := s.store.GetKiosk(&filter)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, kiosks)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) CreateKiosk(w http.ResponseWriter, r *http.Request) {
+	var req types.CreateKiosk
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.Join(strings.Fields(req.Name), " ")
+	if req.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	code, errCode := authutil.NewPairingCode()
+	if errCode != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	uid := r.Context().Value("uid").(string)
+	normalized, _ := authutil.NormalizePairingCode(code)
+
+	pairing, statusCode, err := s.store.CreateKiosk(&uid, &req, authutil.HashToken(normalized))
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	pairing.PairingCode = code
+
+	errResp := jsonutil.Render(w, http.StatusCreated, pairing)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) PairKiosk(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	code, errCode := authutil.NewPairingCode()
+	if errCode != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	normalized, _ := authutil.NormalizePairingCode(code)
+
+	pairing, statusCode, err := s.store.PairKiosk(&id, authutil.HashToken(normalized))
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	pairing.PairingCode = code
+
+	errResp := jsonutil.Render(w, http.StatusOK, pairing)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) DeleteKiosk(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	statusCode, err := s.store.DeleteKiosk(&id)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) RegisterKiosk(w http.ResponseWriter, r *http.Request) {
+	var req types.RegisterKiosk
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	code, errCode := authutil.NormalizePairingCode(req.PairingCode)
+	if errCode != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errCode.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	token, errToken := authutil.NewDeviceToken()
+	if errToken != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	credential, statusCode, err := s.store.RegisterKiosk(authutil.HashToken(code), authutil.HashToken(token))
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	credential.Token = token
+
+	errResp := jsonutil.Render(w, http.StatusOK, credential)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) KioskScan(w http.ResponseWriter, r *http.Request) {
+	var req types.KioskScanRequest
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := patronutil.NormalizeScan(&req.ScanRequest); err != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: err.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	_, statusCode, err := s.store.VerifyPatronPin(&req.CardNumber, &req.Pin)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// bookings made at the kiosk are attributed to the device rather than to an employee
+	deviceId := r.Context().Value("kiosk").(string)
+
+	result, statusCode, err := s.store.ScanItems(&deviceId, &req.ScanRequest)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, result)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
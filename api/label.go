@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"github.com/Tus1688/library-management-api/barcode"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/labelutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxLabels bounds a label sheet request to ten A4 pages.
+const maxLabels = 10 * labelutil.PerPage
+
+var errTooManyLabels = errors.New("too many books, select at most " + strconv.Itoa(maxLabels))
+
+func (s *Server) GetBookBarcode(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	query := r.URL.Query()
+
+	book, statusCode, err := s.store.GetBookById(&id, false)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// the item barcode is what the desk scans, the id is meant for QR codes read by phones
+	data := book.Barcode
+	switch query.Get("data") {
+	case "", "barcode":
+	case "id":
+		data = book.Id
+	default:
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: "invalid data"}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var symbol *barcode.Symbol
+	var errSymbol error
+	scale := 2
+	switch query.Get("type") {
+	case "", "code128":
+		symbol, errSymbol = barcode.Code128(data)
+	case "qr":
+		symbol, errSymbol = barcode.QR(data)
+		scale = 8
+	default:
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: "invalid type"}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	if errSymbol != nil {
+		if err := jsonutil.Render(w, http.StatusUnprocessableEntity, types.Err{Error: errSymbol.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if raw := query.Get("scale"); raw != "" {
+		value, errScale := strconv.Atoi(raw)
+		if errScale != nil || value < 1 || value > barcode.MaxScale {
+			if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: "invalid scale"}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+		scale = value
+	}
+
+	var buf bytes.Buffer
+	switch query.Get("format") {
+	case "", "png":
+		w.Header().Set("Content-Type", "image/png")
+		errSymbol = barcode.PNG(&buf, symbol, scale, 40)
+	case "svg":
+		w.Header().Set("Content-Type", "image/svg+xml")
+		errSymbol = barcode.SVG(&buf, symbol, scale, 40)
+	default:
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: "invalid format"}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	if errSymbol != nil {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = buf.WriteTo(w)
+}
+
+func (s *Server) GetBookLabels(w http.ResponseWriter, r *http.Request) {
+	filter, errParam := bookFilter(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	skip := 0
+	if raw := r.URL.Query().Get("skip"); raw != "" {
+		value, errSkip := strconv.Atoi(raw)
+		if errSkip != nil || value < 0 || value >= labelutil.PerPage {
+			if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: "invalid skip"}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+		skip = value
+	}
+
+	var labels []labelutil.Label
+	statusCode, err := s.store.StreamBooks(&filter, func(book *types.ListBook) error {
+		if len(labels) == maxLabels {
+			return errTooManyLabels
+		}
+		labels = append(labels, labelutil.Label{Id: book.Id, Barcode: book.Barcode, Title: book.Title,
+			Author: book.Author})
+		return nil
+	})
+	if err.Error != "" {
+		// the stream only fails with a 500 when the callback stops it
+		if len(labels) == maxLabels && statusCode == http.StatusInternalServerError {
+			statusCode, err = http.StatusBadRequest, types.Err{Error: errTooManyLabels.Error()}
+		}
+
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if len(labels) == 0 {
+		if err := jsonutil.Render(w, http.StatusNotFound, types.Err{Error: "no books match the selection"}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	if errSheet := labelutil.WriteSheet(&buf, labels, skip); errSheet != nil {
+		if err := jsonutil.Render(w, http.StatusUnprocessableEntity, types.Err{Error: errSheet.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="labels-`+time.Now().Format("20060102")+`.pdf"`)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = buf.WriteTo(w)
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"errors"
+	"github.com/Tus1688/library-management-api/bookutil"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/lookup"
+	"github.com/Tus1688/library-management-api/types"
+	"log"
+	"net/http"
+)
+
+func (s *Server) LookupBook(w http.ResponseWriter, r *http.Request) {
+	isbn, errIsbn := bookutil.NormalizeIsbn(r.URL.Query().Get("isbn"))
+	if errIsbn != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errIsbn.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	book, errLookup := s.lookup.Lookup(r.Context(), isbn)
+	if errLookup != nil {
+		statusCode, message := http.StatusBadGateway, lookup.ErrUnavailable.Error()
+		if errors.Is(errLookup, lookup.ErrNotFound) {
+			statusCode, message = http.StatusNotFound, errLookup.Error()
+		} else {
+			log.Print("unable to look up isbn: ", errLookup)
+		}
+
+		if err := jsonutil.Render(w, statusCode, types.Err{Error: message}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, book)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -4,19 +4,40 @@ import (
 	"context"
 	"fmt"
 	"github.com/Tus1688/library-management-api/authutil"
+	"github.com/Tus1688/library-management-api/availability"
+	"github.com/Tus1688/library-management-api/blob"
 	"github.com/Tus1688/library-management-api/cache"
+	"github.com/Tus1688/library-management-api/jobs"
+	"github.com/Tus1688/library-management-api/live"
+	"github.com/Tus1688/library-management-api/lookup"
+	"github.com/Tus1688/library-management-api/notify"
+	"github.com/Tus1688/library-management-api/receiptutil"
+	"github.com/Tus1688/library-management-api/report"
 	"github.com/Tus1688/library-management-api/storage"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"log"
 	"net/http"
+	"sync"
 )
 
 // Server represents the API server with its dependencies.
 type Server struct {
-	store   storage.Storage
-	cache   cache.Cache
-	session authutil.Session
+	store        storage.Storage
+	cache        cache.Cache
+	session      authutil.Session
+	blobs        blob.Store
+	lookup       lookup.Provider
+	jobs         *jobs.Runner
+	live         *live.Broker
+	reports      *report.Reporter
+	availability *availability.Catalog
+	branding     *receiptutil.Branding
+	scheduler    *notify.Scheduler
+
+	// loginLinks queues the card numbers sign-in links were asked for, sent by loginLinkWorkers
+	loginLinks       chan string
+	loginLinkWorkers sync.WaitGroup
 
 	server *http.Server
 }
@@ -27,12 +48,29 @@ type Server struct {
 // - store: the storage backend.
 // - cache: the cache backend.
 // - session: the session manager.
+// - blobs: the blob store for uploaded files such as book covers.
+// - lookup: the provider of catalog records used to prefill new books.
+// - jobs: the background jobs, started by Run and stopped by Shutdown.
+// - live: the broker of the live streams, run by Run and closed by Shutdown.
+// - branding: the library printed on receipts and overdue notices.
+// - scheduler: the notification scheduler, which also emails the sign-in links of the patron portal.
 // Returns a pointer to the created Server.
-func NewServer(listenAddr string, store storage.Storage, cache cache.Cache, session authutil.Session) *Server {
+func NewServer(listenAddr string, store storage.Storage, cache cache.Cache, session authutil.Session,
+	blobs blob.Store, lookup lookup.Provider, jobs *jobs.Runner, live *live.Broker,
+	branding *receiptutil.Branding, scheduler *notify.Scheduler) *Server {
 	s := &Server{
-		store:   store,
-		cache:   cache,
-		session: session,
+		store:        store,
+		cache:        cache,
+		session:      session,
+		blobs:        blobs,
+		lookup:       lookup,
+		jobs:         jobs,
+		live:         live,
+		reports:      report.NewReporter(store, cache),
+		availability: availability.NewCatalog(store, cache),
+		branding:     branding,
+		scheduler:    scheduler,
+		loginLinks:   make(chan string, loginLinkQueue),
 	}
 
 	s.server = &http.Server{
@@ -44,24 +82,52 @@ func NewServer(listenAddr string, store storage.Storage, cache cache.Cache, sess
 }
 
 // Shutdown gracefully shuts down the server and its dependencies.
+// Background jobs are stopped first, so none of them is cut off by the database going away,
+// then the live streams are ended, so their connections do not keep the server from shutting down.
+// The server then stops taking requests and the sign-in links already queued are sent before the storage is closed.
 // Parameters:
 // - ctx: the context for shutdown.
 // Returns an error if any of the shutdown operations fail.
 func (s *Server) Shutdown(ctx context.Context) error {
-	if err := s.store.Shutdown(); err != nil {
+	if err := s.jobs.Stop(ctx); err != nil {
 		return err
 	}
 
-	if err := s.cache.Shutdown(); err != nil {
+	s.live.Close()
+
+	if err := s.server.Shutdown(ctx); err != nil {
 		return err
 	}
 
-	return s.server.Shutdown(ctx)
+	close(s.loginLinks)
+	drained := make(chan struct{})
+	go func() {
+		s.loginLinkWorkers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if err := s.store.Shutdown(); err != nil {
+		return err
+	}
+
+	return s.cache.Shutdown()
 }
 
-// Run starts the server and listens for incoming requests.
+// Run starts the background jobs, the live streams, the sign-in link workers and the server and listens for incoming
+// requests.
 // Returns an error if the server fails to start.
 func (s *Server) Run() error {
+	s.jobs.Start()
+	go s.live.Run(s.cache)
+	for i := 0; i < loginLinkWorkers; i++ {
+		s.loginLinkWorkers.Add(1)
+		go s.runLoginLinks()
+	}
 	log.Print("server is running on ", s.server.Addr)
 	return s.server.ListenAndServe()
 }
@@ -87,25 +153,128 @@ func handler(s *Server) http.Handler {
 				r.Use(s.EnforceAuthentication(300, true))
 
 				r.Get("/user", s.GetEmployee)
+				r.Get("/user/{id}", s.GetEmployeeById)
+				r.Patch("/user/{id}", s.PatchEmployee)
+				r.Post("/user/{id}/restore", s.RestoreEmployee)
 				r.Post("/user", s.CreateEmployee)
 				r.Delete("/user", s.DeleteEmployee)
+
+				r.Get("/kiosk", s.GetKiosk)
+				r.Post("/kiosk", s.CreateKiosk)
+				r.Post("/kiosk/{id}/pair", s.PairKiosk)
+				r.Delete("/kiosk", s.DeleteKiosk)
+
+				r.Get("/job", s.GetJob)
+				r.Get("/job/run", s.GetJobRun)
+
+				r.Get("/webhook", s.GetWebhook)
+				r.Post("/webhook", s.CreateWebhook)
+				r.Put("/webhook", s.UpdateWebhook)
+				r.Delete("/webhook", s.DeleteWebhook)
+				r.Post("/webhook/{id}/secret", s.RotateWebhookSecret)
+				r.Get("/webhook/delivery", s.GetDelivery)
+				r.Post("/webhook/delivery/{id}/redeliver", s.RedeliverWebhook)
+
+				r.Get("/outbox", s.GetOutbox)
+				r.Get("/outbox/sink", s.GetOutboxSinks)
+				r.Post("/outbox/replay", s.ReplayOutbox)
+
+				r.Get("/report/{report}", s.GetReport)
+			})
+		})
+
+		// self-checkout kiosks may only check out and return books
+		r.Route("/kiosk", func(r chi.Router) {
+			r.Post("/register", s.RegisterKiosk)
+
+			r.Group(func(r chi.Router) {
+				r.Use(s.EnforceKiosk())
+
+				r.Post("/scan", s.KioskScan)
+			})
+		})
+
+		// patrons sign in with cookies of their own, which no employee endpoint accepts
+		r.Route("/patron", func(r chi.Router) {
+			r.Post("/login", s.PatronLogin)
+			r.Post("/login/link", s.RequestPatronLink)
+			r.Post("/login/link/verify", s.PatronLinkLogin)
+			r.Post("/logout", s.PatronLogout)
+			r.Post("/refresh", s.PatronRefreshToken)
+
+			r.Group(func(r chi.Router) {
+				r.Use(s.EnforcePatron(patronAccessTTL))
+
+				r.Get("/me", s.GetPatronProfile)
+				r.Put("/me", s.UpdatePatronContact)
+				r.Get("/loan", s.GetPatronLoans)
+				r.Post("/loan/{id}/renew", s.RenewPatronLoan)
+				r.Get("/history", s.GetPatronHistory)
+				r.Get("/hold", s.GetPatronHolds)
+				r.Post("/hold", s.PlaceHold)
+				r.Delete("/hold", s.CancelHold)
 			})
 		})
 
 		r.Route("/collections", func(r chi.Router) {
 			// public route
 			r.Get("/book", s.GetBook)
+			r.Get("/book/{id}", s.GetBookById)
+			r.Get("/book/{id}/availability", s.GetBookAvailability)
+			r.Get("/availability", s.GetAvailability)
+			r.Get("/cover/{book}/{hash}/{size}", s.GetCover)
+			r.Get("/author", s.GetAuthor)
+			r.Get("/author/{id}", s.GetAuthorById)
+			r.Get("/author/{id}/books", s.GetAuthorBooks)
+			r.Get("/category", s.GetCategoryTree)
+			r.Get("/tag", s.GetTag)
 
 			r.Route("/dashboard", func(r chi.Router) {
 				r.Use(s.EnforceAuthentication(600, true))
 
+				r.Get("/book", s.GetDashboardBook)
+				r.Get("/book/{id}", s.GetBookDetail)
 				r.Post("/book", s.CreateBook)
+				r.Post("/book/import", s.ImportBooks)
+				r.Get("/book/lookup", s.LookupBook)
+				r.Get("/book/labels", s.GetBookLabels)
+				r.Get("/book/{id}/barcode", s.GetBookBarcode)
 				r.Put("/book", s.UpdateBook)
+				r.Patch("/book/{id}", s.PatchBook)
 				r.Delete("/book", s.DeleteBook)
+				r.Post("/book/{id}/restore", s.RestoreBook)
+				r.Put("/book/{id}/cover", s.UploadCover)
+				r.Delete("/book/{id}/cover", s.DeleteCover)
+
+				r.Post("/author", s.CreateAuthor)
+				r.Put("/author", s.UpdateAuthor)
+				r.Delete("/author", s.DeleteAuthor)
+
+				r.Post("/category", s.CreateCategory)
+				r.Put("/category", s.UpdateCategory)
+				r.Delete("/category", s.DeleteCategory)
+
+				r.Put("/tag", s.UpdateTag)
+				r.Post("/tag/merge", s.MergeTags)
+				r.Delete("/tag", s.DeleteTag)
+
+				r.Get("/export/{resource}", s.Export)
 
 				r.Get("/booking", s.GetBooking)
+				r.Get("/booking/stream", s.StreamBooking)
+				r.Get("/booking/{id}", s.GetBookingById)
+				r.Get("/booking/{id}/receipt", s.GetBookingReceipt)
+				r.Get("/booking/{id}/overdue-notice", s.GetBookingNotice)
 				r.Post("/booking", s.CreateBooking)
 				r.Post("/return", s.ReturnBook)
+				r.Post("/scan", s.Scan)
+				r.Get("/notification", s.GetNotification)
+
+				r.Get("/patron", s.GetPatron)
+				r.Get("/patron/{id}", s.GetPatronById)
+				r.Post("/patron", s.CreatePatron)
+				r.Put("/patron", s.UpdatePatron)
+				r.Delete("/patron", s.DeletePatron)
 			})
 		})
 	})
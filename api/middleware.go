@@ -2,12 +2,15 @@ package api
 
 import (
 	"context"
+	"github.com/Tus1688/library-management-api/authutil"
 	"net/http"
+	"strings"
 )
 
 // EnforceAuthentication is a middleware that enforces authentication on incoming HTTP requests.
 // It checks for the presence of an access token in the request cookies and validates it.
-// If the token is valid, it optionally passes the user ID to the request context.
+// If the token is valid, it optionally passes the user ID to the request context as "uid",
+// along with the time the token expires as "expires_at".
 // Parameters:
 // - expiredIn: The time-to-live (TTL) for the token in seconds.
 // - passUserId: A boolean indicating whether to pass the user ID to the request context.
@@ -22,7 +25,7 @@ func (s *Server) EnforceAuthentication(expiredIn uint32, passUserId bool) func(n
 				return
 			}
 
-			uid, errResp := s.session.ValidateToken(access.Value, expiredIn)
+			uid, expiresAt, errResp := s.session.ValidateSession(access.Value, expiredIn)
 			if errResp.Error != "" {
 				w.WriteHeader(http.StatusUnauthorized)
 				return
@@ -30,6 +33,7 @@ func (s *Server) EnforceAuthentication(expiredIn uint32, passUserId bool) func(n
 
 			if passUserId {
 				ctx := context.WithValue(r.Context(), "uid", uid)
+				ctx = context.WithValue(ctx, "expires_at", expiresAt)
 				r = r.WithContext(ctx)
 			}
 
@@ -39,3 +43,63 @@ func (s *Server) EnforceAuthentication(expiredIn uint32, passUserId bool) func(n
 		return http.HandlerFunc(fn)
 	}
 }
+
+// EnforceKiosk is a middleware that only lets paired kiosk devices through.
+// The device sends its token as a bearer token, it is never accepted by EnforceAuthentication,
+// so a kiosk cannot reach any employee endpoint.
+// The device ID is passed to the request context as "kiosk".
+// Returns:
+// - A middleware function that wraps the next HTTP handler.
+func (s *Server) EnforceKiosk() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			deviceId, statusCode, errResp := s.store.AuthenticateKiosk(authutil.HashToken(token))
+			if errResp.Error != "" {
+				w.WriteHeader(statusCode)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), "kiosk", deviceId)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// EnforcePatron is a middleware that only lets patrons signed in to the portal through.
+// Patrons carry their own access cookie, which is signed with another key than the employee one,
+// so neither can be used in place of the other.
+// The patron ID is passed to the request context as "patron".
+// Parameters:
+// - expiredIn: The time-to-live (TTL) for the token in seconds.
+// Returns:
+// - A middleware function that wraps the next HTTP handler.
+func (s *Server) EnforcePatron(expiredIn uint32) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			access, err := r.Cookie(patronAccessCookie)
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			patronId, errResp := s.session.ValidatePatronToken(access.Value, expiredIn)
+			if errResp.Error != "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), "patron", patronId)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
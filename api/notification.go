@@ -0,0 +1,48 @@
+package api
+
+import (
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"net/http"
+)
+
+func (s *Server) GetNotification(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, errParam := pageParams(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	query := r.URL.Query()
+	filter := types.NotificationFilter{
+		Status:    query.Get("status"),
+		BookingId: query.Get("booking_id"),
+		Cursor:    cursor,
+		Limit:     limit,
+	}
+
+	switch filter.Status {
+	case "", types.NotificationPending, types.NotificationSent, types.NotificationFailed:
+	default:
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: "invalid status"}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	notifications, statusCode, err := s.store.GetNotification(&filter)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, notifications)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"net/http"
+	"time"
+)
+
+func (s *Server) GetOutbox(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, errParam := pageParams(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	filter := types.EventFilter{Type: r.URL.Query().Get("type"), Cursor: cursor, Limit: limit}
+
+	events, statusCode, err := s.store.GetOutbox(&filter)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, events)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) GetOutboxSinks(w http.ResponseWriter, r *http.Request) {
+	sinks, statusCode, err := s.store.GetOutboxSinks()
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, sinks)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) ReplayOutbox(w http.ResponseWriter, r *http.Request) {
+	var req types.ReplayOutbox
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var since time.Time
+	if req.Since != "" {
+		var errSince error
+		if since, errSince = time.Parse(time.RFC3339, req.Since); errSince != nil {
+			if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: "invalid since"}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+	} else if req.FromPosition < 1 {
+		if err := jsonutil.Render(w, http.StatusBadRequest,
+			types.Err{Error: "from_position or since is required"}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	position, statusCode, err := s.store.ReplayOutbox(req.Sink, req.FromPosition, since)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	sink := types.OutboxSink{Sink: req.Sink, Position: position}
+	errResp := jsonutil.Render(w, http.StatusAccepted, sink)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"github.com/Tus1688/library-management-api/pageutil"
+	"net/http"
+)
+
+// pageParams reads the "cursor" and "limit" query parameters shared by every list endpoint.
+// Parameters:
+// - r: the incoming request.
+// Returns the decoded cursor, the page size and an error if either parameter is malformed.
+func pageParams(r *http.Request) (int64, int, error) {
+	cursor, err := pageutil.DecodeCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	limit, err := pageutil.ParseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return cursor, limit, nil
+}
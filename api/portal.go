@@ -0,0 +1,544 @@
+package api
+
+import (
+	"context"
+	"github.com/Tus1688/library-management-api/authutil"
+	"github.com/Tus1688/library-management-api/cache"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/notify"
+	"github.com/Tus1688/library-management-api/patronutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/go-chi/chi/v5"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	patronAccessCookie  = "patron_access"
+	patronRefreshCookie = "patron_refresh"
+	// patronCookiePath keeps the patron cookies away from every endpoint outside of the portal
+	patronCookiePath = "/api/v1/patron"
+	// patronAccessTTL is how long a patron access token is valid, in seconds
+	patronAccessTTL = 600
+	// loginLinkTTL is how long a sign-in link can be used
+	loginLinkTTL = 15 * time.Minute
+	// loginLinkInterval is how long a patron waits before another sign-in link is sent
+	loginLinkInterval = time.Minute
+	// loginLinkAddressLimit is how many sign-in links a network address may ask for per loginLinkAddressWindow
+	loginLinkAddressLimit  = 10
+	loginLinkAddressWindow = 15 * time.Minute
+	// loginLinkQueue bounds the sign-in links waiting to be sent, requests beyond it are dropped
+	loginLinkQueue = 100
+	// loginLinkWorkers is how many sign-in links are sent at once
+	loginLinkWorkers = 2
+)
+
+func (s *Server) PatronLogin(w http.ResponseWriter, r *http.Request) {
+	var req types.PatronLoginRequest
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	cardNumber, errNormalize := patronutil.NormalizeCardNumber(req.CardNumber)
+	if errNormalize != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errNormalize.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	patronId, statusCode, err := s.store.VerifyPatronPin(&cardNumber, &req.Pin)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.startPatronSession(w, patronId)
+}
+
+func (s *Server) RequestPatronLink(w http.ResponseWriter, r *http.Request) {
+	if !s.scheduler.SendsLoginLinks() {
+		if err := jsonutil.Render(w, http.StatusNotFound, types.Err{Error: notify.ErrLoginLinksDisabled.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var req types.PatronLinkRequest
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	cardNumber, errNormalize := patronutil.NormalizeCardNumber(req.CardNumber)
+	if errNormalize != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errNormalize.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	address, _, errAddress := net.SplitHostPort(r.RemoteAddr)
+	if errAddress != nil {
+		address = r.RemoteAddr
+	}
+	allowed, err := s.cache.ThrottleLinkRequests(&address, loginLinkAddressLimit, loginLinkAddressWindow)
+	if err.Error != "" {
+		err := jsonutil.Render(w, http.StatusInternalServerError, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if !allowed {
+		if err := jsonutil.Render(w, http.StatusTooManyRequests,
+			types.Err{Error: "too many sign-in links asked for, try again later"}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// the link is sent by the workers and the answer is the same whether a link is sent or not,
+	// so neither its content nor its timing can be used to find card numbers
+	select {
+	case s.loginLinks <- cardNumber:
+	default:
+		log.Print("sign-in link queue is full, dropping a request")
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// runLoginLinks sends the sign-in links queued by RequestPatronLink until the queue is closed by Shutdown.
+func (s *Server) runLoginLinks() {
+	defer s.loginLinkWorkers.Done()
+	for cardNumber := range s.loginLinks {
+		s.sendLoginLink(cardNumber)
+	}
+}
+
+// sendLoginLink emails a sign-in link to the patron holding a card, unless the card is unknown, has no email
+// or was sent a link within loginLinkInterval. It runs on a worker outside of the request, failures are only logged.
+// Parameters:
+// - cardNumber: the normalized card number the link was asked for.
+func (s *Server) sendLoginLink(cardNumber string) {
+	patron, statusCode, err := s.store.GetPatronByCard(&cardNumber)
+	if err.Error != "" {
+		if statusCode != http.StatusNotFound {
+			log.Print("unable to get patron for sign-in link: ", err.Error)
+		}
+		return
+	}
+
+	if patron.Email == "" {
+		return
+	}
+
+	allowed, err := s.cache.ThrottleLoginLink(&patron.Id, loginLinkInterval)
+	if err.Error != "" {
+		log.Print("unable to throttle sign-in link: ", err.Error)
+		return
+	}
+
+	if !allowed {
+		return
+	}
+
+	token, errToken := authutil.NewLoginToken()
+	if errToken != nil {
+		log.Print("unable to create sign-in link: ", errToken)
+		return
+	}
+
+	if err := s.cache.SaveLoginToken(authutil.HashToken(token), &patron.Id, loginLinkTTL); err.Error != "" {
+		log.Print("unable to save sign-in link: ", err.Error)
+		return
+	}
+
+	if err := s.scheduler.SendLoginLink(context.Background(), patron.Email, patron.Name, token); err != nil {
+		log.Print("unable to send sign-in link: ", err)
+	}
+}
+
+func (s *Server) PatronLinkLogin(w http.ResponseWriter, r *http.Request) {
+	var req types.PatronLinkLogin
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	patronId, err := s.cache.TakeLoginToken(authutil.HashToken(req.Token))
+	if err.Error != "" {
+		err := jsonutil.Render(w, http.StatusInternalServerError, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if patronId == "" {
+		if err := jsonutil.Render(w, http.StatusUnauthorized, types.Err{Error: "invalid or expired sign-in link"}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	active, statusCode, err := s.store.IsPatronActive(&patronId)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if !active {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	s.startPatronSession(w, patronId)
+}
+
+// startPatronSession signs a patron in to the portal by setting the patron access and refresh cookies.
+// Parameters:
+// - w: the response the cookies are set on, it is written with 200 or an error.
+// - patronId: the patron who proved holding the card.
+func (s *Server) startPatronSession(w http.ResponseWriter, patronId string) {
+	sessionToken, err := s.session.CreatePatronToken(&patronId)
+	if err.Error != "" {
+		err := jsonutil.Render(w, http.StatusInternalServerError, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	refreshToken, err := s.session.CreateRefreshToken()
+	if err.Error != "" {
+		err := jsonutil.Render(w, http.StatusInternalServerError, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := s.cache.SavePatronRefreshToken(&refreshToken, &patronId); err.Error != "" {
+		err := jsonutil.Render(w, http.StatusInternalServerError, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	err = s.session.SignRefreshToken(&refreshToken)
+	if err.Error != "" {
+		err := jsonutil.Render(w, http.StatusInternalServerError, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	access := http.Cookie{
+		Name:     patronAccessCookie,
+		Value:    sessionToken,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Path:     patronCookiePath,
+		Secure:   true,
+	}
+
+	refresh := http.Cookie{
+		Name:     patronRefreshCookie,
+		Value:    refreshToken,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Path:     patronCookiePath,
+		Secure:   true,
+		MaxAge:   int(cache.PatronRefreshTTL.Seconds()),
+	}
+
+	http.SetCookie(w, &access)
+	http.SetCookie(w, &refresh)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) PatronLogout(w http.ResponseWriter, r *http.Request) {
+	refresh, err := r.Cookie(patronRefreshCookie)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if err := s.session.VerifyRefreshToken(&refresh.Value); err.Error != "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if err := s.cache.DeletePatronRefreshToken(&refresh.Value); err.Error != "" {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	access := http.Cookie{
+		Name:     patronAccessCookie,
+		Value:    "",
+		Path:     patronCookiePath,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   true,
+		MaxAge:   -1,
+	}
+
+	newRefresh := http.Cookie{
+		Name:     patronRefreshCookie,
+		Value:    "",
+		Path:     patronCookiePath,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   true,
+		MaxAge:   -1,
+	}
+
+	http.SetCookie(w, &access)
+	http.SetCookie(w, &newRefresh)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) PatronRefreshToken(w http.ResponseWriter, r *http.Request) {
+	token, err := r.Cookie(patronRefreshCookie)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if err := s.session.VerifyRefreshToken(&token.Value); err.Error != "" {
+		if err := jsonutil.Render(w, http.StatusUnauthorized, err); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// an employee refresh token is stored under another key, so it is unknown here
+	patronId, errResp := s.cache.GetPatronRefreshToken(&token.Value)
+	if errResp.Error != "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	active, statusCode, errResp := s.store.IsPatronActive(&patronId)
+	if errResp.Error != "" {
+		if err := jsonutil.Render(w, statusCode, errResp); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if !active {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	sessionToken, errResp := s.session.CreatePatronToken(&patronId)
+	if errResp.Error != "" {
+		if err := jsonutil.Render(w, http.StatusInternalServerError, errResp); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	access := http.Cookie{
+		Name:     patronAccessCookie,
+		Value:    sessionToken,
+		Path:     patronCookiePath,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   true,
+	}
+
+	http.SetCookie(w, &access)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) GetPatronProfile(w http.ResponseWriter, r *http.Request) {
+	patronId := r.Context().Value("patron").(string)
+
+	patron, statusCode, err := s.store.GetPatronById(&patronId)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, patron)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) UpdatePatronContact(w http.ResponseWriter, r *http.Request) {
+	var req types.UpdatePatronContact
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := patronutil.NormalizeContact(&req); err != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: err.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	patronId := r.Context().Value("patron").(string)
+
+	version, statusCode, err := s.store.UpdatePatronContact(&patronId, &req)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) GetPatronLoans(w http.ResponseWriter, r *http.Request) {
+	patronId := r.Context().Value("patron").(string)
+
+	loans, statusCode, err := s.store.GetPatronLoans(&patronId)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, loans)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) RenewPatronLoan(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	patronId := r.Context().Value("patron").(string)
+
+	renewed, statusCode, err := s.store.RenewLoan(&patronId, &id)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, renewed)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) GetPatronHistory(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, errParam := pageParams(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	filter := types.PatronHistoryFilter{PatronId: r.Context().Value("patron").(string), Cursor: cursor, Limit: limit}
+
+	history, statusCode, err := s.store.GetPatronHistory(&filter)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, history)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) GetPatronHolds(w http.ResponseWriter, r *http.Request) {
+	patronId := r.Context().Value("patron").(string)
+
+	holds, statusCode, err := s.store.GetPatronHolds(&patronId)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, holds)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) PlaceHold(w http.ResponseWriter, r *http.Request) {
+	var req types.CreateHold
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	patronId := r.Context().Value("patron").(string)
+
+	holdId, statusCode, err := s.store.PlaceHold(&patronId, &req)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusCreated, holdId)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) CancelHold(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	patronId := r.Context().Value("patron").(string)
+
+	statusCode, err := s.store.CancelHold(&patronId, &id)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/receiptutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (s *Server) GetBookingReceipt(w http.ResponseWriter, r *http.Request) {
+	s.writeBookingPDF(w, r, "receipt", func(buf *bytes.Buffer, loan *receiptutil.Loan) error {
+		return receiptutil.WriteReceipt(buf, s.branding, loan)
+	})
+}
+
+func (s *Server) GetBookingNotice(w http.ResponseWriter, r *http.Request) {
+	s.writeBookingPDF(w, r, "overdue-notice", func(buf *bytes.Buffer, loan *receiptutil.Loan) error {
+		return receiptutil.WriteOverdueNotice(buf, s.branding, loan, time.Now())
+	})
+}
+
+// writeBookingPDF renders a document of the booking in the URL and sends it as an inline PDF,
+// so the desk can print it from the browser.
+func (s *Server) writeBookingPDF(w http.ResponseWriter, r *http.Request, name string,
+	write func(buf *bytes.Buffer, loan *receiptutil.Loan) error) {
+	id := chi.URLParam(r, "id")
+
+	booking, statusCode, err := s.store.GetBookingById(&id)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	loan, errLoan := receiptutil.LoanFromBooking(&booking)
+	if errLoan != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if errWrite := write(&buf, &loan); errWrite != nil {
+		if errors.Is(errWrite, receiptutil.ErrNotOverdue) {
+			if err := jsonutil.Render(w, http.StatusConflict, types.Err{Error: errWrite.Error()}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="`+name+"-"+strconv.Itoa(booking.PaginationId)+`.pdf"`)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = buf.WriteTo(w)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"github.com/Tus1688/library-management-api/dataio"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/report"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"time"
+)
+
+func (s *Server) GetReport(w http.ResponseWriter, r *http.Request) {
+	params, errParam := report.ParseParams(chi.URLParam(r, "report"), r.URL.Query(), time.Now())
+	if errParam != nil {
+		statusCode := http.StatusBadRequest
+		if errors.Is(errParam, report.ErrUnknownReport) {
+			statusCode = http.StatusNotFound
+		}
+		if err := jsonutil.Render(w, statusCode, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "", "json", dataio.FormatCSV, dataio.FormatJSONL, dataio.FormatXLSX:
+	default:
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: dataio.ErrUnknownFormat.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	result, statusCode, err := s.reports.Run(&params)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if format == "" || format == "json" {
+		errResp := jsonutil.Render(w, http.StatusOK, result)
+		if errResp != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writer, errFormat := dataio.NewRowWriter(w, format, params.Name, result.Columns)
+	if errFormat != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", dataio.ContentType(format))
+	w.Header().Set("Content-Disposition",
+		`attachment; filename="`+params.Name+"-"+params.From+"-"+params.To+"."+dataio.Extension(format)+`"`)
+	// the report is complete at this point, a failure can only be the connection going away
+	for _, row := range result.Rows {
+		if err := writer.WriteRow(row); err != nil {
+			panic(http.ErrAbortHandler)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/live"
+	"github.com/Tus1688/library-management-api/types"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (s *Server) StreamBooking(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// EventSource sends Last-Event-ID on reconnect, a page reload can pass it as a query parameter
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = r.URL.Query().Get("last_event_id")
+	}
+
+	var after int64
+	if lastEventId != "" {
+		var errParse error
+		if after, errParse = strconv.ParseInt(lastEventId, 10, 64); errParse != nil || after < 0 {
+			if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: "invalid last event id"}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	// subscribe before reading the backlog, so nothing is missed in between
+	sub, ok := s.live.Subscribe()
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	defer s.live.Unsubscribe(sub)
+
+	var backlog []types.Event
+	var reset bool
+	if lastEventId != "" {
+		var statusCode int
+		var err types.Err
+		backlog, after, reset, statusCode, err = live.Backlog(s.store, after)
+		if err.Error != "" {
+			err := jsonutil.Render(w, statusCode, err)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := live.WriteRetry(w); err != nil {
+		return
+	}
+	if reset {
+		if err := live.WriteReset(w); err != nil {
+			return
+		}
+	}
+	for i := range backlog {
+		if err := live.WriteEvent(w, &backlog[i]); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(live.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	// the access token is only checked when the stream opens, the stream ends when it expires
+	// so the client reconnects with a fresh one
+	expiry := time.NewTimer(time.Until(r.Context().Value("expires_at").(time.Time)))
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-expiry.C:
+			return
+		case <-heartbeat.C:
+			if err := live.WriteHeartbeat(w); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			// the backlog may already hold events the relay publishes afterwards
+			if event.Position <= after {
+				continue
+			}
+			after = event.Position
+
+			if err := live.WriteEvent(w, &event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/Tus1688/library-management-api/webhook"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+)
+
+func (s *Server) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, errParam := pageParams(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	filter := types.WebhookFilter{Cursor: cursor, Limit: limit}
+
+	webhooks, statusCode, err := s.store.GetWebhook(&filter)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, webhooks)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req types.CreateWebhook
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := webhook.NormalizeSubscription(&req); err != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: err.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	secret, errSecret := webhook.NewSecret()
+	if errSecret != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	uid := r.Context().Value("uid").(string)
+
+	webhookId, statusCode, err := s.store.CreateWebhook(&uid, &req, secret)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusCreated, types.WebhookSecret{Id: webhookId.Id, Secret: secret})
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req types.UpdateWebhook
+	if err := jsonutil.ShouldBind(r, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := webhook.NormalizeSubscription(&req.CreateWebhook); err != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: err.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	version, statusCode, err := s.store.UpdateWebhook(&req, ifMatchVersion(r))
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) RotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	secret, errSecret := webhook.NewSecret()
+	if errSecret != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	statusCode, err := s.store.RotateWebhookSecret(&id, secret)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, types.WebhookSecret{Id: id, Secret: secret})
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	statusCode, err := s.store.DeleteWebhook(&id)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, errParam := pageParams(r)
+	if errParam != nil {
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: errParam.Error()}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	query := r.URL.Query()
+	filter := types.DeliveryFilter{
+		WebhookId: query.Get("webhook_id"),
+		Status:    query.Get("status"),
+		Cursor:    cursor,
+		Limit:     limit,
+	}
+
+	switch filter.Status {
+	case "", types.DeliveryPending, types.DeliveryDelivered, types.DeliveryDead:
+	default:
+		if err := jsonutil.Render(w, http.StatusBadRequest, types.Err{Error: "invalid status"}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	deliveries, statusCode, err := s.store.GetDelivery(&filter)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	errResp := jsonutil.Render(w, http.StatusOK, deliveries)
+	if errResp != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	statusCode, err := s.store.RedeliverWebhook(&id)
+	if err.Error != "" {
+		err := jsonutil.Render(w, statusCode, err)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
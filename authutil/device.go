@@ -0,0 +1,84 @@
+package authutil
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidPairingCode = errors.New("invalid pairing code")
+
+// pairingAlphabet leaves out characters that are easily confused when a code is read off a screen (0/O, 1/I/L).
+const pairingAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// PairingCodeLength is the number of characters of a pairing code, shown as two groups of four.
+const PairingCodeLength = 8
+
+// NewDeviceToken generates the long-lived credential of a kiosk device.
+// Only its hash is stored, see HashToken.
+func NewDeviceToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NewLoginToken generates the single-use token of a patron sign-in link.
+// Only its hash is stored, see HashToken.
+func NewLoginToken() (string, error) {
+	return NewDeviceToken()
+}
+
+// HashToken hashes a device token, pairing code or sign-in token for storage and lookup.
+// Both are random enough that a plain SHA-256 is sufficient, unlike passwords and PINs.
+func HashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// NewPairingCode generates a short code an employee types into a kiosk to pair it, formatted as "ABCD-EFGH".
+func NewPairingCode() (string, error) {
+	b := make([]byte, PairingCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := make([]byte, 0, PairingCodeLength+1)
+	for i, v := range b {
+		if i == PairingCodeLength/2 {
+			code = append(code, '-')
+		}
+		// 256 is not a multiple of the alphabet size, the slight bias is irrelevant for a code that expires in minutes
+		code = append(code, pairingAlphabet[int(v)%len(pairingAlphabet)])
+	}
+
+	return string(code), nil
+}
+
+// NormalizePairingCode uppercases a pairing code as typed on the kiosk and strips spaces and dashes.
+// Parameters:
+// - raw: the code as entered.
+// Returns the code without formatting and ErrInvalidPairingCode if it cannot be a pairing code.
+func NormalizePairingCode(raw string) (string, error) {
+	code := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(raw))
+
+	if len(code) != PairingCodeLength {
+		return "", ErrInvalidPairingCode
+	}
+	for i := 0; i < len(code); i++ {
+		if !strings.ContainsRune(pairingAlphabet, rune(code[i])) {
+			return "", ErrInvalidPairingCode
+		}
+	}
+
+	return code, nil
+}
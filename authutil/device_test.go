@@ -0,0 +1,54 @@
+package authutil
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewPairingCode(t *testing.T) {
+	code, err := NewPairingCode()
+	if err != nil {
+		t.Fatalf("NewPairingCode returned an error: %v", err)
+	}
+
+	if len(code) != PairingCodeLength+1 || code[4] != '-' {
+		t.Errorf("NewPairingCode = %q, want two groups of four", code)
+	}
+
+	normalized, err := NormalizePairingCode(code)
+	if err != nil || len(normalized) != PairingCodeLength {
+		t.Errorf("NormalizePairingCode(%q) = %q, %v", code, normalized, err)
+	}
+}
+
+func TestNormalizePairingCode(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"abcd-efgh", "ABCDEFGH", true},
+		{" ABCD EFGH ", "ABCDEFGH", true},
+		{"ABCD-EFG", "", false},
+		{"ABCD-EFG0", "", false},
+		{"ABCD-EFGHJ", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizePairingCode(tt.raw)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("NormalizePairingCode(%q) = %q, %v", tt.raw, got, err)
+		}
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	token, err := NewDeviceToken()
+	if err != nil {
+		t.Fatalf("NewDeviceToken returned an error: %v", err)
+	}
+
+	if !bytes.Equal(HashToken(token), HashToken(token)) || bytes.Equal(HashToken(token), HashToken(token+"x")) {
+		t.Errorf("HashToken is not a stable hash of the token")
+	}
+}
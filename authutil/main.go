@@ -1,10 +1,12 @@
 package authutil
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"github.com/Tus1688/library-management-api/types"
 	"github.com/essentialkaos/branca/v2"
 	"os"
+	"time"
 )
 
 type Session interface {
@@ -13,10 +15,14 @@ type Session interface {
 	SignRefreshToken(token *string) types.Err
 	VerifyRefreshToken(token *string) types.Err
 	ValidateToken(token string, ttl uint32) (string, types.Err)
+	ValidateSession(token string, ttl uint32) (string, time.Time, types.Err)
+	CreatePatronToken(patronId *string) (string, types.Err)
+	ValidatePatronToken(token string, ttl uint32) (string, types.Err)
 }
 
 type SessionStore struct {
 	session       branca.Branca
+	patron        branca.Branca
 	refreshSecret string
 }
 
@@ -30,6 +36,13 @@ func NewSessionStore() (*SessionStore, error) {
 		return nil, err
 	}
 
+	// patron tokens use a key of their own, so they can never pass as an employee session
+	patronKey := sha256.Sum256([]byte(key + ":patron"))
+	patron, err := branca.NewBranca(patronKey[:])
+	if err != nil {
+		return nil, err
+	}
+
 	refresh := os.Getenv("SESSION_REFRESH_KEY")
 	if refresh == "" {
 		return nil, fmt.Errorf("SESSION_REFRESH_KEY is not set")
@@ -37,6 +50,7 @@ func NewSessionStore() (*SessionStore, error) {
 
 	return &SessionStore{
 		session:       brc,
+		patron:        patron,
 		refreshSecret: refresh,
 	}, nil
 }
@@ -7,6 +7,7 @@ import (
 	"encoding/base64"
 	"github.com/Tus1688/library-management-api/types"
 	"strings"
+	"time"
 )
 
 // CreateSessionToken generates a session token for a given user ID.
@@ -75,7 +76,38 @@ func (s *SessionStore) VerifyRefreshToken(token *string) types.Err {
 // ValidateToken validates the given token and returns the user ID if the token is valid.
 // It checks if the token is expired based on the provided TTL (time-to-live).
 func (s *SessionStore) ValidateToken(token string, ttl uint32) (string, types.Err) {
+	uid, _, err := s.ValidateSession(token, ttl)
+	return uid, err
+}
+
+// ValidateSession validates the given token like ValidateToken, and also returns when the token expires.
+func (s *SessionStore) ValidateSession(token string, ttl uint32) (string, time.Time, types.Err) {
 	decodedString, err := s.session.DecodeString(token)
+	if err != nil {
+		return "", time.Time{}, types.Err{Error: "invalid token"}
+	}
+	if decodedString.IsExpired(ttl) {
+		return "", time.Time{}, types.Err{Error: "token expired"}
+	}
+
+	return string(decodedString.Payload()), decodedString.Timestamp().Add(time.Duration(ttl) * time.Second), types.Err{}
+}
+
+// CreatePatronToken generates a session token of the patron portal for a given patron ID.
+// It returns the session token as a string and an error if any occurs.
+func (s *SessionStore) CreatePatronToken(patronId *string) (string, types.Err) {
+	brc, err := s.patron.EncodeToString([]byte(*patronId))
+	if err != nil {
+		return "", types.Err{Error: "error creating session token"}
+	}
+
+	return brc, types.Err{}
+}
+
+// ValidatePatronToken validates a session token of the patron portal and returns the patron ID if the token is valid.
+// Employee session tokens are not valid here, nor are patron tokens valid for ValidateToken.
+func (s *SessionStore) ValidatePatronToken(token string, ttl uint32) (string, types.Err) {
+	decodedString, err := s.patron.DecodeString(token)
 	if err != nil {
 		return "", types.Err{Error: "invalid token"}
 	}
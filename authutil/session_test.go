@@ -0,0 +1,63 @@
+package authutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPatronTokenRealm(t *testing.T) {
+	t.Setenv("SESSION_KEY", "0123456789abcdef0123456789abcdef")
+	t.Setenv("SESSION_REFRESH_KEY", "refresh")
+
+	store, err := NewSessionStore()
+	if err != nil {
+		t.Fatalf("NewSessionStore returned an error: %v", err)
+	}
+
+	id := "7d3f4b1e-0000-4000-8000-000000000001"
+	patronToken, errResp := store.CreatePatronToken(&id)
+	if errResp.Error != "" {
+		t.Fatalf("CreatePatronToken returned an error: %s", errResp.Error)
+	}
+	sessionToken, errResp := store.CreateSessionToken(&id)
+	if errResp.Error != "" {
+		t.Fatalf("CreateSessionToken returned an error: %s", errResp.Error)
+	}
+
+	if got, errResp := store.ValidatePatronToken(patronToken, 60); got != id || errResp.Error != "" {
+		t.Errorf("ValidatePatronToken = %q, %q", got, errResp.Error)
+	}
+
+	// a token of one realm is never accepted by the other
+	if _, errResp := store.ValidateToken(patronToken, 60); errResp.Error == "" {
+		t.Errorf("ValidateToken accepted a patron token")
+	}
+	if _, errResp := store.ValidatePatronToken(sessionToken, 60); errResp.Error == "" {
+		t.Errorf("ValidatePatronToken accepted an employee token")
+	}
+}
+
+func TestValidateSessionExpiry(t *testing.T) {
+	t.Setenv("SESSION_KEY", "0123456789abcdef0123456789abcdef")
+	t.Setenv("SESSION_REFRESH_KEY", "refresh")
+
+	store, err := NewSessionStore()
+	if err != nil {
+		t.Fatalf("NewSessionStore returned an error: %v", err)
+	}
+
+	id := "7d3f4b1e-0000-4000-8000-000000000001"
+	token, errResp := store.CreateSessionToken(&id)
+	if errResp.Error != "" {
+		t.Fatalf("CreateSessionToken returned an error: %s", errResp.Error)
+	}
+
+	uid, expiresAt, errResp := store.ValidateSession(token, 600)
+	if uid != id || errResp.Error != "" {
+		t.Fatalf("ValidateSession = %q, %q", uid, errResp.Error)
+	}
+	// branca timestamps have a precision of a second
+	if until := time.Until(expiresAt); until <= 598*time.Second || until > 600*time.Second {
+		t.Errorf("ValidateSession expires in %v, want about 600s", until)
+	}
+}
@@ -0,0 +1,126 @@
+package availability
+
+import (
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"log"
+	"time"
+)
+
+const (
+	// CacheTTL is how long the availability of a book is served from the cache.
+	// It is short enough that a checkout or return shows up in the catalog almost at once.
+	CacheTTL = 30 * time.Second
+	// MaxBooks bounds the books asked for at once, a catalog page is well below it.
+	MaxBooks = 50
+)
+
+// Store is the part of the storage availability is read from, it is implemented by storage.Storage.
+type Store interface {
+	GetAvailability(ids []string) ([]types.Availability, int, types.Err)
+}
+
+// Cache keeps the availability of books for CacheTTL, it is implemented by cache.Cache.
+type Cache interface {
+	GetAvailability(ids []string) ([][]byte, types.Err)
+	SaveAvailability(id *string, result []byte, expiration time.Duration) types.Err
+}
+
+// Catalog answers availability questions of the public catalog, from the cache where it can.
+type Catalog struct {
+	store Store
+	cache Cache
+	now   func() time.Time
+}
+
+// NewCatalog creates a catalog, a failing cache only costs reading every book from the store.
+func NewCatalog(store Store, cache Cache) *Catalog {
+	return &Catalog{store: store, cache: cache, now: time.Now}
+}
+
+// Get returns the availability of books in the order they were asked for, unknown books are left out.
+// Parameters:
+// - ids: the book IDs, at most MaxBooks.
+// Returns the availability, status code, and an error if it cannot be read.
+func (c *Catalog) Get(ids []string) ([]types.Availability, int, types.Err) {
+	found := make(map[string]types.Availability, len(ids))
+
+	cached, errCache := c.cache.GetAvailability(ids)
+	if errCache.Error != "" {
+		log.Print("unable to read availability cache: ", errCache.Error)
+	}
+
+	var missing []string
+	queued := make(map[string]bool, len(ids))
+	for i, id := range ids {
+		if _, ok := found[id]; ok || queued[id] {
+			continue
+		}
+		if i < len(cached) && cached[i] != nil {
+			var a types.Availability
+			if err := jsonutil.UnmarshalJSON(cached[i], &a); err == nil {
+				found[id] = a
+				continue
+			}
+		}
+		queued[id] = true
+		missing = append(missing, id)
+	}
+
+	if len(missing) > 0 {
+		availability, statusCode, errResp := c.store.GetAvailability(missing)
+		if errResp.Error != "" {
+			return nil, statusCode, errResp
+		}
+
+		now := c.now()
+		for _, a := range availability {
+			if estimate, ok := Estimate(&a, now); ok {
+				a.EstimatedAvailableAt = estimate.UTC().Format(time.RFC3339)
+			}
+			found[a.BookId] = a
+
+			if encoded, err := jsonutil.MarshalJSON(a); err == nil {
+				if errCache := c.cache.SaveAvailability(&a.BookId, encoded, CacheTTL); errCache.Error != "" {
+					log.Print("unable to write availability cache: ", errCache.Error)
+				}
+			}
+		}
+	}
+
+	result := make([]types.Availability, 0, len(found))
+	seen := make(map[string]bool, len(found))
+	for _, id := range ids {
+		if a, ok := found[id]; ok && !seen[id] {
+			seen[id] = true
+			result = append(result, a)
+		}
+	}
+
+	return result, 200, types.Err{}
+}
+
+// Estimate guesses when a book will likely be free for a new patron.
+// A book on loan is expected back on its due date, or today once overdue, and a book kept for a patron is expected
+// to be borrowed by them straight away. Every patron in the queue is then expected to keep the book for a full types.LoanTerm.
+// Parameters:
+// - a: the availability of the book as read from the store.
+// - now: the current time.
+// Returns the estimate, and false if the book is available with nobody waiting for it.
+func Estimate(a *types.Availability, now time.Time) (time.Time, bool) {
+	free := now
+	switch a.Status {
+	case types.AvailabilityAvailable:
+		if a.QueueLength == 0 {
+			return time.Time{}, false
+		}
+	case types.AvailabilityOnLoan:
+		if due, err := time.Parse(time.RFC3339Nano, a.DueAt); err == nil && due.After(now) {
+			free = due
+		}
+	case types.AvailabilityOnHold:
+		free = now.Add(types.LoanTerm)
+	}
+
+	return free.Add(time.Duration(a.QueueLength) * types.LoanTerm), true
+}
@@ -0,0 +1,99 @@
+package availability
+
+import (
+	"github.com/Tus1688/library-management-api/types"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEstimate(t *testing.T) {
+	now := time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name string
+		a    types.Availability
+		want time.Duration
+		ok   bool
+	}{
+		{"available", types.Availability{Status: types.AvailabilityAvailable}, 0, false},
+		{"available with a queue", types.Availability{Status: types.AvailabilityAvailable, QueueLength: 1}, 7 * day, true},
+		{"on loan", types.Availability{Status: types.AvailabilityOnLoan, DueAt: "2024-05-05T10:00:00Z"}, 2 * day, true},
+		{"on loan with a queue", types.Availability{Status: types.AvailabilityOnLoan, DueAt: "2024-05-05T10:00:00Z",
+			QueueLength: 2}, 16 * day, true},
+		{"overdue", types.Availability{Status: types.AvailabilityOnLoan, DueAt: "2024-04-30T10:00:00Z"}, 0, true},
+		{"on hold", types.Availability{Status: types.AvailabilityOnHold, QueueLength: 1}, 14 * day, true},
+	}
+
+	for _, tt := range tests {
+		got, ok := Estimate(&tt.a, now)
+		if ok != tt.ok || (ok && !got.Equal(now.Add(tt.want))) {
+			t.Errorf("Estimate(%s) = %v, %v, want %v", tt.name, got, ok, now.Add(tt.want))
+		}
+	}
+}
+
+type fakeStore struct {
+	books map[string]types.Availability
+	asked [][]string
+}
+
+func (f *fakeStore) GetAvailability(ids []string) ([]types.Availability, int, types.Err) {
+	f.asked = append(f.asked, ids)
+	var availability []types.Availability
+	for _, id := range ids {
+		if a, ok := f.books[id]; ok {
+			availability = append(availability, a)
+		}
+	}
+	return availability, 200, types.Err{}
+}
+
+type fakeCache struct {
+	entries map[string][]byte
+}
+
+func (f *fakeCache) GetAvailability(ids []string) ([][]byte, types.Err) {
+	results := make([][]byte, len(ids))
+	for i, id := range ids {
+		results[i] = f.entries[id]
+	}
+	return results, types.Err{}
+}
+
+func (f *fakeCache) SaveAvailability(id *string, result []byte, _ time.Duration) types.Err {
+	f.entries[*id] = result
+	return types.Err{}
+}
+
+func TestCatalogGet(t *testing.T) {
+	store := &fakeStore{books: map[string]types.Availability{
+		"b1": {BookId: "b1", Status: types.AvailabilityAvailable, Copies: 1, AvailableCopies: 1},
+		"b2": {BookId: "b2", Status: types.AvailabilityOnLoan, Copies: 1, DueAt: "2024-05-05T10:00:00Z"},
+	}}
+	cache := &fakeCache{entries: map[string][]byte{}}
+	catalog := NewCatalog(store, cache)
+	catalog.now = func() time.Time { return time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC) }
+
+	books, _, errResp := catalog.Get([]string{"b2", "missing", "b1", "b2"})
+	if errResp.Error != "" {
+		t.Fatalf("Get returned an error: %s", errResp.Error)
+	}
+	if len(books) != 2 || books[0].BookId != "b2" || books[1].BookId != "b1" {
+		t.Fatalf("Get = %+v, want b2 and b1 in the order asked", books)
+	}
+	if books[0].EstimatedAvailableAt != "2024-05-05T10:00:00Z" || books[1].EstimatedAvailableAt != "" {
+		t.Errorf("Get estimated %q and %q", books[0].EstimatedAvailableAt, books[1].EstimatedAvailableAt)
+	}
+
+	// the books found are served from the cache, only the unknown one is asked for again
+	books, _, _ = catalog.Get([]string{"b1", "missing", "b2"})
+	if len(books) != 2 || books[1].EstimatedAvailableAt != "2024-05-05T10:00:00Z" {
+		t.Errorf("Get from the cache = %+v", books)
+	}
+	if len(store.asked) != 2 || !slices.Equal(store.asked[0], []string{"b2", "missing", "b1"}) ||
+		!slices.Equal(store.asked[1], []string{"missing"}) {
+		t.Errorf("the store was asked for %v", store.asked)
+	}
+}
@@ -0,0 +1,118 @@
+package barcode
+
+const (
+	code128StartB = 104
+	code128StartC = 105
+	code128CodeB  = 100
+	code128CodeC  = 99
+	code128Stop   = 106
+)
+
+// code128Patterns are the widths of the alternating bars and spaces of every Code 128 value,
+// each value is 11 modules wide except the stop pattern which is 13.
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+// Code128 encodes printable ASCII text as a Code 128 symbol.
+// Code set B is used for text and code set C packs runs of digits two per symbol, keeping labels short.
+// Returns ErrInvalidData if the text is empty or holds characters outside printable ASCII.
+func Code128(data string) (*Symbol, error) {
+	values, err := code128Values(data)
+	if err != nil {
+		return nil, err
+	}
+
+	width := 0
+	for _, value := range values {
+		for _, w := range code128Patterns[value] {
+			width += int(w - '0')
+		}
+	}
+
+	symbol := newSymbol(width, 1, 10)
+	x := 0
+	for _, value := range values {
+		for i, w := range code128Patterns[value] {
+			for j := 0; j < int(w-'0'); j++ {
+				symbol.set(x, 0, i%2 == 0)
+				x++
+			}
+		}
+	}
+
+	return symbol, nil
+}
+
+// code128Values returns the values of the symbol, from the start code through the check value to the stop code.
+func code128Values(data string) ([]int, error) {
+	if data == "" {
+		return nil, ErrInvalidData
+	}
+	for i := 0; i < len(data); i++ {
+		if data[i] < 32 || data[i] > 126 {
+			return nil, ErrInvalidData
+		}
+	}
+
+	var values []int
+	set := 0
+	use := func(next int) {
+		switch {
+		case set == next:
+			return
+		case set == 0 && next == code128StartB:
+			values = append(values, code128StartB)
+		case set == 0:
+			values = append(values, code128StartC)
+		case next == code128StartB:
+			values = append(values, code128CodeB)
+		default:
+			values = append(values, code128CodeC)
+		}
+		set = next
+	}
+
+	for i := 0; i < len(data); {
+		run := 0
+		for i+run < len(data) && data[i+run] >= '0' && data[i+run] <= '9' {
+			run++
+		}
+
+		// switching sets costs a symbol, so short runs of digits stay in set B
+		if run >= 6 || (run >= 4 && (i == 0 || i+run == len(data))) {
+			if run%2 == 1 {
+				use(code128StartB)
+				values = append(values, int(data[i])-32)
+				i, run = i+1, run-1
+			}
+
+			use(code128StartC)
+			for ; run > 0; i, run = i+2, run-2 {
+				values = append(values, int(data[i]-'0')*10+int(data[i+1]-'0'))
+			}
+			continue
+		}
+
+		use(code128StartB)
+		values = append(values, int(data[i])-32)
+		i++
+	}
+
+	checksum := values[0]
+	for i, value := range values[1:] {
+		checksum += (i + 1) * value
+	}
+
+	return append(values, checksum%103, code128Stop), nil
+}
@@ -0,0 +1,58 @@
+package barcode
+
+import "errors"
+
+var (
+	ErrInvalidData = errors.New("data cannot be encoded")
+	ErrTooLong     = errors.New("data is too long to encode")
+)
+
+// Symbol is an encoded barcode as a grid of modules, the narrowest elements of the code.
+// A linear code such as Code 128 has a single row that is stretched to the wanted height when rendered.
+type Symbol struct {
+	Width  int
+	Height int
+	// QuietZone is the number of light modules required around the symbol for it to scan.
+	QuietZone int
+	modules   []bool
+}
+
+func newSymbol(width, height, quietZone int) *Symbol {
+	return &Symbol{Width: width, Height: height, QuietZone: quietZone, modules: make([]bool, width*height)}
+}
+
+// Dark reports whether the module at column x and row y is dark, coordinates outside the symbol are light.
+func (s *Symbol) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= s.Width || y >= s.Height {
+		return false
+	}
+	return s.modules[y*s.Width+x]
+}
+
+func (s *Symbol) set(x, y int, dark bool) {
+	s.modules[y*s.Width+x] = dark
+}
+
+// IsLinear reports whether the symbol is a one-dimensional code.
+func (s *Symbol) IsLinear() bool {
+	return s.Height == 1
+}
+
+// Bars returns the runs of dark modules of the first row as start and width pairs, in modules.
+// Renderers use it to draw a linear code as one rectangle per bar.
+func (s *Symbol) Bars() [][2]int {
+	var bars [][2]int
+	for x := 0; x < s.Width; x++ {
+		if !s.Dark(x, 0) {
+			continue
+		}
+
+		start := x
+		for x < s.Width && s.Dark(x, 0) {
+			x++
+		}
+		bars = append(bars, [2]int{start, x - start})
+	}
+
+	return bars
+}
@@ -0,0 +1,254 @@
+package barcode
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCode128Patterns(t *testing.T) {
+	seen := make(map[string]bool)
+	for value, pattern := range code128Patterns {
+		width := 0
+		for _, w := range pattern {
+			width += int(w - '0')
+		}
+		if want := 11; value == code128Stop {
+			if width != 13 {
+				t.Errorf("stop pattern is %d modules wide, want 13", width)
+			}
+		} else if width != want {
+			t.Errorf("pattern %d is %d modules wide, want %d", value, width, want)
+		}
+
+		if seen[pattern] {
+			t.Errorf("pattern %d is not unique", value)
+		}
+		seen[pattern] = true
+	}
+}
+
+func TestCode128Values(t *testing.T) {
+	for data, want := range map[string][]int{
+		// set B only: 104 + 1*33 + 2*66 + 3*67 = 470, 470 % 103 = 58
+		"Abc": {code128StartB, 33, 66, 67, 58, code128Stop},
+		// set C only: 105 + 1*12 + 2*34 = 185, 185 % 103 = 82
+		"1234": {code128StartC, 12, 34, 82, code128Stop},
+		// an odd run encodes its first digit in set B: 104 + 34 + 2*16 + 3*99 + 4*0 + 5*1 + 6*23 = 610
+		"B0000123": {code128StartB, 34, 16, code128CodeC, 0, 1, 23, 610 % 103, code128Stop},
+		// a short run stays in set B
+		"A12": {code128StartB, 33, 17, 18, (104 + 33 + 2*17 + 3*18) % 103, code128Stop},
+	} {
+		got, err := code128Values(data)
+		if err != nil {
+			t.Fatalf("code128Values(%q) returned an error: %v", data, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("code128Values(%q) = %v, want %v", data, got, want)
+		}
+	}
+
+	for _, data := range []string{"", "tab\there", "café"} {
+		if _, err := Code128(data); !errors.Is(err, ErrInvalidData) {
+			t.Errorf("Code128(%q) = %v, want ErrInvalidData", data, err)
+		}
+	}
+}
+
+func TestCode128Symbol(t *testing.T) {
+	symbol, err := Code128("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// start, two values, check value: 11 modules each, plus the stop pattern
+	if symbol.Width != 4*11+13 || !symbol.IsLinear() {
+		t.Errorf("symbol is %dx%d", symbol.Width, symbol.Height)
+	}
+	// the start C pattern 211232 begins with a two module bar
+	if !symbol.Dark(0, 0) || !symbol.Dark(1, 0) || symbol.Dark(2, 0) {
+		t.Errorf("symbol does not begin with the start C pattern")
+	}
+	if bars := symbol.Bars(); bars[0] != [2]int{0, 2} || bars[len(bars)-1] != [2]int{symbol.Width - 2, 2} {
+		t.Errorf("Bars = %v", bars)
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	// HELLO WORLD as version 1-M in alphanumeric mode, a common worked example of the specification
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("reedSolomonRemainder = %v, want %v", got, want)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	if got := formatBits(0); got != 0b101010000010010 {
+		t.Errorf("formatBits(0) = %015b", got)
+	}
+	if got := versionBits(7); got != 0b000111110010010100 {
+		t.Errorf("versionBits(7) = %018b", got)
+	}
+}
+
+func TestQR(t *testing.T) {
+	for data, version := range map[string]int{
+		"B000000001":                           1,
+		"6ba7b810-9dad-11d1-80b4-00c04fd430c8": 3,
+		strings.Repeat("x", 120):               7,
+		strings.Repeat("x", 213):               10,
+	} {
+		symbol, err := QR(data)
+		if err != nil {
+			t.Fatalf("QR of %d bytes returned an error: %v", len(data), err)
+		}
+		if size := version*4 + 17; symbol.Width != size || symbol.Height != size {
+			t.Errorf("QR of %d bytes is %dx%d, want version %d", len(data), symbol.Width, symbol.Height, version)
+		}
+
+		// finder patterns in three corners, with a dark 3x3 center and a light ring around it
+		for _, corner := range [][2]int{{0, 0}, {symbol.Width - 7, 0}, {0, symbol.Width - 7}} {
+			if !symbol.Dark(corner[0], corner[1]) || symbol.Dark(corner[0]+1, corner[1]+1) ||
+				!symbol.Dark(corner[0]+3, corner[1]+3) {
+				t.Errorf("missing finder pattern at %v", corner)
+			}
+		}
+
+		if got := readQR(symbol); got != data {
+			t.Errorf("QR decodes to %q, want %q", got, data)
+		}
+	}
+
+	if _, err := QR(strings.Repeat("x", 214)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("QR of 214 bytes = %v, want ErrTooLong", err)
+	}
+}
+
+// readQR decodes a symbol produced by QR: it reads the mask from the format information,
+// unmasks and collects the codewords, checks the error correction and returns the byte mode payload.
+func readQR(symbol *Symbol) string {
+	version := (symbol.Width - 17) / 4
+	template := newQRCode(version)
+
+	format := 0
+	for i := 0; i <= 5; i++ {
+		if symbol.Dark(8, i) {
+			format |= 1 << i
+		}
+	}
+	for mask := 0; mask < 8; mask++ {
+		if formatBits(mask)&0b111111 == format {
+			code := &qrCode{Symbol: &Symbol{Width: symbol.Width, Height: symbol.Height,
+				modules: append([]bool(nil), symbol.modules...)}, version: version, function: template.function}
+			code.applyMask(mask)
+			return decodeQRCodewords(code)
+		}
+	}
+	return ""
+}
+
+func decodeQRCodewords(code *qrCode) string {
+	info := qrVersions[code.version]
+	total := info.dataCodewords() + len(info.blocks)*info.ecPerBlock
+
+	var bits bitBuffer
+	for right := code.Width - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vertical := 0; vertical < code.Height; vertical++ {
+			y := vertical
+			if upward {
+				y = code.Height - 1 - vertical
+			}
+			for j := 0; j < 2; j++ {
+				if x := right - j; !code.function[y*code.Width+x] && len(bits) < total*8 {
+					bits = append(bits, code.Dark(x, y))
+				}
+			}
+		}
+	}
+	codewords := bits.bytes()
+
+	blocks := make([][]byte, len(info.blocks))
+	i := 0
+	for k := 0; k < info.blocks[len(info.blocks)-1]; k++ {
+		for b, size := range info.blocks {
+			if k < size {
+				blocks[b] = append(blocks[b], codewords[i])
+				i++
+			}
+		}
+	}
+	divisor := reedSolomonDivisor(info.ecPerBlock)
+	for k := 0; k < info.ecPerBlock; k++ {
+		for b := range blocks {
+			if !bytes.Equal(reedSolomonRemainder(blocks[b], divisor)[k:k+1], codewords[i:i+1]) {
+				return "invalid error correction"
+			}
+			i++
+		}
+	}
+
+	var data bitBuffer
+	for _, block := range blocks {
+		for _, b := range block {
+			data.append(int(b), 8)
+		}
+	}
+	countBits := 8
+	if code.version >= 10 {
+		countBits = 16
+	}
+	read := func(offset, length int) int {
+		value := 0
+		for _, bit := range data[offset : offset+length] {
+			value <<= 1
+			if bit {
+				value |= 1
+			}
+		}
+		return value
+	}
+	if read(0, 4) != 0b0100 {
+		return "not byte mode"
+	}
+	length := read(4, countBits)
+	payload := make([]byte, length)
+	for k := range payload {
+		payload[k] = byte(read(4+countBits+8*k, 8))
+	}
+	return string(payload)
+}
+
+func TestRender(t *testing.T) {
+	symbol, err := Code128("B000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := PNG(&buf, symbol, 2, 40); err != nil {
+		t.Fatalf("PNG returned an error: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("PNG wrote an invalid image: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != (symbol.Width+20)*2 || bounds.Dy() != (40+20)*2 {
+		t.Errorf("PNG is %v", bounds)
+	}
+
+	buf.Reset()
+	if err := SVG(&buf, symbol, 2, 40); err != nil {
+		t.Fatalf("SVG returned an error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "<svg") || strings.Count(buf.String(), "M") != len(symbol.Bars()) {
+		t.Errorf("SVG = %q", buf.String())
+	}
+}
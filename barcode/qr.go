@@ -0,0 +1,444 @@
+package barcode
+
+// qrVersion describes the error correction blocks of a QR code version at error correction level M.
+type qrVersion struct {
+	ecPerBlock int
+	// blocks lists the data codewords of every block, the shorter blocks come first
+	blocks []int
+	// alignment lists the row and column centers of the alignment patterns
+	alignment []int
+}
+
+// qrVersions covers versions 1 to 10, which hold up to 213 bytes at level M,
+// plenty for the identifiers and URLs printed on labels.
+var qrVersions = []qrVersion{
+	{},
+	{ecPerBlock: 10, blocks: []int{16}},
+	{ecPerBlock: 16, blocks: []int{28}, alignment: []int{6, 18}},
+	{ecPerBlock: 26, blocks: []int{44}, alignment: []int{6, 22}},
+	{ecPerBlock: 18, blocks: []int{32, 32}, alignment: []int{6, 26}},
+	{ecPerBlock: 24, blocks: []int{43, 43}, alignment: []int{6, 30}},
+	{ecPerBlock: 16, blocks: []int{27, 27, 27, 27}, alignment: []int{6, 34}},
+	{ecPerBlock: 18, blocks: []int{31, 31, 31, 31}, alignment: []int{6, 22, 38}},
+	{ecPerBlock: 22, blocks: []int{38, 38, 39, 39}, alignment: []int{6, 24, 42}},
+	{ecPerBlock: 22, blocks: []int{36, 36, 36, 37, 37}, alignment: []int{6, 26, 46}},
+	{ecPerBlock: 26, blocks: []int{43, 43, 43, 43, 44}, alignment: []int{6, 28, 50}},
+}
+
+func (v *qrVersion) dataCodewords() int {
+	total := 0
+	for _, block := range v.blocks {
+		total += block
+	}
+	return total
+}
+
+// qrCode is a symbol under construction, function marks the modules that are not data.
+type qrCode struct {
+	*Symbol
+	version  int
+	function []bool
+}
+
+// QR encodes data as a QR code in byte mode at error correction level M, which survives about 15% damage.
+// The smallest version that fits the data is used and the mask with the lowest penalty is applied.
+// Returns ErrInvalidData if the data is empty and ErrTooLong if it exceeds 213 bytes.
+func QR(data string) (*Symbol, error) {
+	if data == "" {
+		return nil, ErrInvalidData
+	}
+
+	version := 0
+	for v := 1; v < len(qrVersions); v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*qrVersions[v].dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	codewords := qrCodewords([]byte(data), version)
+
+	best, bestPenalty := (*qrCode)(nil), -1
+	for mask := 0; mask < 8; mask++ {
+		code := newQRCode(version)
+		code.drawCodewords(codewords)
+		code.applyMask(mask)
+		code.drawFormat(mask)
+
+		if penalty := code.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = code, penalty
+		}
+	}
+
+	return best.Symbol, nil
+}
+
+// qrCodewords encodes the data segment and returns the interleaved data and error correction codewords.
+func qrCodewords(data []byte, version int) []byte {
+	info := qrVersions[version]
+	capacity := info.dataCodewords()
+
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	// terminator, padding to a byte and then alternating pad codewords
+	bits.append(0, min(4, capacity*8-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity*8; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	codewords := bits.bytes()
+	divisor := reedSolomonDivisor(info.ecPerBlock)
+
+	var blocks, ecBlocks [][]byte
+	offset := 0
+	for _, size := range info.blocks {
+		block := codewords[offset : offset+size]
+		offset += size
+		blocks = append(blocks, block)
+		ecBlocks = append(ecBlocks, reedSolomonRemainder(block, divisor))
+	}
+
+	longest := info.blocks[len(info.blocks)-1]
+	result := make([]byte, 0, capacity+len(blocks)*info.ecPerBlock)
+	for i := 0; i < longest; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < info.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+
+	return result
+}
+
+func newQRCode(version int) *qrCode {
+	size := version*4 + 17
+	code := &qrCode{Symbol: newSymbol(size, size, 4), version: version, function: make([]bool, size*size)}
+
+	for i := 0; i < size; i++ {
+		code.setFunction(6, i, i%2 == 0)
+		code.setFunction(i, 6, i%2 == 0)
+	}
+
+	code.drawFinder(3, 3)
+	code.drawFinder(size-4, 3)
+	code.drawFinder(3, size-4)
+
+	alignment := qrVersions[version].alignment
+	last := len(alignment) - 1
+	for i, y := range alignment {
+		for j, x := range alignment {
+			// the corners already hold finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					code.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// reserve the format areas, they are written once the mask is known
+	code.drawFormat(0)
+	code.drawVersion()
+
+	return code
+}
+
+func (q *qrCode) setFunction(x, y int, dark bool) {
+	q.set(x, y, dark)
+	q.function[y*q.Width+x] = true
+}
+
+func (q *qrCode) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= q.Width || y >= q.Height {
+				continue
+			}
+			distance := max(abs(dx), abs(dy))
+			q.setFunction(x, y, distance != 2 && distance != 4)
+		}
+	}
+}
+
+// formatBits returns the 15 bit format information for level M and the given mask.
+func formatBits(mask int) int {
+	// level M is encoded as 00
+	data := mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 9) * 0x537)
+	}
+	return (data<<10 | remainder) ^ 0x5412
+}
+
+func (q *qrCode) drawFormat(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool { return (bits>>i)&1 != 0 }
+	size := q.Width
+
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, bit(i))
+	}
+	q.setFunction(8, 7, bit(6))
+	q.setFunction(8, 8, bit(7))
+	q.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.setFunction(size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, size-15+i, bit(i))
+	}
+	q.setFunction(8, size-8, true)
+}
+
+// versionBits returns the 18 bit version information, only present from version 7 on.
+func versionBits(version int) int {
+	remainder := version
+	for i := 0; i < 12; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 11) * 0x1F25)
+	}
+	return version<<12 | remainder
+}
+
+func (q *qrCode) drawVersion() {
+	if q.version < 7 {
+		return
+	}
+
+	bits := versionBits(q.version)
+	for i := 0; i < 18; i++ {
+		dark := (bits>>i)&1 != 0
+		a, b := q.Width-11+i%3, i/3
+		q.setFunction(a, b, dark)
+		q.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords places the codewords in the two module wide columns zigzagging up and down from the bottom right.
+func (q *qrCode) drawCodewords(codewords []byte) {
+	i := 0
+	for right := q.Width - 1; right >= 1; right -= 2 {
+		// the vertical timing pattern is skipped as a whole column
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vertical := 0; vertical < q.Height; vertical++ {
+			y := vertical
+			if upward {
+				y = q.Height - 1 - vertical
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if q.function[y*q.Width+x] || i >= len(codewords)*8 {
+					continue
+				}
+				q.set(x, y, (codewords[i>>3]>>(7-i&7))&1 != 0)
+				i++
+			}
+		}
+	}
+}
+
+func (q *qrCode) applyMask(mask int) {
+	for y := 0; y < q.Height; y++ {
+		for x := 0; x < q.Width; x++ {
+			if q.function[y*q.Width+x] {
+				continue
+			}
+
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				q.set(x, y, !q.Dark(x, y))
+			}
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to read, following the four rules of the QR specification.
+func (q *qrCode) penalty() int {
+	size := q.Width
+	penalty := 0
+
+	line := func(dark func(i, j int) bool) {
+		for i := 0; i < size; i++ {
+			run := 1
+			for j := 1; j <= size; j++ {
+				if j < size && dark(i, j) == dark(i, j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += 3 + run - 5
+				}
+				run = 1
+			}
+
+			// finder-like 1:1:3:1:1 patterns preceded or followed by four light modules
+			for j := 0; j+11 <= size+4; j++ {
+				if matchesFinder(func(k int) bool { return dark(i, j+k) }, size-j) {
+					penalty += 40
+				}
+			}
+		}
+	}
+	line(func(i, j int) bool { return q.Dark(j, i) })
+	line(func(i, j int) bool { return q.Dark(i, j) })
+
+	dark := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if q.Dark(x, y) {
+				dark++
+			}
+			if x+1 < size && y+1 < size {
+				c := q.Dark(x, y)
+				if q.Dark(x+1, y) == c && q.Dark(x, y+1) == c && q.Dark(x+1, y+1) == c {
+					penalty += 3
+				}
+			}
+		}
+	}
+
+	total := size * size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return penalty + k*10
+}
+
+var finderRuns = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// matchesFinder reports whether the 11 modules starting at the current position form a finder-like pattern,
+// modules past the end of the line (remaining) count as light.
+func matchesFinder(dark func(k int) bool, remaining int) bool {
+	for _, pattern := range finderRuns {
+		matches := true
+		for k, want := range pattern {
+			if (k < remaining && dark(k)) != want {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// reedSolomonDivisor returns the generator polynomial of the given degree, without its leading coefficient.
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// reedSolomonRemainder returns the error correction codewords of the data.
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coefficient := range divisor {
+			result[i] ^= gfMultiply(coefficient, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo the QR polynomial x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 != 0)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	result := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			result[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+	return result
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
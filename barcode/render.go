@@ -0,0 +1,79 @@
+package barcode
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// MaxScale bounds the size of rendered images.
+const MaxScale = 20
+
+var palette = color.Palette{color.White, color.Black}
+
+// PNG renders the symbol with its quiet zone as a black and white PNG.
+// Parameters:
+// - w: where the image is written to.
+// - symbol: the symbol to render.
+// - scale: the size of a module in pixels, from 1 to MaxScale.
+// - barHeight: the height of the bars of a linear code in modules, ignored for two-dimensional codes.
+// Returns an error if the image cannot be written.
+func PNG(w io.Writer, symbol *Symbol, scale, barHeight int) error {
+	scale = min(max(scale, 1), MaxScale)
+	width, height, rows := symbol.extent(barHeight)
+
+	img := image.NewPaletted(image.Rect(0, 0, width*scale, height*scale), palette)
+	for py := 0; py < height*scale; py++ {
+		for px := 0; px < width*scale; px++ {
+			x, y := px/scale-symbol.QuietZone, py/scale-symbol.QuietZone
+			if y >= 0 && symbol.Dark(x, y/rows) {
+				img.SetColorIndex(px, py, 1)
+			}
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// SVG renders the symbol with its quiet zone as an SVG document, one unit per module.
+// Parameters are the same as for PNG, the scale sets the default display size.
+func SVG(w io.Writer, symbol *Symbol, scale, barHeight int) error {
+	scale = min(max(scale, 1), MaxScale)
+	width, height, rows := symbol.extent(barHeight)
+	q := symbol.QuietZone
+
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d"`+
+		` shape-rendering="crispEdges">`, width*scale, height*scale, width, height)
+	fmt.Fprintf(buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, width, height)
+
+	if symbol.IsLinear() {
+		for _, bar := range symbol.Bars() {
+			fmt.Fprintf(buf, "M%d %dh%dv%dh-%dz", bar[0]+q, q, bar[1], rows, bar[1])
+		}
+	} else {
+		for y := 0; y < symbol.Height; y++ {
+			for x := 0; x < symbol.Width; x++ {
+				if symbol.Dark(x, y) {
+					fmt.Fprintf(buf, "M%d %dh1v1h-1z", x+q, y+q)
+				}
+			}
+		}
+	}
+
+	buf.WriteString(`"/></svg>`)
+	return buf.Flush()
+}
+
+// extent returns the size of the rendered symbol in modules, including the quiet zone,
+// and how many rows each symbol row is stretched over.
+func (s *Symbol) extent(barHeight int) (int, int, int) {
+	rows := 1
+	if s.IsLinear() {
+		rows = max(barHeight, 1)
+	}
+	return s.Width + 2*s.QuietZone, s.Height*rows + 2*s.QuietZone, rows
+}
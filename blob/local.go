@@ -0,0 +1,124 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore is a Store backed by a directory of the local filesystem.
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore creates a LocalStore rooted at the BLOB_DIR environment variable, "data/blobs" by default.
+// The directory is created if it does not exist yet.
+// Returns the store and an error if the directory cannot be created.
+func NewLocalStore() (*LocalStore, error) {
+	dir := os.Getenv("BLOB_DIR")
+	if dir == "" {
+		dir = filepath.Join("data", "blobs")
+	}
+
+	return NewLocalStoreAt(dir)
+}
+
+// NewLocalStoreAt creates a LocalStore rooted at the given directory, creating it if needed.
+func NewLocalStoreAt(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalStore{dir: dir}, nil
+}
+
+// Put writes the content to a temporary file first and renames it into place,
+// so readers never see a partially written blob.
+func (l *LocalStore) Put(_ context.Context, key string, r io.Reader) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+func (l *LocalStore) Get(_ context.Context, key string) (io.ReadCloser, Info, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, Info{}, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, Info{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, Info{}, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, Info{}, err
+	}
+
+	return file, Info{Size: stat.Size(), ModTime: stat.ModTime()}, nil
+}
+
+func (l *LocalStore) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// path maps a key to a file below the root directory, rejecting keys that would escape it.
+func (l *LocalStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." || strings.HasPrefix(part, ".") {
+			return "", ErrInvalidKey
+		}
+	}
+
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
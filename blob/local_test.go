@@ -0,0 +1,58 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStoreAt(t.TempDir())
+	if err != nil {
+		t.Fatalf("unable to create store: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Put(ctx, "covers/book/hash/small.jpg", strings.NewReader("first")); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	if err := store.Put(ctx, "covers/book/hash/small.jpg", strings.NewReader("second")); err != nil {
+		t.Fatalf("Put returned an error when replacing: %v", err)
+	}
+
+	r, info, err := store.Get(ctx, "covers/book/hash/small.jpg")
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	content, _ := io.ReadAll(r)
+	r.Close()
+	if string(content) != "second" || info.Size != 6 {
+		t.Errorf("Get = %q (%d bytes), want %q", content, info.Size, "second")
+	}
+
+	if err := store.Delete(ctx, "covers/book/hash/small.jpg"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	if err := store.Delete(ctx, "covers/book/hash/small.jpg"); err != nil {
+		t.Errorf("Delete of a missing key returned an error: %v", err)
+	}
+
+	if _, _, err := store.Get(ctx, "covers/book/hash/small.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of a deleted key = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalStoreInvalidKey(t *testing.T) {
+	store, err := NewLocalStoreAt(t.TempDir())
+	if err != nil {
+		t.Fatalf("unable to create store: %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "covers/../../secret", "covers//small.jpg", "covers/.hidden"} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store keeps binary objects such as cover images outside of the database.
+// Keys are slash separated paths like "covers/<book id>/<hash>/small.jpg".
+type Store interface {
+	// Put stores the content under the key, replacing any previous content.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens the content stored under the key, it returns ErrNotFound if there is none.
+	Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+	// Delete removes the content stored under the key, deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Info describes a stored blob.
+type Info struct {
+	Size    int64
+	ModTime time.Time
+}
@@ -0,0 +1,125 @@
+package bookutil
+
+import (
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidAuthorRole = errors.New("invalid author role, expected author, editor or translator")
+	ErrMissingAuthorId   = errors.New("every linked author needs an id")
+	ErrMissingAuthorName = errors.New("author name is required")
+)
+
+// bylineSeparator matches the separators between names in a byline such as "Terry Pratchett & Neil Gaiman".
+// The migration that split the original author column uses the same expression.
+var bylineSeparator = regexp.MustCompile(`(?i)\s*;\s*|\s*&\s*|\s+and\s+`)
+
+// SplitAuthors splits a byline into the individual names it lists.
+// Names are trimmed and empty parts are dropped.
+func SplitAuthors(byline string) []string {
+	var names []string
+	for _, name := range bylineSeparator.Split(byline, -1) {
+		name = strings.Join(strings.Fields(name), " ")
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// initials matches a run of initials written without spaces, such as "J.K.".
+var initials = regexp.MustCompile(`^(\p{L}\.){2,}$`)
+
+// ParseAuthorName turns a name written either as "First Last" or "Last, First" into its display and sort form.
+// Initials are spaced out, so "J.K. Rowling" and "Rowling, J. K." give the same names.
+// A single word such as "Plato" is its own sort name.
+// Parameters:
+// - raw: the name as written in a byline or a request.
+// Returns the display name ("J. K. Rowling") and the sort name ("Rowling, J. K.").
+func ParseAuthorName(raw string) (string, string) {
+	words := strings.Fields(raw)
+	for i, word := range words {
+		if initials.MatchString(word) {
+			words[i] = strings.TrimSpace(strings.ReplaceAll(word, ".", ". "))
+		}
+	}
+	raw = strings.Join(words, " ")
+
+	if last, first, ok := strings.Cut(raw, ","); ok {
+		last, first = strings.TrimSpace(last), strings.TrimSpace(first)
+		if first == "" {
+			return last, last
+		}
+		return first + " " + last, last + ", " + first
+	}
+
+	i := strings.LastIndex(raw, " ")
+	if i < 0 {
+		return raw, raw
+	}
+
+	return raw, raw[i+1:] + ", " + raw[:i]
+}
+
+// NormalizeAuthor validates an author and derives the sort name when the request does not provide one.
+// Parameters:
+// - a: a pointer to the author, modified in place.
+// Returns an error if the name is blank.
+func NormalizeAuthor(a *types.CreateAuthor) error {
+	name, sortName := ParseAuthorName(a.Name)
+	if name == "" {
+		return ErrMissingAuthorName
+	}
+	a.Name = name
+
+	a.SortName = strings.Join(strings.Fields(a.SortName), " ")
+	if a.SortName == "" {
+		a.SortName = sortName
+	}
+
+	aliases := NormalizeSubjects(a.Aliases)
+	a.Aliases = make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		if !strings.EqualFold(alias, a.Name) {
+			a.Aliases = append(a.Aliases, alias)
+		}
+	}
+
+	return nil
+}
+
+// NormalizeBookAuthors validates the authors linked to a book, defaulting the role to author.
+// Links repeated with the same role are dropped, the remaining order is kept as the credit order.
+// Parameters:
+// - authors: the links as sent by the client.
+// Returns the links without duplicates and an error describing the first invalid link.
+func NormalizeBookAuthors(authors []types.BookAuthorRef) ([]types.BookAuthorRef, error) {
+	normalized := make([]types.BookAuthorRef, 0, len(authors))
+	seen := make(map[types.BookAuthorRef]bool, len(authors))
+	for _, author := range authors {
+		author.Id = strings.TrimSpace(author.Id)
+		author.Role = strings.ToLower(strings.TrimSpace(author.Role))
+		if author.Role == "" {
+			author.Role = types.RoleAuthor
+		}
+
+		if author.Id == "" {
+			return nil, ErrMissingAuthorId
+		}
+		if author.Role != types.RoleAuthor && author.Role != types.RoleEditor && author.Role != types.RoleTranslator {
+			return nil, ErrInvalidAuthorRole
+		}
+
+		if seen[author] {
+			continue
+		}
+		seen[author] = true
+		normalized = append(normalized, author)
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,65 @@
+package bookutil
+
+import (
+	"github.com/Tus1688/library-management-api/types"
+	"slices"
+	"testing"
+)
+
+func TestSplitAuthors(t *testing.T) {
+	cases := map[string][]string{
+		"Terry Pratchett & Neil Gaiman":             {"Terry Pratchett", "Neil Gaiman"},
+		"Rowling, J. K.":                            {"Rowling, J. K."},
+		"Kernighan, Brian; Ritchie, Dennis":         {"Kernighan, Brian", "Ritchie, Dennis"},
+		"Abelson AND Sussman":                       {"Abelson", "Sussman"},
+		"Alexander Sandburg":                        {"Alexander Sandburg"},
+		" Douglas  Adams ;; ":                       {"Douglas Adams"},
+		"Cormen and Leiserson and Rivest and Stein": {"Cormen", "Leiserson", "Rivest", "Stein"},
+	}
+
+	for byline, want := range cases {
+		if got := SplitAuthors(byline); !slices.Equal(got, want) {
+			t.Errorf("SplitAuthors(%q) = %q, want %q", byline, got, want)
+		}
+	}
+}
+
+func TestParseAuthorName(t *testing.T) {
+	cases := map[string][2]string{
+		"J.K. Rowling":   {"J. K. Rowling", "Rowling, J. K."},
+		"Rowling, J. K.": {"J. K. Rowling", "Rowling, J. K."},
+		"Rowling, J.K.":  {"J. K. Rowling", "Rowling, J. K."},
+		"R.R. Martin":    {"R. R. Martin", "Martin, R. R."},
+		"St. Augustine":  {"St. Augustine", "Augustine, St."},
+		"Plato":          {"Plato", "Plato"},
+		" Neil  Gaiman ": {"Neil Gaiman", "Gaiman, Neil"},
+		"Homer,":         {"Homer", "Homer"},
+	}
+
+	for raw, want := range cases {
+		name, sortName := ParseAuthorName(raw)
+		if name != want[0] || sortName != want[1] {
+			t.Errorf("ParseAuthorName(%q) = %q, %q, want %q, %q", raw, name, sortName, want[0], want[1])
+		}
+	}
+}
+
+func TestNormalizeBookAuthors(t *testing.T) {
+	authors, err := NormalizeBookAuthors([]types.BookAuthorRef{
+		{Id: "a"},
+		{Id: "b", Role: "Translator"},
+		{Id: "a", Role: "author"},
+	})
+	if err != nil {
+		t.Fatalf("NormalizeBookAuthors returned an error: %v", err)
+	}
+
+	want := []types.BookAuthorRef{{Id: "a", Role: types.RoleAuthor}, {Id: "b", Role: types.RoleTranslator}}
+	if !slices.Equal(authors, want) {
+		t.Errorf("NormalizeBookAuthors = %v, want %v", authors, want)
+	}
+
+	if _, err := NormalizeBookAuthors([]types.BookAuthorRef{{Id: "a", Role: "illustrator"}}); err == nil {
+		t.Errorf("NormalizeBookAuthors accepted an unknown role")
+	}
+}
@@ -0,0 +1,31 @@
+package bookutil
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidBarcode = errors.New("invalid barcode, expected up to 32 printable ASCII characters")
+
+// maxBarcodeLength keeps barcodes short enough to print legibly on a label.
+const maxBarcodeLength = 32
+
+// NormalizeBarcode validates an item barcode and uppercases it, so barcodes typed by hand match scanned ones.
+// Only printable ASCII is accepted, as that is what Code 128 labels can encode.
+// Parameters:
+// - raw: the barcode as scanned or entered.
+// Returns the normalized barcode and ErrInvalidBarcode if it is empty, too long or holds other characters.
+func NormalizeBarcode(raw string) (string, error) {
+	barcode := strings.ToUpper(strings.TrimSpace(raw))
+	if barcode == "" || len(barcode) > maxBarcodeLength {
+		return "", ErrInvalidBarcode
+	}
+
+	for i := 0; i < len(barcode); i++ {
+		if barcode[i] < 0x20 || barcode[i] > 0x7E {
+			return "", ErrInvalidBarcode
+		}
+	}
+
+	return barcode, nil
+}
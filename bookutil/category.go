@@ -0,0 +1,107 @@
+package bookutil
+
+import (
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"strings"
+)
+
+var ErrInvalidTag = errors.New("tags cannot be longer than 64 characters")
+
+// maxTagLength keeps free-form tags short enough to be shown as labels.
+const maxTagLength = 64
+
+// NormalizeTags lowercases the tags, collapses their whitespace and removes empty and duplicate entries.
+// A nil slice stays nil, as it means the tags are left untouched, while an empty slice clears them.
+// Parameters:
+// - tags: the tags as sent by the client.
+// Returns the normalized tags and an error if a tag is too long.
+func NormalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, ErrInvalidTag
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized, nil
+}
+
+// NormalizeIds lowercases the IDs and removes empty and duplicate entries.
+// Like NormalizeTags a nil slice stays nil.
+func NormalizeIds(ids []string) []string {
+	if ids == nil {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		normalized = append(normalized, id)
+	}
+
+	return normalized
+}
+
+// NormalizeClassification normalizes the categories and tags of a create or update request.
+// Parameters:
+// - categoryIds: the category IDs as sent by the client.
+// - tags: the tags as sent by the client.
+// Returns the normalized category IDs and tags, and an error if a tag is invalid.
+func NormalizeClassification(categoryIds, tags []string) ([]string, []string, error) {
+	tags, err := NormalizeTags(tags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return NormalizeIds(categoryIds), tags, nil
+}
+
+// CategoryTree nests a flat list of categories under their parents.
+// Categories whose parent is not part of the list become roots, the order of the list is kept among siblings.
+// Parameters:
+// - categories: every category of the tree, in the order siblings should appear.
+// Returns the root nodes, never nil.
+func CategoryTree(categories []types.Category) []types.CategoryNode {
+	known := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		known[category.Id] = true
+	}
+
+	children := make(map[string][]types.Category, len(categories))
+	var roots []types.Category
+	for _, category := range categories {
+		if category.ParentId == "" || !known[category.ParentId] {
+			roots = append(roots, category)
+			continue
+		}
+		children[category.ParentId] = append(children[category.ParentId], category)
+	}
+
+	var build func(level []types.Category) []types.CategoryNode
+	build = func(level []types.Category) []types.CategoryNode {
+		nodes := make([]types.CategoryNode, 0, len(level))
+		for _, category := range level {
+			nodes = append(nodes, types.CategoryNode{Category: category, Children: build(children[category.Id])})
+		}
+		return nodes
+	}
+
+	return build(roots)
+}
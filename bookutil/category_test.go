@@ -0,0 +1,51 @@
+package bookutil
+
+import (
+	"github.com/Tus1688/library-management-api/types"
+	"slices"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{" Science  Fiction", "science fiction", "", "Classics"})
+	if err != nil {
+		t.Fatalf("NormalizeTags returned an error: %v", err)
+	}
+	if want := []string{"science fiction", "classics"}; !slices.Equal(tags, want) {
+		t.Errorf("NormalizeTags = %q, want %q", tags, want)
+	}
+
+	if tags, _ := NormalizeTags(nil); tags != nil {
+		t.Errorf("NormalizeTags(nil) = %q, want nil", tags)
+	}
+
+	if tags, _ := NormalizeTags([]string{}); tags == nil || len(tags) != 0 {
+		t.Errorf("NormalizeTags([]) = %#v, want an empty slice", tags)
+	}
+}
+
+func TestCategoryTree(t *testing.T) {
+	tree := CategoryTree([]types.Category{
+		{Id: "800", Name: "Literature"},
+		{Id: "820", ParentId: "800", Name: "English literature"},
+		{Id: "500", Name: "Science"},
+		{Id: "823", ParentId: "820", Name: "English fiction"},
+		{Id: "orphan", ParentId: "missing", Name: "Orphan"},
+	})
+
+	if len(tree) != 3 || tree[0].Id != "800" || tree[1].Id != "500" || tree[2].Id != "orphan" {
+		t.Fatalf("unexpected roots: %+v", tree)
+	}
+
+	literature := tree[0]
+	if len(literature.Children) != 1 || literature.Children[0].Id != "820" {
+		t.Fatalf("unexpected children of 800: %+v", literature.Children)
+	}
+	if grandchildren := literature.Children[0].Children; len(grandchildren) != 1 || grandchildren[0].Id != "823" {
+		t.Errorf("unexpected children of 820: %+v", grandchildren)
+	}
+
+	if tree[1].Children == nil {
+		t.Errorf("leaf categories must have an empty, non-nil list of children")
+	}
+}
@@ -0,0 +1,98 @@
+package bookutil
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidIsbn = errors.New("invalid isbn")
+
+// NormalizeIsbn validates an ISBN-10 or ISBN-13 and returns it as a bare ISBN-13.
+// Hyphens and spaces are ignored and ISBN-10s are converted by prefixing 978.
+// Parameters:
+// - raw: the ISBN as entered, e.g. "0-306-40615-2" or "978-0-306-40615-7".
+// Returns the 13 digit ISBN and ErrInvalidIsbn if the length or checksum is wrong.
+func NormalizeIsbn(raw string) (string, error) {
+	isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(raw)))
+
+	switch len(isbn) {
+	case 10:
+		if !validIsbn10(isbn) {
+			return "", ErrInvalidIsbn
+		}
+		isbn13 := "978" + isbn[:9]
+		return isbn13 + string(isbn13CheckDigit(isbn13)), nil
+	case 13:
+		if !isDigits(isbn) || (!strings.HasPrefix(isbn, "978") && !strings.HasPrefix(isbn, "979")) {
+			return "", ErrInvalidIsbn
+		}
+		if isbn13CheckDigit(isbn[:12]) != isbn[12] {
+			return "", ErrInvalidIsbn
+		}
+		return isbn, nil
+	}
+
+	return "", ErrInvalidIsbn
+}
+
+// Isbn10 converts a normalized ISBN-13 back to its ISBN-10 form.
+// Returns false for 979 ISBNs, which have no ISBN-10 equivalent.
+func Isbn10(isbn13 string) (string, bool) {
+	if len(isbn13) != 13 || !strings.HasPrefix(isbn13, "978") {
+		return "", false
+	}
+
+	body := isbn13[3:12]
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(body[i]-'0') * (10 - i)
+	}
+
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return body + "X", true
+	}
+	return body + string(rune('0'+check)), true
+}
+
+func validIsbn10(isbn string) bool {
+	sum := 0
+	for i := 0; i < 10; i++ {
+		c := isbn[i]
+		var digit int
+		switch {
+		case c >= '0' && c <= '9':
+			digit = int(c - '0')
+		case c == 'X' && i == 9:
+			digit = 10
+		default:
+			return false
+		}
+		sum += digit * (10 - i)
+	}
+
+	return sum%11 == 0
+}
+
+// isbn13CheckDigit computes the check digit for the first 12 digits of an ISBN-13.
+func isbn13CheckDigit(first12 string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		digit := int(first12[i] - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package bookutil
+
+import "testing"
+
+func TestNormalizeIsbn(t *testing.T) {
+	cases := map[string]string{
+		"0-306-40615-2":     "9780306406157",
+		"978-0-306-40615-7": "9780306406157",
+		"080442957X":        "9780804429573",
+		"979 10 90636 07 1": "9791090636071",
+	}
+
+	for raw, want := range cases {
+		got, err := NormalizeIsbn(raw)
+		if err != nil {
+			t.Errorf("NormalizeIsbn(%q) returned an error: %v", raw, err)
+		}
+		if got != want {
+			t.Errorf("NormalizeIsbn(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestNormalizeIsbnFaulty(t *testing.T) {
+	for _, raw := range []string{"0-306-40615-3", "9780306406158", "12345", "X123456789", "1234567890123"} {
+		if _, err := NormalizeIsbn(raw); err == nil {
+			t.Errorf("NormalizeIsbn accepted %q", raw)
+		}
+	}
+}
+
+func TestIsbn10(t *testing.T) {
+	if isbn, ok := Isbn10("9780804429573"); !ok || isbn != "080442957X" {
+		t.Errorf("Isbn10 = %q, %t", isbn, ok)
+	}
+
+	if _, ok := Isbn10("9791090636071"); ok {
+		t.Errorf("Isbn10 converted a 979 isbn")
+	}
+}
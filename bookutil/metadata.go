@@ -0,0 +1,157 @@
+package bookutil
+
+import (
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidLanguage        = errors.New("invalid language, expected an ISO 639 code")
+	ErrInvalidPublicationYear = errors.New("invalid publication year")
+	ErrInvalidPageCount       = errors.New("invalid page count")
+)
+
+// NormalizeMetadata validates the bibliographic details of a book and brings them into the form they are stored in.
+// It is shared by the API and the importers so every entry point applies the same rules.
+// Parameters:
+// - m: a pointer to the metadata, modified in place.
+// Returns an error describing the first invalid field.
+func NormalizeMetadata(m *types.BookMetadata) error {
+	if strings.TrimSpace(m.Isbn) != "" {
+		isbn, err := NormalizeIsbn(m.Isbn)
+		if err != nil {
+			return err
+		}
+		m.Isbn = isbn
+	} else {
+		m.Isbn = ""
+	}
+
+	m.Publisher = strings.TrimSpace(m.Publisher)
+	m.Edition = strings.TrimSpace(m.Edition)
+
+	language, err := normalizeLanguage(m.Language)
+	if err != nil {
+		return err
+	}
+	m.Language = language
+
+	if err := validatePublicationYear(m.PublicationYear); err != nil {
+		return err
+	}
+
+	if m.PageCount < 0 {
+		return ErrInvalidPageCount
+	}
+
+	m.Subjects = NormalizeSubjects(m.Subjects)
+	return nil
+}
+
+// NormalizePatch applies the rules of NormalizeMetadata to the fields present in a merge patch.
+// Parameters:
+// - p: a pointer to the patch, modified in place.
+// Returns an error describing the first invalid field.
+func NormalizePatch(p *types.PatchBook) error {
+	if p.Barcode.Set {
+		barcode, err := NormalizeBarcode(p.Barcode.Value)
+		if err != nil {
+			return err
+		}
+		p.Barcode.Value = barcode
+	}
+
+	if p.Isbn.Set && !p.Isbn.Null && strings.TrimSpace(p.Isbn.Value) != "" {
+		isbn, err := NormalizeIsbn(p.Isbn.Value)
+		if err != nil {
+			return err
+		}
+		p.Isbn.Value = isbn
+	}
+
+	p.Publisher.Value = strings.TrimSpace(p.Publisher.Value)
+	p.Edition.Value = strings.TrimSpace(p.Edition.Value)
+
+	language, err := normalizeLanguage(p.Language.Value)
+	if err != nil {
+		return err
+	}
+	p.Language.Value = language
+
+	if err := validatePublicationYear(p.PublicationYear.Value); err != nil {
+		return err
+	}
+
+	if p.PageCount.Value < 0 {
+		return ErrInvalidPageCount
+	}
+
+	p.Subjects.Value = NormalizeSubjects(p.Subjects.Value)
+
+	authors, err := NormalizeBookAuthors(p.Authors.Value)
+	if err != nil {
+		return err
+	}
+	p.Authors.Value = authors
+
+	// null clears the classification, unlike a nil slice in a create or update request which leaves it untouched
+	categoryIds, tags, err := NormalizeClassification(p.CategoryIds.Value, p.Tags.Value)
+	if err != nil {
+		return err
+	}
+	if p.CategoryIds.Set && categoryIds == nil {
+		categoryIds = []string{}
+	}
+	if p.Tags.Set && tags == nil {
+		tags = []string{}
+	}
+	p.CategoryIds.Value, p.Tags.Value = categoryIds, tags
+	return nil
+}
+
+// NormalizeSubjects trims the subjects and removes empty and duplicate (case-insensitive) entries.
+// Returns an empty, non-nil slice if no subject is left.
+func NormalizeSubjects(subjects []string) []string {
+	normalized := make([]string, 0, len(subjects))
+	seen := make(map[string]bool, len(subjects))
+	for _, subject := range subjects {
+		subject = strings.Join(strings.Fields(subject), " ")
+		key := strings.ToLower(subject)
+		if subject == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, subject)
+	}
+
+	return normalized
+}
+
+func normalizeLanguage(raw string) (string, error) {
+	language := strings.ToLower(strings.TrimSpace(raw))
+	if language == "" {
+		return "", nil
+	}
+
+	if len(language) < 2 || len(language) > 3 {
+		return "", ErrInvalidLanguage
+	}
+	for _, c := range language {
+		if c < 'a' || c > 'z' {
+			return "", ErrInvalidLanguage
+		}
+	}
+
+	return language, nil
+}
+
+// validatePublicationYear accepts 0 (unknown) and years up to next year, for announced titles.
+func validatePublicationYear(year int) error {
+	if year < 0 || year > time.Now().Year()+1 {
+		return ErrInvalidPublicationYear
+	}
+
+	return nil
+}
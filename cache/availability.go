@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"github.com/Tus1688/library-management-api/types"
+	"time"
+)
+
+const availabilityPrefix = "availability:"
+
+func (r *RedisStore) SaveAvailability(id *string, result []byte, expiration time.Duration) types.Err {
+	err := r.db[5].Set(context.TODO(), availabilityPrefix+*id, result, expiration).Err()
+	if err != nil {
+		return types.Err{Error: "unable to save availability"}
+	}
+
+	return types.Err{}
+}
+
+// GetAvailability returns the cached availability of every book in one round trip, nil for the books it does not have.
+func (r *RedisStore) GetAvailability(ids []string) ([][]byte, types.Err) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = availabilityPrefix + id
+	}
+
+	values, err := r.db[5].MGet(context.TODO(), keys...).Result()
+	if err != nil {
+		return nil, types.Err{Error: "unable to get availability"}
+	}
+
+	results := make([][]byte, len(ids))
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			results[i] = []byte(s)
+		}
+	}
+
+	return results, types.Err{}
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// extendLock and releaseLock only touch a lock that is still held by the given owner,
+// so an instance never extends or releases a lock that expired and was taken over by another one.
+var (
+	extendLock = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	releaseLock = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// AcquireLock takes the lock of the given name unless another owner holds it.
+// The lock is released by ReleaseLock or when ttl passes.
+func (r *RedisStore) AcquireLock(name *string, owner *string, ttl time.Duration) (bool, types.Err) {
+	acquired, err := r.db[2].SetNX(context.TODO(), *name, *owner, ttl).Result()
+	if err != nil {
+		return false, types.Err{Error: "unable to acquire lock"}
+	}
+
+	return acquired, types.Err{}
+}
+
+// ExtendLock sets the time to live of a lock held by owner, it returns false if the lock is no longer held.
+func (r *RedisStore) ExtendLock(name *string, owner *string, ttl time.Duration) (bool, types.Err) {
+	extended, err := extendLock.Run(context.TODO(), r.db[2], []string{*name}, *owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, types.Err{Error: "unable to extend lock"}
+	}
+
+	return extended == 1, types.Err{}
+}
+
+// ReleaseLock releases a lock held by owner, it does nothing if the lock is no longer held.
+func (r *RedisStore) ReleaseLock(name *string, owner *string) types.Err {
+	err := releaseLock.Run(context.TODO(), r.db[2], []string{*name}, *owner).Err()
+	if err != nil {
+		return types.Err{Error: "unable to release lock"}
+	}
+
+	return types.Err{}
+}
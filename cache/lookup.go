@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+func (r *RedisStore) SaveLookup(isbn *string, result []byte, expiration time.Duration) types.Err {
+	err := r.db[1].Set(context.TODO(), *isbn, result, expiration).Err()
+	if err != nil {
+		return types.Err{Error: "unable to save lookup"}
+	}
+
+	return types.Err{}
+}
+
+// GetLookup returns the cached lookup result of an ISBN, or nil if it is not cached.
+func (r *RedisStore) GetLookup(isbn *string) ([]byte, types.Err) {
+	result, err := r.db[1].Get(context.TODO(), *isbn).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, types.Err{}
+		}
+		return nil, types.Err{Error: "unable to get lookup"}
+	}
+
+	return result, types.Err{}
+}
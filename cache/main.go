@@ -5,6 +5,7 @@ import (
 	"github.com/Tus1688/library-management-api/types"
 	"github.com/redis/go-redis/v9"
 	"os"
+	"time"
 )
 
 type Cache interface {
@@ -12,6 +13,25 @@ type Cache interface {
 	SaveRefreshToken(token *string, uid *string) types.Err
 	DeleteRefreshToken(token *string) types.Err
 	GetRefreshToken(token *string) (string, types.Err)
+	SavePatronRefreshToken(token *string, patronId *string) types.Err
+	DeletePatronRefreshToken(token *string) types.Err
+	GetPatronRefreshToken(token *string) (string, types.Err)
+	SaveLoginToken(tokenHash []byte, patronId *string, ttl time.Duration) types.Err
+	TakeLoginToken(tokenHash []byte) (string, types.Err)
+	ThrottleLoginLink(patronId *string, interval time.Duration) (bool, types.Err)
+	ThrottleLinkRequests(address *string, limit int64, window time.Duration) (bool, types.Err)
+	SaveLookup(isbn *string, result []byte, expiration time.Duration) types.Err
+	GetLookup(isbn *string) ([]byte, types.Err)
+	AcquireLock(name *string, owner *string, ttl time.Duration) (bool, types.Err)
+	ExtendLock(name *string, owner *string, ttl time.Duration) (bool, types.Err)
+	ReleaseLock(name *string, owner *string) types.Err
+	AppendStream(stream *string, values map[string]interface{}, maxLen int64) types.Err
+	Publish(channel *string, message []byte) types.Err
+	Subscribe(ctx context.Context, channel *string, fn func(message []byte)) types.Err
+	SaveReport(key *string, result []byte, expiration time.Duration) types.Err
+	GetReport(key *string) ([]byte, types.Err)
+	SaveAvailability(id *string, result []byte, expiration time.Duration) types.Err
+	GetAvailability(ids []string) ([][]byte, types.Err)
 }
 
 type RedisStore struct {
@@ -29,7 +49,12 @@ func (r *RedisStore) Shutdown() error {
 }
 
 // NewRedisStore creates a new RedisStore instance
-// 0 for refresh token
+// 0 for refresh tokens and patron sign-in links
+// 1 for isbn lookups
+// 2 for job locks
+// 3 for event streams and live updates
+// 4 for reports
+// 5 for book availability
 func NewRedisStore(numOfInstance int) (*RedisStore, error) {
 	db := make([]*redis.Client, numOfInstance)
 	for i := 0; i < numOfInstance; i++ {
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// patron portal keys share db[0] with the employee refresh tokens, the prefixes keep the realms apart
+const (
+	patronRefreshPrefix  = "patron:refresh:"
+	patronLinkPrefix     = "patron:link:"
+	patronThrottlePrefix = "patron:throttle:"
+	patronAddressPrefix  = "patron:address:"
+)
+
+// PatronRefreshTTL is how long a patron stays signed in to the portal without signing in again.
+const PatronRefreshTTL = 7 * 24 * time.Hour
+
+func (r *RedisStore) SavePatronRefreshToken(token *string, patronId *string) types.Err {
+	err := r.db[0].Set(context.TODO(), patronRefreshPrefix+*token, *patronId, PatronRefreshTTL).Err()
+	if err != nil {
+		return types.Err{Error: "unable to save refresh token"}
+	}
+
+	return types.Err{}
+}
+
+func (r *RedisStore) DeletePatronRefreshToken(token *string) types.Err {
+	err := r.db[0].Del(context.TODO(), patronRefreshPrefix+*token).Err()
+	if err != nil {
+		return types.Err{Error: "unable to delete refresh token"}
+	}
+
+	return types.Err{}
+}
+
+func (r *RedisStore) GetPatronRefreshToken(token *string) (string, types.Err) {
+	patronId, err := r.db[0].Get(context.TODO(), patronRefreshPrefix+*token).Result()
+	if err != nil {
+		return "", types.Err{Error: "unable to get refresh token"}
+	}
+
+	return patronId, types.Err{}
+}
+
+// SaveLoginToken keeps the hash of a sign-in link token until it is used or ttl passes.
+func (r *RedisStore) SaveLoginToken(tokenHash []byte, patronId *string, ttl time.Duration) types.Err {
+	err := r.db[0].Set(context.TODO(), patronLinkPrefix+hex.EncodeToString(tokenHash), *patronId, ttl).Err()
+	if err != nil {
+		return types.Err{Error: "unable to save sign-in link"}
+	}
+
+	return types.Err{}
+}
+
+// TakeLoginToken returns the patron a sign-in link token was made for and deletes it, so it works only once.
+// It returns an empty ID if the token is unknown or has expired.
+func (r *RedisStore) TakeLoginToken(tokenHash []byte) (string, types.Err) {
+	patronId, err := r.db[0].GetDel(context.TODO(), patronLinkPrefix+hex.EncodeToString(tokenHash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", types.Err{}
+	}
+	if err != nil {
+		return "", types.Err{Error: "unable to get sign-in link"}
+	}
+
+	return patronId, types.Err{}
+}
+
+// ThrottleLoginLink reports whether a sign-in link may be sent to a patron, at most one every interval.
+func (r *RedisStore) ThrottleLoginLink(patronId *string, interval time.Duration) (bool, types.Err) {
+	allowed, err := r.db[0].SetNX(context.TODO(), patronThrottlePrefix+*patronId, 1, interval).Result()
+	if err != nil {
+		return false, types.Err{Error: "unable to save sign-in link"}
+	}
+
+	return allowed, types.Err{}
+}
+
+// ThrottleLinkRequests counts the sign-in links asked for from a network address, and reports whether the request
+// is within the limit of the current window. The window starts with the first request.
+func (r *RedisStore) ThrottleLinkRequests(address *string, limit int64, window time.Duration) (bool, types.Err) {
+	key := patronAddressPrefix + *address
+	var count *redis.IntCmd
+	_, err := r.db[0].TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+		count = pipe.Incr(context.TODO(), key)
+		pipe.ExpireNX(context.TODO(), key, window)
+		return nil
+	})
+	if err != nil {
+		return false, types.Err{Error: "unable to throttle sign-in links"}
+	}
+
+	return count.Val() <= limit, types.Err{}
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"github.com/Tus1688/library-management-api/types"
+)
+
+// Publish sends a message to every subscriber of a Redis channel, on any replica.
+func (r *RedisStore) Publish(channel *string, message []byte) types.Err {
+	if err := r.db[3].Publish(context.TODO(), *channel, message).Err(); err != nil {
+		return types.Err{Error: "unable to publish message"}
+	}
+
+	return types.Err{}
+}
+
+// Subscribe passes every message published to a Redis channel to fn, until ctx is done.
+// Returns an error if the subscription cannot be made, messages published before that are not seen.
+func (r *RedisStore) Subscribe(ctx context.Context, channel *string, fn func(message []byte)) types.Err {
+	sub := r.db[3].Subscribe(ctx, *channel)
+	defer sub.Close()
+
+	// wait for the confirmation, the channel below reconnects on its own afterwards
+	if _, err := sub.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return types.Err{}
+		}
+		return types.Err{Error: "unable to subscribe to channel"}
+	}
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return types.Err{}
+		case message, ok := <-messages:
+			if !ok {
+				return types.Err{Error: "subscription closed"}
+			}
+			fn([]byte(message.Payload))
+		}
+	}
+}
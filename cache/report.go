@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+func (r *RedisStore) SaveReport(key *string, result []byte, expiration time.Duration) types.Err {
+	err := r.db[4].Set(context.TODO(), *key, result, expiration).Err()
+	if err != nil {
+		return types.Err{Error: "unable to save report"}
+	}
+
+	return types.Err{}
+}
+
+// GetReport returns a cached report, or nil if it is not cached.
+func (r *RedisStore) GetReport(key *string) ([]byte, types.Err) {
+	result, err := r.db[4].Get(context.TODO(), *key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, types.Err{}
+		}
+		return nil, types.Err{Error: "unable to get report"}
+	}
+
+	return result, types.Err{}
+}
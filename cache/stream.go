@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"context"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/redis/go-redis/v9"
+)
+
+// AppendStream adds an entry to a Redis stream, trimming it to about maxLen entries.
+func (r *RedisStore) AppendStream(stream *string, values map[string]interface{}, maxLen int64) types.Err {
+	err := r.db[3].XAdd(context.TODO(), &redis.XAddArgs{
+		Stream: *stream,
+		MaxLen: maxLen,
+		Approx: true,
+		Values: values,
+	}).Err()
+	if err != nil {
+		return types.Err{Error: "unable to append to stream"}
+	}
+
+	return types.Err{}
+}
@@ -3,38 +3,63 @@ package main
 import (
 	"flag"
 	"fmt"
+	"github.com/Tus1688/library-management-api/dataio"
 	"github.com/Tus1688/library-management-api/storage"
+	"github.com/Tus1688/library-management-api/types"
 	"log"
 	"os"
 )
 
+const usage = "expected 'init-admin', 'import-books' or 'export' subcommand"
+
 // main is the entry point of the CLI application.
-// It parses command-line flags for initializing an admin user and connects to the Postgres database.
+// It parses the subcommand and its flags and connects to the Postgres database.
 // If the 'init-admin' subcommand is provided, it initializes an admin user with the given username and password.
+// If the 'import-books' subcommand is provided, it imports books from a CSV or JSON Lines file.
+// If the 'export' subcommand is provided, it exports books, bookings or employees as CSV, JSON Lines or XLSX.
 func main() {
 	// Define the 'init-admin' subcommand and its flags
 	initAdmin := flag.NewFlagSet("init-admin", flag.ExitOnError)
 	username := initAdmin.String("username", "", "Admin username")
 	password := initAdmin.String("password", "", "Admin password")
 
+	// Define the 'import-books' subcommand and its flags
+	importBooks := flag.NewFlagSet("import-books", flag.ExitOnError)
+	importFile := importBooks.String("file", "", "Path to the CSV, JSON Lines, MARC21 or MARCXML file")
+	importFormat := importBooks.String("format", "", "File format: csv, jsonl, marc or marcxml (default: guessed from the extension)")
+	importDryRun := importBooks.Bool("dry-run", false, "Validate and report without committing anything")
+	importBatchSize := importBooks.Int("batch-size", dataio.DefaultBatchSize, "Rows committed per transaction")
+
+	// Define the 'export' subcommand and its flags
+	export := flag.NewFlagSet("export", flag.ExitOnError)
+	exportResource := export.String("resource", "", "What to export: books, bookings or employees")
+	exportFormat := export.String("format", "", "Export format: csv, jsonl, xlsx, or for books marc or marcxml (default: guessed from -out, or csv)")
+	exportOut := export.String("out", "", "Path of the file to write (default: stdout)")
+	exportSearch := export.String("search", "", "Only export books whose title matches")
+	exportIncludeDeleted := export.Bool("include-deleted", false, "Also export deleted books and employees")
+
 	// Check if a subcommand is provided
 	if len(os.Args) < 2 {
-		fmt.Println("expected 'init-admin' subcommand")
+		fmt.Println(usage)
 		os.Exit(1)
 	}
 
-	// Parse the 'init-admin' subcommand
+	// Parse the subcommand
+	var err error
 	switch os.Args[1] {
 	case "init-admin":
-		err := initAdmin.Parse(os.Args[2:])
-		if err != nil {
-			log.Fatal("unable to parse flags: ", err)
-			return
-		}
+		err = initAdmin.Parse(os.Args[2:])
+	case "import-books":
+		err = importBooks.Parse(os.Args[2:])
+	case "export":
+		err = export.Parse(os.Args[2:])
 	default:
-		fmt.Println("expected 'init-admin' subcommand")
+		fmt.Println(usage)
 		os.Exit(1)
 	}
+	if err != nil {
+		log.Fatal("unable to parse flags: ", err)
+	}
 
 	// Validate the parsed flags and initialize the admin user
 	if initAdmin.Parsed() {
@@ -57,4 +82,100 @@ func main() {
 
 		log.Print("admin user initialized successfully")
 	}
+
+	// Validate the parsed flags and import the books
+	if importBooks.Parsed() {
+		if *importFile == "" || *importBatchSize <= 0 {
+			importBooks.PrintDefaults()
+			os.Exit(1)
+		}
+
+		format := *importFormat
+		if format == "" {
+			format = dataio.FormatFromFilename(*importFile)
+		}
+
+		file, err := os.Open(*importFile)
+		if err != nil {
+			log.Fatal("unable to open file: ", err)
+		}
+		defer file.Close()
+
+		reader, err := dataio.NewBookReader(file, format)
+		if err != nil {
+			log.Fatal("unable to read file: ", err)
+		}
+
+		// Connect to the Postgres database
+		postgres, err := storage.NewPostgresStore()
+		if err != nil {
+			log.Fatal("unable to connect to postgres: ", err)
+		}
+
+		report, _, errResp := dataio.ImportBooks(postgres, reader, *importBatchSize, *importDryRun)
+		for _, row := range report.Rows {
+			fmt.Printf("line %d\t%s\t%s\t%s\n", row.Line, row.Status, row.Id, row.Error)
+		}
+		fmt.Printf("created: %d, updated: %d, skipped: %d, failed: %d, dry run: %t\n",
+			report.Created, report.Updated, report.Skipped, report.Failed, report.DryRun)
+
+		if errResp.Error != "" {
+			log.Fatal("unable to import books: ", errResp.Error)
+		}
+	}
+
+	// Validate the parsed flags and export the requested table
+	if export.Parsed() {
+		if *exportResource == "" {
+			export.PrintDefaults()
+			os.Exit(1)
+		}
+
+		format := *exportFormat
+		if format == "" {
+			format = dataio.FormatFromFilename(*exportOut)
+		}
+		if format == "" {
+			format = dataio.FormatCSV
+		}
+
+		out := os.Stdout
+		if *exportOut != "" {
+			file, err := os.Create(*exportOut)
+			if err != nil {
+				log.Fatal("unable to create file: ", err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		// Connect to the Postgres database
+		postgres, err := storage.NewPostgresStore()
+		if err != nil {
+			log.Fatal("unable to connect to postgres: ", err)
+		}
+
+		var rows int
+		var errResp types.Err
+		switch *exportResource {
+		case "books":
+			filter := types.BookFilter{Search: *exportSearch, IncludeDeleted: *exportIncludeDeleted}
+			rows, _, errResp = dataio.ExportBooks(postgres, &filter, out, format)
+		case "bookings":
+			filter := types.BookingFilter{}
+			rows, _, errResp = dataio.ExportBookings(postgres, &filter, out, format)
+		case "employees":
+			filter := types.EmployeeFilter{IncludeDeleted: *exportIncludeDeleted}
+			rows, _, errResp = dataio.ExportEmployees(postgres, &filter, out, format)
+		default:
+			export.PrintDefaults()
+			os.Exit(1)
+		}
+
+		if errResp.Error != "" {
+			log.Fatal("unable to export: ", errResp.Error)
+		}
+
+		log.Printf("exported %d %s", rows, *exportResource)
+	}
 }
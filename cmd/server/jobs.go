@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/jobs"
+	"github.com/Tus1688/library-management-api/notify"
+	"github.com/Tus1688/library-management-api/outbox"
+	"github.com/Tus1688/library-management-api/storage"
+	"github.com/Tus1688/library-management-api/webhook"
+	"log"
+	"os"
+)
+
+// jobRunHistoryDays is how long the run history of the background jobs is kept.
+const jobRunHistoryDays = 30
+
+// outboxHistoryDays is how long published events are kept in the outbox, so they can be replayed.
+const outboxHistoryDays = 30
+
+// addJobs registers the recurring work of the server with the runner.
+// NOTIFY_SCHEDULE sets when notifications are sent, every 15 minutes by default, when any channel is enabled.
+// Parameters:
+// - runner: the runner the jobs are added to.
+// - postgres: the storage the jobs work on.
+// - scheduler: sends the due date notifications.
+// - relay: publishes the events of the outbox to the sinks, every 5 seconds.
+// - deliverer: posts events to the webhooks, every 30 seconds.
+// The outbox and webhook runs that find nothing to do are not kept in the job history.
+// Holds that were not picked up in time are expired every 15 minutes, passing the book on to the next patron.
+// Returns an error if a schedule is invalid.
+func addJobs(runner *jobs.Runner, postgres *storage.PostgresStore, scheduler *notify.Scheduler,
+	relay *outbox.Relay, deliverer *webhook.Deliverer) error {
+	notifySchedule := os.Getenv("NOTIFY_SCHEDULE")
+	if notifySchedule == "" {
+		notifySchedule = "*/15 * * * *"
+	}
+
+	// without a channel the notifications are left due, rather than recorded as sent when nothing went out
+	if scheduler.Sends() {
+		err := runner.Add("notifications", notifySchedule, func(ctx context.Context) error {
+			sent, failed, err := scheduler.RunOnce(ctx)
+			if sent+failed > 0 {
+				log.Printf("notify: %d sent, %d failed", sent, failed)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Print("no notification channel is enabled, notifications are not sent")
+	}
+
+	err := runner.Add("outbox", "@every 5s", func(ctx context.Context) error {
+		published, err := relay.RunOnce(ctx)
+		if published == 0 && err == nil {
+			return jobs.ErrIdle
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = runner.Add("webhooks", "@every 30s", func(ctx context.Context) error {
+		delivered, failed, err := deliverer.RunOnce(ctx)
+		if delivered+failed == 0 && err == nil {
+			return jobs.ErrIdle
+		}
+		if delivered+failed > 0 {
+			log.Printf("webhook: %d delivered, %d failed", delivered, failed)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = runner.Add("holds", "*/15 * * * *", func(ctx context.Context) error {
+		expired, _, errResp := postgres.ExpireHolds()
+		if errResp.Error != "" {
+			return errors.New(errResp.Error)
+		}
+		if expired > 0 {
+			log.Printf("holds: %d expired", expired)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = runner.Add("kiosk-pairings", "@hourly", func(ctx context.Context) error {
+		_, _, errResp := postgres.ExpireKioskPairings()
+		if errResp.Error != "" {
+			return errors.New(errResp.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = runner.Add("job-history", "@daily", func(ctx context.Context) error {
+		_, _, errResp := postgres.PurgeJobRuns(jobRunHistoryDays)
+		if errResp.Error != "" {
+			return errors.New(errResp.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return runner.Add("outbox-history", "@daily", func(ctx context.Context) error {
+		_, _, errResp := postgres.PurgeOutbox(outboxHistoryDays)
+		if errResp.Error != "" {
+			return errors.New(errResp.Error)
+		}
+		return nil
+	})
+}
@@ -5,8 +5,16 @@ import (
 	"errors"
 	"github.com/Tus1688/library-management-api/api"
 	"github.com/Tus1688/library-management-api/authutil"
+	"github.com/Tus1688/library-management-api/blob"
 	"github.com/Tus1688/library-management-api/cache"
+	"github.com/Tus1688/library-management-api/jobs"
+	"github.com/Tus1688/library-management-api/live"
+	"github.com/Tus1688/library-management-api/lookup"
+	"github.com/Tus1688/library-management-api/notify"
+	"github.com/Tus1688/library-management-api/outbox"
+	"github.com/Tus1688/library-management-api/receiptutil"
 	"github.com/Tus1688/library-management-api/storage"
+	"github.com/Tus1688/library-management-api/webhook"
 	"log"
 	"net/http"
 	"os"
@@ -16,7 +24,8 @@ import (
 )
 
 // main is the entry point of the application.
-// It initializes the Postgres, Redis, and session stores, and starts the HTTP server.
+// It initializes the Postgres, Redis, session and blob stores, the lookup provider and the background jobs,
+// and starts the HTTP server.
 // It also handles graceful shutdown on receiving termination signals.
 func main() {
 	// Initialize Postgres store
@@ -26,7 +35,7 @@ func main() {
 	}
 
 	// Initialize Redis store
-	redis, err := cache.NewRedisStore(1)
+	redis, err := cache.NewRedisStore(6)
 	if err != nil {
 		log.Fatal("Unable to connect to redis")
 	}
@@ -37,8 +46,62 @@ func main() {
 		log.Fatal("Unable to create session store")
 	}
 
+	// Initialize blob store
+	blobs, err := blob.NewLocalStore()
+	if err != nil {
+		log.Fatal("Unable to create blob store")
+	}
+
+	// Initialize the branding of receipts and notices
+	branding, err := receiptutil.NewBranding()
+	if err != nil {
+		log.Fatal("Unable to load library branding: ", err)
+	}
+
+	// Initialize metadata lookup provider
+	provider, err := lookup.NewProvider()
+	if err != nil {
+		log.Fatal("Unable to create lookup provider: ", err)
+	}
+
+	// Initialize notification scheduler
+	sender, err := notify.NewSender()
+	if err != nil {
+		log.Fatal("Unable to create notification sender: ", err)
+	}
+
+	smsSender, err := notify.NewSMSSender()
+	if err != nil {
+		log.Fatal("Unable to create SMS sender: ", err)
+	}
+
+	scheduler, err := notify.NewScheduler(postgres, sender, smsSender)
+	if err != nil {
+		log.Fatal("Unable to create notification scheduler: ", err)
+	}
+
+	// Initialize outbox relay
+	sinks, err := outbox.NewSinks(postgres, redis)
+	if err != nil {
+		log.Fatal("Unable to create outbox sinks: ", err)
+	}
+	relay := outbox.NewRelay(postgres, sinks...)
+
+	// Initialize webhook deliverer
+	deliverer, err := webhook.NewDeliverer(postgres)
+	if err != nil {
+		log.Fatal("Unable to create webhook deliverer: ", err)
+	}
+
+	// Initialize background jobs, only one instance runs each of them at a time
+	runner := jobs.NewRunner(redis, postgres)
+	if err := addJobs(runner, postgres, scheduler, relay, deliverer); err != nil {
+		log.Fatal("Unable to add background jobs: ", err)
+	}
+
 	// Create a new server
-	server := api.NewServer(":8080", postgres, redis, session)
+	server := api.NewServer(":8080", postgres, redis, session, blobs, lookup.WithCache(provider, redis), runner,
+		live.NewBroker(), branding, scheduler)
 	serverCtx, serverStopCtx := context.WithCancel(context.Background())
 
 	// Set up signal handling for graceful shutdown
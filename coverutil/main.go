@@ -0,0 +1,179 @@
+package coverutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+// MaxUploadSize is the largest cover file accepted, in bytes.
+const MaxUploadSize = 5 << 20
+
+// maxPixels guards against small files that decode into huge images.
+const maxPixels = 40_000_000
+
+var (
+	ErrTooLarge        = errors.New("cover image is too large")
+	ErrUnsupportedType = errors.New("cover image must be a jpeg, png or gif")
+	ErrInvalidImage    = errors.New("cover image cannot be decoded")
+)
+
+// Size is a generated variant of a cover, scaled to fit within Width x Height.
+type Size struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// Sizes lists the variants generated for every cover, from the smallest to the largest.
+var Sizes = []Size{
+	{Name: "small", Width: 160, Height: 240},
+	{Name: "medium", Width: 400, Height: 600},
+	{Name: "large", Width: 1200, Height: 1800},
+}
+
+// ContentType is the content type of every generated variant.
+const ContentType = "image/jpeg"
+
+// Cover is a processed upload, ready to be written to a blob store.
+type Cover struct {
+	// Hash identifies the uploaded content, it is part of the cover key so cover URLs can be cached forever.
+	Hash string
+	// Images holds the encoded variants by size name.
+	Images map[string][]byte
+}
+
+// Process validates an uploaded cover and generates every variant listed in Sizes.
+// Transparent images are flattened onto a white background, images are never scaled up.
+// Parameters:
+// - data: the uploaded file.
+// Returns the processed cover and an error if the file is too large, not a supported image, or corrupt.
+func Process(data []byte) (Cover, error) {
+	if len(data) > MaxUploadSize {
+		return Cover{}, ErrTooLarge
+	}
+
+	switch http.DetectContentType(data) {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return Cover{}, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= 0 || config.Height <= 0 {
+		return Cover{}, ErrInvalidImage
+	}
+	if config.Width*config.Height > maxPixels {
+		return Cover{}, ErrTooLarge
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Cover{}, ErrInvalidImage
+	}
+
+	bounds := decoded.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), decoded, bounds.Min, draw.Over)
+
+	sum := sha256.Sum256(data)
+	cover := Cover{Hash: hex.EncodeToString(sum[:8]), Images: make(map[string][]byte, len(Sizes))}
+	for _, size := range Sizes {
+		width, height := fit(bounds.Dx(), bounds.Dy(), size.Width, size.Height)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(flat, width, height), &jpeg.Options{Quality: 85}); err != nil {
+			return Cover{}, err
+		}
+		cover.Images[size.Name] = buf.Bytes()
+	}
+
+	return cover, nil
+}
+
+// IsSize reports whether name is one of the generated variants.
+func IsSize(name string) bool {
+	for _, size := range Sizes {
+		if size.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Key returns the blob key of a cover variant.
+// Parameters:
+// - coverKey: the key stored with the book, "<book id>/<hash>".
+// - size: the name of the variant.
+func Key(coverKey, size string) string {
+	return "covers/" + coverKey + "/" + size + ".jpg"
+}
+
+// fit scales width x height down to fit within maxWidth x maxHeight, keeping the aspect ratio.
+func fit(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+
+	// compare maxWidth/width with maxHeight/height without floating point
+	if maxWidth*height <= maxHeight*width {
+		return maxWidth, max(1, (height*maxWidth+width/2)/width)
+	}
+
+	return max(1, (width*maxHeight+height/2)/height), maxHeight
+}
+
+// resize scales src to width x height by averaging the source pixels covered by each destination pixel.
+// It is meant for downscaling, where it avoids the aliasing of nearest neighbour sampling.
+func resize(src *image.RGBA, width, height int) *image.RGBA {
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+	if srcWidth == width && srcHeight == height {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcHeight/height, (y+1)*srcHeight/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcWidth/width, (x+1)*srcWidth/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(src.Pix[offset])
+					g += uint32(src.Pix[offset+1])
+					b += uint32(src.Pix[offset+2])
+					a += uint32(src.Pix[offset+3])
+					n++
+					offset += 4
+				}
+			}
+
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = uint8(r / n)
+			dst.Pix[offset+1] = uint8(g / n)
+			dst.Pix[offset+2] = uint8(b / n)
+			dst.Pix[offset+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
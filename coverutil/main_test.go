@@ -0,0 +1,79 @@
+package coverutil
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("unable to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	cover, err := Process(encodePNG(t, 800, 1000))
+	if err != nil {
+		t.Fatalf("Process returned an error: %v", err)
+	}
+
+	if len(cover.Hash) != 16 {
+		t.Errorf("unexpected hash %q", cover.Hash)
+	}
+
+	want := map[string][2]int{"small": {160, 200}, "medium": {400, 500}, "large": {800, 1000}}
+	for name, size := range want {
+		config, err := jpeg.DecodeConfig(bytes.NewReader(cover.Images[name]))
+		if err != nil {
+			t.Fatalf("%s is not a jpeg: %v", name, err)
+		}
+		if config.Width != size[0] || config.Height != size[1] {
+			t.Errorf("%s is %dx%d, want %dx%d", name, config.Width, config.Height, size[0], size[1])
+		}
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	if _, err := Process(encodePNG(t, 64, 64)[:100]); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("Process of a truncated png = %v, want ErrInvalidImage", err)
+	}
+
+	if _, err := Process([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>")); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Process of an svg = %v, want ErrUnsupportedType", err)
+	}
+
+	if _, err := Process(make([]byte, MaxUploadSize+1)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Process of an oversized file = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestFit(t *testing.T) {
+	cases := [][6]int{
+		{800, 1000, 160, 240, 160, 200},
+		{3000, 1000, 400, 600, 400, 133},
+		{100, 150, 400, 600, 100, 150},
+		{1000, 4000, 160, 240, 60, 240},
+	}
+
+	for _, c := range cases {
+		if width, height := fit(c[0], c[1], c[2], c[3]); width != c[4] || height != c[5] {
+			t.Errorf("fit(%d, %d, %d, %d) = %d, %d, want %d, %d", c[0], c[1], c[2], c[3], width, height, c[4], c[5])
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package dataio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/goccy/go-json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FormatXLSX is only supported for exports.
+const FormatXLSX = "xlsx"
+
+// RowWriter writes a table one row at a time.
+// Nothing is written to the underlying writer before the first row (or Close),
+// so a failure to start the export can still be reported as a regular error response.
+type RowWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// Exporter streams the tables that can be exported, it is implemented by storage.Storage.
+type Exporter interface {
+	StreamBooks(filter *types.BookFilter, fn func(book *types.ListBook) error) (int, types.Err)
+	StreamBookings(filter *types.BookingFilter, fn func(booking *types.GetBooking) error) (int, types.Err)
+	StreamEmployees(filter *types.EmployeeFilter, fn func(employee *types.ListEmployee) error) (int, types.Err)
+}
+
+// NewRowWriter creates a RowWriter for the given format.
+// Parameters:
+// - w: where the export is written to.
+// - format: one of FormatCSV, FormatJSONL or FormatXLSX.
+// - sheet: the name of the table, used as the XLSX sheet name.
+// - columns: the column names, written as a header row or used as JSON keys.
+// Returns the writer and an error if the format is unknown.
+func NewRowWriter(w io.Writer, format, sheet string, columns []string) (RowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvRowWriter{writer: csv.NewWriter(w), columns: columns}, nil
+	case FormatJSONL:
+		return &jsonlRowWriter{writer: bufio.NewWriter(w), columns: columns}, nil
+	case FormatXLSX:
+		return &xlsxRowWriter{w: w, sheet: sheet, columns: columns}, nil
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+// ContentType returns the MIME type of an export format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatMARC:
+		return "application/marc"
+	case FormatMARCXML:
+		return "application/marcxml+xml"
+	}
+
+	return "application/octet-stream"
+}
+
+// Extension returns the file extension of an export format, without the leading dot.
+func Extension(format string) string {
+	switch format {
+	case FormatMARC:
+		return "mrc"
+	case FormatMARCXML:
+		return "xml"
+	}
+
+	return format
+}
+
+var bookExportColumns = []string{"id", "title", "author", "description", "isbn", "publisher",
+	"publication_year", "edition", "language", "page_count", "subjects", "tags", "barcode", "is_booked",
+	"booked_until", "created_at", "updated_at", "deleted_at"}
+
+var bookingExportColumns = []string{"id", "book_id", "book_title", "book_author", "customer_name",
+	"customer_phone", "customer_email", "booked_until", "is_returned", "returned_at", "created_at", "updated_at", "updated_by"}
+
+var employeeExportColumns = []string{"id", "username", "created_at", "updated_at", "deleted_at"}
+
+// ExportBooks writes every book matching the filter to w.
+// Parameters:
+// - store: the storage the books are streamed from.
+// - filter: a pointer to the BookFilter, the same one the list endpoint uses.
+// - w: where the export is written to.
+// - format: one of FormatCSV, FormatJSONL, FormatXLSX, FormatMARC or FormatMARCXML.
+// Returns the number of rows written, status code, and an error if the export fails.
+func ExportBooks(store Exporter, filter *types.BookFilter, w io.Writer, format string) (int, int, types.Err) {
+	if isMARC(format) {
+		return exportBooksMARC(store, filter, w, format)
+	}
+
+	writer, err := NewRowWriter(w, format, "books", bookExportColumns)
+	if err != nil {
+		return 0, 400, types.Err{Error: err.Error()}
+	}
+
+	count := 0
+	statusCode, errResp := store.StreamBooks(filter, func(book *types.ListBook) error {
+		count++
+		return writer.WriteRow([]interface{}{book.Id, book.Title, book.Author, book.Description, book.Isbn,
+			book.Publisher, book.PublicationYear, book.Edition, book.Language, book.PageCount, book.Subjects,
+			book.Tags, book.Barcode, book.IsBooked, book.BookedUntil, book.CreatedAt, book.UpdatedAt, book.DeletedAt})
+	})
+
+	return finishExport(writer, count, statusCode, errResp)
+}
+
+// ExportBookings writes every booking matching the filter to w.
+// Parameters:
+// - store: the storage the bookings are streamed from.
+// - filter: a pointer to the BookingFilter, the same one the list endpoint uses.
+// - w: where the export is written to.
+// - format: one of FormatCSV, FormatJSONL or FormatXLSX.
+// Returns the number of rows written, status code, and an error if the export fails.
+func ExportBookings(store Exporter, filter *types.BookingFilter, w io.Writer, format string) (int, int, types.Err) {
+	writer, err := NewRowWriter(w, format, "bookings", bookingExportColumns)
+	if err != nil {
+		return 0, 400, types.Err{Error: err.Error()}
+	}
+
+	count := 0
+	statusCode, errResp := store.StreamBookings(filter, func(booking *types.GetBooking) error {
+		count++
+		return writer.WriteRow([]interface{}{booking.Id, booking.BookId, booking.BookTitle, booking.BookAuthor,
+			booking.CustomerName, booking.CustomerPhone, booking.CustomerEmail, booking.BookedUntil, booking.IsReturned, booking.ReturnedAt,
+			booking.CreatedAt, booking.UpdatedAt, booking.UpdatedBy})
+	})
+
+	return finishExport(writer, count, statusCode, errResp)
+}
+
+// ExportEmployees writes every employee matching the filter to w, without any credentials.
+// Parameters:
+// - store: the storage the employees are streamed from.
+// - filter: a pointer to the EmployeeFilter, the same one the list endpoint uses.
+// - w: where the export is written to.
+// - format: one of FormatCSV, FormatJSONL or FormatXLSX.
+// Returns the number of rows written, status code, and an error if the export fails.
+func ExportEmployees(store Exporter, filter *types.EmployeeFilter, w io.Writer, format string) (int, int, types.Err) {
+	writer, err := NewRowWriter(w, format, "employees", employeeExportColumns)
+	if err != nil {
+		return 0, 400, types.Err{Error: err.Error()}
+	}
+
+	count := 0
+	statusCode, errResp := store.StreamEmployees(filter, func(employee *types.ListEmployee) error {
+		count++
+		return writer.WriteRow([]interface{}{employee.Id, employee.Username, employee.CreatedAt, employee.UpdatedAt,
+			employee.DeletedAt})
+	})
+
+	return finishExport(writer, count, statusCode, errResp)
+}
+
+// finishExport closes the writer once the stream has ended successfully.
+func finishExport(writer io.Closer, count, statusCode int, errResp types.Err) (int, int, types.Err) {
+	if errResp.Error != "" {
+		return count, statusCode, errResp
+	}
+
+	if err := writer.Close(); err != nil {
+		return count, 500, types.Err{Error: "unable to write export"}
+	}
+
+	return count, 200, types.Err{}
+}
+
+// formatValue renders a cell value as text for formats without types.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, "; ")
+	case nil:
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+type csvRowWriter struct {
+	writer  *csv.Writer
+	columns []string
+	started bool
+}
+
+func (c *csvRowWriter) start() error {
+	if c.started {
+		return nil
+	}
+	c.started = true
+	return c.writer.Write(c.columns)
+}
+
+func (c *csvRowWriter) WriteRow(values []interface{}) error {
+	if err := c.start(); err != nil {
+		return err
+	}
+
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatValue(value)
+	}
+	return c.writer.Write(record)
+}
+
+func (c *csvRowWriter) Close() error {
+	if err := c.start(); err != nil {
+		return err
+	}
+
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type jsonlRowWriter struct {
+	writer  *bufio.Writer
+	columns []string
+}
+
+func (j *jsonlRowWriter) WriteRow(values []interface{}) error {
+	// keys are written by hand to keep the column order stable
+	_ = j.writer.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			_ = j.writer.WriteByte(',')
+		}
+
+		key, err := json.Marshal(j.columns[i])
+		if err != nil {
+			return err
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		_, _ = j.writer.Write(key)
+		_ = j.writer.WriteByte(':')
+		_, _ = j.writer.Write(encoded)
+	}
+
+	_, err := j.writer.WriteString("}\n")
+	return err
+}
+
+func (j *jsonlRowWriter) Close() error {
+	return j.writer.Flush()
+}
@@ -0,0 +1,86 @@
+package dataio
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/Tus1688/library-management-api/types"
+	"io"
+	"strings"
+	"testing"
+)
+
+type fakeExporter struct {
+	books []types.ListBook
+}
+
+func (f *fakeExporter) StreamBooks(_ *types.BookFilter, fn func(book *types.ListBook) error) (int, types.Err) {
+	for i := range f.books {
+		if err := fn(&f.books[i]); err != nil {
+			return 500, types.Err{Error: "unable to export books"}
+		}
+	}
+	return 200, types.Err{}
+}
+
+func (f *fakeExporter) StreamBookings(_ *types.BookingFilter, _ func(*types.GetBooking) error) (int, types.Err) {
+	return 200, types.Err{}
+}
+
+func (f *fakeExporter) StreamEmployees(_ *types.EmployeeFilter, _ func(*types.ListEmployee) error) (int, types.Err) {
+	return 200, types.Err{}
+}
+
+var exportedBooks = &fakeExporter{books: []types.ListBook{
+	{Id: "1", Title: "Dune", Author: "Frank Herbert", Description: "Spice, sand & worms", IsBooked: true},
+}}
+
+func TestExportBooksCSV(t *testing.T) {
+	var buf bytes.Buffer
+	rows, _, err := ExportBooks(exportedBooks, &types.BookFilter{}, &buf, FormatCSV)
+	if err.Error != "" || rows != 1 {
+		t.Fatalf("ExportBooks failed: %d rows, %v", rows, err.Error)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "id,title") || !strings.Contains(lines[1], `"Spice, sand & worms"`) {
+		t.Errorf("ExportBooks wrote the wrong csv: %q", buf.String())
+	}
+}
+
+func TestExportBooksJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	if _, _, err := ExportBooks(exportedBooks, &types.BookFilter{}, &buf, FormatJSONL); err.Error != "" {
+		t.Fatalf("ExportBooks returned an error: %v", err.Error)
+	}
+
+	if !strings.HasPrefix(buf.String(), `{"id":"1","title":"Dune"`) || !strings.Contains(buf.String(), `"is_booked":true`) {
+		t.Errorf("ExportBooks wrote the wrong json lines: %q", buf.String())
+	}
+}
+
+func TestExportBooksXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if _, _, err := ExportBooks(exportedBooks, &types.BookFilter{}, &buf, FormatXLSX); err.Error != "" {
+		t.Fatalf("ExportBooks returned an error: %v", err.Error)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ExportBooks wrote an invalid zip: %v", err)
+	}
+
+	for _, file := range archive.File {
+		if file.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+
+		reader, _ := file.Open()
+		sheet, _ := io.ReadAll(reader)
+		if !strings.Contains(string(sheet), "Spice, sand &amp; worms") || !strings.Contains(string(sheet), `<row r="2">`) {
+			t.Errorf("ExportBooks wrote the wrong sheet: %s", sheet)
+		}
+		return
+	}
+
+	t.Errorf("ExportBooks did not write a worksheet")
+}
@@ -0,0 +1,340 @@
+package dataio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/Tus1688/library-management-api/bookutil"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BookRecord is a single record read from an import file.
+// Err is set when the record could not be decoded or does not pass the CreateBook binding rules.
+type BookRecord struct {
+	Line int
+	Book types.CreateBook
+	Err  error
+}
+
+// BookReader streams book records out of an import file one at a time.
+// Next returns io.EOF once the file is exhausted.
+type BookReader interface {
+	Next() (BookRecord, error)
+}
+
+// BookImporter persists a batch of validated rows, it is implemented by storage.Storage.
+type BookImporter interface {
+	ImportBooks(rows []types.ImportBookRow, dryRun bool) ([]types.ImportResult, int, types.Err)
+}
+
+// NewBookReader creates a BookReader for the given format.
+// Parameters:
+// - r: the file or request body to read from.
+// - format: one of FormatCSV, FormatJSONL, FormatMARC or FormatMARCXML.
+// Returns the reader and an error if the format is unknown or the CSV header cannot be read.
+func NewBookReader(r io.Reader, format string) (BookReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVBookReader(r)
+	case FormatJSONL:
+		return newJSONLBookReader(r), nil
+	case FormatMARC, FormatMARCXML:
+		return newMARCBookReader(r, format), nil
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+// ImportBooks reads every record from the reader and hands them to the importer in batches.
+// Records that fail to decode or validate are reported as failed without reaching the importer.
+// Each batch is committed on its own, so a failure leaves earlier batches in place: the report is then returned
+// along with the error, with the rows of the failing batch, or the line that could not be read, reported as failed.
+// Parameters:
+// - importer: the storage the rows are written to.
+// - reader: the source of the records.
+// - batchSize: how many rows are committed per transaction.
+// - dryRun: whether the transactions are rolled back instead of committed.
+// Returns the import report, status code, and an error if the file cannot be read or a batch could not be imported.
+func ImportBooks(importer BookImporter, reader BookReader, batchSize int,
+	dryRun bool) (types.ImportReport, int, types.Err) {
+	report := types.ImportReport{DryRun: dryRun, Rows: []types.ImportResult{}}
+	batch := make([]types.ImportBookRow, 0, batchSize)
+	line := 0
+
+	flush := func() (int, types.Err) {
+		if len(batch) == 0 {
+			return 200, types.Err{}
+		}
+
+		results, statusCode, err := importer.ImportBooks(batch, dryRun)
+		if err.Error != "" {
+			for _, row := range batch {
+				addResult(&report, types.ImportResult{Line: row.Line, Status: types.ImportFailed, Error: err.Error})
+			}
+			return statusCode, err
+		}
+
+		for _, result := range results {
+			addResult(&report, result)
+		}
+		batch = batch[:0]
+		return 200, types.Err{}
+	}
+
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			errResp := types.Err{Error: "unable to read import file: " + err.Error()}
+			addResult(&report, types.ImportResult{Line: line + 1, Status: types.ImportFailed, Error: errResp.Error})
+			return stopImport(&report, readStatus(err), errResp)
+		}
+		line = max(line, record.Line)
+
+		if record.Err != nil {
+			addResult(&report, types.ImportResult{
+				Line:   record.Line,
+				Status: types.ImportFailed,
+				Error:  record.Err.Error(),
+			})
+			continue
+		}
+
+		batch = append(batch, types.ImportBookRow{Line: record.Line, Book: record.Book})
+		if len(batch) >= batchSize {
+			if statusCode, err := flush(); err.Error != "" {
+				return stopImport(&report, statusCode, err)
+			}
+		}
+	}
+
+	if statusCode, err := flush(); err.Error != "" {
+		return stopImport(&report, statusCode, err)
+	}
+
+	sortRows(&report)
+	return report, 200, types.Err{}
+}
+
+// stopImport ends an import that failed partway, the report keeps the rows handled so far.
+func stopImport(report *types.ImportReport, statusCode int, err types.Err) (types.ImportReport, int, types.Err) {
+	report.Error = err.Error
+	sortRows(report)
+	return *report, statusCode, err
+}
+
+// sortRows orders the rows of a report by line.
+// Rejected records are reported right away while valid ones wait for their batch.
+func sortRows(report *types.ImportReport) {
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		return report.Rows[i].Line < report.Rows[j].Line
+	})
+}
+
+// readStatus maps an error reading the import file to a status code, a body over its limit is 413.
+func readStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
+
+type csvBookReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVBookReader(r io.Reader) (*csvBookReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+
+	return &csvBookReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvBookReader) Next() (BookRecord, error) {
+	record, err := c.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return BookRecord{}, io.EOF
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return BookRecord{Line: parseErr.StartLine, Err: parseErr.Err}, nil
+	}
+	if err != nil {
+		return BookRecord{}, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	book := types.CreateBook{
+		Title:       c.field(record, "title"),
+		Author:      c.field(record, "author"),
+		Description: c.field(record, "description"),
+		BookMetadata: types.BookMetadata{
+			Isbn:      c.field(record, "isbn"),
+			Publisher: c.field(record, "publisher"),
+			Edition:   c.field(record, "edition"),
+			Language:  c.field(record, "language"),
+			Subjects:  strings.FieldsFunc(c.field(record, "subjects"), isSubjectSeparator),
+		},
+	}
+
+	// a missing tags column leaves the tags untouched, an empty cell clears them
+	if _, ok := c.columns["tags"]; ok {
+		book.Tags = strings.FieldsFunc(c.field(record, "tags"), isSubjectSeparator)
+		if book.Tags == nil {
+			book.Tags = []string{}
+		}
+	}
+
+	if book.PublicationYear, err = c.intField(record, "publication_year"); err != nil {
+		return BookRecord{Line: line, Err: err}, nil
+	}
+	if book.PageCount, err = c.intField(record, "page_count"); err != nil {
+		return BookRecord{Line: line, Err: err}, nil
+	}
+
+	return BookRecord{Line: line, Book: book, Err: validateBook(&book)}, nil
+}
+
+// field returns the trimmed value of the named column, or an empty string if the row has no such column.
+func (c *csvBookReader) field(record []string, name string) string {
+	i, ok := c.columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[i])
+}
+
+// intField parses the named column as an integer, an empty or missing column yields 0.
+func (c *csvBookReader) intField(record []string, name string) (int, error) {
+	value := c.field(record, name)
+	if value == "" {
+		return 0, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("field %s is not a number", name)
+	}
+	return number, nil
+}
+
+// isSubjectSeparator splits the subjects and tags columns, which list values separated by ";" or "|".
+func isSubjectSeparator(r rune) bool {
+	return r == ';' || r == '|'
+}
+
+type jsonlBookReader struct {
+	scanner *bufio.Scanner
+	line    int
+	// unterminated is set when the scanner hands out a last line that has no newline
+	unterminated bool
+}
+
+func newJSONLBookReader(r io.Reader) *jsonlBookReader {
+	j := &jsonlBookReader{scanner: newLineScanner(r)}
+	j.scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		j.unterminated = atEOF && advance == len(data) && !bytes.HasSuffix(data, []byte("\n"))
+		return advance, token, err
+	})
+	return j
+}
+
+func (j *jsonlBookReader) Next() (BookRecord, error) {
+	for j.scanner.Scan() {
+		// the scanner still hands out the rest of the input after a read error, a last line without newline is cut short
+		if err := j.scanner.Err(); err != nil && j.unterminated {
+			return BookRecord{}, err
+		}
+
+		j.line++
+		text := strings.TrimSpace(j.scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var book types.CreateBook
+		if err := jsonutil.UnmarshalJSON([]byte(text), &book); err != nil {
+			return BookRecord{Line: j.line, Err: errors.New("invalid json")}, nil
+		}
+
+		return BookRecord{Line: j.line, Book: book, Err: validateBook(&book)}, nil
+	}
+
+	if err := j.scanner.Err(); err != nil {
+		return BookRecord{}, err
+	}
+
+	return BookRecord{}, io.EOF
+}
+
+// validateBook applies the rules of the create book endpoint: the binding tags of types.CreateBook
+// and the normalization of its bibliographic metadata, linked authors and classification.
+func validateBook(book *types.CreateBook) error {
+	if err := jsonutil.Validate(book); err != nil {
+		return err
+	}
+
+	authors, err := bookutil.NormalizeBookAuthors(book.Authors)
+	if err != nil {
+		return err
+	}
+	book.Authors = authors
+
+	categoryIds, tags, err := bookutil.NormalizeClassification(book.CategoryIds, book.Tags)
+	if err != nil {
+		return err
+	}
+	book.CategoryIds, book.Tags = categoryIds, tags
+
+	return bookutil.NormalizeMetadata(&book.BookMetadata)
+}
+
+// newLineScanner creates a line scanner that tolerates long lines such as lengthy descriptions.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	return scanner
+}
+
+// addResult records a row result in the report and updates the matching counter.
+func addResult(report *types.ImportReport, result types.ImportResult) {
+	switch result.Status {
+	case types.ImportCreated:
+		report.Created++
+	case types.ImportUpdated:
+		report.Updated++
+	case types.ImportSkipped:
+		report.Skipped++
+	case types.ImportFailed:
+		report.Failed++
+	}
+
+	report.Rows = append(report.Rows, result)
+}
@@ -0,0 +1,103 @@
+package dataio
+
+import (
+	"github.com/Tus1688/library-management-api/types"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type fakeImporter struct {
+	batches int
+	failAt  int
+}
+
+func (f *fakeImporter) ImportBooks(rows []types.ImportBookRow, _ bool) ([]types.ImportResult, int, types.Err) {
+	f.batches++
+	if f.batches == f.failAt {
+		return nil, 500, types.Err{Error: "unable to import books"}
+	}
+	results := make([]types.ImportResult, len(rows))
+	for i, row := range rows {
+		results[i] = types.ImportResult{Line: row.Line, Status: types.ImportCreated}
+	}
+	return results, 200, types.Err{}
+}
+
+func TestImportBooksCSV(t *testing.T) {
+	file := "Title,Author,Description\n" +
+		"Dune,Frank Herbert,Spice\n" +
+		"\"Missing author\",,Nothing\n" +
+		"Emma,Jane Austen,Matchmaking\n"
+
+	reader, err := NewBookReader(strings.NewReader(file), FormatCSV)
+	if err != nil {
+		t.Fatalf("NewBookReader returned an error: %v", err)
+	}
+
+	importer := &fakeImporter{}
+	report, _, errResp := ImportBooks(importer, reader, 1, true)
+	if errResp.Error != "" {
+		t.Fatalf("ImportBooks returned an error: %v", errResp.Error)
+	}
+
+	if report.Created != 2 || report.Failed != 1 || importer.batches != 2 {
+		t.Errorf("ImportBooks produced the wrong report: %+v in %d batches", report, importer.batches)
+	}
+
+	if report.Rows[1].Line != 3 || report.Rows[1].Status != types.ImportFailed {
+		t.Errorf("ImportBooks reported the invalid row wrongly: %+v", report.Rows[1])
+	}
+}
+
+func TestImportBooksJSONL(t *testing.T) {
+	file := `{"title": "Dune", "author": "Frank Herbert", "description": "Spice"}` + "\n\n" + `{"title": ` + "\n"
+
+	reader, err := NewBookReader(strings.NewReader(file), FormatJSONL)
+	if err != nil {
+		t.Fatalf("NewBookReader returned an error: %v", err)
+	}
+
+	report, _, errResp := ImportBooks(&fakeImporter{}, reader, DefaultBatchSize, false)
+	if errResp.Error != "" {
+		t.Fatalf("ImportBooks returned an error: %v", errResp.Error)
+	}
+
+	if report.Created != 1 || report.Failed != 1 || report.Rows[1].Line != 3 {
+		t.Errorf("ImportBooks produced the wrong report: %+v", report)
+	}
+}
+
+func TestImportBooksPartial(t *testing.T) {
+	line := `{"title": "Dune", "author": "Frank Herbert", "description": "Spice"}` + "\n"
+	file := strings.Repeat(line, 3)
+
+	reader, err := NewBookReader(io.LimitReader(strings.NewReader(file+file), int64(len(file))), FormatJSONL)
+	if err != nil {
+		t.Fatalf("NewBookReader returned an error: %v", err)
+	}
+
+	report, statusCode, errResp := ImportBooks(&fakeImporter{failAt: 2}, reader, 2, false)
+	if statusCode != 500 || errResp.Error == "" || report.Error != errResp.Error {
+		t.Fatalf("ImportBooks = %d %q with report error %q, want 500", statusCode, errResp.Error, report.Error)
+	}
+	if report.Created != 2 || report.Failed != 1 || report.Rows[2].Line != 3 ||
+		report.Rows[2].Status != types.ImportFailed {
+		t.Errorf("ImportBooks produced the wrong report: %+v", report)
+	}
+
+	body := http.MaxBytesReader(nil, io.NopCloser(strings.NewReader(file)), int64(len(line)+10))
+	reader, err = NewBookReader(body, FormatJSONL)
+	if err != nil {
+		t.Fatalf("NewBookReader returned an error: %v", err)
+	}
+
+	report, statusCode, errResp = ImportBooks(&fakeImporter{}, reader, 1, false)
+	if statusCode != http.StatusRequestEntityTooLarge || errResp.Error == "" {
+		t.Fatalf("ImportBooks = %d %q, want 413", statusCode, errResp.Error)
+	}
+	if report.Created != 1 || report.Failed != 1 || report.Rows[1].Line != 2 {
+		t.Errorf("ImportBooks produced the wrong report: %+v", report)
+	}
+}
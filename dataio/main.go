@@ -0,0 +1,57 @@
+package dataio
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// DefaultBatchSize is how many imported rows are committed per transaction.
+const DefaultBatchSize = 500
+
+// MaxImportSize is the largest import file accepted in a request body, in bytes.
+const MaxImportSize = 64 << 20
+
+var ErrUnknownFormat = errors.New("unknown format")
+
+// FormatFromFilename guesses the format of a file from its extension.
+// Returns an empty string if the extension is not recognized.
+func FormatFromFilename(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	case ".xlsx":
+		return FormatXLSX
+	case ".mrc", ".marc":
+		return FormatMARC
+	case ".xml", ".marcxml":
+		return FormatMARCXML
+	}
+
+	return ""
+}
+
+// FormatFromContentType maps the Content-Type of a request body to a format.
+// Returns an empty string if the content type is not recognized.
+func FormatFromContentType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "text/csv":
+		return FormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return FormatJSONL
+	case "application/marc":
+		return FormatMARC
+	case "application/marcxml+xml", "application/xml", "text/xml":
+		return FormatMARCXML
+	}
+
+	return ""
+}
@@ -0,0 +1,324 @@
+package dataio
+
+import (
+	"errors"
+	"github.com/Tus1688/library-management-api/bookutil"
+	"github.com/Tus1688/library-management-api/marc"
+	"github.com/Tus1688/library-management-api/types"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	FormatMARC    = "marc"
+	FormatMARCXML = "marcxml"
+)
+
+var (
+	marcYear  = regexp.MustCompile(`\d{4}`)
+	marcPages = regexp.MustCompile(`(\d+)\s*(?:pages|p\b)`)
+	// isbdEnding is the punctuation MARC records carry between elements, e.g. "Dune :" or "Herbert, Frank,".
+	isbdEnding = regexp.MustCompile(`[\s/:;,=]+$`)
+	// initialEnding matches a name ending in an initial, whose period belongs to the name.
+	initialEnding = regexp.MustCompile(`(^|[\s.])\p{Lu}\.$`)
+)
+
+// maxMARCDescription keeps the 520 field below the 9999 byte limit of a MARC field.
+const maxMARCDescription = 9000
+
+// isMARC reports whether the format is one of the MARC record formats.
+func isMARC(format string) bool {
+	return format == FormatMARC || format == FormatMARCXML
+}
+
+type marcBookReader struct {
+	reader marc.RecordReader
+	record int
+}
+
+func newMARCBookReader(r io.Reader, format string) *marcBookReader {
+	if format == FormatMARCXML {
+		return &marcBookReader{reader: marc.NewXMLReader(r)}
+	}
+	return &marcBookReader{reader: marc.NewReader(r)}
+}
+
+// Next maps the next MARC record to a book, the Line of a record is its position in the file.
+func (m *marcBookReader) Next() (BookRecord, error) {
+	record, err := m.reader.Next()
+	if errors.Is(err, io.EOF) {
+		return BookRecord{}, io.EOF
+	}
+
+	m.record++
+	if errors.Is(err, marc.ErrMalformed) {
+		return BookRecord{Line: m.record, Err: err}, nil
+	}
+	if err != nil {
+		return BookRecord{}, err
+	}
+
+	book := bookFromMARC(&record)
+	return BookRecord{Line: m.record, Book: book, Err: validateBook(&book)}, nil
+}
+
+// bookFromMARC maps a bibliographic record to a book.
+// The byline lists the main entry (100) followed by the added entries (700) credited as authors,
+// the description falls back to the general notes (500) and then to the title as it is required.
+func bookFromMARC(record *marc.Record) types.CreateBook {
+	book := types.CreateBook{Title: trimISBD(record.Subfield("245", 'a'))}
+	if subtitle := trimISBD(record.Subfield("245", 'b')); subtitle != "" {
+		book.Title += ": " + subtitle
+	}
+
+	var names []string
+	if main := record.Field("100"); main != nil {
+		names = append(names, displayName(main.Subfield('a')))
+	}
+	for _, added := range record.FieldsByTag("700") {
+		if isAuthorEntry(&added) {
+			names = append(names, displayName(added.Subfield('a')))
+		}
+	}
+	book.Author = strings.Join(names, "; ")
+
+	book.Description = strings.TrimSpace(record.Subfield("520", 'a'))
+	if book.Description == "" {
+		var notes []string
+		for _, note := range record.FieldsByTag("500") {
+			notes = append(notes, strings.TrimSpace(note.Subfield('a')))
+		}
+		book.Description = strings.TrimSpace(strings.Join(notes, " "))
+	}
+	if book.Description == "" {
+		book.Description = book.Title
+	}
+
+	for _, field := range record.FieldsByTag("020") {
+		// the ISBN is often followed by a qualifier, e.g. "9780134190440 (pbk.)"
+		candidate, _, _ := strings.Cut(strings.TrimSpace(field.Subfield('a')), " ")
+		if isbn, err := bookutil.NormalizeIsbn(candidate); err == nil {
+			book.Isbn = isbn
+			break
+		}
+	}
+
+	publication := record.Field("260")
+	for _, field := range record.FieldsByTag("264") {
+		if field.Ind2 == '1' {
+			publication = &field
+			break
+		}
+	}
+	if publication != nil {
+		book.Publisher = trimISBD(publication.Subfield('b'))
+		book.PublicationYear, _ = strconv.Atoi(marcYear.FindString(publication.Subfield('c')))
+	}
+
+	fixed := record.ControlField("008")
+	if book.PublicationYear == 0 && len(fixed) >= 11 {
+		book.PublicationYear, _ = strconv.Atoi(fixed[7:11])
+	}
+
+	book.Language = strings.TrimSpace(record.Subfield("041", 'a'))
+	if book.Language == "" && len(fixed) >= 38 && isLetters(fixed[35:38]) {
+		book.Language = fixed[35:38]
+	}
+	if len(book.Language) > 3 {
+		book.Language = book.Language[:3]
+	}
+
+	book.Edition = trimISBD(record.Subfield("250", 'a'))
+	if pages := marcPages.FindAllStringSubmatch(record.Subfield("300", 'a'), -1); len(pages) > 0 {
+		book.PageCount, _ = strconv.Atoi(pages[len(pages)-1][1])
+	}
+
+	for _, subject := range record.FieldsByTag("650") {
+		book.Subjects = append(book.Subjects, strings.TrimSuffix(trimISBD(subject.Subfield('a')), "."))
+	}
+
+	// uncontrolled index terms are what the catalog calls tags, a record without them leaves the tags untouched
+	for _, term := range record.FieldsByTag("653") {
+		book.Tags = append(book.Tags, trimISBD(term.Subfield('a')))
+	}
+
+	return book
+}
+
+// isAuthorEntry reports whether an added entry credits an author rather than e.g. a translator or an editor,
+// an entry without a relator term is taken as an author.
+func isAuthorEntry(field *marc.Field) bool {
+	term, code := strings.ToLower(field.Subfield('e')), strings.ToLower(field.Subfield('4'))
+	if term == "" && code == "" {
+		return true
+	}
+	return strings.Contains(term, "author") || code == "aut"
+}
+
+// displayName turns a heading such as "Herbert, Frank," into "Frank Herbert".
+func displayName(heading string) string {
+	name, _ := bookutil.ParseAuthorName(trimISBD(heading))
+	return name
+}
+
+// trimISBD removes the punctuation that separates MARC elements from the end of a value.
+// A final period is removed too, unless it ends an initial as in "Kernighan, Brian W.".
+func trimISBD(value string) string {
+	value = isbdEnding.ReplaceAllString(strings.TrimSpace(value), "")
+	if strings.HasSuffix(value, ".") && !strings.HasSuffix(value, "..") && !initialEnding.MatchString(value) {
+		value = strings.TrimSuffix(value, ".")
+	}
+	return value
+}
+
+func isLetters(value string) bool {
+	for _, c := range value {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewMARCWriter creates a record writer for the given MARC format.
+// Returns the writer and an error if the format is not a MARC format.
+func NewMARCWriter(w io.Writer, format string) (marc.RecordWriter, error) {
+	switch format {
+	case FormatMARC:
+		return marc.NewWriter(w), nil
+	case FormatMARCXML:
+		return marc.NewXMLWriter(w), nil
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+// exportBooksMARC writes every book matching the filter to w as MARC records, see ExportBooks.
+func exportBooksMARC(store Exporter, filter *types.BookFilter, w io.Writer, format string) (int, int, types.Err) {
+	writer, err := NewMARCWriter(w, format)
+	if err != nil {
+		return 0, 400, types.Err{Error: err.Error()}
+	}
+
+	count := 0
+	statusCode, errResp := store.StreamBooks(filter, func(book *types.ListBook) error {
+		count++
+		record := marcFromBook(book)
+		return writer.Write(&record)
+	})
+
+	return finishExport(writer, count, statusCode, errResp)
+}
+
+// marcFromBook maps a book to a bibliographic record, the inverse of bookFromMARC.
+// The byline is split into a main entry for the first author and added entries for the others,
+// a soft-deleted book is exported with the deleted record status.
+func marcFromBook(book *types.ListBook) marc.Record {
+	leader := []byte("00000nam a2200000 i 4500")
+	if book.DeletedAt != "" {
+		leader[5] = 'd'
+	}
+	record := marc.Record{Leader: string(leader)}
+
+	record.Fields = append(record.Fields, marc.Field{Tag: "001", Value: book.Id}, marc.Field{Tag: "008",
+		Value: fixedField(book)})
+	if book.Isbn != "" {
+		record.Fields = append(record.Fields, marc.NewDataField("020", ' ', ' ', "a", book.Isbn))
+	}
+	if book.Language != "" {
+		record.Fields = append(record.Fields, marc.NewDataField("041", ' ', ' ', "a", book.Language))
+	}
+
+	authors := bookutil.SplitAuthors(book.Author)
+	for i, raw := range authors {
+		_, sortName := bookutil.ParseAuthorName(raw)
+		ind1 := byte('0')
+		if strings.Contains(sortName, ",") {
+			ind1 = '1'
+		}
+
+		tag := "700"
+		if i == 0 {
+			tag = "100"
+		}
+		record.Fields = append(record.Fields, marc.NewDataField(tag, ind1, ' ', "a", sortName, "e", "author"))
+	}
+
+	titleInd1 := byte('0')
+	if len(authors) > 0 {
+		titleInd1 = '1'
+	}
+	record.Fields = append(record.Fields,
+		marc.NewDataField("245", titleInd1, '0', "a", book.Title, "c", book.Author),
+		marc.NewDataField("250", ' ', ' ', "a", book.Edition))
+
+	year := ""
+	if book.PublicationYear != 0 {
+		year = strconv.Itoa(book.PublicationYear)
+	}
+	record.Fields = append(record.Fields, marc.NewDataField("264", ' ', '1', "b", book.Publisher, "c", year))
+	if book.PageCount != 0 {
+		record.Fields = append(record.Fields, marc.NewDataField("300", ' ', ' ', "a",
+			strconv.Itoa(book.PageCount)+" pages"))
+	}
+	record.Fields = append(record.Fields, marc.NewDataField("520", ' ', ' ', "a",
+		truncate(book.Description, maxMARCDescription)))
+
+	for _, subject := range book.Subjects {
+		record.Fields = append(record.Fields, marc.NewDataField("650", ' ', '4', "a", subject))
+	}
+	for _, tag := range book.Tags {
+		record.Fields = append(record.Fields, marc.NewDataField("653", ' ', ' ', "a", tag))
+	}
+
+	// fields without any subfield, such as a missing edition, are left out
+	fields := record.Fields[:0]
+	for _, field := range record.Fields {
+		if field.IsControl() || len(field.Subfields) > 0 {
+			fields = append(fields, field)
+		}
+	}
+	record.Fields = fields
+
+	return record
+}
+
+// fixedField builds the 008 field, only the date entered, publication date and language positions are filled.
+func fixedField(book *types.ListBook) string {
+	fixed := []byte(strings.Repeat(" ", 40))
+	if len(book.CreatedAt) >= 10 {
+		if created, err := time.Parse(time.DateOnly, book.CreatedAt[:10]); err == nil {
+			copy(fixed[0:6], created.Format("060102"))
+		}
+	}
+
+	fixed[6] = 'n'
+	if book.PublicationYear >= 1000 && book.PublicationYear <= 9999 {
+		fixed[6] = 's'
+		copy(fixed[7:11], strconv.Itoa(book.PublicationYear))
+	}
+	copy(fixed[15:18], "xx ")
+	if len(book.Language) == 3 {
+		copy(fixed[35:38], book.Language)
+	}
+	fixed[39] = 'd'
+
+	return string(fixed)
+}
+
+// truncate shortens value to at most limit bytes without splitting a character.
+func truncate(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+
+	value = value[:limit]
+	for !utf8.ValidString(value) {
+		value = value[:len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,154 @@
+package dataio
+
+import (
+	"bytes"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// readBooks reads every record of a sample file from the marc package.
+func readBooks(t *testing.T, name, format string) []BookRecord {
+	t.Helper()
+
+	file, err := os.Open("../marc/testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader, err := NewBookReader(file, format)
+	if err != nil {
+		t.Fatalf("NewBookReader returned an error: %v", err)
+	}
+
+	var records []BookRecord
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("Next returned an error: %v", err)
+		}
+		records = append(records, record)
+	}
+}
+
+func TestImportMARC(t *testing.T) {
+	records := readBooks(t, "books.mrc", FormatMARC)
+	if len(records) != 2 {
+		t.Fatalf("read %d records, want 2", len(records))
+	}
+
+	for _, record := range records {
+		if record.Err != nil {
+			t.Fatalf("record %d failed: %v", record.Line, record.Err)
+		}
+	}
+
+	got := records[0].Book
+	want := types.CreateBook{
+		Title:       "The Go programming language",
+		Author:      "Alan A. A. Donovan; Brian W. Kernighan",
+		Description: "An introduction to the Go programming language.",
+		BookMetadata: types.BookMetadata{
+			Isbn:            "9780134190440",
+			Publisher:       "Addison-Wesley",
+			PublicationYear: 2016,
+			Language:        "eng",
+			PageCount:       380,
+			Subjects:        []string{"Go (Computer program language)", "Open source software"},
+		},
+		Authors: []types.BookAuthorRef{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("record 1 = %+v, want %+v", got, want)
+	}
+
+	dune := records[1].Book
+	if dune.Title != "Dune: a novel" || dune.Author != "Frank Herbert" || dune.Isbn != "9780441013593" ||
+		dune.Publisher != "Ace Books" || dune.PublicationYear != 2005 || dune.Edition != "40th anniversary ed" ||
+		dune.PageCount != 528 || dune.Description != dune.Title {
+		t.Errorf("record 2 = %+v", dune)
+	}
+}
+
+func TestImportMARCXML(t *testing.T) {
+	records := readBooks(t, "books.xml", FormatMARCXML)
+	if len(records) != 2 {
+		t.Fatalf("read %d records, want 2", len(records))
+	}
+
+	book := records[0].Book
+	if records[0].Err != nil {
+		t.Fatalf("record 1 failed: %v", records[0].Err)
+	}
+	// the translator is not part of the byline and the invalid ISBN is skipped
+	if book.Author != "Antoine de Saint-Exupéry" || book.Isbn != "9782070368228" || book.Language != "fre" ||
+		book.Publisher != "Gallimard" || book.PublicationYear != 2019 || book.PageCount != 96 ||
+		book.Description != "A pilot & a little prince meet in the desert." {
+		t.Errorf("record 1 = %+v", book)
+	}
+
+	if records[1].Line != 2 || records[1].Err == nil {
+		t.Errorf("record without an author = %+v, want a failure", records[1])
+	}
+}
+
+func TestExportBooksMARCRoundTrip(t *testing.T) {
+	books := &fakeExporter{books: []types.ListBook{{
+		Id: "1", Title: "Good Omens", Author: "Terry Pratchett & Neil Gaiman", Description: "The end is nigh",
+		BookMetadata: types.BookMetadata{Isbn: "9780060853983", Publisher: "HarperTorch", PublicationYear: 2006,
+			Language: "eng", PageCount: 412, Subjects: []string{"Fantasy fiction"}},
+		Tags:      []string{"humor"},
+		CreatedAt: "2024-05-01T10:00:00Z",
+	}}}
+
+	for _, format := range []string{FormatMARC, FormatMARCXML} {
+		var buf bytes.Buffer
+		rows, _, err := ExportBooks(books, &types.BookFilter{}, &buf, format)
+		if err.Error != "" || rows != 1 {
+			t.Fatalf("%s: ExportBooks failed: %d rows, %v", format, rows, err.Error)
+		}
+
+		reader, errReader := NewBookReader(&buf, format)
+		if errReader != nil {
+			t.Fatal(errReader)
+		}
+		record, errNext := reader.Next()
+		if errNext != nil || record.Err != nil {
+			t.Fatalf("%s: unable to read the export back: %v %v", format, errNext, record.Err)
+		}
+
+		want := types.CreateBook{Title: "Good Omens", Author: "Terry Pratchett; Neil Gaiman",
+			Description: "The end is nigh", BookMetadata: books.books[0].BookMetadata, Authors: []types.BookAuthorRef{},
+			Tags: []string{"humor"}}
+		if !reflect.DeepEqual(record.Book, want) {
+			t.Errorf("%s: read back %+v, want %+v", format, record.Book, want)
+		}
+	}
+}
+
+func TestExportBookingsMARC(t *testing.T) {
+	if _, statusCode, _ := ExportBookings(exportedBooks, &types.BookingFilter{}, io.Discard, FormatMARC); statusCode != 400 {
+		t.Errorf("ExportBookings as marc = %d, want 400", statusCode)
+	}
+}
+
+func TestTrimISBD(t *testing.T) {
+	for raw, want := range map[string]string{
+		"The Go programming language /": "The Go programming language",
+		"Herbert, Frank.":               "Herbert, Frank",
+		"Kernighan, Brian W.,":          "Kernighan, Brian W.",
+		"Addison-Wesley,":               "Addison-Wesley",
+		"Wait...":                       "Wait...",
+	} {
+		if got := trimISBD(raw); got != want {
+			t.Errorf("trimISBD(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package dataio
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The static parts of a minimal SpreadsheetML workbook with a single sheet.
+// The sheet itself is streamed with inline strings, so no shared string table has to be kept in memory.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`
+
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" ` +
+		`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" ` +
+		`Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" ` +
+		`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" ` +
+		`Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" ` +
+		`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" ` +
+		`Target="styles.xml"/>` +
+		`</Relationships>`
+
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font>` +
+		`<font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="1"><fill><patternFill patternType="none"/></fill></fills>` +
+		`<borders count="1"><border/></borders>` +
+		`<cellStyleXfs count="1"><xf/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf/><xf fontId="1" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`
+
+	xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+	xlsxSheetFooter = `</sheetData></worksheet>`
+)
+
+type xlsxRowWriter struct {
+	w       io.Writer
+	sheet   string
+	columns []string
+
+	zip   *zip.Writer
+	body  *bufio.Writer
+	row   int
+	ready bool
+}
+
+// start writes the static workbook parts and opens the sheet entry, which must be the last one in the archive.
+func (x *xlsxRowWriter) start() error {
+	if x.ready {
+		return nil
+	}
+	x.ready = true
+	x.zip = zip.NewWriter(x.w)
+
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + escapeXML(x.sheet) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		entry, err := x.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(entry, part.body); err != nil {
+			return err
+		}
+	}
+
+	entry, err := x.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	x.body = bufio.NewWriter(entry)
+	if _, err := x.body.WriteString(xlsxSheetHeader); err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(x.columns))
+	for i, column := range x.columns {
+		header[i] = column
+	}
+	return x.writeRow(header, ` s="1"`)
+}
+
+func (x *xlsxRowWriter) WriteRow(values []interface{}) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+
+	return x.writeRow(values, "")
+}
+
+// writeRow writes a <row> element, style is an optional attribute applied to every cell.
+func (x *xlsxRowWriter) writeRow(values []interface{}, style string) error {
+	x.row++
+	_, _ = x.body.WriteString(`<row r="` + strconv.Itoa(x.row) + `">`)
+	for _, value := range values {
+		switch v := value.(type) {
+		case bool:
+			flag := "0"
+			if v {
+				flag = "1"
+			}
+			_, _ = x.body.WriteString(`<c t="b"` + style + `><v>` + flag + `</v></c>`)
+		case int, int64, float64:
+			_, _ = x.body.WriteString(`<c` + style + `><v>` + formatValue(v) + `</v></c>`)
+		default:
+			_, _ = x.body.WriteString(`<c t="inlineStr"` + style + `><is><t xml:space="preserve">` +
+				escapeXML(formatValue(v)) + `</t></is></c>`)
+		}
+	}
+	_, err := x.body.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxRowWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+
+	if _, err := x.body.WriteString(xlsxSheetFooter); err != nil {
+		return err
+	}
+	if err := x.body.Flush(); err != nil {
+		return err
+	}
+
+	return x.zip.Close()
+}
+
+// escapeXML escapes text for use in element content and attribute values.
+func escapeXML(text string) string {
+	var builder strings.Builder
+	_ = xml.EscapeText(&builder, []byte(text))
+	return builder.String()
+}
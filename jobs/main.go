@@ -0,0 +1,301 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Tus1688/library-management-api/types"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// lockTTL is how long the lock of a running job outlives its instance, the lock is extended while the job runs.
+const lockTTL = time.Minute
+
+// Func is the work of a job, ctx is cancelled when the runner stops or the lock of the job is lost.
+type Func func(ctx context.Context) error
+
+// ErrIdle is returned by a job that found nothing to do, its run is left out of the history.
+var ErrIdle = errors.New("nothing to do")
+
+// Locker makes sure only one instance runs a job at a time, it is implemented by cache.RedisStore.
+type Locker interface {
+	AcquireLock(name *string, owner *string, ttl time.Duration) (bool, types.Err)
+	ExtendLock(name *string, owner *string, ttl time.Duration) (bool, types.Err)
+	ReleaseLock(name *string, owner *string) types.Err
+}
+
+// History records every run of a job that did work or failed, it is implemented by storage.PostgresStore.
+type History interface {
+	StartJobRun(job, instance *string) (string, int, types.Err)
+	FinishJobRun(id *string, failure string) (int, types.Err)
+	DiscardJobRun(id *string) (int, types.Err)
+}
+
+type job struct {
+	name     string
+	spec     string
+	schedule Schedule
+	run      Func
+
+	mu      sync.Mutex
+	next    time.Time
+	running bool
+}
+
+// Runner runs jobs on their schedules. Every instance of the server has a runner with the same jobs,
+// at each scheduled time the instance that takes the lock of a job runs it and the others skip that time.
+type Runner struct {
+	locker   Locker
+	history  History
+	instance string
+	lockTTL  time.Duration
+
+	mu     sync.Mutex
+	jobs   []*job
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+// NewRunner creates a runner without any jobs.
+// Parameters:
+// - locker: where the locks of the jobs are taken.
+// - history: where the runs of the jobs are recorded.
+func NewRunner(locker Locker, history History) *Runner {
+	hostname, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+
+	return &Runner{
+		locker:   locker,
+		history:  history,
+		instance: hostname + "-" + hex.EncodeToString(suffix),
+		lockTTL:  lockTTL,
+	}
+}
+
+// Add registers a job, jobs added after Start only run once the runner is started again.
+// Parameters:
+// - name: the name of the job, also the name of its lock, it must be unique.
+// - spec: when the job runs, see ParseSchedule.
+// - run: the work of the job.
+// Returns an error if the name is taken or the schedule cannot be parsed.
+func (r *Runner) Add(name, spec string, run Func) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, j := range r.jobs {
+		if j.name == name {
+			return errors.New("job " + name + " is already registered")
+		}
+	}
+
+	r.jobs = append(r.jobs, &job{name: name, spec: spec, schedule: schedule, run: run})
+	return nil
+}
+
+// Start runs every job on its schedule in the background until Stop is called.
+func (r *Runner) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	for _, j := range r.jobs {
+		r.done.Add(1)
+		go func(j *job) {
+			defer r.done.Done()
+			r.loop(ctx, j)
+		}(j)
+	}
+}
+
+// Stop cancels the running jobs and waits for them to return.
+// Parameters:
+// - ctx: bounds how long to wait.
+// Returns an error if ctx ends before every job has returned.
+func (r *Runner) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.cancel = nil
+	r.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		r.done.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Jobs lists the registered jobs with their next scheduled run on this instance.
+func (r *Runner) Jobs() []types.ListJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := make([]types.ListJob, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		j.mu.Lock()
+		item := types.ListJob{Name: j.name, Schedule: j.spec, Running: j.running}
+		if !j.next.IsZero() {
+			item.NextRun = j.next.Format(time.RFC3339)
+		}
+		j.mu.Unlock()
+		list = append(list, item)
+	}
+
+	return list
+}
+
+// loop waits for every scheduled time of a job and tries to run it.
+func (r *Runner) loop(ctx context.Context, j *job) {
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+
+		j.mu.Lock()
+		j.next = next
+		j.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		r.runOnce(ctx, j, next)
+	}
+}
+
+// runOnce runs a job for the given scheduled time, unless another instance holds its lock.
+// The lock is kept until halfway to the following scheduled time, so an instance whose clock is a little behind
+// does not run the job a second time.
+func (r *Runner) runOnce(ctx context.Context, j *job, slot time.Time) {
+	lock := "job:" + j.name
+	acquired, errResp := r.locker.AcquireLock(&lock, &r.instance, r.lockTTL)
+	if errResp.Error != "" {
+		log.Printf("jobs: %s: %s", j.name, errResp.Error)
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	j.mu.Lock()
+	j.running = true
+	j.mu.Unlock()
+
+	runId, _, errResp := r.history.StartJobRun(&j.name, &r.instance)
+	if errResp.Error != "" {
+		log.Printf("jobs: %s: %s", j.name, errResp.Error)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	stopExtending := r.extendWhileRunning(lock, cancel)
+	err := r.call(runCtx, j)
+	stopExtending()
+	cancel()
+
+	idle := errors.Is(err, ErrIdle)
+	failure := ""
+	if err != nil && !idle {
+		failure = err.Error()
+		log.Printf("jobs: %s failed: %s", j.name, failure)
+	}
+
+	if runId != "" {
+		if idle {
+			_, errResp = r.history.DiscardJobRun(&runId)
+		} else {
+			_, errResp = r.history.FinishJobRun(&runId, failure)
+		}
+		if errResp.Error != "" {
+			log.Printf("jobs: %s: %s", j.name, errResp.Error)
+		}
+	}
+
+	j.mu.Lock()
+	j.running = false
+	j.mu.Unlock()
+
+	hold := time.Until(slot.Add(j.schedule.Next(slot).Sub(slot) / 2))
+	if hold > 0 && ctx.Err() == nil {
+		_, errResp = r.locker.ExtendLock(&lock, &r.instance, hold)
+	} else {
+		errResp = r.locker.ReleaseLock(&lock, &r.instance)
+	}
+	if errResp.Error != "" {
+		log.Printf("jobs: %s: %s", j.name, errResp.Error)
+	}
+}
+
+// call runs a job, turning a panic into an error so one broken job does not take the server down.
+func (r *Runner) call(ctx context.Context, j *job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return j.run(ctx)
+}
+
+// extendWhileRunning keeps extending a lock until the returned function is called.
+// When the lock cannot be extended, as it expired and may be taken by another instance, lost is called
+// so the job stops instead of running twice.
+func (r *Runner) extendWhileRunning(lock string, lost context.CancelFunc) func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(r.lockTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				extended, errResp := r.locker.ExtendLock(&lock, &r.instance, r.lockTTL)
+				if errResp.Error != "" {
+					log.Printf("jobs: %s: %s", lock, errResp.Error)
+				}
+				if !extended {
+					log.Printf("jobs: %s: lock lost, stopping the job", lock)
+					lost()
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
@@ -0,0 +1,269 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	from := time.Date(2024, 5, 3, 10, 7, 30, 0, time.Local) // a Friday
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 5, 3, 10, 15, 0, 0, time.Local)},
+		{"0 * * * *", time.Date(2024, 5, 3, 11, 0, 0, 0, time.Local)},
+		{"@daily", time.Date(2024, 5, 4, 0, 0, 0, 0, time.Local)},
+		{"30 2 * * 1-5", time.Date(2024, 5, 6, 2, 30, 0, 0, time.Local)},
+		{"0 9 * * 7", time.Date(2024, 5, 5, 9, 0, 0, 0, time.Local)},
+		{"0 0 1,15 * *", time.Date(2024, 5, 15, 0, 0, 0, 0, time.Local)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.Local)},
+		// a restricted day of month or day of week matches either one
+		{"0 0 13 * 6", time.Date(2024, 5, 4, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, test := range tests {
+		schedule, err := ParseSchedule(test.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q) returned an error: %v", test.spec, err)
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(test.want) {
+			t.Errorf("ParseSchedule(%q).Next = %v, want %v", test.spec, got, test.want)
+		}
+	}
+
+	schedule, _ := ParseSchedule("0 0 30 2 *")
+	if next := schedule.Next(from); !next.IsZero() {
+		t.Errorf("a schedule that never matches runs at %v", next)
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "5-1 * * * *", "*/0 * * * *",
+		"a * * * *", "@every 0s", "@yearly"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) returned no error", spec)
+		}
+	}
+}
+
+func TestEverySchedule(t *testing.T) {
+	schedule, err := ParseSchedule("@every 10m")
+	if err != nil {
+		t.Fatalf("ParseSchedule returned an error: %v", err)
+	}
+
+	from := time.Date(2024, 5, 3, 10, 7, 30, 0, time.UTC)
+	if next := schedule.Next(from); !next.Equal(time.Date(2024, 5, 3, 10, 10, 0, 0, time.UTC)) {
+		t.Errorf("Next = %v", next)
+	}
+}
+
+type fakeLocker struct {
+	mu    sync.Mutex
+	locks map[string]string
+	ttls  map[string]time.Duration
+}
+
+func (f *fakeLocker) AcquireLock(name *string, owner *string, ttl time.Duration) (bool, types.Err) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, held := f.locks[*name]; held {
+		return false, types.Err{}
+	}
+	f.locks[*name] = *owner
+	f.ttls[*name] = ttl
+	return true, types.Err{}
+}
+
+func (f *fakeLocker) ExtendLock(name *string, owner *string, ttl time.Duration) (bool, types.Err) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.locks[*name] != *owner {
+		return false, types.Err{}
+	}
+	f.ttls[*name] = ttl
+	return true, types.Err{}
+}
+
+func (f *fakeLocker) ReleaseLock(name *string, owner *string) types.Err {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.locks[*name] == *owner {
+		delete(f.locks, *name)
+	}
+	return types.Err{}
+}
+
+type fakeHistory struct {
+	mu   sync.Mutex
+	runs map[string]string
+}
+
+func (f *fakeHistory) StartJobRun(job, instance *string) (string, int, types.Err) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := *job + "@" + *instance
+	f.runs[id] = types.JobRunning
+	return id, 201, types.Err{}
+}
+
+func (f *fakeHistory) FinishJobRun(id *string, failure string) (int, types.Err) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.runs[*id] = failure
+	return 200, types.Err{}
+}
+
+func (f *fakeHistory) DiscardJobRun(id *string) (int, types.Err) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.runs, *id)
+	return 200, types.Err{}
+}
+
+func TestRunnerRunsOnce(t *testing.T) {
+	locker := &fakeLocker{locks: map[string]string{}, ttls: map[string]time.Duration{}}
+	history := &fakeHistory{runs: map[string]string{}}
+	first := &Runner{locker: locker, history: history, instance: "a", lockTTL: time.Minute}
+	second := &Runner{locker: locker, history: history, instance: "b", lockTTL: time.Minute}
+
+	calls := 0
+	for _, runner := range []*Runner{first, second} {
+		if err := runner.Add("count", "@every 1h", func(ctx context.Context) error {
+			calls++
+			return nil
+		}); err != nil {
+			t.Fatalf("Add returned an error: %v", err)
+		}
+	}
+
+	slot := time.Now().Truncate(time.Hour)
+	first.runOnce(context.Background(), first.jobs[0], slot)
+	second.runOnce(context.Background(), second.jobs[0], slot)
+
+	if calls != 1 {
+		t.Errorf("the job ran %d times, want once", calls)
+	}
+	if status, ok := history.runs["count@a"]; !ok || status != "" {
+		t.Errorf("the history is %v", history.runs)
+	}
+
+	// the lock is kept until halfway to the next run, the second instance may be a little late
+	if locker.locks["job:count"] != "a" || locker.ttls["job:count"] > 30*time.Minute {
+		t.Errorf("the lock is %q for %v", locker.locks["job:count"], locker.ttls["job:count"])
+	}
+}
+
+func TestRunnerRecordsFailures(t *testing.T) {
+	locker := &fakeLocker{locks: map[string]string{}, ttls: map[string]time.Duration{}}
+	history := &fakeHistory{runs: map[string]string{}}
+	runner := &Runner{locker: locker, history: history, instance: "a", lockTTL: time.Minute}
+	_ = runner.Add("broken", "@hourly", func(ctx context.Context) error {
+		return errors.New("database is gone")
+	})
+	_ = runner.Add("panics", "@hourly", func(ctx context.Context) error {
+		panic("out of range")
+	})
+
+	// a slot long past releases the lock right away
+	slot := time.Now().Add(-24 * time.Hour)
+	for _, j := range runner.jobs {
+		runner.runOnce(context.Background(), j, slot)
+	}
+
+	if history.runs["broken@a"] != "database is gone" || history.runs["panics@a"] != "panic: out of range" {
+		t.Errorf("the history is %v", history.runs)
+	}
+	if len(locker.locks) != 0 {
+		t.Errorf("locks are still held: %v", locker.locks)
+	}
+
+	if err := runner.Add("broken", "@daily", nil); err == nil {
+		t.Error("Add accepted a duplicate name")
+	}
+}
+
+func TestRunnerDiscardsIdleRuns(t *testing.T) {
+	locker := &fakeLocker{locks: map[string]string{}, ttls: map[string]time.Duration{}}
+	history := &fakeHistory{runs: map[string]string{}}
+	runner := &Runner{locker: locker, history: history, instance: "a", lockTTL: time.Minute}
+	_ = runner.Add("idle", "@every 5s", func(ctx context.Context) error {
+		return ErrIdle
+	})
+
+	runner.runOnce(context.Background(), runner.jobs[0], time.Now().Add(-time.Hour))
+
+	if len(history.runs) != 0 {
+		t.Errorf("the history is %v, want no runs", history.runs)
+	}
+}
+
+func TestRunnerStopsOnLostLock(t *testing.T) {
+	locker := &fakeLocker{locks: map[string]string{}, ttls: map[string]time.Duration{}}
+	history := &fakeHistory{runs: map[string]string{}}
+	runner := &Runner{locker: locker, history: history, instance: "a", lockTTL: 30 * time.Millisecond}
+
+	_ = runner.Add("slow", "@hourly", func(ctx context.Context) error {
+		// the lock expires and another instance takes it while the job runs
+		locker.mu.Lock()
+		locker.locks["job:slow"] = "b"
+		locker.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(3 * time.Second):
+			return nil
+		}
+	})
+
+	runner.runOnce(context.Background(), runner.jobs[0], time.Now().Add(-24*time.Hour))
+
+	if history.runs["slow@a"] != context.Canceled.Error() {
+		t.Errorf("the history is %v", history.runs)
+	}
+	if locker.locks["job:slow"] != "b" {
+		t.Errorf("the lock of the other instance was taken over: %v", locker.locks)
+	}
+}
+
+func TestRunnerStop(t *testing.T) {
+	locker := &fakeLocker{locks: map[string]string{}, ttls: map[string]time.Duration{}}
+	history := &fakeHistory{runs: map[string]string{}}
+	runner := &Runner{locker: locker, history: history, instance: "a", lockTTL: time.Minute}
+
+	started := make(chan struct{})
+	_ = runner.Add("slow", "@every 1s", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	runner.Start()
+
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("the job did not start")
+	}
+
+	if jobs := runner.Jobs(); len(jobs) != 1 || !jobs[0].Running {
+		t.Errorf("Jobs = %+v", jobs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := runner.Stop(ctx); err != nil {
+		t.Fatalf("Stop returned an error: %v", err)
+	}
+
+	if history.runs["slow@a"] != context.Canceled.Error() {
+		t.Errorf("the history is %v", history.runs)
+	}
+	if len(locker.locks) != 0 {
+		t.Errorf("locks are still held after Stop: %v", locker.locks)
+	}
+}
@@ -0,0 +1,168 @@
+package jobs
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Schedule tells when a job runs next.
+type Schedule interface {
+	// Next returns the first time after t the job runs, or the zero time if it never runs again.
+	Next(t time.Time) time.Time
+}
+
+// descriptors are the shorthands accepted in place of the five cron fields.
+var descriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// ParseSchedule parses a cron expression in the local time zone: "minute hour day-of-month month day-of-week",
+// where every field is "*", a number, a range "1-5" or a list "1,15", each optionally followed by a step "/10".
+// Day of week 0 and 7 are both Sunday. The shorthands @hourly, @daily, @midnight, @weekly and @monthly are accepted,
+// and "@every 15m" runs at every multiple of the duration since the zero time, so every instance agrees on the times.
+// Parameters:
+// - spec: the cron expression.
+// Returns the schedule and ErrInvalidSchedule if spec cannot be parsed.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if raw, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || interval < time.Second {
+			return nil, ErrInvalidSchedule
+		}
+		return everySchedule(interval), nil
+	}
+
+	if expanded, ok := descriptors[spec]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, ErrInvalidSchedule
+	}
+
+	var c cronSchedule
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+
+	// 7 is another name for Sunday
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	// like cron, a restricted day of month and day of week match either one
+	c.anyDay = fields[2] != "*" && fields[4] != "*"
+
+	return &c, nil
+}
+
+// parseField parses one cron field into a bit set of the values it matches.
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, rawStep, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(rawStep); err != nil || step < 1 {
+				return 0, ErrInvalidSchedule
+			}
+		}
+
+		start, end := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = strconv.Atoi(from); err != nil {
+				return 0, ErrInvalidSchedule
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(to); err != nil {
+					return 0, ErrInvalidSchedule
+				}
+			} else if hasStep {
+				end = max
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return 0, ErrInvalidSchedule
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+type everySchedule time.Duration
+
+func (e everySchedule) Next(t time.Time) time.Time {
+	interval := time.Duration(e)
+	return t.Truncate(interval).Add(interval)
+}
+
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	anyDay                        bool
+}
+
+// maxSearch bounds the search for the next time, an expression such as "0 0 30 2 *" never matches.
+const maxSearch = 5
+
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearch, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.anyDay {
+		return dom || dow
+	}
+
+	return dom && dow
+}
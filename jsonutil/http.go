@@ -75,7 +75,17 @@ func ShouldBind(r *http.Request, v interface{}) error {
 	return checkRequiredFields(v)
 }
 
+// Validate checks the binding rules of the given value without decoding anything.
+// It is used for records that do not come from a request body, such as rows of an import file.
+// Parameters:
+// - v: the value to check.
+// Returns an error if any binding rule is violated.
+func Validate(v interface{}) error {
+	return checkRequiredFields(v)
+}
+
 // checkRequiredFields checks if the required fields in the given value are set.
+// Fields tagged "nonempty" may be omitted, but must not be null or empty when present.
 // Parameters:
 // - v: the value to check for required fields.
 // Returns an error if any required field is not set.
@@ -92,6 +102,11 @@ func checkRequiredFields(v interface{}) error {
 		if tag == "required" && isZeroOfUnderlyingType(field.Interface()) {
 			return fmt.Errorf("field %s is required", typeOfV.Field(i).Name)
 		}
+		if tag == "nonempty" {
+			if opt, ok := field.Interface().(interface{ IsEmpty() bool }); ok && opt.IsEmpty() {
+				return fmt.Errorf("field %s cannot be empty", typeOfV.Field(i).Name)
+			}
+		}
 	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package jsonutil
 
 import (
+	"github.com/Tus1688/library-management-api/types"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -46,3 +47,32 @@ func TestShouldBindFaulty(t *testing.T) {
 		t.Errorf("ShouldBind cannot detect binding error")
 	}
 }
+
+func TestShouldBindNonEmpty(t *testing.T) {
+	// Create a mock request object that explicitly clears a field
+	req := httptest.NewRequest("PATCH", "/path", strings.NewReader(`{"foo": null}`))
+
+	var v struct {
+		Foo types.Optional[string] `json:"foo" binding:"nonempty"`
+		Bar types.Optional[string] `json:"bar" binding:"nonempty"`
+	}
+
+	// Call the ShouldBind function with the mock request and object
+	err := ShouldBind(req, &v)
+
+	// Check that clearing a nonempty field is rejected
+	if err == nil {
+		t.Errorf("ShouldBind cannot detect null nonempty field")
+	}
+
+	// Omitted fields are fine
+	req = httptest.NewRequest("PATCH", "/path", strings.NewReader(`{"foo": "bar"}`))
+	v.Foo, v.Bar = types.Optional[string]{}, types.Optional[string]{}
+	if err := ShouldBind(req, &v); err != nil {
+		t.Errorf("ShouldBind returned an error: %v", err)
+	}
+
+	if !v.Foo.Set || v.Foo.Value != "bar" || v.Bar.Set {
+		t.Errorf("ShouldBind decoded the wrong value: %+v", v)
+	}
+}
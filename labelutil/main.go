@@ -0,0 +1,118 @@
+package labelutil
+
+import (
+	"errors"
+	"github.com/Tus1688/library-management-api/barcode"
+	"github.com/Tus1688/library-management-api/pdf"
+	"io"
+)
+
+// The sheet layout matches the common A4 sheets of 24 self-adhesive labels of 70 x 37 mm.
+const (
+	Columns = 3
+	Rows    = 8
+	PerPage = Columns * Rows
+
+	labelWidth  = 70 * pdf.Millimeter
+	labelHeight = 37 * pdf.Millimeter
+	marginTop   = 0.5 * pdf.Millimeter
+	padding     = 4 * pdf.Millimeter
+	qrSize      = 20 * pdf.Millimeter
+	barHeight   = 11 * pdf.Millimeter
+	// maxModule keeps short barcodes from being stretched over the whole label
+	maxModule = 0.4 * pdf.Millimeter
+)
+
+var ErrNoLabels = errors.New("no labels to print")
+
+// Label is the content of the label of a book.
+// The Code 128 barcode encodes Barcode, the item barcode scanned at the desk, and the QR code encodes Id.
+type Label struct {
+	Id      string
+	Barcode string
+	Title   string
+	Author  string
+}
+
+// WriteSheet lays the labels out on as many A4 pages as needed and writes them as a PDF.
+// Parameters:
+// - w: where the PDF is written to.
+// - labels: the labels to print, in order.
+// - skip: how many positions of the first page to leave empty, so a partially used sheet can be fed again.
+// Returns ErrNoLabels if there is nothing to print, or an error if a code cannot be encoded or the PDF written.
+func WriteSheet(w io.Writer, labels []Label, skip int) error {
+	if len(labels) == 0 {
+		return ErrNoLabels
+	}
+	skip = min(max(skip, 0), PerPage-1)
+
+	doc := pdf.New()
+	var page *pdf.Page
+	for i, label := range labels {
+		position := skip + i
+		if position%PerPage == 0 || page == nil {
+			page = doc.AddPage(pdf.A4Width, pdf.A4Height)
+		}
+
+		slot := position % PerPage
+		x := float64(slot%Columns) * labelWidth
+		y := marginTop + float64(slot/Columns)*labelHeight
+		if err := drawLabel(page, &label, x, y); err != nil {
+			return err
+		}
+	}
+
+	return doc.Write(w)
+}
+
+// drawLabel draws a label with its top left corner at x, y: the title, author, barcode and its
+// human readable text on the left and the QR code on the right.
+func drawLabel(page *pdf.Page, label *Label, x, y float64) error {
+	code, err := barcode.Code128(label.Barcode)
+	if err != nil {
+		return err
+	}
+	qr, err := barcode.QR(label.Id)
+	if err != nil {
+		return err
+	}
+
+	textWidth := labelWidth - 3*padding - qrSize
+	left, top := x+padding, y+padding
+
+	page.Text(left, top+8, 9, pdf.Truncate(label.Title, 9, textWidth))
+	page.Text(left, top+17, 7, pdf.Truncate(label.Author, 7, textWidth))
+
+	module := min(textWidth/float64(code.Width+2*code.QuietZone), maxModule)
+	drawSymbol(page, code, left+float64(code.QuietZone)*module, top+22, module, barHeight)
+	page.Text(left+float64(code.QuietZone)*module, top+22+barHeight+9, 8, label.Barcode)
+
+	drawSymbol(page, qr, x+labelWidth-padding-qrSize, y+(labelHeight-qrSize)/2, qrSize/float64(qr.Width), 0)
+	return nil
+}
+
+// drawSymbol draws the dark modules of a symbol, without its quiet zone, with the top left corner at x, y.
+// The rows of a linear code are drawn height tall, the modules of a two-dimensional code are square.
+func drawSymbol(page *pdf.Page, symbol *barcode.Symbol, x, y, module, height float64) {
+	if symbol.IsLinear() {
+		for _, bar := range symbol.Bars() {
+			page.Rect(x+float64(bar[0])*module, y, float64(bar[1])*module, height)
+		}
+		return
+	}
+
+	// horizontal runs are merged to keep the page content small
+	for row := 0; row < symbol.Height; row++ {
+		for col := 0; col < symbol.Width; col++ {
+			if !symbol.Dark(col, row) {
+				continue
+			}
+
+			start := col
+			for col < symbol.Width && symbol.Dark(col, row) {
+				col++
+			}
+			page.Rect(x+float64(start)*module, y+float64(row)*module, float64(col-start)*module, module)
+		}
+	}
+}
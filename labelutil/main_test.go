@@ -0,0 +1,41 @@
+package labelutil
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteSheet(t *testing.T) {
+	labels := make([]Label, 30)
+	for i := range labels {
+		labels[i] = Label{Id: "6ba7b810-9dad-11d1-80b4-00c04fd430c8", Barcode: "B000000042",
+			Title: "A Very Long Title That Certainly Does Not Fit On A Single Label", Author: "Frank Herbert"}
+	}
+
+	for skip, pages := range map[int]int{0: 2, 18: 2, 20: 3, 100: 3} {
+		var buf bytes.Buffer
+		if err := WriteSheet(&buf, labels, skip); err != nil {
+			t.Fatalf("WriteSheet returned an error: %v", err)
+		}
+		if !strings.HasPrefix(buf.String(), "%PDF-") || strings.Count(buf.String(), "/Type /Page ") != pages {
+			t.Errorf("WriteSheet with skip %d wrote %d pages, want %d", skip, strings.Count(buf.String(), "/Type /Page "),
+				pages)
+		}
+	}
+}
+
+func TestWriteSheetErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSheet(&buf, nil, 0); !errors.Is(err, ErrNoLabels) {
+		t.Errorf("WriteSheet without labels = %v, want ErrNoLabels", err)
+	}
+
+	if err := WriteSheet(&buf, []Label{{Id: "1", Barcode: "café"}}, 0); err == nil {
+		t.Errorf("WriteSheet with an invalid barcode succeeded")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("WriteSheet wrote %d bytes before failing", buf.Len())
+	}
+}
@@ -0,0 +1,147 @@
+package live
+
+import (
+	"context"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/goccy/go-json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Channel is the Redis channel the outbox relay publishes events to, every replica subscribes to it.
+const Channel = "library:live"
+
+// bufferSize is how many events a subscriber may fall behind before it is dropped.
+// A dropped stream is closed, the client reconnects and resumes with Last-Event-ID.
+const bufferSize = 64
+
+// resubscribeDelay is how long Run waits before subscribing again after the subscription failed.
+const resubscribeDelay = 5 * time.Second
+
+// Source delivers the messages published to a channel, it is implemented by cache.RedisStore.
+type Source interface {
+	Subscribe(ctx context.Context, channel *string, fn func(message []byte)) types.Err
+}
+
+// IsLive reports whether an event is streamed to the front desks: booking changes and book changes,
+// which include the availability of the book.
+func IsLive(eventType string) bool {
+	return strings.HasPrefix(eventType, "booking.") || strings.HasPrefix(eventType, "book.")
+}
+
+// Subscription receives the live events broadcast after it was made.
+type Subscription struct {
+	events chan types.Event
+}
+
+// Events is closed when the broker is closed or the subscriber fell too far behind.
+func (s *Subscription) Events() <-chan types.Event {
+	return s.events
+}
+
+// Broker fans out the live events to the subscribers on this replica.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	closed      bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewBroker creates a broker without subscribers, events reach it through Broadcast or Run.
+func NewBroker() *Broker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Broker{subscribers: make(map[*Subscription]struct{}), ctx: ctx, cancel: cancel}
+}
+
+// Subscribe adds a subscriber.
+// Returns false once the broker is closed.
+func (b *Broker) Subscribe() (*Subscription, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, false
+	}
+
+	sub := &Subscription{events: make(chan types.Event, bufferSize)}
+	b.subscribers[sub] = struct{}{}
+	return sub, true
+}
+
+// Unsubscribe removes a subscriber, it is safe to call more than once.
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(sub)
+}
+
+// remove closes the events of a subscriber, b.mu must be held.
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Broadcast passes an event to every subscriber without waiting, a subscriber that is full is dropped.
+func (b *Broker) Broadcast(event *types.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		select {
+		case sub.events <- *event:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Run broadcasts the live events published to Channel until the broker is closed.
+// The subscription is made again when it fails, events published in the meantime are only seen on resume.
+func (b *Broker) Run(source Source) {
+	channel := Channel
+	for b.ctx.Err() == nil {
+		errResp := source.Subscribe(b.ctx, &channel, b.receive)
+		if b.ctx.Err() != nil {
+			return
+		}
+		log.Printf("live: %s, subscribing again in %s", errResp.Error, resubscribeDelay)
+
+		select {
+		case <-b.ctx.Done():
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+// receive broadcasts a published event if it is live.
+func (b *Broker) receive(message []byte) {
+	var event types.Event
+	if err := json.Unmarshal(message, &event); err != nil {
+		log.Print("live: invalid event: ", err)
+		return
+	}
+
+	if IsLive(event.Type) {
+		b.Broadcast(&event)
+	}
+}
+
+// Close stops Run and ends every subscription, so the streams return and do not hold up the server shutdown.
+func (b *Broker) Close() {
+	b.cancel()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
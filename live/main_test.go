@@ -0,0 +1,151 @@
+package live
+
+import (
+	"bytes"
+	"context"
+	"github.com/Tus1688/library-management-api/types"
+	"testing"
+	"time"
+)
+
+func TestBrokerBroadcast(t *testing.T) {
+	broker := NewBroker()
+	sub, ok := broker.Subscribe()
+	if !ok {
+		t.Fatal("Subscribe failed on an open broker")
+	}
+
+	broker.receive([]byte(`{"position":7,"type":"booking.created","data":{"id":"b1","book_id":"k1"}}`))
+	broker.receive([]byte(`{"position":8,"type":"webhook.deleted"}`))
+	broker.receive([]byte(`not json`))
+
+	select {
+	case event := <-sub.Events():
+		if event.Position != 7 || event.Type != types.EventBookingCreated {
+			t.Errorf("received %+v", event)
+		}
+	default:
+		t.Fatal("no event was broadcast")
+	}
+	select {
+	case event := <-sub.Events():
+		t.Errorf("received %+v, want only live events", event)
+	default:
+	}
+
+	broker.Unsubscribe(sub)
+	broker.Unsubscribe(sub)
+	if _, open := <-sub.Events(); open {
+		t.Error("events are still open after Unsubscribe")
+	}
+}
+
+func TestBrokerDropsSlowSubscriber(t *testing.T) {
+	broker := NewBroker()
+	slow, _ := broker.Subscribe()
+
+	for i := 1; i <= bufferSize+1; i++ {
+		broker.Broadcast(&types.Event{Position: int64(i), Type: types.EventBookUpdated})
+	}
+
+	count := 0
+	for range slow.Events() {
+		count++
+	}
+	if count != bufferSize {
+		t.Errorf("slow subscriber received %d events before being dropped, want %d", count, bufferSize)
+	}
+}
+
+type fakeSource struct {
+	subscribed chan struct{}
+}
+
+func (f *fakeSource) Subscribe(ctx context.Context, channel *string, fn func(message []byte)) types.Err {
+	close(f.subscribed)
+	<-ctx.Done()
+	return types.Err{}
+}
+
+func TestBrokerClose(t *testing.T) {
+	broker := NewBroker()
+	sub, _ := broker.Subscribe()
+	source := &fakeSource{subscribed: make(chan struct{})}
+
+	done := make(chan struct{})
+	go func() {
+		broker.Run(source)
+		close(done)
+	}()
+	<-source.subscribed
+
+	broker.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after Close")
+	}
+	if _, open := <-sub.Events(); open {
+		t.Error("events are still open after Close")
+	}
+	if _, ok := broker.Subscribe(); ok {
+		t.Error("Subscribe succeeded on a closed broker")
+	}
+}
+
+type fakeStore struct {
+	events []types.Event
+}
+
+func (f *fakeStore) GetOutboxEvents(after int64, limit int) ([]types.Event, int, types.Err) {
+	var events []types.Event
+	for _, event := range f.events {
+		if event.Position > after && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, 200, types.Err{}
+}
+
+func TestBacklog(t *testing.T) {
+	store := &fakeStore{}
+	for i := 1; i <= 10; i++ {
+		eventType := types.EventBookingReturned
+		if i%2 == 0 {
+			eventType = "webhook.deleted"
+		}
+		store.events = append(store.events, types.Event{Position: int64(i), Type: eventType})
+	}
+
+	backlog, position, reset, _, errResp := Backlog(store, 4)
+	if errResp.Error != "" || reset || position != 10 {
+		t.Fatalf("Backlog = %d, %v, %v", position, reset, errResp)
+	}
+	if len(backlog) != 3 || backlog[0].Position != 5 || backlog[2].Position != 9 {
+		t.Errorf("Backlog returned %+v, want the live events 5, 7 and 9", backlog)
+	}
+
+	for i := 11; i <= maxBacklog+20; i++ {
+		store.events = append(store.events, types.Event{Position: int64(i), Type: types.EventBookCreated})
+	}
+	backlog, position, reset, _, _ = Backlog(store, 0)
+	if !reset || backlog != nil || position != maxBacklog {
+		t.Errorf("Backlog far behind = %d events, %d, %v, want a reset at %d", len(backlog), position, reset, maxBacklog)
+	}
+}
+
+func TestWriteEvent(t *testing.T) {
+	var buf bytes.Buffer
+	event := types.Event{Position: 42, Id: "e1", Type: types.EventBookingCreated, Data: []byte(`{"id":"b1"}`),
+		CreatedAt: "2024-05-03T10:00:00Z"}
+	if err := WriteEvent(&buf, &event); err != nil {
+		t.Fatalf("WriteEvent returned an error: %v", err)
+	}
+
+	want := "id: 42\nevent: booking.created\n" +
+		`data: {"position":42,"id":"e1","type":"booking.created","data":{"id":"b1"},"created_at":"2024-05-03T10:00:00Z"}` +
+		"\n\n"
+	if buf.String() != want {
+		t.Errorf("WriteEvent wrote %q, want %q", buf.String(), want)
+	}
+}
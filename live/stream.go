@@ -0,0 +1,92 @@
+package live
+
+import (
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/goccy/go-json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// HeartbeatInterval is how often an idle stream sends a comment, to keep proxies from closing it.
+const HeartbeatInterval = 15 * time.Second
+
+// RetryMillis is the reconnection delay sent to the clients.
+const RetryMillis = 3000
+
+// maxBacklog is how many events a resumed stream reads from the outbox at most.
+// A client that is further behind is told to reset and reload instead.
+const maxBacklog = 1000
+
+// backlogBatch is how many events are read from the outbox at once.
+const backlogBatch = 100
+
+// EventReset tells the client it missed too many events to catch up and should reload what it shows.
+const EventReset = "reset"
+
+// Store reads the outbox, it is implemented by storage.PostgresStore.
+type Store interface {
+	GetOutboxEvents(after int64, limit int) ([]types.Event, int, types.Err)
+}
+
+// Backlog reads the live events after a position, for a client resuming with Last-Event-ID.
+// Parameters:
+// - store: the outbox the events are read from.
+// - after: the position of the last event the client has seen.
+// Returns the live events, the position of the last event read, whether the client is too far behind
+// (the events are then left out), status code, and an error if the outbox cannot be read.
+func Backlog(store Store, after int64) ([]types.Event, int64, bool, int, types.Err) {
+	var backlog []types.Event
+	position, read := after, 0
+	for {
+		events, statusCode, errResp := store.GetOutboxEvents(position, backlogBatch)
+		if errResp.Error != "" {
+			return nil, 0, false, statusCode, errResp
+		}
+		if len(events) == 0 {
+			return backlog, position, false, 200, types.Err{}
+		}
+
+		for _, event := range events {
+			if IsLive(event.Type) {
+				backlog = append(backlog, event)
+			}
+		}
+		position = events[len(events)-1].Position
+
+		read += len(events)
+		if read >= maxBacklog {
+			return nil, position, true, 200, types.Err{}
+		}
+	}
+}
+
+// WriteEvent writes an event in the text/event-stream format, its position is the event ID.
+func WriteEvent(w io.Writer, event *types.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "id: "+strconv.FormatInt(event.Position, 10)+"\nevent: "+event.Type+
+		"\ndata: "+string(data)+"\n\n")
+	return err
+}
+
+// WriteReset tells the client to reload, see EventReset.
+func WriteReset(w io.Writer) error {
+	_, err := io.WriteString(w, "event: "+EventReset+"\ndata: {}\n\n")
+	return err
+}
+
+// WriteRetry sets the reconnection delay of the client.
+func WriteRetry(w io.Writer) error {
+	_, err := io.WriteString(w, "retry: "+strconv.Itoa(RetryMillis)+"\n\n")
+	return err
+}
+
+// WriteHeartbeat writes a comment, which clients ignore.
+func WriteHeartbeat(w io.Writer) error {
+	_, err := io.WriteString(w, ": heartbeat\n\n")
+	return err
+}
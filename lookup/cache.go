@@ -0,0 +1,74 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"log"
+	"time"
+)
+
+const (
+	// foundExpiration is how long a record is reused, catalog records rarely change once published.
+	foundExpiration = 7 * 24 * time.Hour
+	// notFoundExpiration is kept short as catalogs add records for new titles over time.
+	notFoundExpiration = time.Hour
+)
+
+// notFound is cached for an ISBN the provider has no record of.
+var notFound = []byte("null")
+
+// Cache stores lookup results, it is implemented by cache.Cache.
+type Cache interface {
+	GetLookup(isbn *string) ([]byte, types.Err)
+	SaveLookup(isbn *string, result []byte, expiration time.Duration) types.Err
+}
+
+type cachedProvider struct {
+	provider Provider
+	cache    Cache
+}
+
+// WithCache wraps a provider so results, including the absence of a record, are reused across lookups.
+// Provider failures are not cached and a failing cache only costs the extra provider requests.
+func WithCache(provider Provider, cache Cache) Provider {
+	return &cachedProvider{provider: provider, cache: cache}
+}
+
+func (c *cachedProvider) Lookup(ctx context.Context, isbn string) (types.CreateBook, error) {
+	result, errCache := c.cache.GetLookup(&isbn)
+	if errCache.Error != "" {
+		log.Print("unable to read lookup cache: ", errCache.Error)
+	}
+	if result != nil {
+		if string(result) == string(notFound) {
+			return types.CreateBook{}, ErrNotFound
+		}
+
+		var book types.CreateBook
+		if err := jsonutil.UnmarshalJSON(result, &book); err == nil {
+			return book, nil
+		}
+	}
+
+	book, err := c.provider.Lookup(ctx, isbn)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return types.CreateBook{}, err
+	}
+
+	result, expiration := notFound, notFoundExpiration
+	if err == nil {
+		encoded, errEncode := jsonutil.MarshalJSON(book)
+		if errEncode != nil {
+			return book, nil
+		}
+		result, expiration = encoded, foundExpiration
+	}
+
+	if errCache := c.cache.SaveLookup(&isbn, result, expiration); errCache.Error != "" {
+		log.Print("unable to write lookup cache: ", errCache.Error)
+	}
+
+	return book, err
+}
@@ -0,0 +1,50 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"github.com/Tus1688/library-management-api/bookutil"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"os"
+)
+
+// File is a Provider answering from a JSON file that maps ISBNs to CreateBook payloads,
+// for offline installations and tests.
+type File struct {
+	books map[string]types.CreateBook
+}
+
+// NewFile loads the lookup file, its ISBNs may be written in any form NormalizeIsbn accepts.
+// Returns the provider and an error if the file cannot be read or holds an invalid ISBN.
+func NewFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]types.CreateBook
+	if err := jsonutil.UnmarshalJSON(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid lookup file: %w", err)
+	}
+
+	books := make(map[string]types.CreateBook, len(raw))
+	for key, book := range raw {
+		isbn, err := bookutil.NormalizeIsbn(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lookup file: %q: %w", key, err)
+		}
+		books[isbn] = book
+	}
+
+	return &File{books: books}, nil
+}
+
+func (f *File) Lookup(_ context.Context, isbn string) (types.CreateBook, error) {
+	book, ok := f.books[isbn]
+	if !ok {
+		return types.CreateBook{}, ErrNotFound
+	}
+
+	return prefill(book, isbn), nil
+}
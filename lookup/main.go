@@ -0,0 +1,56 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/bookutil"
+	"github.com/Tus1688/library-management-api/types"
+	"os"
+)
+
+var (
+	ErrNotFound    = errors.New("no record found for this isbn")
+	ErrUnavailable = errors.New("metadata provider is unavailable")
+)
+
+// Provider looks up the bibliographic record of an ISBN in an external catalog.
+type Provider interface {
+	// Lookup returns a CreateBook prefilled with what the catalog knows about the book.
+	// The isbn is a normalized ISBN-13, the returned book carries it and normalized metadata.
+	// Returns ErrNotFound if the catalog has no record and an error wrapping ErrUnavailable if it cannot be reached.
+	Lookup(ctx context.Context, isbn string) (types.CreateBook, error)
+}
+
+// NewProvider creates the provider selected by the LOOKUP_PROVIDER environment variable:
+// "openlibrary" (the default) queries LOOKUP_URL, https://openlibrary.org by default,
+// and "file" answers from the JSON file at LOOKUP_FILE for offline use.
+// Returns the provider and an error if the provider is unknown or the file cannot be loaded.
+func NewProvider() (Provider, error) {
+	switch os.Getenv("LOOKUP_PROVIDER") {
+	case "", "openlibrary":
+		return NewOpenLibrary(os.Getenv("LOOKUP_URL")), nil
+	case "file":
+		return NewFile(os.Getenv("LOOKUP_FILE"))
+	}
+
+	return nil, errors.New("unknown lookup provider " + os.Getenv("LOOKUP_PROVIDER"))
+}
+
+// prefill sets the ISBN of a looked up book and normalizes its metadata.
+// Catalog data is only a suggestion, so values the book endpoints would reject are dropped instead of failing the lookup.
+func prefill(book types.CreateBook, isbn string) types.CreateBook {
+	book.Isbn = isbn
+	for {
+		err := bookutil.NormalizeMetadata(&book.BookMetadata)
+		switch {
+		case errors.Is(err, bookutil.ErrInvalidLanguage):
+			book.Language = ""
+		case errors.Is(err, bookutil.ErrInvalidPublicationYear):
+			book.PublicationYear = 0
+		case errors.Is(err, bookutil.ErrInvalidPageCount):
+			book.PageCount = 0
+		default:
+			return book
+		}
+	}
+}
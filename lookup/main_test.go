@@ -0,0 +1,160 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOpenLibrary(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata/openlibrary")))
+	defer server.Close()
+
+	provider := NewOpenLibrary(server.URL + "/")
+	book, err := provider.Lookup(context.Background(), "9780134190440")
+	if err != nil {
+		t.Fatalf("Lookup returned an error: %v", err)
+	}
+
+	// the description and subjects come from the work, the unknown author is skipped
+	want := types.CreateBook{
+		Title:       "The Go Programming Language",
+		Author:      "Alan A. A. Donovan",
+		Description: "The authoritative resource to writing clear and idiomatic Go.",
+		BookMetadata: types.BookMetadata{
+			Isbn:            "9780134190440",
+			Publisher:       "Addison-Wesley",
+			PublicationYear: 2015,
+			Language:        "eng",
+			PageCount:       380,
+			Subjects: []string{"Go (Computer program language)", "Computer programming", "Programming languages",
+				"Open source software", "Software engineering", "Concurrency", "Networking", "Testing", "Algorithms",
+				"Data structures"},
+		},
+	}
+	if !reflect.DeepEqual(book, want) {
+		t.Errorf("Lookup = %+v, want %+v", book, want)
+	}
+
+	dune, err := provider.Lookup(context.Background(), "9780441013593")
+	if err != nil {
+		t.Fatalf("Lookup returned an error: %v", err)
+	}
+	if dune.Title != "Dune: 40th anniversary edition" || dune.Author != "Frank Herbert" ||
+		dune.Description != "Set on the desert planet Arrakis." || dune.PublicationYear != 2005 {
+		t.Errorf("Lookup = %+v", dune)
+	}
+
+	if _, err := provider.Lookup(context.Background(), "9780306406157"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup of an unknown isbn = %v, want ErrNotFound", err)
+	}
+}
+
+func TestOpenLibraryUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := NewOpenLibrary(server.URL).Lookup(context.Background(), "9780134190440"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Lookup = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestFile(t *testing.T) {
+	provider, err := NewFile("testdata/books.json")
+	if err != nil {
+		t.Fatalf("NewFile returned an error: %v", err)
+	}
+
+	dune, err := provider.Lookup(context.Background(), "9780441013593")
+	if err != nil {
+		t.Fatalf("Lookup returned an error: %v", err)
+	}
+	if dune.Isbn != "9780441013593" || dune.Language != "eng" ||
+		!reflect.DeepEqual(dune.Subjects, []string{"Science fiction", "Dune (Imaginary place)"}) {
+		t.Errorf("Lookup = %+v", dune)
+	}
+
+	// invalid values are dropped rather than failing the lookup
+	pride, err := provider.Lookup(context.Background(), "9780141439518")
+	if err != nil {
+		t.Fatalf("Lookup returned an error: %v", err)
+	}
+	if pride.PublicationYear != 0 || pride.Language != "" || pride.Title != "Pride and Prejudice" {
+		t.Errorf("Lookup = %+v", pride)
+	}
+
+	if _, err := provider.Lookup(context.Background(), "9780306406157"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup of an unknown isbn = %v, want ErrNotFound", err)
+	}
+}
+
+type fakeCache struct {
+	results     map[string][]byte
+	expirations map[string]time.Duration
+}
+
+func (f *fakeCache) GetLookup(isbn *string) ([]byte, types.Err) {
+	return f.results[*isbn], types.Err{}
+}
+
+func (f *fakeCache) SaveLookup(isbn *string, result []byte, expiration time.Duration) types.Err {
+	f.results[*isbn], f.expirations[*isbn] = result, expiration
+	return types.Err{}
+}
+
+type countingProvider struct {
+	calls int
+	err   error
+}
+
+func (c *countingProvider) Lookup(_ context.Context, isbn string) (types.CreateBook, error) {
+	c.calls++
+	if c.err != nil {
+		return types.CreateBook{}, c.err
+	}
+	return types.CreateBook{Title: "Dune", BookMetadata: types.BookMetadata{Isbn: isbn}}, nil
+}
+
+func TestWithCache(t *testing.T) {
+	cache := &fakeCache{results: map[string][]byte{}, expirations: map[string]time.Duration{}}
+	provider := &countingProvider{}
+	cached := WithCache(provider, cache)
+
+	for i := 0; i < 2; i++ {
+		book, err := cached.Lookup(context.Background(), "9780441013593")
+		if err != nil || book.Title != "Dune" || book.Isbn != "9780441013593" {
+			t.Fatalf("Lookup = %+v, %v", book, err)
+		}
+	}
+	if provider.calls != 1 || cache.expirations["9780441013593"] != foundExpiration {
+		t.Errorf("provider called %d times, cached for %v", provider.calls, cache.expirations["9780441013593"])
+	}
+
+	provider.err = ErrNotFound
+	for i := 0; i < 2; i++ {
+		if _, err := cached.Lookup(context.Background(), "9780306406157"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Lookup = %v, want ErrNotFound", err)
+		}
+	}
+	if provider.calls != 2 || cache.expirations["9780306406157"] != notFoundExpiration {
+		t.Errorf("provider called %d times, cached for %v", provider.calls, cache.expirations["9780306406157"])
+	}
+
+	// failures are retried on the next lookup
+	provider.err = ErrUnavailable
+	for i := 0; i < 2; i++ {
+		if _, err := cached.Lookup(context.Background(), "9780134190440"); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("Lookup = %v, want ErrUnavailable", err)
+		}
+	}
+	if provider.calls != 4 {
+		t.Errorf("provider called %d times, want 4", provider.calls)
+	}
+}
@@ -0,0 +1,200 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/goccy/go-json"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxAuthors bounds the author requests made for a single lookup.
+	maxAuthors = 5
+	// maxSubjects keeps the subjects of popular works, which can list hundreds, to the most relevant ones.
+	maxSubjects = 10
+	// maxResponseSize guards against unexpectedly large responses.
+	maxResponseSize = 1 << 20
+)
+
+var publishYear = regexp.MustCompile(`\b\d{4}\b`)
+
+// OpenLibrary is a Provider for the Open Library API or any service exposing the same
+// /isbn, /works and /authors JSON endpoints.
+type OpenLibrary struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewOpenLibrary creates an OpenLibrary provider, an empty baseURL defaults to https://openlibrary.org.
+func NewOpenLibrary(baseURL string) *OpenLibrary {
+	if baseURL == "" {
+		baseURL = "https://openlibrary.org"
+	}
+
+	return &OpenLibrary{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type olKey struct {
+	Key string `json:"key"`
+}
+
+// olText is a text field that Open Library returns either as a string or as {"type": ..., "value": ...}.
+type olText string
+
+func (t *olText) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*t = olText(text)
+		return nil
+	}
+
+	var typed struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return err
+	}
+	*t = olText(typed.Value)
+	return nil
+}
+
+type olEdition struct {
+	Title         string   `json:"title"`
+	Subtitle      string   `json:"subtitle"`
+	Authors       []olKey  `json:"authors"`
+	Works         []olKey  `json:"works"`
+	Publishers    []string `json:"publishers"`
+	PublishDate   string   `json:"publish_date"`
+	NumberOfPages int      `json:"number_of_pages"`
+	Languages     []olKey  `json:"languages"`
+	EditionName   string   `json:"edition_name"`
+	Description   olText   `json:"description"`
+	Subjects      []string `json:"subjects"`
+}
+
+type olWork struct {
+	Description olText   `json:"description"`
+	Subjects    []string `json:"subjects"`
+	Authors     []struct {
+		Author olKey `json:"author"`
+	} `json:"authors"`
+}
+
+type olAuthor struct {
+	Name string `json:"name"`
+}
+
+// Lookup reads the edition of the ISBN, completing it with the description, subjects and authors of its work
+// when the edition itself lacks them, as is common in Open Library.
+func (o *OpenLibrary) Lookup(ctx context.Context, isbn string) (types.CreateBook, error) {
+	var edition olEdition
+	if err := o.get(ctx, "/isbn/"+isbn+".json", &edition); err != nil {
+		return types.CreateBook{}, err
+	}
+
+	var work olWork
+	if len(edition.Works) > 0 {
+		if err := o.get(ctx, edition.Works[0].Key+".json", &work); err != nil && !errors.Is(err, ErrNotFound) {
+			return types.CreateBook{}, err
+		}
+	}
+
+	book := types.CreateBook{
+		Title:       strings.TrimSpace(edition.Title),
+		Description: strings.TrimSpace(string(edition.Description)),
+		BookMetadata: types.BookMetadata{
+			Edition:   edition.EditionName,
+			PageCount: edition.NumberOfPages,
+			Subjects:  edition.Subjects,
+		},
+	}
+	if edition.Subtitle != "" {
+		book.Title += ": " + strings.TrimSpace(edition.Subtitle)
+	}
+	if book.Description == "" {
+		book.Description = strings.TrimSpace(string(work.Description))
+	}
+	if len(book.Subjects) == 0 {
+		book.Subjects = work.Subjects
+	}
+	if len(book.Subjects) > maxSubjects {
+		book.Subjects = book.Subjects[:maxSubjects]
+	}
+	if len(edition.Publishers) > 0 {
+		book.Publisher = edition.Publishers[0]
+	}
+	if len(edition.Languages) > 0 {
+		book.Language = strings.TrimPrefix(edition.Languages[0].Key, "/languages/")
+	}
+	book.PublicationYear, _ = strconv.Atoi(publishYear.FindString(edition.PublishDate))
+
+	authorKeys := edition.Authors
+	if len(authorKeys) == 0 {
+		for _, author := range work.Authors {
+			authorKeys = append(authorKeys, author.Author)
+		}
+	}
+
+	var names []string
+	for i, key := range authorKeys {
+		if i == maxAuthors {
+			break
+		}
+
+		var author olAuthor
+		if err := o.get(ctx, key.Key+".json", &author); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return types.CreateBook{}, err
+		}
+		if name := strings.TrimSpace(author.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	book.Author = strings.Join(names, "; ")
+
+	return prefill(book, isbn), nil
+}
+
+// get decodes the JSON document at path into v.
+// Returns ErrNotFound for a 404 and an error wrapping ErrUnavailable for any other failure.
+func (o *OpenLibrary) get(ctx context.Context, path string, v interface{}) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("%w: unexpected key %q", ErrUnavailable, path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%w: %s returned %d", ErrUnavailable, path, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: invalid response for %s: %v", ErrUnavailable, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package marc
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	subfieldDelimiter = 0x1f
+	fieldTerminator   = 0x1e
+	recordTerminator  = 0x1d
+
+	leaderLength         = 24
+	directoryEntryLength = 12
+)
+
+var (
+	ErrMalformed = errors.New("malformed marc record")
+	ErrTooLong   = errors.New("marc record or field is too long")
+)
+
+// Record is a MARC 21 bibliographic record.
+type Record struct {
+	Leader string
+	Fields []Field
+}
+
+// Field is a variable field of a record.
+// Control fields (tags 001 to 009) only have a Value, data fields have indicators and subfields.
+type Field struct {
+	Tag       string
+	Ind1      byte
+	Ind2      byte
+	Value     string
+	Subfields []Subfield
+}
+
+type Subfield struct {
+	Code  byte
+	Value string
+}
+
+// IsControl reports whether the field is a control field, which has no indicators or subfields.
+func (f *Field) IsControl() bool {
+	return strings.HasPrefix(f.Tag, "00")
+}
+
+// Subfield returns the value of the first subfield with the given code, or an empty string.
+func (f *Field) Subfield(code byte) string {
+	for _, subfield := range f.Subfields {
+		if subfield.Code == code {
+			return subfield.Value
+		}
+	}
+
+	return ""
+}
+
+// Field returns the first field with the given tag, or nil if the record has none.
+func (r *Record) Field(tag string) *Field {
+	for i := range r.Fields {
+		if r.Fields[i].Tag == tag {
+			return &r.Fields[i]
+		}
+	}
+
+	return nil
+}
+
+// FieldsByTag returns every field with the given tag, in record order.
+func (r *Record) FieldsByTag(tag string) []Field {
+	var fields []Field
+	for _, field := range r.Fields {
+		if field.Tag == tag {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// Subfield returns the value of the first subfield with the given code in the first field with the given tag.
+func (r *Record) Subfield(tag string, code byte) string {
+	if field := r.Field(tag); field != nil {
+		return field.Subfield(code)
+	}
+
+	return ""
+}
+
+// ControlField returns the value of the first control field with the given tag.
+func (r *Record) ControlField(tag string) string {
+	if field := r.Field(tag); field != nil {
+		return field.Value
+	}
+
+	return ""
+}
+
+// NewDataField creates a data field, subfields are given as alternating codes and values
+// and the pairs with an empty value are left out.
+func NewDataField(tag string, ind1, ind2 byte, subfields ...string) Field {
+	field := Field{Tag: tag, Ind1: ind1, Ind2: ind2}
+	for i := 0; i+1 < len(subfields); i += 2 {
+		if subfields[i+1] != "" {
+			field.Subfields = append(field.Subfields, Subfield{Code: subfields[i][0], Value: subfields[i+1]})
+		}
+	}
+
+	return field
+}
+
+// indicator maps a missing indicator to the blank MARC uses instead.
+func indicator(value byte) byte {
+	if value == 0 {
+		return ' '
+	}
+	return value
+}
+
+// defaultLeader describes a new, Unicode encoded monograph record, the lengths are filled in when writing.
+const defaultLeader = "00000nam a2200000 i 4500"
+
+// leader returns the leader of the record, falling back to defaultLeader when it is missing or invalid.
+func (r *Record) leader() []byte {
+	if len(r.Leader) != leaderLength {
+		return []byte(defaultLeader)
+	}
+	return []byte(r.Leader)
+}
@@ -0,0 +1,173 @@
+package marc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func readAll(t *testing.T, reader RecordReader) []Record {
+	t.Helper()
+
+	var records []Record
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("Next returned an error: %v", err)
+		}
+		records = append(records, record)
+	}
+}
+
+func TestReader(t *testing.T) {
+	file, err := os.Open("testdata/books.mrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	records := readAll(t, NewReader(file))
+	if len(records) != 2 {
+		t.Fatalf("read %d records, want 2", len(records))
+	}
+
+	first := records[0]
+	if got := first.ControlField("001"); got != "ocm12345" {
+		t.Errorf("001 = %q, want %q", got, "ocm12345")
+	}
+	if got := first.Subfield("245", 'a'); got != "The Go programming language /" {
+		t.Errorf("245 $a = %q", got)
+	}
+	if title := first.Field("245"); title.Ind1 != '1' || title.Ind2 != '4' {
+		t.Errorf("245 indicators = %q %q, want 1 4", title.Ind1, title.Ind2)
+	}
+	if subjects := first.FieldsByTag("650"); len(subjects) != 2 || subjects[1].Subfield('a') != "Open source software." {
+		t.Errorf("650 fields = %+v", subjects)
+	}
+	if got := records[1].Subfield("264", 'b'); got != "Ace Books," {
+		t.Errorf("264 $b = %q, want %q", got, "Ace Books,")
+	}
+}
+
+func TestXMLReader(t *testing.T) {
+	file, err := os.Open("testdata/books.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	records := readAll(t, NewXMLReader(file))
+	if len(records) != 2 {
+		t.Fatalf("read %d records, want 2", len(records))
+	}
+
+	if got := records[0].Subfield("100", 'a'); got != "Saint-Exupéry, Antoine de," {
+		t.Errorf("100 $a = %q", got)
+	}
+	if got := records[0].Subfield("500", 'a'); got != "A pilot & a little prince meet in the desert." {
+		t.Errorf("500 $a = %q", got)
+	}
+	if got := records[0].FieldsByTag("020"); len(got) != 2 {
+		t.Errorf("read %d 020 fields, want 2", len(got))
+	}
+	if records[1].Field("100") != nil {
+		t.Errorf("second record has an unexpected 100 field")
+	}
+}
+
+func TestReaderMalformed(t *testing.T) {
+	data, err := os.ReadFile("testdata/books.mrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// corrupt the base address of the first record, the second one must still be readable
+	corrupt := bytes.Clone(data)
+	copy(corrupt[12:17], "99999")
+
+	reader := NewReader(bytes.NewReader(corrupt))
+	if _, err := reader.Next(); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("Next = %v, want ErrMalformed", err)
+	}
+	record, err := reader.Next()
+	if err != nil || record.ControlField("001") != "ocm67890" {
+		t.Errorf("Next after a malformed record = %v, %v", record.ControlField("001"), err)
+	}
+
+	if _, err := NewReader(bytes.NewReader([]byte("abcde"))).Next(); err == nil || errors.Is(err, ErrMalformed) {
+		t.Errorf("Next with an invalid length = %v, want a fatal error", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	record := Record{Fields: []Field{
+		{Tag: "001", Value: "42"},
+		NewDataField("020", ' ', ' ', "a", "9780134190440"),
+		NewDataField("100", '1', ' ', "a", "Herbert, Frank", "d", ""),
+		NewDataField("245", '1', '0', "a", "Dune <&> \"Messiah\"", "c", "Frank Herbert"),
+		NewDataField("650", ' ', '0', "a", "Science fiction"),
+	}}
+
+	for name, codec := range map[string]struct {
+		writer func(w io.Writer) RecordWriter
+		reader func(r io.Reader) RecordReader
+	}{
+		"binary": {
+			writer: func(w io.Writer) RecordWriter { return NewWriter(w) },
+			reader: func(r io.Reader) RecordReader { return NewReader(r) },
+		},
+		"xml": {
+			writer: func(w io.Writer) RecordWriter { return NewXMLWriter(w) },
+			reader: func(r io.Reader) RecordReader { return NewXMLReader(r) },
+		},
+	} {
+		var buf bytes.Buffer
+		writer := codec.writer(&buf)
+		for i := 0; i < 2; i++ {
+			if err := writer.Write(&record); err != nil {
+				t.Fatalf("%s: Write returned an error: %v", name, err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("%s: Close returned an error: %v", name, err)
+		}
+
+		records := readAll(t, codec.reader(&buf))
+		if len(records) != 2 {
+			t.Fatalf("%s: read %d records, want 2", name, len(records))
+		}
+		if !reflect.DeepEqual(records[1].Fields, record.Fields) {
+			t.Errorf("%s: fields = %+v, want %+v", name, records[1].Fields, record.Fields)
+		}
+		if len(records[1].Leader) != leaderLength {
+			t.Errorf("%s: leader = %q", name, records[1].Leader)
+		}
+	}
+}
+
+func TestWriterTooLong(t *testing.T) {
+	record := Record{Fields: []Field{NewDataField("520", ' ', ' ', "a", string(bytes.Repeat([]byte("x"), 10000)))}}
+	if err := NewWriter(io.Discard).Write(&record); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Write = %v, want ErrTooLong", err)
+	}
+}
+
+func TestXMLWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewXMLWriter(&buf)
+	if buf.Len() != 0 {
+		t.Errorf("NewXMLWriter wrote before the first record")
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if records := readAll(t, NewXMLReader(&buf)); len(records) != 0 {
+		t.Errorf("read %d records from an empty collection", len(records))
+	}
+}
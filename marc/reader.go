@@ -0,0 +1,232 @@
+package marc
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// RecordReader reads records one at a time, Next returns io.EOF once the input is exhausted.
+// An error wrapping ErrMalformed only concerns the current record, the next one can still be read.
+type RecordReader interface {
+	Next() (Record, error)
+}
+
+// Reader reads binary MARC 21 (ISO 2709) records.
+type Reader struct {
+	reader *bufio.Reader
+}
+
+// NewReader creates a Reader for binary MARC 21 records.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{reader: bufio.NewReader(r)}
+}
+
+func (m *Reader) Next() (Record, error) {
+	// some exports put line breaks between records
+	for {
+		b, err := m.reader.Peek(1)
+		if err != nil {
+			return Record{}, err
+		}
+		if b[0] != '\n' && b[0] != '\r' && b[0] != ' ' {
+			break
+		}
+		_, _ = m.reader.ReadByte()
+	}
+
+	prefix, err := m.reader.Peek(5)
+	if err != nil {
+		return Record{}, io.ErrUnexpectedEOF
+	}
+
+	length, err := strconv.Atoi(string(prefix))
+	if err != nil || length < leaderLength+1 {
+		return Record{}, fmt.Errorf("invalid record length %q", prefix)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(m.reader, data); err != nil {
+		return Record{}, io.ErrUnexpectedEOF
+	}
+
+	return parseRecord(data)
+}
+
+// parseRecord decodes a single record including its leader and record terminator.
+func parseRecord(data []byte) (Record, error) {
+	if data[len(data)-1] != recordTerminator {
+		return Record{}, fmt.Errorf("%w: missing record terminator", ErrMalformed)
+	}
+
+	base, err := strconv.Atoi(string(data[12:17]))
+	if err != nil || base <= leaderLength || base > len(data) {
+		return Record{}, fmt.Errorf("%w: invalid base address", ErrMalformed)
+	}
+
+	record := Record{Leader: string(data[:leaderLength])}
+	directory := data[leaderLength : base-1]
+	if len(directory)%directoryEntryLength != 0 || data[base-1] != fieldTerminator {
+		return Record{}, fmt.Errorf("%w: invalid directory", ErrMalformed)
+	}
+
+	for i := 0; i < len(directory); i += directoryEntryLength {
+		entry := directory[i : i+directoryEntryLength]
+		fieldLength, errLength := strconv.Atoi(string(entry[3:7]))
+		start, errStart := strconv.Atoi(string(entry[7:12]))
+		end := base + start + fieldLength
+		if errLength != nil || errStart != nil || fieldLength < 1 || end > len(data) {
+			return Record{}, fmt.Errorf("%w: invalid directory entry %q", ErrMalformed, entry)
+		}
+
+		// the field terminator is part of the field length
+		field, err := parseField(string(entry[:3]), data[base+start:end-1])
+		if err != nil {
+			return Record{}, err
+		}
+		record.Fields = append(record.Fields, field)
+	}
+
+	return record, nil
+}
+
+func parseField(tag string, data []byte) (Field, error) {
+	if !utf8.Valid(data) {
+		return Field{}, fmt.Errorf("%w: field %s is not valid UTF-8", ErrMalformed, tag)
+	}
+
+	field := Field{Tag: tag}
+	if field.IsControl() {
+		field.Value = string(data)
+		return field, nil
+	}
+
+	if len(data) < 2 {
+		return Field{}, fmt.Errorf("%w: field %s has no indicators", ErrMalformed, tag)
+	}
+	field.Ind1, field.Ind2 = data[0], data[1]
+
+	for _, raw := range splitSubfields(data[2:]) {
+		if len(raw) == 0 {
+			continue
+		}
+		field.Subfields = append(field.Subfields, Subfield{Code: raw[0], Value: string(raw[1:])})
+	}
+
+	return field, nil
+}
+
+// splitSubfields splits the content of a data field on the subfield delimiter, dropping what precedes the first one.
+func splitSubfields(data []byte) [][]byte {
+	var subfields [][]byte
+	start := -1
+	for i, b := range data {
+		if b != subfieldDelimiter {
+			continue
+		}
+		if start >= 0 {
+			subfields = append(subfields, data[start:i])
+		}
+		start = i + 1
+	}
+	if start >= 0 {
+		subfields = append(subfields, data[start:])
+	}
+
+	return subfields
+}
+
+// XMLReader reads MARCXML records, either a single record or a collection of them.
+type XMLReader struct {
+	decoder *xml.Decoder
+}
+
+// NewXMLReader creates a XMLReader for MARCXML.
+func NewXMLReader(r io.Reader) *XMLReader {
+	return &XMLReader{decoder: xml.NewDecoder(r)}
+}
+
+type xmlRecord struct {
+	Leader        string            `xml:"leader"`
+	ControlFields []xmlControlField `xml:"controlfield"`
+	DataFields    []xmlDataField    `xml:"datafield"`
+}
+
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+func (x *XMLReader) Next() (Record, error) {
+	for {
+		token, err := x.decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return Record{}, io.EOF
+			}
+			return Record{}, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+
+		var raw xmlRecord
+		if err := x.decoder.DecodeElement(&raw, &start); err != nil {
+			return Record{}, err
+		}
+
+		return raw.record()
+	}
+}
+
+func (x *xmlRecord) record() (Record, error) {
+	record := Record{Leader: x.Leader}
+	for _, control := range x.ControlFields {
+		if len(control.Tag) != 3 {
+			return Record{}, fmt.Errorf("%w: invalid tag %q", ErrMalformed, control.Tag)
+		}
+		record.Fields = append(record.Fields, Field{Tag: control.Tag, Value: control.Value})
+	}
+
+	for _, data := range x.DataFields {
+		if len(data.Tag) != 3 {
+			return Record{}, fmt.Errorf("%w: invalid tag %q", ErrMalformed, data.Tag)
+		}
+
+		field := Field{Tag: data.Tag, Ind1: firstByte(data.Ind1), Ind2: firstByte(data.Ind2)}
+		for _, subfield := range data.Subfields {
+			if subfield.Code == "" {
+				return Record{}, fmt.Errorf("%w: subfield without code in field %s", ErrMalformed, data.Tag)
+			}
+			field.Subfields = append(field.Subfields, Subfield{Code: subfield.Code[0], Value: subfield.Value})
+		}
+		record.Fields = append(record.Fields, field)
+	}
+
+	return record, nil
+}
+
+func firstByte(value string) byte {
+	if value == "" {
+		return ' '
+	}
+	return value[0]
+}
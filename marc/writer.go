@@ -0,0 +1,137 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// RecordWriter writes records one at a time, Close must be called to complete the output.
+type RecordWriter interface {
+	Write(record *Record) error
+	Close() error
+}
+
+// Writer writes binary MARC 21 (ISO 2709) records.
+type Writer struct {
+	writer *bufio.Writer
+}
+
+// NewWriter creates a Writer for binary MARC 21 records.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{writer: bufio.NewWriter(w)}
+}
+
+// Write encodes the record, computing its leader lengths and directory.
+// Returns ErrTooLong if a field exceeds 9999 bytes or the record 99999 bytes, the limits of the format.
+func (m *Writer) Write(record *Record) error {
+	var directory, data bytes.Buffer
+	for _, field := range record.Fields {
+		start := data.Len()
+		if field.IsControl() {
+			data.WriteString(field.Value)
+		} else {
+			data.WriteByte(indicator(field.Ind1))
+			data.WriteByte(indicator(field.Ind2))
+			for _, subfield := range field.Subfields {
+				data.WriteByte(subfieldDelimiter)
+				data.WriteByte(subfield.Code)
+				data.WriteString(subfield.Value)
+			}
+		}
+		data.WriteByte(fieldTerminator)
+
+		length := data.Len() - start
+		if length > 9999 || len(field.Tag) != 3 {
+			return fmt.Errorf("%w: field %s", ErrTooLong, field.Tag)
+		}
+		fmt.Fprintf(&directory, "%s%04d%05d", field.Tag, length, start)
+	}
+	directory.WriteByte(fieldTerminator)
+	data.WriteByte(recordTerminator)
+
+	base := leaderLength + directory.Len()
+	length := base + data.Len()
+	if length > 99999 {
+		return ErrTooLong
+	}
+
+	leader := record.leader()
+	copy(leader[0:5], fmt.Sprintf("%05d", length))
+	leader[9] = 'a'
+	copy(leader[10:12], "22")
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+	copy(leader[20:24], "4500")
+
+	_, _ = m.writer.Write(leader)
+	_, _ = m.writer.Write(directory.Bytes())
+	_, err := m.writer.Write(data.Bytes())
+	return err
+}
+
+func (m *Writer) Close() error {
+	return m.writer.Flush()
+}
+
+// XMLWriter writes MARCXML records wrapped in a collection element.
+// Nothing is written before the first record or Close.
+type XMLWriter struct {
+	writer  *bufio.Writer
+	started bool
+}
+
+// NewXMLWriter creates a XMLWriter for MARCXML.
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	return &XMLWriter{writer: bufio.NewWriter(w)}
+}
+
+func (x *XMLWriter) start() {
+	if x.started {
+		return
+	}
+	x.started = true
+	_, _ = x.writer.WriteString(xml.Header + `<collection xmlns="http://www.loc.gov/MARC21/slim">` + "\n")
+}
+
+func (x *XMLWriter) Write(record *Record) error {
+	x.start()
+
+	_, _ = x.writer.WriteString("<record>\n<leader>")
+	_ = xml.EscapeText(x.writer, record.leader())
+	_, _ = x.writer.WriteString("</leader>\n")
+
+	for _, field := range record.Fields {
+		if field.IsControl() {
+			fmt.Fprintf(x.writer, `<controlfield tag="%s">`, escapeAttr(field.Tag))
+			_ = xml.EscapeText(x.writer, []byte(field.Value))
+			_, _ = x.writer.WriteString("</controlfield>\n")
+			continue
+		}
+
+		fmt.Fprintf(x.writer, `<datafield tag="%s" ind1="%s" ind2="%s">`, escapeAttr(field.Tag),
+			escapeAttr(string(indicator(field.Ind1))), escapeAttr(string(indicator(field.Ind2))))
+		for _, subfield := range field.Subfields {
+			fmt.Fprintf(x.writer, `<subfield code="%s">`, escapeAttr(string(subfield.Code)))
+			_ = xml.EscapeText(x.writer, []byte(subfield.Value))
+			_, _ = x.writer.WriteString("</subfield>")
+		}
+		_, _ = x.writer.WriteString("</datafield>\n")
+	}
+
+	_, err := x.writer.WriteString("</record>\n")
+	return err
+}
+
+func (x *XMLWriter) Close() error {
+	x.start()
+	_, _ = x.writer.WriteString("</collection>\n")
+	return x.writer.Flush()
+}
+
+func escapeAttr(value string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(value))
+	return buf.String()
+}
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Message is a rendered notification ready to be delivered.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages to borrowers.
+type Sender interface {
+	// Send delivers a single message, it returns once the message has been handed over to the server.
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender creates the email sender configured by the environment.
+// With SMTP_HOST set messages go out through that server, using SMTP_PORT (587 by default), SMTP_USERNAME,
+// SMTP_PASSWORD and SMTP_FROM.
+// Returns the sender, nil when SMTP_HOST is empty as email is then disabled,
+// and an error if the configuration is incomplete.
+func NewSender() (Sender, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		log.Print("SMTP_HOST is not set, no emails are sent")
+		return nil, nil
+	}
+
+	port := 587
+	if raw := os.Getenv("SMTP_PORT"); raw != "" {
+		var err error
+		if port, err = strconv.Atoi(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewSMTP(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("SMTP_FROM"))
+}
+
+// LogSender writes messages to the log instead of delivering them.
+type LogSender struct{}
+
+func (LogSender) Send(_ context.Context, msg Message) error {
+	if msg.Subject == "" {
+		log.Printf("notification to %s: %s", msg.To, msg.Body)
+		return nil
+	}
+
+	log.Printf("notification to %s: %s", msg.To, msg.Subject)
+	return nil
+}
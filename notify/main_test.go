@@ -0,0 +1,417 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/patronutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/goccy/go-json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpStandIn accepts a single SMTP session on a local port and returns what the client sent.
+func smtpStandIn(t *testing.T) (string, <-chan []string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		var lines []string
+		_ = text.PrintfLine("220 localhost ESMTP stand-in")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				break
+			}
+			lines = append(lines, line)
+
+			switch verb := strings.ToUpper(strings.Fields(line + " x")[0]); verb {
+			case "EHLO", "HELO":
+				_ = text.PrintfLine("250-localhost\r\n250 8BITMIME")
+			case "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				data, _ := text.ReadDotLines()
+				lines = append(lines, data...)
+				_ = text.PrintfLine("250 queued")
+			case "QUIT":
+				_ = text.PrintfLine("221 bye")
+				received <- lines
+				return
+			default:
+				_ = text.PrintfLine("250 ok")
+			}
+		}
+		received <- lines
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestSMTPSender(t *testing.T) {
+	addr, received := smtpStandIn(t)
+	host, rawPort, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(rawPort)
+
+	sender, err := NewSMTP(host, port, "", "", "Library <library@example.com>")
+	if err != nil {
+		t.Fatalf("NewSMTP returned an error: %v", err)
+	}
+
+	msg := Message{To: "ada@example.com", Subject: "Überfällig", Body: "Hello Ada,\n\n\"Dune\" is due today.\n"}
+	if err := sender.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send returned an error: %v", err)
+	}
+
+	var lines []string
+	select {
+	case lines = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stand-in did not receive a message")
+	}
+
+	session := strings.Join(lines, "\n")
+	for _, want := range []string{
+		"MAIL FROM:<library@example.com>",
+		"RCPT TO:<ada@example.com>",
+		"To: <ada@example.com>",
+		"Subject: =?utf-8?q?=C3=9Cberf=C3=A4llig?=",
+		"Content-Transfer-Encoding: quoted-printable",
+		`"Dune" is due today.`,
+	} {
+		if !strings.Contains(session, want) {
+			t.Errorf("the session does not contain %q:\n%s", want, session)
+		}
+	}
+}
+
+func TestSMTPSenderRejectsInvalidFrom(t *testing.T) {
+	if _, err := NewSMTP("localhost", 25, "", "", "not an address"); err == nil {
+		t.Error("NewSMTP accepted an invalid from address")
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	templates, err := LoadTemplates(types.ChannelEmail, "")
+	if err != nil {
+		t.Fatalf("LoadTemplates returned an error: %v", err)
+	}
+
+	data := TemplateData{Library: "City Library", Name: "Ada", Title: "Dune", DueDate: "Friday, 3 May 2024", Days: 1}
+	subject, body, err := templates.Render(types.NotifyReminder, data)
+	if err != nil {
+		t.Fatalf("Render returned an error: %v", err)
+	}
+
+	if subject != `"Dune" is due in 1 day` {
+		t.Errorf("Render subject = %q", subject)
+	}
+	if !strings.HasPrefix(body, "Hello Ada,") || !strings.Contains(body, "Friday, 3 May 2024") ||
+		!strings.HasSuffix(body, "City Library\n") {
+		t.Errorf("Render body = %q", body)
+	}
+
+	data.Days = 8
+	if subject, _, _ := templates.Render(types.NotifyOverdue, data); subject != `"Dune" is overdue` {
+		t.Errorf("Render overdue subject = %q", subject)
+	}
+}
+
+func TestTemplatesFallback(t *testing.T) {
+	// a template directory made before holds and sign-in links keeps working, their built-in templates are used
+	dir := t.TempDir()
+	for _, kind := range []string{types.NotifyReminder, types.NotifyDue, types.NotifyOverdue} {
+		if err := os.WriteFile(filepath.Join(dir, kind+".txt"), []byte("Subject: "+kind+"\n{{.Title}}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templates, err := LoadTemplates(types.ChannelEmail, dir)
+	if err != nil {
+		t.Fatalf("LoadTemplates returned an error: %v", err)
+	}
+
+	data := TemplateData{Library: "City Library", Name: "Ada", Title: "Dune", DueDate: "Friday, 3 May 2024"}
+	if subject, _, _ := templates.Render(types.NotifyDue, data); subject != "due" {
+		t.Errorf("Render due subject = %q, want the custom template", subject)
+	}
+	if subject, body, err := templates.Render(types.NotifyHoldAvailable, data); err != nil ||
+		subject != `"Dune" is ready for pickup` || !strings.Contains(body, "until Friday, 3 May 2024") {
+		t.Errorf("Render hold_available = %q, %q, %v", subject, body, err)
+	}
+	if _, _, err := templates.Render(KindLoginLink, data); err != nil {
+		t.Errorf("Render login_link returned an error: %v", err)
+	}
+}
+
+func TestSendLoginLink(t *testing.T) {
+	templates, _ := LoadTemplates(types.ChannelEmail, "")
+	sender := &fakeSender{}
+	scheduler := &Scheduler{library: "City Library",
+		channels: []*Channel{{Name: types.ChannelEmail, Sender: sender, Templates: templates}}}
+
+	if err := scheduler.SendLoginLink(context.Background(), "ada@example.com", "Ada", "t0k"); !errors.Is(err, ErrLoginLinksDisabled) {
+		t.Errorf("SendLoginLink without PATRON_LOGIN_URL = %v, want ErrLoginLinksDisabled", err)
+	}
+
+	scheduler.loginURL = "https://library.example/account/login?next=loans"
+	if err := scheduler.SendLoginLink(context.Background(), "ada@example.com", "Ada", "t0k"); err != nil {
+		t.Fatalf("SendLoginLink returned an error: %v", err)
+	}
+
+	if len(sender.sent) != 1 || sender.sent[0].To != "ada@example.com" || sender.sent[0].Subject != "Sign in to City Library" ||
+		!strings.Contains(sender.sent[0].Body, "https://library.example/account/login?next=loans&token=t0k\n") {
+		t.Errorf("SendLoginLink sent %+v", sender.sent)
+	}
+
+	// without email no link can reach the patron
+	scheduler.channels = nil
+	if scheduler.SendsLoginLinks() {
+		t.Error("SendsLoginLinks without email = true, want false")
+	}
+	if err := scheduler.SendLoginLink(context.Background(), "ada@example.com", "Ada", "t0k"); !errors.Is(err, ErrLoginLinksDisabled) {
+		t.Errorf("SendLoginLink without email = %v, want ErrLoginLinksDisabled", err)
+	}
+}
+
+type fakeStore struct {
+	due      []types.DueNotification
+	claimed  map[string]bool
+	finished map[string]string
+	// stale are the claims left pending by a stopped instance
+	stale []string
+}
+
+func (f *fakeStore) GetDueNotifications(channel string, kinds []string, _, _ int) (
+	[]types.DueNotification, int, types.Err) {
+	var due []types.DueNotification
+	for _, n := range f.due {
+		if n.Channel == channel && slices.Contains(kinds, n.Kind) {
+			due = append(due, n)
+		}
+	}
+	return due, 200, types.Err{}
+}
+
+func (f *fakeStore) ClaimNotification(n *types.DueNotification, _ int) (bool, int, types.Err) {
+	key := n.BookingId + n.HoldId + n.Kind + n.Channel
+	if f.claimed[key] {
+		return false, 200, types.Err{}
+	}
+	f.claimed[key] = true
+	n.Id = key
+	return true, 200, types.Err{}
+}
+
+func (f *fakeStore) FinishNotification(id *string, failure string) (int, types.Err) {
+	f.finished[*id] = failure
+	return 200, types.Err{}
+}
+
+func (f *fakeStore) ExpireNotificationClaims(lease time.Duration) (int64, int, types.Err) {
+	if lease != claimLease {
+		return 0, 500, types.Err{Error: "unexpected lease"}
+	}
+	for _, key := range f.stale {
+		delete(f.claimed, key)
+	}
+	expired := len(f.stale)
+	f.stale = nil
+	return int64(expired), 200, types.Err{}
+}
+
+type fakeSender struct {
+	sent []Message
+}
+
+func (f *fakeSender) Send(_ context.Context, msg Message) error {
+	if strings.HasSuffix(msg.To, "@unreachable.test") {
+		return errors.New("connection refused")
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func TestSchedulerRunOnce(t *testing.T) {
+	templates, _ := LoadTemplates(types.ChannelEmail, "")
+	store := &fakeStore{claimed: map[string]bool{}, finished: map[string]string{}, due: []types.DueNotification{
+		{BookingId: "1", Kind: types.NotifyDue, Channel: types.ChannelEmail, Recipient: "ada@example.com",
+			CustomerName: "Ada", BookTitle: "Dune", BookedUntil: time.Now()},
+		{BookingId: "2", Kind: types.NotifyOverdue, Channel: types.ChannelEmail, Recipient: "bob@unreachable.test",
+			CustomerName: "Bob", BookTitle: "Emma", BookedUntil: time.Now().AddDate(0, 0, -3)},
+	}}
+	sender := &fakeSender{}
+	scheduler := &Scheduler{store: store, library: "City Library", maxAttempts: 3,
+		channels: []*Channel{{Name: types.ChannelEmail, Sender: sender, Templates: templates,
+			Kinds: []string{types.NotifyReminder, types.NotifyDue, types.NotifyOverdue}}}}
+
+	sent, failed, err := scheduler.RunOnce(context.Background())
+	if err != nil || sent != 1 || failed != 1 {
+		t.Fatalf("RunOnce = %d sent, %d failed, %v", sent, failed, err)
+	}
+
+	if len(sender.sent) != 1 || sender.sent[0].Subject != `"Dune" is due today` {
+		t.Errorf("RunOnce sent %+v", sender.sent)
+	}
+	if store.finished["1dueemail"] != "" || store.finished["2overdueemail"] != "connection refused" {
+		t.Errorf("RunOnce recorded %v", store.finished)
+	}
+
+	// claimed notifications are not sent again
+	if sent, failed, _ := scheduler.RunOnce(context.Background()); sent != 0 || failed != 0 {
+		t.Errorf("a second RunOnce sent %d and failed %d", sent, failed)
+	}
+
+	// an expired claim is sent again
+	store.stale = []string{"1dueemail"}
+	if sent, failed, err := scheduler.RunOnce(context.Background()); err != nil || sent != 1 || failed != 0 {
+		t.Errorf("RunOnce after an expired claim = %d sent, %d failed, %v", sent, failed, err)
+	}
+}
+
+func TestSchedulerSMSChannel(t *testing.T) {
+	templates, err := LoadTemplates(types.ChannelSMS, "")
+	if err != nil {
+		t.Fatalf("LoadTemplates returned an error: %v", err)
+	}
+
+	store := &fakeStore{claimed: map[string]bool{}, finished: map[string]string{}, due: []types.DueNotification{
+		{BookingId: "1", Kind: types.NotifyOverdue, Channel: types.ChannelSMS, Recipient: "0812-3456-7890",
+			CustomerName: "Ada", BookTitle: "Dune", BookedUntil: time.Now().AddDate(0, 0, -2)},
+		{BookingId: "2", Kind: types.NotifyOverdue, Channel: types.ChannelSMS, Recipient: "12",
+			CustomerName: "Bob", BookTitle: "Emma", BookedUntil: time.Now().AddDate(0, 0, -2)},
+		{BookingId: "3", Kind: types.NotifyDue, Channel: types.ChannelSMS, Recipient: "+6281234567890",
+			CustomerName: "Cy", BookTitle: "Ulysses", BookedUntil: time.Now()},
+	}}
+	sender := &fakeSender{}
+	channel := &Channel{Name: types.ChannelSMS, Sender: sender, Templates: templates,
+		Kinds: []string{types.NotifyOverdue},
+		Recipient: func(recipient string) (string, error) {
+			return patronutil.NormalizePhone(recipient, "62")
+		}}
+	scheduler := &Scheduler{store: store, library: "City Library", maxAttempts: 3, channels: []*Channel{channel}}
+
+	sent, failed, err := scheduler.RunOnce(context.Background())
+	if err != nil || sent != 1 || failed != 1 {
+		t.Fatalf("RunOnce = %d sent, %d failed, %v", sent, failed, err)
+	}
+
+	want := `City Library: "Dune" is 2 days overdue. Please return it as soon as possible.`
+	if len(sender.sent) != 1 || sender.sent[0].To != "+6281234567890" || sender.sent[0].Subject != "" ||
+		strings.TrimSpace(sender.sent[0].Body) != want {
+		t.Errorf("RunOnce sent %+v", sender.sent)
+	}
+	if store.finished["2overduesms"] == "" {
+		t.Errorf("RunOnce did not record the invalid number as failed: %v", store.finished)
+	}
+
+	// nothing goes out during quiet hours
+	store.claimed = map[string]bool{}
+	channel.Quiet = &QuietHours{start: 0, end: 24 * 60, location: time.UTC}
+	if sent, failed, _ := scheduler.RunOnce(context.Background()); sent != 0 || failed != 0 {
+		t.Errorf("RunOnce during quiet hours sent %d and failed %d", sent, failed)
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	quiet, err := ParseQuietHours("21:00-08:00", "UTC")
+	if err != nil {
+		t.Fatalf("ParseQuietHours returned an error: %v", err)
+	}
+
+	for hour, want := range map[int]bool{20: false, 21: true, 23: true, 0: true, 7: true, 8: false, 12: false} {
+		if got := quiet.Contains(time.Date(2024, 5, 3, hour, 30, 0, 0, time.UTC)); got != want {
+			t.Errorf("Contains(%02d:30) = %v, want %v", hour, got, want)
+		}
+	}
+
+	quiet, _ = ParseQuietHours("12:00-13:00", "UTC")
+	if !quiet.Contains(time.Date(2024, 5, 3, 12, 0, 0, 0, time.UTC)) ||
+		quiet.Contains(time.Date(2024, 5, 3, 13, 0, 0, 0, time.UTC)) {
+		t.Error("Contains does not handle a window within a day")
+	}
+
+	if quiet, err := ParseQuietHours("", ""); quiet != nil || err != nil || quiet.Contains(time.Now()) {
+		t.Errorf("ParseQuietHours(\"\") = %v, %v", quiet, err)
+	}
+
+	for _, window := range []string{"21:00", "25:00-08:00", "08:00-08:00"} {
+		if _, err := ParseQuietHours(window, ""); err == nil {
+			t.Errorf("ParseQuietHours(%q) returned no error", window)
+		}
+	}
+	if _, err := ParseQuietHours("21:00-08:00", "Nowhere/Town"); err == nil {
+		t.Error("ParseQuietHours accepted an unknown time zone")
+	}
+}
+
+func TestWebhookSMS(t *testing.T) {
+	var got smsPayload
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil || got.To == "+10000000000" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	sender := NewWebhookSMS(server.URL, "secret")
+	if err := sender.Send(context.Background(), Message{To: "+6281234567890", Body: "Dune is due"}); err != nil {
+		t.Fatalf("Send returned an error: %v", err)
+	}
+	if got.To != "+6281234567890" || got.Text != "Dune is due" || auth != "Bearer secret" {
+		t.Errorf("the gateway received %+v with %q", got, auth)
+	}
+
+	if err := sender.Send(context.Background(), Message{To: "+10000000000", Body: "x"}); err == nil {
+		t.Error("Send ignored a failed response")
+	}
+}
+
+func TestFileSMS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sms.jsonl")
+	sender := NewFileSMS(path)
+	for _, to := range []string{"+6281234567890", "+6289876543210"} {
+		if err := sender.Send(context.Background(), Message{To: to, Body: "Dune is due"}); err != nil {
+			t.Fatalf("Send returned an error: %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read %s: %v", path, err)
+	}
+	want := "{\"to\":\"+6281234567890\",\"text\":\"Dune is due\"}\n{\"to\":\"+6289876543210\",\"text\":\"Dune is due\"}\n"
+	if string(content) != want {
+		t.Errorf("the file contains %q", content)
+	}
+}
+
+func TestCalendarDays(t *testing.T) {
+	from := time.Date(2024, 5, 3, 23, 30, 0, 0, time.UTC)
+	if days := calendarDays(from, time.Date(2024, 5, 6, 0, 15, 0, 0, time.UTC)); days != 3 {
+		t.Errorf("calendarDays = %d, want 3", days)
+	}
+}
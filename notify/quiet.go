@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidQuietHours = errors.New("invalid quiet hours, expected a window such as 21:00-08:00")
+
+// QuietHours is a daily window in which no messages are sent, it may span midnight.
+type QuietHours struct {
+	start, end int
+	location   *time.Location
+}
+
+// ParseQuietHours parses a window written as "21:00-08:00" in the given time zone.
+// Parameters:
+// - window: the window, empty for no quiet hours.
+// - timezone: an IANA time zone such as "Asia/Jakarta", empty for the local time zone of the server.
+// Returns the quiet hours, nil when window is empty, and an error if the window or the time zone is invalid.
+func ParseQuietHours(window, timezone string) (*QuietHours, error) {
+	if window == "" {
+		return nil, nil
+	}
+
+	from, to, ok := strings.Cut(window, "-")
+	if !ok {
+		return nil, ErrInvalidQuietHours
+	}
+
+	start, errStart := time.Parse("15:04", strings.TrimSpace(from))
+	end, errEnd := time.Parse("15:04", strings.TrimSpace(to))
+	if errStart != nil || errEnd != nil || start.Equal(end) {
+		return nil, ErrInvalidQuietHours
+	}
+
+	location := time.Local
+	if timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	return &QuietHours{
+		start:    start.Hour()*60 + start.Minute(),
+		end:      end.Hour()*60 + end.Minute(),
+		location: location,
+	}, nil
+}
+
+// Contains reports whether t falls within the quiet hours, always false for nil quiet hours.
+func (q *QuietHours) Contains(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+
+	t = t.In(q.location)
+	minute := t.Hour()*60 + t.Minute()
+	if q.start < q.end {
+		return minute >= q.start && minute < q.end
+	}
+
+	return minute >= q.start || minute < q.end
+}
@@ -0,0 +1,319 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/patronutil"
+	"github.com/Tus1688/library-management-api/types"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Store is the part of the storage the scheduler needs.
+type Store interface {
+	GetDueNotifications(channel string, kinds []string, reminderDays, maxAttempts int) (
+		[]types.DueNotification, int, types.Err)
+	ClaimNotification(notification *types.DueNotification, maxAttempts int) (bool, int, types.Err)
+	FinishNotification(id *string, failure string) (int, types.Err)
+	ExpireNotificationClaims(lease time.Duration) (int64, int, types.Err)
+}
+
+// claimLease is how long sending a claimed message may take, well over the timeouts of the senders.
+// A message still pending after that was left behind by an instance that stopped halfway.
+const claimLease = 15 * time.Minute
+
+// Channel is one way of delivering notifications, with its own templates and the kinds it is used for.
+type Channel struct {
+	Name      string
+	Sender    Sender
+	Templates *Templates
+	Kinds     []string
+	// Quiet holds back every message of the channel during its window, nil to send at any time.
+	Quiet *QuietHours
+	// Recipient normalizes the recipient before it is claimed, nil to use it as stored.
+	Recipient func(recipient string) (string, error)
+}
+
+// Scheduler sends the due date reminders and overdue notices of open bookings, and tells patrons when a held book is
+// ready, on every channel, each time RunOnce is called by the notifications job.
+// Every message is claimed in the store before it is sent, so restarts and parallel instances never send it twice.
+type Scheduler struct {
+	store        Store
+	channels     []*Channel
+	library      string
+	reminderDays int
+	maxAttempts  int
+	// loginURL is where the sign-in links of the patron portal point to, empty when they are not sent.
+	loginURL string
+}
+
+// ErrLoginLinksDisabled is returned by SendLoginLink when PATRON_LOGIN_URL is not set or email is disabled.
+var ErrLoginLinksDisabled = errors.New("sign-in links are not enabled")
+
+// NewScheduler creates a scheduler configured by the environment:
+// NOTIFY_REMINDER_DAYS (2 by default, 0 disables reminders), NOTIFY_MAX_ATTEMPTS (3 by default), NOTIFY_TEMPLATE_DIR to replace the built-in templates
+// and LIBRARY_NAME to sign the messages. PATRON_LOGIN_URL is the page of the patron portal that signs in with the token
+// of a sign-in link, passed as the token query parameter; without it no sign-in links are sent.
+// Email is used for every kind of notification, unless it is disabled. SMS is used for the kinds in SMS_KINDS (overdue by default,
+// comma separated), outside of SMS_QUIET_HOURS (such as 21:00-08:00 in SMS_TIMEZONE), with SMS_TEMPLATE_DIR
+// to replace its templates and SMS_DEFAULT_COUNTRY_CODE (such as 62) for phone numbers stored without one.
+// Parameters:
+// - store: where due notifications are found and their delivery is recorded.
+// - email: how emails are delivered, nil to send none.
+// - sms: how SMS are delivered, nil to send none.
+// Returns the scheduler and an error if a setting is invalid or the templates cannot be loaded.
+func NewScheduler(store Store, email, sms Sender) (*Scheduler, error) {
+	templates, err := LoadTemplates(types.ChannelEmail, os.Getenv("NOTIFY_TEMPLATE_DIR"))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Scheduler{
+		store:        store,
+		library:      os.Getenv("LIBRARY_NAME"),
+		reminderDays: 2,
+		maxAttempts:  3,
+		loginURL:     os.Getenv("PATRON_LOGIN_URL"),
+	}
+	if s.library == "" {
+		s.library = "Your library"
+	}
+
+	if raw := os.Getenv("NOTIFY_REMINDER_DAYS"); raw != "" {
+		if s.reminderDays, err = strconv.Atoi(raw); err != nil || s.reminderDays < 0 {
+			return nil, errors.New("invalid NOTIFY_REMINDER_DAYS")
+		}
+	}
+
+	if raw := os.Getenv("NOTIFY_MAX_ATTEMPTS"); raw != "" {
+		if s.maxAttempts, err = strconv.Atoi(raw); err != nil || s.maxAttempts < 1 {
+			return nil, errors.New("invalid NOTIFY_MAX_ATTEMPTS")
+		}
+	}
+
+	if s.loginURL != "" {
+		if link, err := url.Parse(s.loginURL); err != nil || !link.IsAbs() {
+			return nil, errors.New("invalid PATRON_LOGIN_URL")
+		}
+	}
+
+	if email != nil {
+		s.channels = append(s.channels, &Channel{
+			Name:      types.ChannelEmail,
+			Sender:    email,
+			Templates: templates,
+			Kinds:     []string{types.NotifyReminder, types.NotifyDue, types.NotifyOverdue, types.NotifyHoldAvailable},
+		})
+	}
+
+	if sms != nil {
+		channel, err := newSMSChannel(sms)
+		if err != nil {
+			return nil, err
+		}
+		s.channels = append(s.channels, channel)
+	}
+
+	return s, nil
+}
+
+// newSMSChannel creates the SMS channel from the SMS_* environment variables described at NewScheduler.
+func newSMSChannel(sender Sender) (*Channel, error) {
+	templates, err := LoadTemplates(types.ChannelSMS, os.Getenv("SMS_TEMPLATE_DIR"))
+	if err != nil {
+		return nil, err
+	}
+
+	quiet, err := ParseQuietHours(os.Getenv("SMS_QUIET_HOURS"), os.Getenv("SMS_TIMEZONE"))
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := []string{types.NotifyOverdue}
+	if raw := os.Getenv("SMS_KINDS"); raw != "" {
+		kinds = nil
+		for _, kind := range strings.Split(raw, ",") {
+			kind = strings.TrimSpace(kind)
+			if kind != types.NotifyReminder && kind != types.NotifyDue && kind != types.NotifyOverdue &&
+				kind != types.NotifyHoldAvailable {
+				return nil, errors.New("invalid SMS_KINDS")
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+
+	countryCode := os.Getenv("SMS_DEFAULT_COUNTRY_CODE")
+	return &Channel{
+		Name:      types.ChannelSMS,
+		Sender:    sender,
+		Templates: templates,
+		Kinds:     kinds,
+		Quiet:     quiet,
+		Recipient: func(recipient string) (string, error) {
+			return patronutil.NormalizePhone(recipient, countryCode)
+		},
+	}, nil
+}
+
+// Sends reports whether any channel is enabled, so the notifications job has a way to deliver its messages.
+func (s *Scheduler) Sends() bool {
+	return len(s.channels) > 0
+}
+
+// RunOnce sends every notification that is due now on every channel outside of its quiet hours.
+// A message that cannot be rendered or delivered is recorded as failed and tried again on a later run,
+// as is a message whose claim expired before it was finished.
+// Returns the number of messages sent and failed, and an error if the store cannot be queried.
+func (s *Scheduler) RunOnce(ctx context.Context) (int, int, error) {
+	if _, _, errResp := s.store.ExpireNotificationClaims(claimLease); errResp.Error != "" {
+		return 0, 0, errors.New(errResp.Error)
+	}
+
+	sent, failed := 0, 0
+	for _, channel := range s.channels {
+		if channel.Quiet.Contains(time.Now()) {
+			continue
+		}
+
+		channelSent, channelFailed, err := s.runChannel(ctx, channel)
+		sent += channelSent
+		failed += channelFailed
+		if err != nil {
+			return sent, failed, err
+		}
+	}
+
+	return sent, failed, nil
+}
+
+// runChannel sends the notifications that are due now on one channel.
+func (s *Scheduler) runChannel(ctx context.Context, channel *Channel) (int, int, error) {
+	due, _, errResp := s.store.GetDueNotifications(channel.Name, channel.Kinds, s.reminderDays, s.maxAttempts)
+	if errResp.Error != "" {
+		return 0, 0, errors.New(errResp.Error)
+	}
+
+	sent, failed := 0, 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+
+		// an invalid recipient is still claimed so the failure shows up in the notification list
+		notification := &due[i]
+		var invalid error
+		if channel.Recipient != nil {
+			recipient, err := channel.Recipient(notification.Recipient)
+			if err != nil {
+				invalid = err
+			} else {
+				notification.Recipient = recipient
+			}
+		}
+
+		claimed, _, errResp := s.store.ClaimNotification(notification, s.maxAttempts)
+		if errResp.Error != "" {
+			return sent, failed, errors.New(errResp.Error)
+		}
+		if !claimed {
+			continue
+		}
+
+		failure := ""
+		if invalid != nil {
+			failure = invalid.Error()
+			failed++
+		} else if err := s.send(ctx, channel, notification); err != nil {
+			failure = err.Error()
+			failed++
+		} else {
+			sent++
+		}
+
+		if _, errResp := s.store.FinishNotification(&notification.Id, failure); errResp.Error != "" {
+			return sent, failed, errors.New(errResp.Error)
+		}
+	}
+
+	return sent, failed, nil
+}
+
+// send renders and delivers a single notification on a channel.
+func (s *Scheduler) send(ctx context.Context, channel *Channel, notification *types.DueNotification) error {
+	data := TemplateData{
+		Library: s.library,
+		Name:    notification.CustomerName,
+		Title:   notification.BookTitle,
+		DueDate: notification.BookedUntil.Format("Monday, 2 January 2006"),
+	}
+
+	days := calendarDays(time.Now(), notification.BookedUntil)
+	if notification.Kind == types.NotifyOverdue {
+		days = -days
+	}
+	data.Days = days
+
+	subject, body, err := channel.Templates.Render(notification.Kind, data)
+	if err != nil {
+		return err
+	}
+
+	return channel.Sender.Send(ctx, Message{To: notification.Recipient, Subject: subject, Body: body})
+}
+
+// SendsLoginLinks reports whether PATRON_LOGIN_URL is set and email is enabled, so patrons can sign in with a link.
+func (s *Scheduler) SendsLoginLinks() bool {
+	return s.loginURL != "" && s.emailChannel() != nil
+}
+
+// emailChannel returns the email channel, nil when email is disabled.
+func (s *Scheduler) emailChannel() *Channel {
+	for _, channel := range s.channels {
+		if channel.Name == types.ChannelEmail {
+			return channel
+		}
+	}
+	return nil
+}
+
+// SendLoginLink emails a patron a link that signs in to the patron portal.
+// It is sent right away, outside of the notifications job, since the patron is waiting for it.
+// Parameters:
+// - ctx: the context the email is sent in.
+// - to: the email address of the patron.
+// - name: the name of the patron.
+// - token: the single-use token the link carries.
+// Returns ErrLoginLinksDisabled when PATRON_LOGIN_URL is not set or email is disabled,
+// or an error if the email cannot be sent.
+func (s *Scheduler) SendLoginLink(ctx context.Context, to, name, token string) error {
+	channel := s.emailChannel()
+	if s.loginURL == "" || channel == nil {
+		return ErrLoginLinksDisabled
+	}
+
+	link, err := url.Parse(s.loginURL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	subject, body, err := channel.Templates.Render(KindLoginLink,
+		TemplateData{Library: s.library, Name: name, Link: link.String()})
+	if err != nil {
+		return err
+	}
+
+	return channel.Sender.Send(ctx, Message{To: to, Subject: subject, Body: body})
+}
+
+// calendarDays returns the number of calendar days from one date to another, ignoring the time of day.
+func calendarDays(from, to time.Time) int {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
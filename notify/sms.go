@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/goccy/go-json"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// NewSMSSender creates the SMS provider selected by the SMS_PROVIDER environment variable:
+// "webhook" posts every message to SMS_WEBHOOK_URL, with SMS_WEBHOOK_TOKEN as bearer token if set,
+// "file" appends every message to SMS_FILE (sms.jsonl by default) and "log" writes them to the log.
+// SMS providers implement Sender, the subject of a message is not used.
+// Returns the provider, nil when SMS_PROVIDER is empty as the channel is then disabled,
+// and an error if the provider is unknown or not configured.
+func NewSMSSender() (Sender, error) {
+	switch os.Getenv("SMS_PROVIDER") {
+	case "":
+		return nil, nil
+	case "webhook":
+		url := os.Getenv("SMS_WEBHOOK_URL")
+		if url == "" {
+			return nil, errors.New("SMS_WEBHOOK_URL is not set")
+		}
+		return NewWebhookSMS(url, os.Getenv("SMS_WEBHOOK_TOKEN")), nil
+	case "file":
+		path := os.Getenv("SMS_FILE")
+		if path == "" {
+			path = "sms.jsonl"
+		}
+		return NewFileSMS(path), nil
+	case "log":
+		return LogSender{}, nil
+	}
+
+	return nil, errors.New("unknown sms provider " + os.Getenv("SMS_PROVIDER"))
+}
+
+// smsPayload is what the webhook and file providers write for every message.
+type smsPayload struct {
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+// WebhookSMS hands messages to any SMS gateway that accepts a JSON POST, such as a small relay in front of
+// a commercial provider. Any 2xx response counts as accepted.
+type WebhookSMS struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewWebhookSMS creates a provider posting {"to": "+6281234567890", "text": "..."} to url.
+// Parameters:
+// - url: the endpoint of the gateway.
+// - token: sent as a bearer token, empty to send none.
+func NewWebhookSMS(url, token string) *WebhookSMS {
+	return &WebhookSMS{url: url, token: token, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (s *WebhookSMS) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(smsPayload{To: msg.To, Text: msg.Body})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("sms gateway responded with " + strconv.Itoa(resp.StatusCode))
+	}
+
+	return nil
+}
+
+// FileSMS appends every message as a JSON line to a file, to check messages locally without a gateway.
+type FileSMS struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileSMS creates a provider appending to the file at path, which is created if needed.
+func NewFileSMS(path string) *FileSMS {
+	return &FileSMS{path: path}
+}
+
+func (s *FileSMS) Send(_ context.Context, msg Message) error {
+	line, err := json.Marshal(smsPayload{To: msg.To, Text: msg.Body})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// smtpTimeout bounds a whole SMTP exchange, a hanging server must not block the scheduler.
+const smtpTimeout = time.Minute
+
+// SMTPSender delivers messages through an SMTP server, upgrading the connection with STARTTLS when offered.
+type SMTPSender struct {
+	addr string
+	host string
+	from mail.Address
+	auth smtp.Auth
+}
+
+// NewSMTP creates a sender for the SMTP server at host:port.
+// Parameters:
+// - host, port: the address of the server.
+// - username, password: the credentials, no authentication is attempted when username is empty.
+// - from: the sender address, such as "Library <library@example.com>".
+// Returns the sender and an error if the from address is invalid.
+func NewSMTP(host string, port int, username, password, from string) (*SMTPSender, error) {
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, errors.New("invalid SMTP_FROM address")
+	}
+
+	s := &SMTPSender{addr: net.JoinHostPort(host, strconv.Itoa(port)), host: host, from: *address}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return s, nil
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	data, err := s.compose(msg)
+	if err != nil {
+		return err
+	}
+
+	dialer := net.Dialer{Timeout: smtpTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+
+	if s.auth != nil {
+		if err := client.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// compose builds a plain text email, the body is quoted-printable so any UTF-8 text survives the transfer.
+func (s *SMTPSender) compose(msg Message) ([]byte, error) {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("From: " + s.from.String() + "\r\n")
+	buf.WriteString("To: " + to.String() + "\r\n")
+	buf.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("Message-ID: <" + hex.EncodeToString(id) + "@" + s.host + ">\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"io/fs"
+	"os"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates
+var defaultTemplates embed.FS
+
+// KindLoginLink is the template of the sign-in link emailed to patrons, it is not a notification kind of the scheduler.
+const KindLoginLink = "login_link"
+
+// TemplateData is what a notification template can refer to.
+type TemplateData struct {
+	Library string
+	Name    string
+	Title   string
+	DueDate string
+	// Days is the number of days left before the due date for a reminder, or the days overdue for an overdue notice.
+	// For a hold that is ready, DueDate and Days tell until when the book is kept.
+	Days int
+	// Link is the sign-in link of a login_link email.
+	Link string
+}
+
+// Templates renders the messages of every notification kind for one channel.
+// An email template starts with a "Subject: " line, the rest of it is the body. An SMS template is only a body.
+type Templates struct {
+	set *template.Template
+}
+
+// LoadTemplates parses the reminder.txt, due.txt and overdue.txt templates of a channel from dir,
+// or the built-in ones when dir is empty.
+// The hold_available.txt and login_link.txt templates are optional in dir, the built-in ones are used when they are missing.
+// Parameters:
+// - channel: types.ChannelEmail or types.ChannelSMS, selects the built-in templates.
+// - dir: the directory holding the templates, empty for the defaults.
+// Returns the templates and an error if one is missing or cannot be parsed.
+func LoadTemplates(channel, dir string) (*Templates, error) {
+	var set *template.Template
+	var err error
+	if dir == "" {
+		set, err = template.ParseFS(defaultTemplates, "templates/"+channel+"/*.txt")
+	} else {
+		set, err = template.ParseFS(os.DirFS(dir), "*.txt")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, kind := range []string{types.NotifyReminder, types.NotifyDue, types.NotifyOverdue} {
+		if set.Lookup(kind+".txt") == nil {
+			return nil, errors.New("missing template " + kind + ".txt")
+		}
+	}
+
+	// added after custom template directories were supported, so those keep working without them
+	for _, kind := range []string{types.NotifyHoldAvailable, KindLoginLink} {
+		name := "templates/" + channel + "/" + kind + ".txt"
+		if set.Lookup(kind+".txt") != nil {
+			continue
+		}
+		if _, err := fs.Stat(defaultTemplates, name); err != nil {
+			continue
+		}
+		if set, err = set.ParseFS(defaultTemplates, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Templates{set: set}, nil
+}
+
+// Render renders the message of a notification kind.
+// Parameters:
+// - kind: one of types.NotifyReminder, types.NotifyDue, types.NotifyOverdue, types.NotifyHoldAvailable or KindLoginLink.
+// - data: the values the template refers to.
+// Returns the subject, empty if the template has none, the body, and an error if the template fails.
+func (t *Templates) Render(kind string, data TemplateData) (string, string, error) {
+	var buf bytes.Buffer
+	if err := t.set.ExecuteTemplate(&buf, kind+".txt", data); err != nil {
+		return "", "", err
+	}
+
+	head, body, _ := strings.Cut(buf.String(), "\n")
+	subject, ok := strings.CutPrefix(head, "Subject: ")
+	if !ok {
+		return "", buf.String(), nil
+	}
+
+	return strings.TrimSpace(subject), strings.TrimLeft(body, "\n"), nil
+}
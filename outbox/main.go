@@ -0,0 +1,95 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+)
+
+// batchSize is how many events are read at once.
+const batchSize = 100
+
+// Sink publishes events somewhere outside of the database.
+// Publish may see an event again after a failure or a replay, sinks that cannot tell must be idempotent.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event *types.Event) error
+}
+
+// Store is the part of the storage the relay needs.
+type Store interface {
+	GetOutboxCursor(sink string) (int64, int, types.Err)
+	UpdateOutboxCursor(sink string, position int64, failure string) (int, types.Err)
+	GetOutboxEvents(after int64, limit int) ([]types.Event, int, types.Err)
+	SequenceOutbox() (int64, int, types.Err)
+}
+
+// Relay publishes the events of the outbox to every sink in order.
+// Every sink has a cursor of its own, a sink that fails stops at the failing event and picks up from there
+// on the next run, without holding up the other sinks. Delivery is at least once: a crash between publishing
+// an event and moving the cursor publishes the event again.
+type Relay struct {
+	store Store
+	sinks []Sink
+}
+
+// NewRelay creates a relay publishing to the given sinks.
+func NewRelay(store Store, sinks ...Sink) *Relay {
+	return &Relay{store: store, sinks: sinks}
+}
+
+// RunOnce numbers the committed events and publishes the pending events to every sink.
+// Returns the number of events published over all sinks, and an error if the store cannot be queried
+// or a sink failed.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	if _, _, errResp := r.store.SequenceOutbox(); errResp.Error != "" {
+		return 0, errors.New(errResp.Error)
+	}
+
+	published := 0
+	var failures []error
+	for _, sink := range r.sinks {
+		count, err := r.relay(ctx, sink)
+		published += count
+		if err != nil {
+			failures = append(failures, errors.New(sink.Name()+": "+err.Error()))
+		}
+	}
+
+	return published, errors.Join(failures...)
+}
+
+// relay publishes the pending events to one sink.
+func (r *Relay) relay(ctx context.Context, sink Sink) (int, error) {
+	position, _, errResp := r.store.GetOutboxCursor(sink.Name())
+	if errResp.Error != "" {
+		return 0, errors.New(errResp.Error)
+	}
+
+	published := 0
+	for ctx.Err() == nil {
+		events, _, errResp := r.store.GetOutboxEvents(position, batchSize)
+		if errResp.Error != "" {
+			return published, errors.New(errResp.Error)
+		}
+		if len(events) == 0 {
+			return published, nil
+		}
+
+		for i := range events {
+			if err := sink.Publish(ctx, &events[i]); err != nil {
+				// the cursor stays before the failed event, so the order is kept
+				_, _ = r.store.UpdateOutboxCursor(sink.Name(), position, err.Error())
+				return published, err
+			}
+			position = events[i].Position
+			published++
+		}
+
+		if _, errResp := r.store.UpdateOutboxCursor(sink.Name(), position, ""); errResp.Error != "" {
+			return published, errors.New(errResp.Error)
+		}
+	}
+
+	return published, nil
+}
@@ -0,0 +1,164 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"slices"
+	"testing"
+)
+
+type fakeStore struct {
+	events   []types.Event
+	pending  int
+	cursors  map[string]int64
+	failures map[string]string
+}
+
+func newFakeStore(count int) *fakeStore {
+	store := &fakeStore{cursors: map[string]int64{}, failures: map[string]string{}}
+	for i := 1; i <= count; i++ {
+		store.events = append(store.events, types.Event{Position: int64(i), Type: types.EventBookCreated})
+	}
+	return store
+}
+
+func (f *fakeStore) GetOutboxCursor(sink string) (int64, int, types.Err) {
+	return f.cursors[sink], 200, types.Err{}
+}
+
+func (f *fakeStore) UpdateOutboxCursor(sink string, position int64, failure string) (int, types.Err) {
+	f.cursors[sink], f.failures[sink] = position, failure
+	return 200, types.Err{}
+}
+
+func (f *fakeStore) SequenceOutbox() (int64, int, types.Err) {
+	sequenced := f.pending
+	for ; f.pending > 0; f.pending-- {
+		f.events = append(f.events, types.Event{Position: int64(len(f.events) + 1), Type: types.EventBookCreated})
+	}
+	return int64(sequenced), 200, types.Err{}
+}
+
+func (f *fakeStore) GetOutboxEvents(after int64, limit int) ([]types.Event, int, types.Err) {
+	var events []types.Event
+	for _, event := range f.events {
+		if event.Position > after && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, 200, types.Err{}
+}
+
+type fakeSink struct {
+	name      string
+	failAt    int64
+	published []int64
+}
+
+func (f *fakeSink) Name() string {
+	return f.name
+}
+
+func (f *fakeSink) Publish(_ context.Context, event *types.Event) error {
+	if event.Position == f.failAt {
+		return errors.New("sink is down")
+	}
+	f.published = append(f.published, event.Position)
+	return nil
+}
+
+func TestRelayPublishesInOrder(t *testing.T) {
+	store := newFakeStore(250)
+	sink := &fakeSink{name: "test"}
+	store.cursors["test"] = 40
+
+	published, err := NewRelay(store, sink).RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce returned an error: %v", err)
+	}
+	if published != 210 || len(sink.published) != 210 || sink.published[0] != 41 ||
+		!slices.IsSorted(sink.published) {
+		t.Errorf("RunOnce published %d events, sink saw %d", published, len(sink.published))
+	}
+	if store.cursors["test"] != 250 {
+		t.Errorf("cursor = %d, want 250", store.cursors["test"])
+	}
+
+	published, err = NewRelay(store, sink).RunOnce(context.Background())
+	if err != nil || published != 0 {
+		t.Errorf("second RunOnce = %d, %v, want nothing to publish", published, err)
+	}
+}
+
+func TestRelayFailureKeepsCursor(t *testing.T) {
+	store := newFakeStore(10)
+	failing := &fakeSink{name: "failing", failAt: 4}
+	healthy := &fakeSink{name: "healthy"}
+	relay := NewRelay(store, failing, healthy)
+
+	published, err := relay.RunOnce(context.Background())
+	if err == nil {
+		t.Fatal("RunOnce returned no error for a failing sink")
+	}
+	if published != 13 {
+		t.Errorf("RunOnce published %d events, want 13", published)
+	}
+	if store.cursors["failing"] != 3 || store.failures["failing"] != "sink is down" {
+		t.Errorf("failing cursor = %d %q, want 3 with the error", store.cursors["failing"], store.failures["failing"])
+	}
+	if store.cursors["healthy"] != 10 || store.failures["healthy"] != "" {
+		t.Errorf("healthy cursor = %d %q, want 10", store.cursors["healthy"], store.failures["healthy"])
+	}
+
+	failing.failAt = 0
+	if _, err := relay.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce returned an error after recovery: %v", err)
+	}
+	if !slices.Equal(failing.published, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}) || store.failures["failing"] != "" {
+		t.Errorf("failing sink published %v after recovery", failing.published)
+	}
+}
+
+func TestRelaySequencesCommittedEvents(t *testing.T) {
+	store := newFakeStore(5)
+	store.pending = 3
+	sink := &fakeSink{name: "test"}
+
+	published, err := NewRelay(store, sink).RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce returned an error: %v", err)
+	}
+	if published != 8 || store.pending != 0 || store.cursors["test"] != 8 {
+		t.Errorf("RunOnce published %d events with %d pending, cursor = %d, want 8 with none pending",
+			published, store.pending, store.cursors["test"])
+	}
+}
+
+func TestNewSinks(t *testing.T) {
+	t.Setenv("OUTBOX_SINKS", "")
+	sinks, err := NewSinks(nil, nil)
+	if err != nil || len(sinks) != 2 || sinks[0].Name() != "webhook" || sinks[1].Name() != "live" {
+		t.Errorf("NewSinks default = %v, %v", sinks, err)
+	}
+
+	t.Setenv("OUTBOX_SINKS", "webhook, stream,log")
+	t.Setenv("OUTBOX_STREAM_MAXLEN", "500")
+	sinks, err = NewSinks(nil, nil)
+	if err != nil || len(sinks) != 3 {
+		t.Fatalf("NewSinks = %v, %v", sinks, err)
+	}
+	if stream, ok := sinks[1].(*StreamSink); !ok || stream.stream != "library:events" || stream.maxLen != 500 {
+		t.Errorf("stream sink = %+v", sinks[1])
+	}
+
+	t.Setenv("OUTBOX_STREAM_MAXLEN", "lots")
+	if _, err := NewSinks(nil, nil); err == nil {
+		t.Error("NewSinks accepted an invalid OUTBOX_STREAM_MAXLEN")
+	}
+
+	t.Setenv("OUTBOX_SINKS", "kafka")
+	if _, err := NewSinks(nil, nil); err == nil {
+		t.Error("NewSinks accepted an unknown sink")
+	}
+}
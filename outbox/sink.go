@@ -0,0 +1,145 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/live"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/goccy/go-json"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// WebhookStore queues events for the webhook subscriptions, it is implemented by storage.PostgresStore.
+type WebhookStore interface {
+	EnqueueEvent(event *types.Event) (int64, int, types.Err)
+}
+
+// StreamStore appends to Redis streams and publishes to Redis channels, it is implemented by cache.RedisStore.
+type StreamStore interface {
+	AppendStream(stream *string, values map[string]interface{}, maxLen int64) types.Err
+	Publish(channel *string, message []byte) types.Err
+}
+
+// NewSinks creates the sinks named in OUTBOX_SINKS, comma separated, "webhook,live" by default:
+// "webhook" queues the events for the webhook subscriptions, "live" publishes them to the live streams of every replica,
+// "stream" appends them to the Redis stream OUTBOX_STREAM (library:events by default, trimmed to about
+// OUTBOX_STREAM_MAXLEN entries, 100000 by default) and "log" writes them to the log.
+// Returns the sinks and an error if a sink is unknown or a setting is invalid.
+func NewSinks(webhooks WebhookStore, streams StreamStore) ([]Sink, error) {
+	names := os.Getenv("OUTBOX_SINKS")
+	if names == "" {
+		names = "webhook,live"
+	}
+
+	var sinks []Sink
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "webhook":
+			sinks = append(sinks, &WebhookSink{store: webhooks})
+		case "live":
+			sinks = append(sinks, &LiveSink{store: streams, channel: live.Channel})
+		case "stream":
+			sink := &StreamSink{store: streams, stream: os.Getenv("OUTBOX_STREAM"), maxLen: 100000}
+			if sink.stream == "" {
+				sink.stream = "library:events"
+			}
+			if raw := os.Getenv("OUTBOX_STREAM_MAXLEN"); raw != "" {
+				var err error
+				if sink.maxLen, err = strconv.ParseInt(raw, 10, 64); err != nil || sink.maxLen < 1 {
+					return nil, errors.New("invalid OUTBOX_STREAM_MAXLEN")
+				}
+			}
+			sinks = append(sinks, sink)
+		case "log":
+			sinks = append(sinks, LogSink{})
+		default:
+			return nil, errors.New("unknown outbox sink " + name)
+		}
+	}
+
+	return sinks, nil
+}
+
+// WebhookSink queues every event for the webhooks subscribed to its type, deliveries are made by webhook.Deliverer.
+type WebhookSink struct {
+	store WebhookStore
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Publish(_ context.Context, event *types.Event) error {
+	if _, _, errResp := s.store.EnqueueEvent(event); errResp.Error != "" {
+		return errors.New(errResp.Error)
+	}
+
+	return nil
+}
+
+// StreamSink appends every event to a Redis stream, consumers read it with XREAD or a consumer group.
+// An entry has the fields id, type, position, created_at and data, a replayed event is appended again
+// with the same id and position.
+type StreamSink struct {
+	store  StreamStore
+	stream string
+	maxLen int64
+}
+
+func (s *StreamSink) Name() string {
+	return "stream"
+}
+
+func (s *StreamSink) Publish(_ context.Context, event *types.Event) error {
+	values := map[string]interface{}{
+		"id":         event.Id,
+		"type":       event.Type,
+		"position":   event.Position,
+		"created_at": event.CreatedAt,
+		"data":       string(event.Data),
+	}
+	if errResp := s.store.AppendStream(&s.stream, values, s.maxLen); errResp.Error != "" {
+		return errors.New(errResp.Error)
+	}
+
+	return nil
+}
+
+// LiveSink publishes every event to the Redis channel the live.Broker of every replica subscribes to.
+// Nobody keeps what is published, clients that were not connected catch up from the outbox.
+type LiveSink struct {
+	store   StreamStore
+	channel string
+}
+
+func (s *LiveSink) Name() string {
+	return "live"
+}
+
+func (s *LiveSink) Publish(_ context.Context, event *types.Event) error {
+	message, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if errResp := s.store.Publish(&s.channel, message); errResp.Error != "" {
+		return errors.New(errResp.Error)
+	}
+
+	return nil
+}
+
+// LogSink writes every event to the log, to follow the events locally.
+type LogSink struct{}
+
+func (LogSink) Name() string {
+	return "log"
+}
+
+func (LogSink) Publish(_ context.Context, event *types.Event) error {
+	log.Printf("event %d %s %s: %s", event.Position, event.Type, event.Id, event.Data)
+	return nil
+}
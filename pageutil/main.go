@@ -0,0 +1,95 @@
+package pageutil
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultLimit is the page size used when the client does not ask for one.
+	DefaultLimit = 20
+	// MaxLimit is the largest page size a client is allowed to request.
+	MaxLimit = 100
+
+	cursorPrefix = "p:"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+var ErrInvalidLimit = errors.New("invalid limit")
+
+// ParseLimit converts the raw "limit" query parameter into a page size.
+// An empty value yields DefaultLimit and values above MaxLimit are clamped.
+// Returns ErrInvalidLimit if the value is not a positive integer.
+func ParseLimit(raw string) (int, error) {
+	if raw == "" {
+		return DefaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		return 0, ErrInvalidLimit
+	}
+
+	if limit > MaxLimit {
+		return MaxLimit, nil
+	}
+
+	return limit, nil
+}
+
+// EncodeCursor turns a pagination id into an opaque cursor string.
+func EncodeCursor(paginationId int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatInt(paginationId, 10)))
+}
+
+// DecodeCursor turns an opaque cursor back into a pagination id.
+// An empty cursor yields 0, meaning "start from the first page".
+// Returns ErrInvalidCursor if the cursor was not produced by EncodeCursor.
+func DecodeCursor(raw string) (int64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	value, found := strings.CutPrefix(string(decoded), cursorPrefix)
+	if !found {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return id, nil
+}
+
+// NewPage builds a page envelope out of rows fetched with LIMIT limit+1.
+// The extra row only signals that another page exists and is trimmed off.
+// Parameters:
+// - items: the fetched rows, at most limit+1 of them.
+// - limit: the page size requested by the client.
+// - total: the estimated number of rows matching the query.
+// - paginationId: returns the pagination id of a row, used for the next cursor.
+// Returns the page envelope, never with a nil Items slice.
+func NewPage[T any](items []T, limit int, total int64, paginationId func(T) int64) types.Page[T] {
+	page := types.Page[T]{Items: items, TotalEstimate: total}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.HasMore = true
+		page.NextCursor = EncodeCursor(paginationId(page.Items[limit-1]))
+	}
+
+	return page
+}
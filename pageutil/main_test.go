@@ -0,0 +1,58 @@
+package pageutil
+
+import "testing"
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := EncodeCursor(4213)
+
+	id, err := DecodeCursor(cursor)
+	if err != nil {
+		t.Fatalf("DecodeCursor returned an error: %v", err)
+	}
+
+	if id != 4213 {
+		t.Errorf("DecodeCursor decoded the wrong value: got %d, want %d", id, 4213)
+	}
+}
+
+func TestDecodeCursorFaulty(t *testing.T) {
+	for _, raw := range []string{"4213", "%%%", EncodeCursor(0)} {
+		if _, err := DecodeCursor(raw); err == nil {
+			t.Errorf("DecodeCursor accepted %q", raw)
+		}
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	cases := map[string]int{"": DefaultLimit, "5": 5, "100000": MaxLimit}
+	for raw, want := range cases {
+		got, err := ParseLimit(raw)
+		if err != nil {
+			t.Errorf("ParseLimit(%q) returned an error: %v", raw, err)
+		}
+		if got != want {
+			t.Errorf("ParseLimit(%q) = %d, want %d", raw, got, want)
+		}
+	}
+
+	if _, err := ParseLimit("-1"); err == nil {
+		t.Errorf("ParseLimit accepted a negative limit")
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	page := NewPage([]int64{9, 8, 7}, 2, 3, func(v int64) int64 { return v })
+	if !page.HasMore || len(page.Items) != 2 {
+		t.Fatalf("NewPage did not trim the look-ahead row: %+v", page)
+	}
+
+	id, _ := DecodeCursor(page.NextCursor)
+	if id != 8 {
+		t.Errorf("NewPage produced the wrong cursor: got %d, want %d", id, 8)
+	}
+
+	empty := NewPage[int64](nil, 2, 0, func(v int64) int64 { return v })
+	if empty.Items == nil || empty.HasMore {
+		t.Errorf("NewPage should return an empty, final page: %+v", empty)
+	}
+}
@@ -0,0 +1,213 @@
+package patronutil
+
+import (
+	"errors"
+	"github.com/Tus1688/library-management-api/bookutil"
+	"github.com/Tus1688/library-management-api/types"
+	"net/mail"
+	"strings"
+)
+
+var (
+	ErrInvalidCardNumber = errors.New("invalid card number, expected up to 32 printable ASCII characters")
+	ErrMissingName       = errors.New("patron name is required")
+	ErrMissingPhone      = errors.New("patron phone is required")
+	ErrInvalidPin        = errors.New("invalid pin, expected 4 to 8 digits")
+	ErrInvalidEmail      = errors.New("invalid email address")
+	ErrInvalidPhone      = errors.New("invalid phone number, expected an international number such as +6281234567890")
+	ErrEmptyScan         = errors.New("scan at least one barcode")
+	ErrTooManyScans      = errors.New("too many barcodes in one scan")
+)
+
+// MaxScanItems bounds a batch scan, so one request cannot hold row locks on a large part of the catalog.
+const MaxScanItems = 50
+
+// NormalizeCardNumber validates a library card number and uppercases it.
+// Cards are printed with the same Code 128 labels as items, so the same rules apply.
+// Parameters:
+// - raw: the card number as scanned or entered.
+// Returns the normalized card number and ErrInvalidCardNumber if it cannot be printed on a label.
+func NormalizeCardNumber(raw string) (string, error) {
+	cardNumber, err := bookutil.NormalizeBarcode(raw)
+	if err != nil {
+		return "", ErrInvalidCardNumber
+	}
+
+	return cardNumber, nil
+}
+
+// ValidatePin checks that a PIN is 4 to 8 digits, short enough to type on a kiosk keypad.
+// Parameters:
+// - pin: the PIN as entered.
+// Returns ErrInvalidPin if the PIN has another length or holds anything but digits.
+func ValidatePin(pin string) error {
+	if len(pin) < 4 || len(pin) > 8 {
+		return ErrInvalidPin
+	}
+
+	for i := 0; i < len(pin); i++ {
+		if pin[i] < '0' || pin[i] > '9' {
+			return ErrInvalidPin
+		}
+	}
+
+	return nil
+}
+
+// NormalizeEmail validates a plain email address such as "ada@example.com" and lowercases its domain.
+// An empty address is valid, it means the borrower is not contacted by email.
+// Parameters:
+// - raw: the address as entered.
+// Returns the normalized address and ErrInvalidEmail if it is not a single plain address.
+func NormalizeEmail(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+
+	address, err := mail.ParseAddress(raw)
+	if err != nil || address.Address != raw || address.Name != "" {
+		return "", ErrInvalidEmail
+	}
+
+	local, domain, _ := strings.Cut(address.Address, "@")
+	return local + "@" + strings.ToLower(domain), nil
+}
+
+// NormalizePhone turns a phone number into its E.164 form, such as "+6281234567890".
+// Spaces, dashes, dots and parentheses are ignored, an international prefix of "00" is read as "+", and a national
+// number starting with a trunk "0" gets the country code, if one is given.
+// Parameters:
+// - raw: the number as entered.
+// - countryCode: the calling code of national numbers without "+", such as "62", empty to only accept international ones.
+// Returns the E.164 number and ErrInvalidPhone if the number cannot be made international or has the wrong length.
+func NormalizePhone(raw, countryCode string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '/':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case strings.HasPrefix(digits, "0") && countryCode != "":
+		digits = countryCode + digits[1:]
+	default:
+		return "", ErrInvalidPhone
+	}
+
+	// E.164 numbers have at most 15 digits, the shortest national numbers in use are 7 digits plus the country code
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return "", ErrInvalidPhone
+		}
+	}
+
+	return "+" + digits, nil
+}
+
+// NormalizePatron collapses the whitespace of the name and phone and normalizes the card number when one is given.
+// A PIN is validated but kept as is, leading zeros and all.
+// Parameters:
+// - p: a pointer to the patron, modified in place.
+// Returns an error describing the first invalid field.
+func NormalizePatron(p *types.CreatePatron) error {
+	p.Name = strings.Join(strings.Fields(p.Name), " ")
+	if p.Name == "" {
+		return ErrMissingName
+	}
+
+	p.Phone = strings.Join(strings.Fields(p.Phone), " ")
+	if p.Phone == "" {
+		return ErrMissingPhone
+	}
+
+	email, err := NormalizeEmail(p.Email)
+	if err != nil {
+		return err
+	}
+	p.Email = email
+
+	if p.Pin != "" {
+		if err := ValidatePin(p.Pin); err != nil {
+			return err
+		}
+	}
+
+	if strings.TrimSpace(p.CardNumber) == "" {
+		p.CardNumber = ""
+		return nil
+	}
+
+	cardNumber, err := NormalizeCardNumber(p.CardNumber)
+	if err != nil {
+		return err
+	}
+	p.CardNumber = cardNumber
+
+	return nil
+}
+
+// NormalizeContact collapses the whitespace of the phone and validates the email of the contact details a patron changed.
+// Parameters:
+// - c: a pointer to the contact details, modified in place.
+// Returns an error describing the first invalid field.
+func NormalizeContact(c *types.UpdatePatronContact) error {
+	c.Phone = strings.Join(strings.Fields(c.Phone), " ")
+	if c.Phone == "" {
+		return ErrMissingPhone
+	}
+
+	email, err := NormalizeEmail(c.Email)
+	if err != nil {
+		return err
+	}
+	c.Email = email
+
+	return nil
+}
+
+// NormalizeScan normalizes the card number and barcodes of a scan.
+// A barcode scanned twice in the same batch is kept once, otherwise the second read would undo the first.
+// Parameters:
+// - req: a pointer to the scan, modified in place.
+// Returns an error if the card or a barcode is invalid, or the batch is empty or too large.
+func NormalizeScan(req *types.ScanRequest) error {
+	cardNumber, err := NormalizeCardNumber(req.CardNumber)
+	if err != nil {
+		return err
+	}
+	req.CardNumber = cardNumber
+
+	barcodes := make([]string, 0, len(req.Barcodes))
+	seen := make(map[string]bool, len(req.Barcodes))
+	for _, raw := range req.Barcodes {
+		barcode, err := bookutil.NormalizeBarcode(raw)
+		if err != nil {
+			return err
+		}
+		if seen[barcode] {
+			continue
+		}
+		seen[barcode] = true
+		barcodes = append(barcodes, barcode)
+	}
+
+	if len(barcodes) == 0 {
+		return ErrEmptyScan
+	}
+	if len(barcodes) > MaxScanItems {
+		return ErrTooManyScans
+	}
+	req.Barcodes = barcodes
+
+	return nil
+}
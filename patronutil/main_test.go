@@ -0,0 +1,144 @@
+package patronutil
+
+import (
+	"errors"
+	"github.com/Tus1688/library-management-api/bookutil"
+	"github.com/Tus1688/library-management-api/types"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestNormalizePatron(t *testing.T) {
+	p := types.CreatePatron{CardNumber: " p000000042 ", Name: "  Ada   Lovelace ", Phone: " +44 20  7946 0000 "}
+	if err := NormalizePatron(&p); err != nil {
+		t.Fatalf("NormalizePatron returned an error: %v", err)
+	}
+
+	want := types.CreatePatron{CardNumber: "P000000042", Name: "Ada Lovelace", Phone: "+44 20 7946 0000"}
+	if p != want {
+		t.Errorf("NormalizePatron = %+v, want %+v", p, want)
+	}
+
+	blank := types.CreatePatron{CardNumber: "  ", Name: "Ada", Phone: "1"}
+	if err := NormalizePatron(&blank); err != nil || blank.CardNumber != "" {
+		t.Errorf("NormalizePatron kept a blank card number: %q, %v", blank.CardNumber, err)
+	}
+
+	for _, tt := range []struct {
+		patron types.CreatePatron
+		want   error
+	}{
+		{types.CreatePatron{Name: " ", Phone: "1"}, ErrMissingName},
+		{types.CreatePatron{Name: "Ada", Phone: " "}, ErrMissingPhone},
+		{types.CreatePatron{CardNumber: "Café", Name: "Ada", Phone: "1"}, ErrInvalidCardNumber},
+		{types.CreatePatron{Name: "Ada", Phone: "1", Pin: "12a4"}, ErrInvalidPin},
+	} {
+		if err := NormalizePatron(&tt.patron); !errors.Is(err, tt.want) {
+			t.Errorf("NormalizePatron(%+v) = %v, want %v", tt.patron, err, tt.want)
+		}
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"", "", true},
+		{" Ada@Example.COM ", "Ada@example.com", true},
+		{"Ada <ada@example.com>", "", false},
+		{"ada@example.com, bob@example.com", "", false},
+		{"ada", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeEmail(tt.raw)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("NormalizeEmail(%q) = %q, %v", tt.raw, got, err)
+		}
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		raw         string
+		countryCode string
+		want        string
+		ok          bool
+	}{
+		{"+62 812-3456-7890", "", "+6281234567890", true},
+		{"0062 812 3456 7890", "", "+6281234567890", true},
+		{"0812 3456 7890", "62", "+6281234567890", true},
+		{"(020) 7946.0000", "44", "+442079460000", true},
+		{"0812 3456 7890", "", "", false},
+		{"812 3456 7890", "62", "", false},
+		{"+0 123 4567", "", "", false},
+		{"+62 812 CALL ME", "", "", false},
+		{"+1234567", "", "", false},
+		{"+1234567890123456", "", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizePhone(tt.raw, tt.countryCode)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("NormalizePhone(%q, %q) = %q, %v", tt.raw, tt.countryCode, got, err)
+		}
+	}
+}
+
+func TestValidatePin(t *testing.T) {
+	for pin, ok := range map[string]bool{"0042": true, "12345678": true, "123": false, "123456789": false, " 1234": false} {
+		if err := ValidatePin(pin); (err == nil) != ok {
+			t.Errorf("ValidatePin(%q) = %v", pin, err)
+		}
+	}
+}
+
+func TestNormalizeScan(t *testing.T) {
+	req := types.ScanRequest{CardNumber: "p000000001", Barcodes: []string{"b000000001", " B000000002", "B000000001"}}
+	if err := NormalizeScan(&req); err != nil {
+		t.Fatalf("NormalizeScan returned an error: %v", err)
+	}
+
+	if req.CardNumber != "P000000001" || !reflect.DeepEqual(req.Barcodes, []string{"B000000001", "B000000002"}) {
+		t.Errorf("NormalizeScan = %+v", req)
+	}
+
+	tooMany := types.ScanRequest{CardNumber: "P1"}
+	for i := 0; i <= MaxScanItems; i++ {
+		tooMany.Barcodes = append(tooMany.Barcodes, "B"+strconv.Itoa(i))
+	}
+
+	for _, tt := range []struct {
+		req  types.ScanRequest
+		want error
+	}{
+		{types.ScanRequest{CardNumber: "P1", Barcodes: []string{}}, ErrEmptyScan},
+		{types.ScanRequest{CardNumber: " ", Barcodes: []string{"B1"}}, ErrInvalidCardNumber},
+		{types.ScanRequest{CardNumber: "P1", Barcodes: []string{"B1", ""}}, bookutil.ErrInvalidBarcode},
+		{tooMany, ErrTooManyScans},
+	} {
+		if err := NormalizeScan(&tt.req); !errors.Is(err, tt.want) {
+			t.Errorf("NormalizeScan(%v) = %v, want %v", tt.req.Barcodes, err, tt.want)
+		}
+	}
+}
+
+func TestNormalizeContact(t *testing.T) {
+	contact := types.UpdatePatronContact{Phone: "  +62 812  3456 ", Email: " Ada@Example.COM "}
+	if err := NormalizeContact(&contact); err != nil {
+		t.Fatalf("NormalizeContact returned an error: %v", err)
+	}
+	if contact.Phone != "+62 812 3456" || contact.Email != "Ada@example.com" {
+		t.Errorf("NormalizeContact = %+v", contact)
+	}
+
+	if err := NormalizeContact(&types.UpdatePatronContact{Phone: " "}); !errors.Is(err, ErrMissingPhone) {
+		t.Errorf("NormalizeContact without a phone = %v, want ErrMissingPhone", err)
+	}
+	if err := NormalizeContact(&types.UpdatePatronContact{Phone: "1", Email: "ada"}); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("NormalizeContact with an invalid email = %v, want ErrInvalidEmail", err)
+	}
+}
@@ -0,0 +1,119 @@
+package pdf
+
+import "strings"
+
+// helveticaWidths are the advance widths of the printable ASCII characters in Helvetica, in 1/1000 of the font size.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// helveticaBoldWidths are the advance widths of the printable ASCII characters in Helvetica-Bold.
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// windows1252 maps the characters of Windows-1252 that differ from Latin-1.
+var windows1252 = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A,
+	'‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// encode converts text to the WinAnsiEncoding of the standard fonts.
+func encode(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			encoded = append(encoded, byte(r))
+		case windows1252[r] != 0:
+			encoded = append(encoded, windows1252[r])
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// TextWidth returns the width of the text in points when written with Text at the given size.
+// Characters beyond ASCII are measured with an average width.
+func TextWidth(text string, size float64) float64 {
+	return measure(&helveticaWidths, text, size)
+}
+
+// BoldTextWidth returns the width of the text in points when written with BoldText at the given size.
+func BoldTextWidth(text string, size float64) float64 {
+	return measure(&helveticaBoldWidths, text, size)
+}
+
+func measure(widths *[95]int, text string, size float64) float64 {
+	total := 0
+	for _, c := range encode(text) {
+		if c >= 0x20 && c < 0x7F {
+			total += widths[c-0x20]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Truncate shortens the text with an ellipsis so it fits the width at the given size.
+func Truncate(text string, size, width float64) string {
+	return truncate(TextWidth, text, size, width)
+}
+
+func truncate(measure func(string, float64) float64, text string, size, width float64) string {
+	if measure(text, size) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && measure(string(runes)+"…", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	if len(runes) == 0 {
+		return ""
+	}
+	return string(runes) + "…"
+}
+
+// Wrap breaks the text into lines that fit the width when written with Text at the given size.
+// Lines are broken between words, a word wider than the width is truncated. Line breaks in the text are kept.
+func Wrap(text string, size, width float64) []string {
+	return wrap(TextWidth, text, size, width)
+}
+
+// WrapBold breaks the text into lines like Wrap, for text written with BoldText.
+func WrapBold(text string, size, width float64) []string {
+	return wrap(BoldTextWidth, text, size, width)
+}
+
+func wrap(measure func(string, float64) float64, text string, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && measure(line+" "+word, size) <= width {
+				line += " " + word
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = truncate(measure, word, size, width)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
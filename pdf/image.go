@@ -0,0 +1,119 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+)
+
+var ErrUnsupportedImage = errors.New("image must be a jpeg or png")
+
+// Image is a picture added to a Document, it can be drawn on any of its pages.
+type Image struct {
+	// Width and Height are the size of the image in pixels.
+	Width  int
+	Height int
+
+	index int
+	dict  string
+	data  []byte
+	mask  *Image
+}
+
+// AddImage adds a JPEG or PNG image to the document.
+// JPEG images are embedded as they are, PNG images are decoded and compressed again,
+// with their transparency kept as a soft mask.
+// Returns the image and ErrUnsupportedImage if the data is neither, or an error if it cannot be decoded.
+func (d *Document) AddImage(data []byte) (*Image, error) {
+	var img *Image
+	var err error
+	switch {
+	case bytes.HasPrefix(data, []byte("\xff\xd8")):
+		img, err = jpegImage(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG")):
+		img, err = pngImage(data)
+	default:
+		return nil, ErrUnsupportedImage
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	img.index = len(d.images) + 1
+	d.images = append(d.images, img)
+	return img, nil
+}
+
+// AspectRatio is the width of the image divided by its height.
+func (i *Image) AspectRatio() float64 {
+	return float64(i.Width) / float64(i.Height)
+}
+
+func jpegImage(data []byte) (*Image, error) {
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	colorSpace := "/DeviceRGB"
+	switch config.ColorModel {
+	case color.GrayModel:
+		colorSpace = "/DeviceGray"
+	case color.CMYKModel:
+		// the CMYK images written by Adobe applications store inverted values
+		colorSpace = "/DeviceCMYK /Decode [1 0 1 0 1 0 1 0]"
+	}
+
+	return &Image{
+		Width:  config.Width,
+		Height: config.Height,
+		dict: fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 "+
+			"/Filter /DCTDecode", config.Width, config.Height, colorSpace),
+		data: data,
+	}, nil
+}
+
+func pngImage(data []byte) (*Image, error) {
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := decoded.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	rgb := make([]byte, 0, width*height*3)
+	alpha := make([]byte, 0, width*height)
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			opaque = opaque && c.A == 0xff
+		}
+	}
+
+	img := &Image{Width: width, Height: height, dict: flateImageDict(width, height, "/DeviceRGB"), data: deflate(rgb)}
+	if !opaque {
+		img.mask = &Image{Width: width, Height: height, dict: flateImageDict(width, height, "/DeviceGray"),
+			data: deflate(alpha)}
+	}
+	return img, nil
+}
+
+func flateImageDict(width, height int, colorSpace string) string {
+	return fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 "+
+		"/Filter /FlateDecode", width, height, colorSpace)
+}
+
+func deflate(data []byte) []byte {
+	var compressed bytes.Buffer
+	z := zlib.NewWriter(&compressed)
+	_, _ = z.Write(data)
+	_ = z.Close()
+	return compressed.Bytes()
+}
@@ -0,0 +1,181 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page sizes and units in points, the unit of PDF coordinates.
+const (
+	A4Width    = 595.28
+	A4Height   = 841.89
+	Millimeter = 72 / 25.4
+)
+
+// Document is a PDF document built page by page in memory and written at once.
+// Only the standard Helvetica fonts are available, so no font needs to be embedded.
+type Document struct {
+	pages  []*Page
+	images []*Image
+}
+
+// Page is a page of a Document.
+// Coordinates start at the top left corner of the page and grow to the right and downwards.
+type Page struct {
+	Width   float64
+	Height  float64
+	content bytes.Buffer
+}
+
+// New creates an empty document.
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage appends a page of the given size in points.
+func (d *Document) AddPage(width, height float64) *Page {
+	page := &Page{Width: width, Height: height}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Rect fills a black rectangle whose top left corner is at x, y.
+func (p *Page) Rect(x, y, width, height float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re f\n", number(x), number(p.Height-y-height), number(width), number(height))
+}
+
+// Image draws an image of the document scaled to width and height, with its top left corner at x, y.
+func (p *Page) Image(image *Image, x, y, width, height float64) {
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", number(width), number(height), number(x),
+		number(p.Height-y-height), image.index)
+}
+
+// Line strokes a black line of the given width.
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", number(width), number(x1), number(p.Height-y1), number(x2),
+		number(p.Height-y2))
+}
+
+// Text writes a line of text in Helvetica with its baseline at y.
+// Characters outside the Windows-1252 character set are replaced by a question mark.
+func (p *Page) Text(x, y, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /F1 %s Tf %s %s Td (%s) Tj ET\n", number(size), number(x), number(p.Height-y),
+		escape(encode(text)))
+}
+
+// BoldText writes a line of text in Helvetica-Bold, like Text.
+func (p *Page) BoldText(x, y, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /F2 %s Tf %s %s Td (%s) Tj ET\n", number(size), number(x), number(p.Height-y),
+		escape(encode(text)))
+}
+
+// Write serializes the document.
+// Returns an error if the document has no page or cannot be written.
+func (d *Document) Write(w io.Writer) error {
+	if len(d.pages) == 0 {
+		return fmt.Errorf("pdf: document has no page")
+	}
+
+	out := &counter{w: bufio.NewWriter(w)}
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.n)
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	fmt.Fprint(out, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// objects 1 to 4 are the catalog, the page tree and the fonts, followed by the images and their masks,
+	// each page then takes a page and a content object
+	next := 5
+	var xobjects []string
+	for _, image := range d.images {
+		xobjects = append(xobjects, fmt.Sprintf("/Im%d %d 0 R", image.index, next))
+		next++
+		if image.mask != nil {
+			next++
+		}
+	}
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", next+2*i)
+	}
+	resources := "/Font << /F1 3 0 R /F2 4 0 R >>"
+	if len(xobjects) > 0 {
+		resources += " /XObject << " + strings.Join(xobjects, " ") + " >>"
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for _, image := range d.images {
+		dict := image.dict
+		if image.mask != nil {
+			dict += fmt.Sprintf(" /SMask %d 0 R", len(offsets)+2)
+		}
+		object(fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(image.data), image.data))
+		if image.mask != nil {
+			object(fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", image.mask.dict, len(image.mask.data),
+				image.mask.data))
+		}
+	}
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << %s >> "+
+			"/Contents %d 0 R >>", number(page.Width), number(page.Height), resources, next+1+2*i))
+
+		compressed := deflate(page.content.Bytes())
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", len(compressed),
+			compressed))
+	}
+
+	xref := out.n
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// counter tracks the offset of the objects for the cross-reference table.
+type counter struct {
+	w   *bufio.Writer
+	n   int
+	err error
+}
+
+func (c *counter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	if err != nil && c.err == nil {
+		c.err = err
+	}
+	return n, err
+}
+
+// number formats a coordinate with at most two decimals, as PDF readers do not accept exponents.
+func number(value float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.2f", value), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+func escape(text []byte) string {
+	var b strings.Builder
+	for _, c := range text {
+		if c == '\\' || c == '(' || c == ')' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	doc := New()
+	first := doc.AddPage(A4Width, A4Height)
+	first.Text(10, 20, 12, "Dune (1965) \\ Herbert")
+	first.Rect(10, 30, 5, 40)
+	doc.AddPage(A4Width, A4Height).Line(0, 0, 10, 10, 0.5)
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatalf("Write returned an error: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "%PDF-1.4\n") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Fatalf("Write produced no PDF envelope")
+	}
+	if !strings.Contains(out, "/Count 2") {
+		t.Errorf("page tree does not count 2 pages")
+	}
+
+	// every cross-reference entry must point at the start of its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(out)
+	xref, _ := strconv.Atoi(startxref[1])
+	if !strings.HasPrefix(out[xref:], "xref\n") {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	for i, entry := range regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(out[xref:], -1) {
+		offset, _ := strconv.Atoi(entry[1])
+		if want := fmt.Sprintf("%d 0 obj", i+1); !strings.HasPrefix(out[offset:], want) {
+			t.Errorf("xref entry %d points at %q", i+1, out[offset:offset+10])
+		}
+	}
+
+	// the first content stream holds the escaped text, with y measured from the top of the page
+	stream := regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindStringSubmatch(out)
+	z, err := zlib.NewReader(strings.NewReader(stream[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(z)
+	if !strings.Contains(string(content), `10 821.89 Td (Dune \(1965\) \\ Herbert) Tj`) {
+		t.Errorf("content = %q", content)
+	}
+	if !strings.Contains(string(content), "10 771.89 5 40 re f") {
+		t.Errorf("content = %q", content)
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	if err := New().Write(io.Discard); err == nil {
+		t.Errorf("Write of a document without pages succeeded")
+	}
+}
+
+func TestText(t *testing.T) {
+	if got := encode("Café – 5€ ☃"); !bytes.Equal(got, []byte{'C', 'a', 'f', 0xE9, ' ', 0x96, ' ', '5', 0x80, ' ', '?'}) {
+		t.Errorf("encode = %v", got)
+	}
+
+	if got := TextWidth("Hi", 10); got != (722+222)*10/1000.0 {
+		t.Errorf("TextWidth = %v", got)
+	}
+
+	title := "The Hitchhiker's Guide to the Galaxy"
+	if got := Truncate(title, 10, 1000); got != title {
+		t.Errorf("Truncate of a fitting text = %q", got)
+	}
+	if got := Truncate(title, 10, 60); TextWidth(got, 10) > 60 || !strings.HasSuffix(got, "…") {
+		t.Errorf("Truncate = %q (%v wide)", got, TextWidth(got, 10))
+	}
+}
+
+func TestBoldAndWrap(t *testing.T) {
+	if got := BoldTextWidth("Hi", 10); got != (722+278)*10/1000.0 {
+		t.Errorf("BoldTextWidth = %v", got)
+	}
+
+	lines := Wrap("Please return the book\nThank you", 10, 80)
+	want := []string{"Please return the", "book", "Thank you"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("Wrap = %q, want %q", lines, want)
+	}
+	for _, line := range WrapBold("Supercalifragilisticexpialidocious words", 10, 60) {
+		if BoldTextWidth(line, 10) > 60 {
+			t.Errorf("WrapBold line %q is %v wide", line, BoldTextWidth(line, 10))
+		}
+	}
+}
+
+func TestImage(t *testing.T) {
+	logo := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	logo.Set(0, 0, color.NRGBA{R: 0xff, A: 0x80})
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, logo); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := New()
+	img, err := doc.AddImage(encoded.Bytes())
+	if err != nil {
+		t.Fatalf("AddImage returned an error: %v", err)
+	}
+	if img.Width != 4 || img.Height != 2 || img.AspectRatio() != 2 || img.mask == nil {
+		t.Errorf("AddImage = %+v, want a 4 x 2 image with a soft mask", img)
+	}
+	doc.AddPage(A4Width, A4Height).Image(img, 10, 20, 40, 20)
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatalf("Write returned an error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "/XObject << /Im1 5 0 R >>") || !strings.Contains(out, "/SMask 6 0 R") {
+		t.Errorf("the image is not referenced from the page resources")
+	}
+	if !strings.Contains(out, "/Kids [7 0 R]") || !strings.Contains(out, "/Contents 8 0 R") {
+		t.Errorf("the page objects do not follow the image objects")
+	}
+
+	var jpegData bytes.Buffer
+	if err := jpeg.Encode(&jpegData, image.NewGray(image.Rect(0, 0, 3, 3)), nil); err != nil {
+		t.Fatal(err)
+	}
+	img, err = doc.AddImage(jpegData.Bytes())
+	if err != nil || img.index != 2 || !strings.Contains(img.dict, "/DeviceGray") || !bytes.Equal(img.data, jpegData.Bytes()) {
+		t.Errorf("AddImage of a JPEG = %+v, %v", img, err)
+	}
+
+	if _, err := doc.AddImage([]byte("GIF89a")); err != ErrUnsupportedImage {
+		t.Errorf("AddImage of a GIF = %v, want %v", err, ErrUnsupportedImage)
+	}
+}
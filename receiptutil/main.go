@@ -0,0 +1,126 @@
+package receiptutil
+
+import (
+	"errors"
+	"github.com/Tus1688/library-management-api/pdf"
+	"github.com/Tus1688/library-management-api/types"
+	"os"
+	"strings"
+	"time"
+)
+
+// dateLayout is how dates are written on receipts and notices.
+const dateLayout = "2 January 2006"
+
+var ErrNotOverdue = errors.New("booking is not overdue")
+
+// Branding is the identity of the library printed on receipts and notices.
+type Branding struct {
+	Name    string
+	Address string
+	Phone   string
+	Email   string
+	Website string
+	// Footer is printed at the bottom of receipts, such as opening hours or a renewal hint.
+	Footer string
+	// Logo is a JPEG or PNG image, printed at the top when set.
+	Logo []byte
+}
+
+// NewBranding reads the branding from the environment: LIBRARY_NAME (the same name that signs the notifications),
+// LIBRARY_ADDRESS, LIBRARY_PHONE, LIBRARY_EMAIL, LIBRARY_WEBSITE, RECEIPT_FOOTER and LIBRARY_LOGO,
+// the path of a JPEG or PNG image.
+// Returns the branding and an error if the logo cannot be read or is not a supported image.
+func NewBranding() (*Branding, error) {
+	branding := &Branding{
+		Name:    os.Getenv("LIBRARY_NAME"),
+		Address: os.Getenv("LIBRARY_ADDRESS"),
+		Phone:   os.Getenv("LIBRARY_PHONE"),
+		Email:   os.Getenv("LIBRARY_EMAIL"),
+		Website: os.Getenv("LIBRARY_WEBSITE"),
+		Footer:  os.Getenv("RECEIPT_FOOTER"),
+	}
+	if branding.Name == "" {
+		branding.Name = "Your library"
+	}
+
+	if path := os.Getenv("LIBRARY_LOGO"); path != "" {
+		logo, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// decode the logo once, so a broken file is found at startup rather than on the first receipt
+		if _, err := pdf.New().AddImage(logo); err != nil {
+			return nil, errors.New("invalid LIBRARY_LOGO: " + err.Error())
+		}
+		branding.Logo = logo
+	}
+
+	return branding, nil
+}
+
+// contact joins the contact details that are set into one line.
+func (b *Branding) contact() string {
+	var parts []string
+	for _, part := range []string{b.Phone, b.Email, b.Website} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// Loan is a booking as printed on receipts and notices.
+type Loan struct {
+	BookingId     string
+	Title         string
+	Author        string
+	Barcode       string
+	Isbn          string
+	BorrowerName  string
+	BorrowerPhone string
+	BorrowerEmail string
+	LoanedAt      time.Time
+	DueAt         time.Time
+	IsReturned    bool
+}
+
+// LoanFromBooking takes the loan out of a booking.
+// Returns an error if a date of the booking cannot be parsed.
+func LoanFromBooking(booking *types.BookingDetail) (Loan, error) {
+	loanedAt, err := time.Parse(time.RFC3339Nano, booking.CreatedAt)
+	if err != nil {
+		return Loan{}, err
+	}
+	dueAt, err := time.Parse(time.RFC3339Nano, booking.BookedUntil)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	return Loan{
+		BookingId:     booking.Id,
+		Title:         booking.Book.Title,
+		Author:        booking.Book.Author,
+		Barcode:       booking.Book.Barcode,
+		Isbn:          booking.Book.Isbn,
+		BorrowerName:  booking.CustomerName,
+		BorrowerPhone: booking.CustomerPhone,
+		BorrowerEmail: booking.CustomerEmail,
+		LoanedAt:      loanedAt,
+		DueAt:         dueAt,
+		IsReturned:    booking.IsReturned,
+	}, nil
+}
+
+// DaysOverdue is the number of whole days the loan is past its due date at now, 0 if it is not.
+func (l *Loan) DaysOverdue(now time.Time) int {
+	if l.IsReturned || !now.After(l.DueAt) {
+		return 0
+	}
+	return int(now.Sub(l.DueAt).Hours() / 24)
+}
+
+// IsOverdue reports whether the loan is still out after its due date at now.
+func (l *Loan) IsOverdue(now time.Time) bool {
+	return !l.IsReturned && now.After(l.DueAt)
+}
@@ -0,0 +1,151 @@
+package receiptutil
+
+import (
+	"bytes"
+	"compress/zlib"
+	"github.com/Tus1688/library-management-api/types"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testLoan(t *testing.T) Loan {
+	booking := types.BookingDetail{Id: "7d0f", CustomerName: "Ada Lovelace", CustomerEmail: "ada@example.com",
+		CreatedAt: "2024-05-01T09:30:00Z", BookedUntil: "2024-05-08T09:30:00Z",
+		Book: types.ListBook{Title: "Dune", Author: "Frank Herbert", Barcode: "B000000042"}}
+	loan, err := LoanFromBooking(&booking)
+	if err != nil {
+		t.Fatalf("LoanFromBooking returned an error: %v", err)
+	}
+	return loan
+}
+
+// content returns the uncompressed content streams of a PDF written by the pdf package.
+func content(t *testing.T, out []byte) string {
+	var text strings.Builder
+	for _, stream := range regexp.MustCompile(`(?s)/FlateDecode >>\nstream\n(.*?)\nendstream`).FindAllSubmatch(out, -1) {
+		z, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, _ := io.ReadAll(z)
+		text.Write(decoded)
+	}
+	return text.String()
+}
+
+func TestLoan(t *testing.T) {
+	loan := testLoan(t)
+	if loan.Title != "Dune" || !loan.DueAt.Equal(time.Date(2024, 5, 8, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("LoanFromBooking = %+v", loan)
+	}
+
+	if _, err := LoanFromBooking(&types.BookingDetail{CreatedAt: "yesterday"}); err == nil {
+		t.Error("LoanFromBooking accepted an invalid date")
+	}
+
+	tests := []struct {
+		now     time.Time
+		overdue bool
+		days    int
+	}{
+		{time.Date(2024, 5, 8, 9, 0, 0, 0, time.UTC), false, 0},
+		{time.Date(2024, 5, 8, 10, 0, 0, 0, time.UTC), true, 0},
+		{time.Date(2024, 5, 11, 10, 0, 0, 0, time.UTC), true, 3},
+	}
+	for _, test := range tests {
+		if loan.IsOverdue(test.now) != test.overdue || loan.DaysOverdue(test.now) != test.days {
+			t.Errorf("at %s overdue = %v for %d days, want %v for %d", test.now, loan.IsOverdue(test.now),
+				loan.DaysOverdue(test.now), test.overdue, test.days)
+		}
+	}
+
+	loan.IsReturned = true
+	if loan.IsOverdue(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("a returned loan is overdue")
+	}
+}
+
+func TestWriteReceipt(t *testing.T) {
+	loan := testLoan(t)
+	branding := &Branding{Name: "Riverside Library", Phone: "+62 21 555 0100", Footer: "Renew online at riverside.example"}
+
+	var buf bytes.Buffer
+	if err := WriteReceipt(&buf, branding, &loan); err != nil {
+		t.Fatalf("WriteReceipt returned an error: %v", err)
+	}
+	text := content(t, buf.Bytes())
+	for _, want := range []string{"(Riverside Library)", "(LOAN RECEIPT)", "(Dune)", "(Due back by 8 May 2024)",
+		"(Renew online at riverside.example)", "/F2 "} {
+		if !strings.Contains(text, want) {
+			t.Errorf("the receipt does not contain %s", want)
+		}
+	}
+	if !strings.Contains(buf.String(), "/MediaBox [0 0 226.77 ") {
+		t.Error("the receipt is not 80 mm wide")
+	}
+}
+
+func TestWriteOverdueNotice(t *testing.T) {
+	loan := testLoan(t)
+	branding := &Branding{Name: "Riverside Library", Email: "desk@riverside.example"}
+
+	var buf bytes.Buffer
+	if err := WriteOverdueNotice(&buf, branding, &loan, loan.DueAt.Add(-time.Hour)); err != ErrNotOverdue {
+		t.Errorf("WriteOverdueNotice before the due date = %v, want %v", err, ErrNotOverdue)
+	}
+
+	if err := WriteOverdueNotice(&buf, branding, &loan, time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("WriteOverdueNotice returned an error: %v", err)
+	}
+	text := content(t, buf.Bytes())
+	for _, want := range []string{"(Overdue notice)", "(Ada Lovelace)", "(20 May 2024)", "12 days overdue",
+		"desk@riverside.example"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("the notice does not contain %s", want)
+		}
+	}
+}
+
+func TestNewBranding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logo.png")
+	var logo bytes.Buffer
+	if err := png.Encode(&logo, image.NewGray(image.Rect(0, 0, 8, 4))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, logo.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("LIBRARY_NAME", "")
+	t.Setenv("LIBRARY_LOGO", path)
+	branding, err := NewBranding()
+	if err != nil {
+		t.Fatalf("NewBranding returned an error: %v", err)
+	}
+	if branding.Name != "Your library" || !bytes.Equal(branding.Logo, logo.Bytes()) {
+		t.Errorf("NewBranding = %+v", branding)
+	}
+
+	loan := testLoan(t)
+	var buf bytes.Buffer
+	if err := WriteReceipt(&buf, branding, &loan); err != nil {
+		t.Fatalf("WriteReceipt returned an error: %v", err)
+	}
+	if !strings.Contains(content(t, buf.Bytes()), "/Im1 Do") {
+		t.Error("the receipt does not draw the logo")
+	}
+
+	if err := os.WriteFile(path, []byte("not an image"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBranding(); err == nil {
+		t.Error("NewBranding accepted an invalid logo")
+	}
+}
@@ -0,0 +1,119 @@
+package receiptutil
+
+import (
+	"github.com/Tus1688/library-management-api/pdf"
+	"io"
+	"strconv"
+	"time"
+)
+
+// The notice is an A4 letter.
+const (
+	noticeMargin = 20 * pdf.Millimeter
+	noticeLogo   = 22 * pdf.Millimeter
+	noticeBody   = 10.5
+	noticeLine   = noticeBody * 1.45
+)
+
+// WriteOverdueNotice writes a formal notice that a loan is overdue as a PDF letter to the borrower.
+// Parameters:
+// - w: where the PDF is written to.
+// - branding: the library sending the notice.
+// - loan: the overdue loan.
+// - now: the date of the notice.
+// Returns ErrNotOverdue if the loan is returned or not due yet, or an error if the logo cannot be embedded
+// or the PDF written.
+func WriteOverdueNotice(w io.Writer, branding *Branding, loan *Loan, now time.Time) error {
+	if !loan.IsOverdue(now) {
+		return ErrNotOverdue
+	}
+
+	doc := pdf.New()
+	page := doc.AddPage(pdf.A4Width, pdf.A4Height)
+	width := pdf.A4Width - 2*noticeMargin
+	y := noticeMargin
+
+	// letterhead: the logo on the left, the library on the right
+	if branding.Logo != nil {
+		logo, err := doc.AddImage(branding.Logo)
+		if err != nil {
+			return err
+		}
+		logoWidth := min(noticeLogo*logo.AspectRatio(), width/2)
+		page.Image(logo, noticeMargin, y, logoWidth, logoWidth/logo.AspectRatio())
+	}
+	right := func(y, size float64, bold bool, text string) {
+		if bold {
+			page.BoldText(pdf.A4Width-noticeMargin-pdf.BoldTextWidth(text, size), y, size, text)
+			return
+		}
+		page.Text(pdf.A4Width-noticeMargin-pdf.TextWidth(text, size), y, size, text)
+	}
+	headY := y + 12
+	right(headY, 14, true, pdf.Truncate(branding.Name, 14, width/2))
+	for _, detail := range []string{branding.Address, branding.Phone, branding.Email, branding.Website} {
+		if detail != "" {
+			headY += 12
+			right(headY, 9, false, pdf.Truncate(detail, 9, width/2))
+		}
+	}
+	y = max(y+noticeLogo, headY) + 8*pdf.Millimeter
+	page.Line(noticeMargin, y, pdf.A4Width-noticeMargin, y, 0.75)
+
+	// recipient and date
+	y += 12 * pdf.Millimeter
+	page.BoldText(noticeMargin, y, noticeBody, loan.BorrowerName)
+	right(y, noticeBody, false, now.Format(dateLayout))
+	for _, detail := range []string{loan.BorrowerEmail, loan.BorrowerPhone} {
+		if detail != "" {
+			y += noticeLine
+			page.Text(noticeMargin, y, noticeBody, detail)
+		}
+	}
+
+	y += 3 * noticeLine
+	page.BoldText(noticeMargin, y, 13, "Overdue notice")
+	y += noticeLine
+
+	paragraph := func(text string) {
+		for _, line := range pdf.Wrap(text, noticeBody, width) {
+			y += noticeLine
+			page.Text(noticeMargin, y, noticeBody, line)
+		}
+		y += noticeLine / 2
+	}
+
+	days := loan.DaysOverdue(now)
+	overdue := strconv.Itoa(days) + " days"
+	if days == 1 {
+		overdue = "1 day"
+	} else if days == 0 {
+		overdue = "less than a day"
+	}
+
+	paragraph("Dear " + loan.BorrowerName + ",")
+	paragraph("Our records show that the item below, borrowed on " + loan.LoanedAt.Format(dateLayout) +
+		", was due back on " + loan.DueAt.Format(dateLayout) + " and is now " + overdue + " overdue.")
+
+	// the item, as a small table of labels and values
+	y += noticeLine / 2
+	for _, row := range [][2]string{{"Title", loan.Title}, {"Author", loan.Author}, {"Barcode", loan.Barcode},
+		{"Due date", loan.DueAt.Format(dateLayout)}, {"Booking", loan.BookingId}} {
+		y += noticeLine
+		page.BoldText(noticeMargin+10, y, noticeBody, row[0])
+		page.Text(noticeMargin+80, y, noticeBody, pdf.Truncate(row[1], noticeBody, width-90))
+	}
+	y += noticeLine
+
+	paragraph("Please return it to the library as soon as possible so that other readers can borrow it. " +
+		"If you have already returned it, please accept our thanks and disregard this notice.")
+	if contact := branding.contact(); contact != "" {
+		paragraph("If you have any questions, please contact us: " + contact + ".")
+	}
+
+	y += noticeLine
+	paragraph("Yours sincerely,")
+	page.BoldText(noticeMargin, y+noticeLine/2, noticeBody, branding.Name)
+
+	return doc.Write(w)
+}
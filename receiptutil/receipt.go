@@ -0,0 +1,109 @@
+package receiptutil
+
+import (
+	"github.com/Tus1688/library-management-api/pdf"
+	"io"
+)
+
+// The receipt is laid out for 80 mm receipt printers, its height follows the content.
+const (
+	receiptWidth   = 80 * pdf.Millimeter
+	receiptMargin  = 5 * pdf.Millimeter
+	receiptLogo    = 18 * pdf.Millimeter
+	receiptSpacing = 1.4
+)
+
+// line is a line of text of the receipt, an empty text adds a gap and rule draws a separator.
+type line struct {
+	text string
+	size float64
+	bold bool
+	rule bool
+}
+
+// WriteReceipt writes the receipt of a loan as a PDF: the library, the borrower, the book and its due date.
+// Parameters:
+// - w: where the PDF is written to.
+// - branding: the library printed at the top.
+// - loan: the loan the receipt is for.
+// Returns an error if the logo cannot be embedded or the PDF written.
+func WriteReceipt(w io.Writer, branding *Branding, loan *Loan) error {
+	width := receiptWidth - 2*receiptMargin
+	var lines []line
+	text := func(value string, size float64, bold bool) {
+		wrapped := pdf.Wrap(value, size, width)
+		if bold {
+			wrapped = pdf.WrapBold(value, size, width)
+		}
+		for _, part := range wrapped {
+			lines = append(lines, line{text: part, size: size, bold: bold})
+		}
+	}
+	gap := func() { lines = append(lines, line{size: 4}) }
+	rule := func() { lines = append(lines, line{size: 4, rule: true}) }
+
+	text(branding.Name, 11, true)
+	if branding.Address != "" {
+		text(branding.Address, 8, false)
+	}
+	if contact := branding.contact(); contact != "" {
+		text(contact, 8, false)
+	}
+	rule()
+	text("LOAN RECEIPT", 10, true)
+	text("Date: "+loan.LoanedAt.Format(dateLayout+" 15:04"), 8, false)
+	text("Booking: "+loan.BookingId, 7, false)
+	text("Borrower: "+loan.BorrowerName, 8, false)
+	rule()
+	text(loan.Title, 10, true)
+	text(loan.Author, 9, false)
+	text("Barcode: "+loan.Barcode, 8, false)
+	if loan.Isbn != "" {
+		text("ISBN: "+loan.Isbn, 8, false)
+	}
+	gap()
+	text("Due back by "+loan.DueAt.Format(dateLayout), 11, true)
+	rule()
+	if branding.Footer != "" {
+		text(branding.Footer, 8, false)
+	}
+	text("Please keep this receipt.", 8, false)
+
+	doc := pdf.New()
+	var logo *pdf.Image
+	logoHeight := 0.0
+	if branding.Logo != nil {
+		var err error
+		if logo, err = doc.AddImage(branding.Logo); err != nil {
+			return err
+		}
+		logoHeight = receiptLogo + 3*pdf.Millimeter
+	}
+
+	height := 2*receiptMargin + logoHeight
+	for _, l := range lines {
+		height += l.size * receiptSpacing
+	}
+	page := doc.AddPage(receiptWidth, height)
+
+	y := receiptMargin
+	if logo != nil {
+		logoWidth := min(receiptLogo*logo.AspectRatio(), width)
+		page.Image(logo, (receiptWidth-logoWidth)/2, y, logoWidth, logoWidth/logo.AspectRatio())
+		y += logoHeight
+	}
+
+	for _, l := range lines {
+		y += l.size * receiptSpacing
+		switch {
+		case l.rule:
+			page.Line(receiptMargin, y-l.size*receiptSpacing/2, receiptWidth-receiptMargin, y-l.size*receiptSpacing/2, 0.5)
+		case l.bold:
+			page.BoldText(receiptMargin, y-l.size*0.3, l.size, l.text)
+		default:
+			page.Text(receiptMargin, y-l.size*0.3, l.size, l.text)
+		}
+	}
+
+	return doc.Write(w)
+}
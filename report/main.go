@@ -0,0 +1,277 @@
+package report
+
+import (
+	"errors"
+	"github.com/Tus1688/library-management-api/jsonutil"
+	"github.com/Tus1688/library-management-api/types"
+	"log"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultDays is the length of the range when from is not given, today included.
+	defaultDays = 30
+	// defaultLimit and maxLimit bound the reports listing books or authors.
+	defaultLimit = 10
+	maxLimit     = 1000
+
+	// openExpiration is how long a report covering today is reused, new loans keep changing it.
+	openExpiration = 10 * time.Minute
+	// closedExpiration is how long a report of a range in the past is reused, it only changes with corrections.
+	closedExpiration = 6 * time.Hour
+)
+
+// dateLayout is the format of the from and to parameters.
+const dateLayout = "2006-01-02"
+
+// maxPeriods bounds the periods of the loans report per interval, every period is a row computed and cached.
+var maxPeriods = map[string]int{"day": 366, "week": 260, "month": 120}
+
+var (
+	ErrUnknownReport   = errors.New("unknown report")
+	ErrInvalidDate     = errors.New("invalid date, expected YYYY-MM-DD")
+	ErrInvalidRange    = errors.New("from is after to")
+	ErrRangeTooLong    = errors.New("range is too long, at most 366 days, 260 weeks or 120 months")
+	ErrInvalidInterval = errors.New("invalid interval, expected day, week or month")
+	ErrInvalidLimit    = errors.New("invalid limit")
+)
+
+// Store computes the reports, it is implemented by storage.PostgresStore.
+type Store interface {
+	ReportLoans(params *types.ReportParams) ([]types.LoanPeriod, int, types.Err)
+	ReportTopTitles(params *types.ReportParams) ([]types.TitleLoans, int, types.Err)
+	ReportTopAuthors(params *types.ReportParams) ([]types.AuthorLoans, int, types.Err)
+	ReportLoanDuration(params *types.ReportParams) (types.LoanDuration, int, types.Err)
+	ReportOnTime(params *types.ReportParams) (types.ReturnRate, int, types.Err)
+	ReportEmployees(params *types.ReportParams) ([]types.HandlerLoans, int, types.Err)
+	ReportIdleBooks(params *types.ReportParams) ([]types.IdleBook, int, types.Err)
+}
+
+// Cache stores computed reports, it is implemented by cache.Cache.
+type Cache interface {
+	GetReport(key *string) ([]byte, types.Err)
+	SaveReport(key *string, result []byte, expiration time.Duration) types.Err
+}
+
+// ParseParams reads the parameters of a report from a query string.
+// from and to are inclusive dates, to defaults to today and from to 30 days before to.
+// The loans report takes an interval of day (the default), week or month, over at most 366 days, 260 weeks
+// or 120 months,
+// the top-titles, top-authors and idle-books reports take a limit, 10 by default and at most 1000.
+// Parameters:
+// - name: the name of the report.
+// - query: the query string of the request.
+// - now: the current time, which decides what today is.
+// Returns the ReportParams and an error if the report is unknown or a parameter is invalid.
+func ParseParams(name string, query url.Values, now time.Time) (types.ReportParams, error) {
+	params := types.ReportParams{Name: name}
+	switch name {
+	case types.ReportLoans:
+		params.Interval = strings.ToLower(query.Get("interval"))
+		switch params.Interval {
+		case "":
+			params.Interval = "day"
+		case "day", "week", "month":
+		default:
+			return types.ReportParams{}, ErrInvalidInterval
+		}
+	case types.ReportTopTitles, types.ReportTopAuthors, types.ReportIdleBooks:
+		params.Limit = defaultLimit
+		if raw := query.Get("limit"); raw != "" {
+			limit, err := strconv.Atoi(raw)
+			if err != nil || limit < 1 || limit > maxLimit {
+				return types.ReportParams{}, ErrInvalidLimit
+			}
+			params.Limit = limit
+		}
+	case types.ReportLoanDuration, types.ReportOnTime, types.ReportEmployees:
+	default:
+		return types.ReportParams{}, ErrUnknownReport
+	}
+
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if raw := query.Get("to"); raw != "" {
+		var err error
+		if to, err = time.Parse(dateLayout, raw); err != nil {
+			return types.ReportParams{}, ErrInvalidDate
+		}
+	}
+
+	from := to.AddDate(0, 0, 1-defaultDays)
+	if raw := query.Get("from"); raw != "" {
+		var err error
+		if from, err = time.Parse(dateLayout, raw); err != nil {
+			return types.ReportParams{}, ErrInvalidDate
+		}
+	}
+
+	if from.After(to) {
+		return types.ReportParams{}, ErrInvalidRange
+	}
+	if params.Interval != "" && periods(from, to, params.Interval) > maxPeriods[params.Interval] {
+		return types.ReportParams{}, ErrRangeTooLong
+	}
+	params.From, params.To = from.Format(dateLayout), to.Format(dateLayout)
+
+	return params, nil
+}
+
+// periods counts the periods of an interval the inclusive dates from and to fall in, as the loans report lists them.
+func periods(from, to time.Time, interval string) int {
+	switch interval {
+	case "week":
+		// weeks start on Monday, as date_trunc has them
+		monday := func(t time.Time) time.Time { return t.AddDate(0, 0, -(int(t.Weekday())+6)%7) }
+		return int(monday(to).Sub(monday(from)).Hours()/24)/7 + 1
+	case "month":
+		return (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+	}
+
+	return int(to.Sub(from).Hours()/24) + 1
+}
+
+// Reporter computes reports, reusing the ones computed lately with the same parameters.
+type Reporter struct {
+	store Store
+	cache Cache
+	now   func() time.Time
+}
+
+// NewReporter creates a reporter, a failing cache only costs computing every report again.
+func NewReporter(store Store, cache Cache) *Reporter {
+	return &Reporter{store: store, cache: cache, now: time.Now}
+}
+
+// Run returns the report selected by params.
+// Parameters:
+// - params: a pointer to the ReportParams, as returned by ParseParams.
+// Returns the Report, status code, and an error if the report cannot be computed.
+func (r *Reporter) Run(params *types.ReportParams) (types.Report, int, types.Err) {
+	key := cacheKey(params)
+	cached, errCache := r.cache.GetReport(&key)
+	if errCache.Error != "" {
+		log.Print("unable to read report cache: ", errCache.Error)
+	}
+	if cached != nil {
+		var report types.Report
+		if err := jsonutil.UnmarshalJSON(cached, &report); err == nil {
+			return report, 200, types.Err{}
+		}
+	}
+
+	now := r.now()
+	report, statusCode, errResp := r.compute(params)
+	if errResp.Error != "" {
+		return types.Report{}, statusCode, errResp
+	}
+	report.Name, report.From, report.To = params.Name, params.From, params.To
+	report.GeneratedAt = now.UTC().Format(time.RFC3339)
+
+	expiration := closedExpiration
+	if params.To >= now.Format(dateLayout) {
+		expiration = openExpiration
+	}
+	if encoded, err := jsonutil.MarshalJSON(report); err == nil {
+		if errCache := r.cache.SaveReport(&key, encoded, expiration); errCache.Error != "" {
+			log.Print("unable to write report cache: ", errCache.Error)
+		}
+	}
+
+	return report, 200, types.Err{}
+}
+
+// cacheKey identifies a report by all of its parameters.
+func cacheKey(params *types.ReportParams) string {
+	return "report:" + params.Name + ":" + params.From + ":" + params.To + ":" + params.Interval + ":" +
+		strconv.Itoa(params.Limit)
+}
+
+// compute runs the report in the store and lays the result out as a table.
+func (r *Reporter) compute(params *types.ReportParams) (types.Report, int, types.Err) {
+	var report types.Report
+	switch params.Name {
+	case types.ReportLoans:
+		periods, statusCode, errResp := r.store.ReportLoans(params)
+		if errResp.Error != "" {
+			return types.Report{}, statusCode, errResp
+		}
+		report.Columns = []string{"period", "loans"}
+		for _, period := range periods {
+			report.Rows = append(report.Rows, []interface{}{period.Period, period.Loans})
+		}
+	case types.ReportTopTitles:
+		titles, statusCode, errResp := r.store.ReportTopTitles(params)
+		if errResp.Error != "" {
+			return types.Report{}, statusCode, errResp
+		}
+		report.Columns = []string{"book_id", "title", "author", "loans"}
+		for _, title := range titles {
+			report.Rows = append(report.Rows, []interface{}{title.BookId, title.Title, title.Author, title.Loans})
+		}
+	case types.ReportTopAuthors:
+		authors, statusCode, errResp := r.store.ReportTopAuthors(params)
+		if errResp.Error != "" {
+			return types.Report{}, statusCode, errResp
+		}
+		report.Columns = []string{"author_id", "name", "loans"}
+		for _, author := range authors {
+			report.Rows = append(report.Rows, []interface{}{author.AuthorId, author.Name, author.Loans})
+		}
+	case types.ReportLoanDuration:
+		duration, statusCode, errResp := r.store.ReportLoanDuration(params)
+		if errResp.Error != "" {
+			return types.Report{}, statusCode, errResp
+		}
+		report.Columns = []string{"returned", "average_days", "median_days"}
+		report.Rows = [][]interface{}{{duration.Returned, duration.AverageDays, duration.MedianDays}}
+	case types.ReportOnTime:
+		rate, statusCode, errResp := r.store.ReportOnTime(params)
+		if errResp.Error != "" {
+			return types.Report{}, statusCode, errResp
+		}
+		report.Columns = []string{"due", "on_time", "returned_late", "overdue", "on_time_rate"}
+		report.Rows = [][]interface{}{{rate.Due, rate.OnTime, rate.ReturnedLate, rate.Overdue, OnTimeRate(rate)}}
+	case types.ReportEmployees:
+		handlers, statusCode, errResp := r.store.ReportEmployees(params)
+		if errResp.Error != "" {
+			return types.Report{}, statusCode, errResp
+		}
+		report.Columns = []string{"id", "name", "loans"}
+		for _, handler := range handlers {
+			report.Rows = append(report.Rows, []interface{}{handler.Id, handler.Name, handler.Loans})
+		}
+	case types.ReportIdleBooks:
+		books, statusCode, errResp := r.store.ReportIdleBooks(params)
+		if errResp.Error != "" {
+			return types.Report{}, statusCode, errResp
+		}
+		report.Columns = []string{"book_id", "title", "author", "barcode", "created_at", "last_loaned_at"}
+		for _, book := range books {
+			report.Rows = append(report.Rows, []interface{}{book.BookId, book.Title, book.Author, book.Barcode,
+				book.CreatedAt, book.LastLoanedAt})
+		}
+	default:
+		return types.Report{}, 404, types.Err{Error: ErrUnknownReport.Error()}
+	}
+
+	// an empty report is a table without rows rather than null
+	if report.Rows == nil {
+		report.Rows = [][]interface{}{}
+	}
+
+	return report, 200, types.Err{}
+}
+
+// OnTimeRate is the share of the due loans that were returned on time, rounded to 4 decimals.
+// It is 0 when no loan was due.
+func OnTimeRate(rate types.ReturnRate) float64 {
+	if rate.Due == 0 {
+		return 0
+	}
+
+	return math.Round(float64(rate.OnTime)/float64(rate.Due)*10000) / 10000
+}
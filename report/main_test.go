@@ -0,0 +1,128 @@
+package report
+
+import (
+	"github.com/Tus1688/library-management-api/types"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseParams(t *testing.T) {
+	now := time.Date(2024, 5, 3, 15, 4, 5, 0, time.UTC)
+
+	params, err := ParseParams(types.ReportLoans, url.Values{}, now)
+	if err != nil {
+		t.Fatalf("ParseParams returned an error: %v", err)
+	}
+	want := types.ReportParams{Name: types.ReportLoans, From: "2024-04-04", To: "2024-05-03", Interval: "day"}
+	if params != want {
+		t.Errorf("ParseParams defaults = %+v, want %+v", params, want)
+	}
+
+	params, err = ParseParams(types.ReportTopTitles,
+		url.Values{"from": {"2024-01-01"}, "to": {"2024-03-31"}, "limit": {"25"}, "interval": {"month"}}, now)
+	if err != nil {
+		t.Fatalf("ParseParams returned an error: %v", err)
+	}
+	want = types.ReportParams{Name: types.ReportTopTitles, From: "2024-01-01", To: "2024-03-31", Limit: 25}
+	if params != want {
+		t.Errorf("ParseParams = %+v, want %+v", params, want)
+	}
+
+	tests := []struct {
+		name  string
+		query url.Values
+		want  error
+	}{
+		{"busiest-hour", url.Values{}, ErrUnknownReport},
+		{types.ReportLoans, url.Values{"interval": {"year"}}, ErrInvalidInterval},
+		{types.ReportIdleBooks, url.Values{"limit": {"0"}}, ErrInvalidLimit},
+		{types.ReportIdleBooks, url.Values{"limit": {"5000"}}, ErrInvalidLimit},
+		{types.ReportOnTime, url.Values{"from": {"03/05/2024"}}, ErrInvalidDate},
+		{types.ReportEmployees, url.Values{"from": {"2024-05-04"}}, ErrInvalidRange},
+		{types.ReportLoans, url.Values{"from": {"0001-01-01"}, "to": {"9999-12-31"}}, ErrRangeTooLong},
+		{types.ReportLoans, url.Values{"from": {"2023-05-03"}}, ErrRangeTooLong},
+		{types.ReportLoans, url.Values{"from": {"2023-05-04"}}, nil},
+		{types.ReportLoans, url.Values{"from": {"2019-05-13"}, "interval": {"week"}}, nil},
+		{types.ReportLoans, url.Values{"from": {"2019-05-12"}, "interval": {"week"}}, ErrRangeTooLong},
+		{types.ReportLoans, url.Values{"from": {"2014-06-30"}, "interval": {"month"}}, nil},
+		{types.ReportLoans, url.Values{"from": {"2014-05-31"}, "interval": {"month"}}, ErrRangeTooLong},
+		{types.ReportOnTime, url.Values{"from": {"2000-01-01"}}, nil},
+	}
+	for _, test := range tests {
+		if _, err := ParseParams(test.name, test.query, now); err != test.want {
+			t.Errorf("ParseParams(%s, %v) = %v, want %v", test.name, test.query, err, test.want)
+		}
+	}
+}
+
+type fakeStore struct {
+	Store
+	calls int
+}
+
+func (f *fakeStore) ReportTopTitles(params *types.ReportParams) ([]types.TitleLoans, int, types.Err) {
+	f.calls++
+	return []types.TitleLoans{{BookId: "b1", Title: "Dune", Author: "Frank Herbert", Loans: 12}}, 200, types.Err{}
+}
+
+func (f *fakeStore) ReportEmployees(params *types.ReportParams) ([]types.HandlerLoans, int, types.Err) {
+	return nil, 200, types.Err{}
+}
+
+type fakeCache struct {
+	reports     map[string][]byte
+	expirations map[string]time.Duration
+}
+
+func (f *fakeCache) GetReport(key *string) ([]byte, types.Err) {
+	return f.reports[*key], types.Err{}
+}
+
+func (f *fakeCache) SaveReport(key *string, result []byte, expiration time.Duration) types.Err {
+	f.reports[*key], f.expirations[*key] = result, expiration
+	return types.Err{}
+}
+
+func TestReporterCaches(t *testing.T) {
+	store := &fakeStore{}
+	cache := &fakeCache{reports: map[string][]byte{}, expirations: map[string]time.Duration{}}
+	reporter := NewReporter(store, cache)
+	reporter.now = func() time.Time { return time.Date(2024, 5, 3, 12, 0, 0, 0, time.Local) }
+
+	past := types.ReportParams{Name: types.ReportTopTitles, From: "2024-01-01", To: "2024-03-31", Limit: 10}
+	for i := 0; i < 2; i++ {
+		report, _, errResp := reporter.Run(&past)
+		if errResp.Error != "" {
+			t.Fatalf("Run returned an error: %v", errResp)
+		}
+		if report.Name != types.ReportTopTitles || len(report.Columns) != 4 || len(report.Rows) != 1 ||
+			report.Rows[0][1] != "Dune" {
+			t.Errorf("Run = %+v", report)
+		}
+	}
+	if store.calls != 1 {
+		t.Errorf("the store computed the report %d times, want once", store.calls)
+	}
+	if expiration := cache.expirations[cacheKey(&past)]; expiration != closedExpiration {
+		t.Errorf("a past range is cached for %s, want %s", expiration, closedExpiration)
+	}
+
+	current := types.ReportParams{Name: types.ReportEmployees, From: "2024-04-04", To: "2024-05-03"}
+	report, _, _ := reporter.Run(&current)
+	if report.Rows == nil || len(report.Rows) != 0 {
+		t.Errorf("an empty report has rows %v, want an empty table", report.Rows)
+	}
+	if expiration := cache.expirations[cacheKey(&current)]; expiration != openExpiration {
+		t.Errorf("a range covering today is cached for %s, want %s", expiration, openExpiration)
+	}
+}
+
+func TestOnTimeRate(t *testing.T) {
+	if rate := OnTimeRate(types.ReturnRate{}); rate != 0 {
+		t.Errorf("OnTimeRate without due loans = %v, want 0", rate)
+	}
+	if rate := OnTimeRate(types.ReturnRate{Due: 3, OnTime: 2, Overdue: 1}); rate != 0.6667 {
+		t.Errorf("OnTimeRate = %v, want 0.6667", rate)
+	}
+}
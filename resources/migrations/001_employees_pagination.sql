@@ -0,0 +1,2 @@
+-- employees are listed with cursor pagination like books and bookings
+ALTER TABLE employees ADD COLUMN pagination_id BIGSERIAL;
@@ -0,0 +1,26 @@
+-- updated_at is maintained by the database and books / employees carry a version
+-- used for ETag / If-Match optimistic concurrency
+ALTER TABLE books ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE employees ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE FUNCTION touch_row() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION touch_versioned_row() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_employees_touch BEFORE UPDATE ON employees
+    FOR EACH ROW EXECUTE FUNCTION touch_versioned_row();
+CREATE TRIGGER trg_books_touch BEFORE UPDATE ON books
+    FOR EACH ROW EXECUTE FUNCTION touch_versioned_row();
+CREATE TRIGGER trg_bookings_touch BEFORE UPDATE ON bookings
+    FOR EACH ROW EXECUTE FUNCTION touch_row();
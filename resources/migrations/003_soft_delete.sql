@@ -0,0 +1,10 @@
+-- books and employees are soft-deleted so bookings referencing them stay intact;
+-- titles and usernames only have to be unique among rows that are not deleted
+ALTER TABLE books ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE employees ADD COLUMN deleted_at TIMESTAMP;
+
+ALTER TABLE books DROP CONSTRAINT books_title_key;
+ALTER TABLE employees DROP CONSTRAINT employees_username_key;
+
+CREATE UNIQUE INDEX uq_books_title ON books(title) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX uq_employees_username ON employees(username) WHERE deleted_at IS NULL;
@@ -0,0 +1,17 @@
+-- bibliographic metadata; different editions may share a title, so uniqueness moves to the isbn
+ALTER TABLE books
+    ADD COLUMN isbn TEXT,
+    ADD COLUMN publisher TEXT NOT NULL DEFAULT '',
+    ADD COLUMN publication_year INTEGER,
+    ADD COLUMN edition TEXT NOT NULL DEFAULT '',
+    ADD COLUMN language TEXT NOT NULL DEFAULT '',
+    ADD COLUMN page_count INTEGER,
+    ADD COLUMN subjects TEXT[] NOT NULL DEFAULT '{}';
+
+DROP INDEX uq_books_title;
+CREATE INDEX idx_books_title ON books(title);
+CREATE UNIQUE INDEX uq_books_isbn ON books(isbn) WHERE deleted_at IS NULL;
+CREATE INDEX idx_books_publisher ON books(publisher);
+CREATE INDEX idx_books_language ON books(language);
+CREATE INDEX idx_books_publication_year ON books(publication_year);
+CREATE INDEX idx_books_subjects ON books USING GIN(subjects);
@@ -0,0 +1,57 @@
+-- authors become their own entity, books.author stays as the display byline
+CREATE TABLE authors(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    name TEXT NOT NULL,
+    sort_name TEXT NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX idx_authors_name ON authors(lower(name));
+CREATE INDEX idx_authors_sort_name ON authors(sort_name);
+CREATE INDEX idx_authors_aliases ON authors USING GIN(aliases);
+
+CREATE TABLE book_authors(
+    book_id UUID NOT NULL,
+    author_id UUID NOT NULL,
+    role TEXT NOT NULL DEFAULT 'author' CHECK (role IN ('author', 'editor', 'translator')),
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (book_id, author_id, role),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES authors(id)
+);
+
+CREATE INDEX idx_book_authors_author_id ON book_authors(author_id);
+
+CREATE TRIGGER trg_authors_touch BEFORE UPDATE ON authors
+    FOR EACH ROW EXECUTE FUNCTION touch_versioned_row();
+
+-- split the existing bylines the same way bookutil.SplitAuthors and bookutil.ParseAuthorName do:
+-- names are separated by ';', '&' or 'and', and "Last, First" is turned into "First Last"
+CREATE TEMPORARY TABLE byline_authors AS
+SELECT book_id, position,
+    CASE WHEN part LIKE '%,%' AND trim(split_part(part, ',', 2)) <> ''
+        THEN trim(split_part(part, ',', 2)) || ' ' || trim(split_part(part, ',', 1))
+        ELSE trim(split_part(part, ',', 1)) END AS name,
+    CASE WHEN part LIKE '%,%' AND trim(split_part(part, ',', 2)) <> ''
+        THEN trim(split_part(part, ',', 1)) || ', ' || trim(split_part(part, ',', 2))
+        ELSE regexp_replace(trim(split_part(part, ',', 1)), '^(.*\S)\s+(\S+)$', '\2, \1') END AS sort_name
+FROM (
+    SELECT b.id AS book_id, t.position - 1 AS position, regexp_replace(trim(t.part), '\s+', ' ', 'g') AS part
+    FROM books b,
+        regexp_split_to_table(b.author, '\s*;\s*|\s*&\s*|\s+and\s+', 'i') WITH ORDINALITY AS t(part, position)
+) parts
+WHERE part <> '';
+
+INSERT INTO authors(name, sort_name)
+SELECT DISTINCT ON (lower(name)) name, sort_name FROM byline_authors ORDER BY lower(name), name;
+
+INSERT INTO book_authors(book_id, author_id, role, position)
+SELECT DISTINCT ON (ba.book_id, a.id) ba.book_id, a.id, 'author', ba.position
+FROM byline_authors ba INNER JOIN authors a ON lower(a.name) = lower(ba.name)
+ORDER BY ba.book_id, a.id, ba.position;
+
+DROP TABLE byline_authors;
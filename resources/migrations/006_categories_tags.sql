@@ -0,0 +1,46 @@
+-- hierarchical categories (e.g. Dewey classes) and free-form tags
+CREATE TABLE categories(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    parent_id UUID,
+    code TEXT NOT NULL DEFAULT '',
+    name TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (parent_id) REFERENCES categories(id)
+);
+
+CREATE UNIQUE INDEX uq_categories_name
+    ON categories(COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'), lower(name));
+CREATE INDEX idx_categories_parent_id ON categories(parent_id);
+
+CREATE TABLE book_categories(
+    book_id UUID NOT NULL,
+    category_id UUID NOT NULL,
+    PRIMARY KEY (book_id, category_id),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_book_categories_category_id ON book_categories(category_id);
+
+CREATE TABLE tags(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE book_tags(
+    book_id UUID NOT NULL,
+    tag_id UUID NOT NULL,
+    PRIMARY KEY (book_id, tag_id),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_book_tags_tag_id ON book_tags(tag_id);
+
+CREATE TRIGGER trg_categories_touch BEFORE UPDATE ON categories
+    FOR EACH ROW EXECUTE FUNCTION touch_versioned_row();
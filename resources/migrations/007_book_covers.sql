@@ -0,0 +1,2 @@
+-- key of the cover images in the blob store, "<book id>/<content hash>"
+ALTER TABLE books ADD COLUMN cover_key TEXT;
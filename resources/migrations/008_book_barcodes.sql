@@ -0,0 +1,15 @@
+-- item barcode printed on the book label and scanned at the desk, numbered from a sequence unless one is given
+CREATE SEQUENCE book_barcode_seq;
+
+ALTER TABLE books ADD COLUMN barcode TEXT;
+UPDATE books SET barcode = numbered.barcode FROM (
+    SELECT id, 'B' || lpad(nextval('book_barcode_seq')::TEXT, 9, '0') AS barcode
+    FROM (SELECT id FROM books ORDER BY pagination_id) ordered
+) numbered WHERE books.id = numbered.id;
+
+ALTER TABLE books
+    ALTER COLUMN barcode SET DEFAULT ('B' || lpad(nextval('book_barcode_seq')::TEXT, 9, '0')),
+    ALTER COLUMN barcode SET NOT NULL;
+
+-- deleted books keep their barcode so a stray label never resolves to another book
+CREATE UNIQUE INDEX uq_books_barcode ON books(barcode);
@@ -0,0 +1,27 @@
+-- patrons carry a library card whose number is scanned at the desk, numbered from a sequence unless one is given
+CREATE SEQUENCE patron_card_seq;
+
+CREATE TABLE patrons(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    card_number TEXT NOT NULL DEFAULT ('P' || lpad(nextval('patron_card_seq')::TEXT, 9, '0')),
+    name TEXT NOT NULL,
+    phone TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP
+);
+
+-- like item barcodes, card numbers of deleted patrons are never handed out again
+CREATE UNIQUE INDEX uq_patrons_card_number ON patrons(card_number);
+CREATE INDEX idx_patrons_name ON patrons(lower(name));
+
+CREATE TRIGGER trg_patrons_touch BEFORE UPDATE ON patrons
+    FOR EACH ROW EXECUTE FUNCTION touch_versioned_row();
+
+-- bookings made by scanning a card point at the patron, older ones only have the customer columns
+ALTER TABLE bookings ADD COLUMN patron_id UUID REFERENCES patrons(id);
+
+CREATE INDEX idx_bookings_patron_id ON bookings(patron_id);
+CREATE INDEX idx_bookings_open_book_id ON bookings(book_id) WHERE is_returned = FALSE;
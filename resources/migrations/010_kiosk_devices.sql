@@ -0,0 +1,27 @@
+-- unattended self-checkout kiosks, a device is paired once with a short-lived code and then keeps a
+-- long-lived token; only hashes of the code and the token are stored
+CREATE TABLE kiosk_devices(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    name TEXT NOT NULL,
+    token_hash BYTEA,
+    pairing_code_hash BYTEA,
+    pairing_expires_at TIMESTAMP,
+    last_seen_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    created_by UUID,
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES employees(id)
+);
+
+CREATE UNIQUE INDEX uq_kiosk_devices_token_hash ON kiosk_devices(token_hash);
+CREATE UNIQUE INDEX uq_kiosk_devices_pairing_code_hash ON kiosk_devices(pairing_code_hash);
+
+-- patrons identify themselves at a kiosk with their card and a PIN, repeated wrong PINs lock the card for a while
+ALTER TABLE patrons
+    ADD COLUMN pin_hash BYTEA,
+    ADD COLUMN pin_failures INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN pin_locked_until TIMESTAMP;
+
+-- bookings made at a kiosk are attributed to the device, so updated_by refers to an employee or a kiosk device
+ALTER TABLE bookings DROP CONSTRAINT bookings_updated_by_fkey;
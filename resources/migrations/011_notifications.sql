@@ -0,0 +1,28 @@
+-- borrowers are emailed about their due dates, an email address is optional for patrons and manual bookings
+ALTER TABLE patrons ADD COLUMN email TEXT NOT NULL DEFAULT '';
+ALTER TABLE bookings ADD COLUMN customer_email TEXT NOT NULL DEFAULT '';
+
+-- one row per message the scheduler decided to send; the unique key makes a second scheduler run, or a second
+-- instance, skip messages that were already sent or are being sent
+CREATE TABLE notifications(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    booking_id UUID NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('reminder', 'due', 'overdue')),
+    sequence INTEGER NOT NULL DEFAULT 0,
+    channel TEXT NOT NULL DEFAULT 'email',
+    recipient TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (booking_id) REFERENCES bookings(id)
+);
+
+CREATE UNIQUE INDEX uq_notifications_booking ON notifications(booking_id, kind, sequence, channel);
+CREATE INDEX idx_notifications_status ON notifications(status);
+
+CREATE TRIGGER trg_notifications_touch BEFORE UPDATE ON notifications
+    FOR EACH ROW EXECUTE FUNCTION touch_row();
@@ -0,0 +1,2 @@
+-- notifications can also go out by SMS to the booking's phone number, patrons can opt out of them
+ALTER TABLE patrons ADD COLUMN sms_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,14 @@
+-- one row per run of a background job, whichever instance won the lock for it
+CREATE TABLE job_runs(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    job TEXT NOT NULL,
+    instance TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_job_runs_job ON job_runs(job);
+CREATE INDEX idx_job_runs_started_at ON job_runs(started_at);
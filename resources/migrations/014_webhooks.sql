@@ -0,0 +1,44 @@
+-- webhook subscriptions managed by admins; the secret signs every payload, so it is kept as is
+CREATE TABLE webhooks(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES employees(id)
+);
+
+-- one row per event and subscription; failed deliveries are retried with a growing delay and end up 'dead'
+-- (the dead letters) once they run out of attempts
+CREATE TABLE webhook_deliveries(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    webhook_id UUID NOT NULL,
+    event_id UUID NOT NULL,
+    event TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    response_status INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id)
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id);
+CREATE INDEX idx_webhook_deliveries_status ON webhook_deliveries(status);
+
+CREATE TRIGGER trg_webhooks_touch BEFORE UPDATE ON webhooks
+    FOR EACH ROW EXECUTE FUNCTION touch_versioned_row();
+CREATE TRIGGER trg_webhook_deliveries_touch BEFORE UPDATE ON webhook_deliveries
+    FOR EACH ROW EXECUTE FUNCTION touch_row();
@@ -0,0 +1,25 @@
+-- domain events are written here in the transaction of the change they report, and relayed to the sinks from there;
+-- the position orders them, writers serialize on an advisory lock so positions are also committed in order
+CREATE TABLE outbox(
+    position BIGSERIAL PRIMARY KEY,
+    id UUID NOT NULL DEFAULT gen_random_uuid(),
+    type TEXT NOT NULL,
+    data JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_outbox_created_at ON outbox(created_at);
+
+-- how far every sink has got; moving a cursor back replays the events after it
+CREATE TABLE outbox_cursors(
+    sink TEXT PRIMARY KEY,
+    position BIGINT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- the webhook sink may see an event twice, its deliveries are created once
+CREATE UNIQUE INDEX uq_webhook_deliveries_event ON webhook_deliveries(webhook_id, event_id);
+
+CREATE TRIGGER trg_outbox_cursors_touch BEFORE UPDATE ON outbox_cursors
+    FOR EACH ROW EXECUTE FUNCTION touch_row();
@@ -0,0 +1,38 @@
+-- a renewal moves the due date of a loan, so it is stored instead of derived from created_at
+ALTER TABLE bookings ADD COLUMN due_at TIMESTAMP;
+UPDATE bookings SET due_at = created_at + INTERVAL '7 days';
+ALTER TABLE bookings ALTER COLUMN due_at SET NOT NULL,
+    ALTER COLUMN due_at SET DEFAULT NOW() + INTERVAL '7 days';
+ALTER TABLE bookings ADD COLUMN renewals INTEGER NOT NULL DEFAULT 0;
+
+-- patrons queue for books on loan; the first waiting hold becomes ready when the book is returned and is kept
+-- for the patron until expires_at, a patron holds a book at most once at a time
+CREATE TABLE holds(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    book_id UUID NOT NULL,
+    patron_id UUID NOT NULL,
+    status TEXT NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'ready', 'fulfilled', 'cancelled', 'expired')),
+    ready_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (book_id) REFERENCES books(id),
+    FOREIGN KEY (patron_id) REFERENCES patrons(id)
+);
+
+CREATE UNIQUE INDEX uq_holds_active ON holds(book_id, patron_id) WHERE status IN ('waiting', 'ready');
+CREATE INDEX idx_holds_patron_id ON holds(patron_id);
+CREATE INDEX idx_holds_ready ON holds(expires_at) WHERE status = 'ready';
+
+CREATE TRIGGER trg_holds_touch BEFORE UPDATE ON holds
+    FOR EACH ROW EXECUTE FUNCTION touch_row();
+
+-- patrons are told when a held book is ready for pickup, such a notification belongs to the hold
+ALTER TABLE notifications ALTER COLUMN booking_id DROP NOT NULL,
+    ADD COLUMN hold_id UUID REFERENCES holds(id),
+    ADD CONSTRAINT notifications_subject_check CHECK (booking_id IS NOT NULL OR hold_id IS NOT NULL),
+    DROP CONSTRAINT notifications_kind_check,
+    ADD CONSTRAINT notifications_kind_check CHECK (kind IN ('reminder', 'due', 'overdue', 'hold_available'));
+
+CREATE UNIQUE INDEX uq_notifications_hold ON notifications(hold_id, kind, sequence, channel) WHERE hold_id IS NOT NULL;
@@ -0,0 +1,12 @@
+-- writers no longer serialize on an advisory lock: an event is inserted without a position, and the relay numbers
+-- the events of the transactions older than every running one, so a position is never committed behind the relay;
+-- seq keeps the order of the events within a transaction
+ALTER TABLE outbox DROP CONSTRAINT outbox_pkey,
+    ADD PRIMARY KEY (id),
+    ALTER COLUMN position DROP DEFAULT,
+    ALTER COLUMN position DROP NOT NULL,
+    ADD COLUMN xid XID8 NOT NULL DEFAULT pg_current_xact_id(),
+    ADD COLUMN seq BIGSERIAL;
+
+CREATE UNIQUE INDEX uq_outbox_position ON outbox(position);
+CREATE INDEX idx_outbox_unsequenced ON outbox(xid, seq) WHERE position IS NULL;
@@ -0,0 +1,6 @@
+-- a claimed notification is leased: one still pending after the lease was left behind by a stopped instance,
+-- it is marked failed so it is tried again or shows up as failed
+ALTER TABLE notifications ADD COLUMN claimed_at TIMESTAMP;
+UPDATE notifications SET claimed_at = updated_at WHERE status = 'pending';
+
+CREATE INDEX idx_notifications_claimed_at ON notifications(claimed_at) WHERE status = 'pending';
@@ -0,0 +1,2 @@
+-- the loan term is set by the application for every new loan, a default here could drift from it
+ALTER TABLE bookings ALTER COLUMN due_at DROP DEFAULT;
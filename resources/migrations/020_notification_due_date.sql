@@ -0,0 +1,9 @@
+-- a renewal moves the due date of a loan, the notices of a loan are kept apart per due date so the reminder and
+-- due notice are sent again for the new one
+ALTER TABLE notifications ADD COLUMN due_date DATE;
+UPDATE notifications n SET due_date = bo.due_at::DATE FROM bookings bo WHERE n.booking_id = bo.id;
+ALTER TABLE notifications ADD CONSTRAINT notifications_due_date_check CHECK (booking_id IS NULL OR due_date IS NOT NULL);
+
+DROP INDEX uq_notifications_booking;
+CREATE UNIQUE INDEX uq_notifications_booking ON notifications(booking_id, due_date, kind, sequence, channel)
+    WHERE booking_id IS NOT NULL;
@@ -0,0 +1,37 @@
+-- authors were matched by their exact name, so "J.K. Rowling" and "Rowling, J. K." became two authors and two bylines
+-- naming a new author at once could create it twice. name_key ignores case, punctuation and spacing, authors sharing
+-- one are merged into the oldest of them before it is made unique
+ALTER TABLE authors ADD COLUMN name_key TEXT NOT NULL
+    GENERATED ALWAYS AS (trim(regexp_replace(lower(name), '[^[:alnum:]]+', ' ', 'g'))) STORED;
+
+CREATE TEMPORARY TABLE author_merges AS
+SELECT a.id AS duplicate_id, keep.id AS author_id, a.name, a.aliases
+FROM authors a
+INNER JOIN LATERAL (
+    SELECT k.id FROM authors k WHERE k.name_key = a.name_key ORDER BY k.pagination_id LIMIT 1
+) keep ON keep.id <> a.id;
+
+INSERT INTO book_authors(book_id, author_id, role, position)
+SELECT ba.book_id, m.author_id, ba.role, ba.position
+FROM book_authors ba INNER JOIN author_merges m ON ba.author_id = m.duplicate_id
+ON CONFLICT DO NOTHING;
+
+DELETE FROM book_authors WHERE author_id IN (SELECT duplicate_id FROM author_merges);
+
+-- the names and aliases of the merged authors stay searchable as aliases of the one that is kept
+UPDATE authors a SET aliases = merged.aliases
+FROM (
+    SELECT m.author_id, array_agg(DISTINCT alias ORDER BY alias) AS aliases
+    FROM author_merges m
+    INNER JOIN authors k ON k.id = m.author_id,
+        unnest(k.aliases || m.aliases || m.name) AS alias
+    WHERE lower(alias) <> lower(k.name)
+    GROUP BY m.author_id
+) merged
+WHERE a.id = merged.author_id;
+
+DELETE FROM authors WHERE id IN (SELECT duplicate_id FROM author_merges);
+
+DROP TABLE author_merges;
+
+CREATE UNIQUE INDEX uq_authors_name_key ON authors(name_key);
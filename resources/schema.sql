@@ -1,39 +1,343 @@
 CREATE TABLE employees(
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    username TEXT NOT NULL UNIQUE,
+    pagination_id BIGSERIAL,
+    username TEXT NOT NULL,
     password BYTEA NOT NULL,
     created_at TIMESTAMP DEFAULT NOW(),
-    updated_at TIMESTAMP DEFAULT NOW()
+    updated_at TIMESTAMP DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP
 );
 
+CREATE UNIQUE INDEX uq_employees_username ON employees(username) WHERE deleted_at IS NULL;
+
+CREATE SEQUENCE book_barcode_seq;
+
 CREATE TABLE books(
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     pagination_id BIGSERIAL,
-    title TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL,
     author TEXT NOT NULL,
     description TEXT NOT NULL,
+    isbn TEXT,
+    publisher TEXT NOT NULL DEFAULT '',
+    publication_year INTEGER,
+    edition TEXT NOT NULL DEFAULT '',
+    language TEXT NOT NULL DEFAULT '',
+    page_count INTEGER,
+    subjects TEXT[] NOT NULL DEFAULT '{}',
+    cover_key TEXT,
+    barcode TEXT NOT NULL DEFAULT ('B' || lpad(nextval('book_barcode_seq')::TEXT, 9, '0')),
     is_booked BOOLEAN DEFAULT FALSE,
     booked_until TIMESTAMP,
     created_at TIMESTAMP DEFAULT NOW(),
-    updated_at TIMESTAMP DEFAULT NOW()
+    updated_at TIMESTAMP DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP
 );
 
+CREATE INDEX idx_books_title ON books(title);
+CREATE UNIQUE INDEX uq_books_isbn ON books(isbn) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX uq_books_barcode ON books(barcode);
+CREATE INDEX idx_books_publisher ON books(publisher);
+CREATE INDEX idx_books_language ON books(language);
+CREATE INDEX idx_books_publication_year ON books(publication_year);
+CREATE INDEX idx_books_subjects ON books USING GIN(subjects);
 CREATE INDEX idx_books_is_booked ON books(is_booked);
 CREATE INDEX idx_books_booked_until ON books(booked_until);
 
+CREATE TABLE authors(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    name TEXT NOT NULL,
+    sort_name TEXT NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1,
+    name_key TEXT NOT NULL GENERATED ALWAYS AS (trim(regexp_replace(lower(name), '[^[:alnum:]]+', ' ', 'g'))) STORED
+);
+
+CREATE INDEX idx_authors_name ON authors(lower(name));
+CREATE UNIQUE INDEX uq_authors_name_key ON authors(name_key);
+CREATE INDEX idx_authors_sort_name ON authors(sort_name);
+CREATE INDEX idx_authors_aliases ON authors USING GIN(aliases);
+
+CREATE TABLE book_authors(
+    book_id UUID NOT NULL,
+    author_id UUID NOT NULL,
+    role TEXT NOT NULL DEFAULT 'author' CHECK (role IN ('author', 'editor', 'translator')),
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (book_id, author_id, role),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES authors(id)
+);
+
+CREATE INDEX idx_book_authors_author_id ON book_authors(author_id);
+
+CREATE TABLE categories(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    parent_id UUID,
+    code TEXT NOT NULL DEFAULT '',
+    name TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (parent_id) REFERENCES categories(id)
+);
+
+CREATE UNIQUE INDEX uq_categories_name
+    ON categories(COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'), lower(name));
+CREATE INDEX idx_categories_parent_id ON categories(parent_id);
+
+CREATE TABLE book_categories(
+    book_id UUID NOT NULL,
+    category_id UUID NOT NULL,
+    PRIMARY KEY (book_id, category_id),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_book_categories_category_id ON book_categories(category_id);
+
+CREATE TABLE tags(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE book_tags(
+    book_id UUID NOT NULL,
+    tag_id UUID NOT NULL,
+    PRIMARY KEY (book_id, tag_id),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_book_tags_tag_id ON book_tags(tag_id);
+
+CREATE SEQUENCE patron_card_seq;
+
+CREATE TABLE patrons(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    card_number TEXT NOT NULL DEFAULT ('P' || lpad(nextval('patron_card_seq')::TEXT, 9, '0')),
+    name TEXT NOT NULL,
+    phone TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    sms_opt_out BOOLEAN NOT NULL DEFAULT FALSE,
+    pin_hash BYTEA,
+    pin_failures INTEGER NOT NULL DEFAULT 0,
+    pin_locked_until TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uq_patrons_card_number ON patrons(card_number);
+CREATE INDEX idx_patrons_name ON patrons(lower(name));
+
+CREATE TABLE kiosk_devices(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    name TEXT NOT NULL,
+    token_hash BYTEA,
+    pairing_code_hash BYTEA,
+    pairing_expires_at TIMESTAMP,
+    last_seen_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    created_by UUID,
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES employees(id)
+);
+
+CREATE UNIQUE INDEX uq_kiosk_devices_token_hash ON kiosk_devices(token_hash);
+CREATE UNIQUE INDEX uq_kiosk_devices_pairing_code_hash ON kiosk_devices(pairing_code_hash);
+
 CREATE TABLE bookings(
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     pagination_id BIGSERIAL,
     book_id UUID NOT NULL,
     customer_name TEXT NOT NULL,
     customer_phone TEXT NOT NULL,
+    customer_email TEXT NOT NULL DEFAULT '',
     is_returned BOOLEAN DEFAULT FALSE,
     returned_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT NOW(),
     updated_at TIMESTAMP DEFAULT NOW(),
     updated_by UUID,
+    patron_id UUID,
+    due_at TIMESTAMP NOT NULL,
+    renewals INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (book_id) REFERENCES books(id),
+    FOREIGN KEY (patron_id) REFERENCES patrons(id)
+);
+
+CREATE INDEX idx_bookings_is_returned ON bookings(is_returned);
+CREATE INDEX idx_bookings_patron_id ON bookings(patron_id);
+CREATE INDEX idx_bookings_open_book_id ON bookings(book_id) WHERE is_returned = FALSE;
+
+CREATE TABLE holds(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    book_id UUID NOT NULL,
+    patron_id UUID NOT NULL,
+    status TEXT NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'ready', 'fulfilled', 'cancelled', 'expired')),
+    ready_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
     FOREIGN KEY (book_id) REFERENCES books(id),
-    FOREIGN KEY (updated_by) REFERENCES employees(id)
+    FOREIGN KEY (patron_id) REFERENCES patrons(id)
+);
+
+CREATE UNIQUE INDEX uq_holds_active ON holds(book_id, patron_id) WHERE status IN ('waiting', 'ready');
+CREATE INDEX idx_holds_patron_id ON holds(patron_id);
+CREATE INDEX idx_holds_ready ON holds(expires_at) WHERE status = 'ready';
+
+CREATE TABLE notifications(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    booking_id UUID,
+    hold_id UUID,
+    kind TEXT NOT NULL CHECK (kind IN ('reminder', 'due', 'overdue', 'hold_available')),
+    sequence INTEGER NOT NULL DEFAULT 0,
+    channel TEXT NOT NULL DEFAULT 'email',
+    recipient TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    sent_at TIMESTAMP,
+    claimed_at TIMESTAMP,
+    due_date DATE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (booking_id) REFERENCES bookings(id),
+    FOREIGN KEY (hold_id) REFERENCES holds(id),
+    CHECK (booking_id IS NOT NULL OR hold_id IS NOT NULL),
+    CHECK (booking_id IS NULL OR due_date IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX uq_notifications_booking ON notifications(booking_id, due_date, kind, sequence, channel)
+    WHERE booking_id IS NOT NULL;
+CREATE UNIQUE INDEX uq_notifications_hold ON notifications(hold_id, kind, sequence, channel) WHERE hold_id IS NOT NULL;
+CREATE INDEX idx_notifications_status ON notifications(status);
+CREATE INDEX idx_notifications_claimed_at ON notifications(claimed_at) WHERE status = 'pending';
+
+CREATE TABLE job_runs(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    job TEXT NOT NULL,
+    instance TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_job_runs_job ON job_runs(job);
+CREATE INDEX idx_job_runs_started_at ON job_runs(started_at);
+
+CREATE TABLE webhooks(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES employees(id)
+);
+
+CREATE TABLE webhook_deliveries(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pagination_id BIGSERIAL,
+    webhook_id UUID NOT NULL,
+    event_id UUID NOT NULL,
+    event TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    response_status INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id)
 );
 
-CREATE INDEX idx_bookings_is_returned ON bookings(is_returned);
\ No newline at end of file
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id);
+CREATE INDEX idx_webhook_deliveries_status ON webhook_deliveries(status);
+CREATE UNIQUE INDEX uq_webhook_deliveries_event ON webhook_deliveries(webhook_id, event_id);
+
+CREATE SEQUENCE outbox_position_seq;
+
+CREATE TABLE outbox(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    position BIGINT,
+    xid XID8 NOT NULL DEFAULT pg_current_xact_id(),
+    seq BIGSERIAL,
+    type TEXT NOT NULL,
+    data JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER SEQUENCE outbox_position_seq OWNED BY outbox.position;
+
+CREATE INDEX idx_outbox_created_at ON outbox(created_at);
+CREATE UNIQUE INDEX uq_outbox_position ON outbox(position);
+CREATE INDEX idx_outbox_unsequenced ON outbox(xid, seq) WHERE position IS NULL;
+
+CREATE TABLE outbox_cursors(
+    sink TEXT PRIMARY KEY,
+    position BIGINT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE FUNCTION touch_row() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION touch_versioned_row() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_employees_touch BEFORE UPDATE ON employees
+    FOR EACH ROW EXECUTE FUNCTION touch_versioned_row();
+CREATE TRIGGER trg_books_touch BEFORE UPDATE ON books
+    FOR EACH ROW EXECUTE FUNCTION touch_versioned_row();
+CREATE TRIGGER trg_authors_touch BEFORE UPDATE ON authors
+    FOR EACH ROW EXECUTE FUNCTION touch_versioned_row();
+CREATE TRIGGER trg_categories_touch BEFORE UPDATE ON categories
+    FOR EACH ROW EXECUTE FUNCTION touch_versioned_row();
+CREATE TRIGGER trg_patrons_touch BEFORE UPDATE ON patrons
+    FOR EACH ROW EXECUTE FUNCTION touch_versioned_row();
+CREATE TRIGGER trg_bookings_touch BEFORE UPDATE ON bookings
+    FOR EACH ROW EXECUTE FUNCTION touch_row();
+CREATE TRIGGER trg_holds_touch BEFORE UPDATE ON holds
+    FOR EACH ROW EXECUTE FUNCTION touch_row();
+CREATE TRIGGER trg_notifications_touch BEFORE UPDATE ON notifications
+    FOR EACH ROW EXECUTE FUNCTION touch_row();
+CREATE TRIGGER trg_webhooks_touch BEFORE UPDATE ON webhooks
+    FOR EACH ROW EXECUTE FUNCTION touch_versioned_row();
+CREATE TRIGGER trg_webhook_deliveries_touch BEFORE UPDATE ON webhook_deliveries
+    FOR EACH ROW EXECUTE FUNCTION touch_row();
+CREATE TRIGGER trg_outbox_cursors_touch BEFORE UPDATE ON outbox_cursors
+    FOR EACH ROW EXECUTE FUNCTION touch_row();
@@ -20,8 +20,8 @@ func (s *PostgresStore) InitAdmin(username, password *string) error {
 	if err != nil {
 		return err
 	}
-	_, err = s.db.Exec(`INSERT INTO employees(username, password) VALUES ($1, $2) ON CONFLICT (username) DO
-	UPDATE SET password = $2`, *username, string(hashedPassword))
+	_, err = s.db.Exec(`INSERT INTO employees(username, password) VALUES ($1, $2)
+	ON CONFLICT (username) WHERE deleted_at IS NULL DO UPDATE SET password = $2`, *username, string(hashedPassword))
 	if err != nil {
 		return err
 	}
@@ -37,7 +37,7 @@ func (s *PostgresStore) InitAdmin(username, password *string) error {
 // Returns the user ID, status code, and an error if the operation fails.
 func (s *PostgresStore) Login(req *types.LoginRequest) (string, int, types.Err) {
 	var hashedPassword, userId string
-	err := s.db.QueryRow(`SELECT id, password FROM employees WHERE username = $1`, req.Username).
+	err := s.db.QueryRow(`SELECT id, password FROM employees WHERE username = $1 AND deleted_at IS NULL`, req.Username).
 		Scan(&userId, &hashedPassword)
 	if err != nil {
 		// random sleep to simulate query / bcrypt time
@@ -61,3 +61,19 @@ func (s *PostgresStore) Login(req *types.LoginRequest) (string, int, types.Err)
 
 	return userId, 200, types.Err{}
 }
+
+// IsEmployeeActive reports whether the employee exists and has not been deleted.
+// It is used to stop deleted employees from refreshing their session.
+// Parameters:
+// - id: a pointer to the employee ID
+// Returns whether the employee is active, status code, and an error if the operation fails.
+func (s *PostgresStore) IsEmployeeActive(id *string) (bool, int, types.Err) {
+	var active bool
+	err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM employees WHERE id = $1 AND deleted_at IS NULL)`, *id).
+		Scan(&active)
+	if err != nil {
+		return false, 500, types.Err{Error: "unable to check employee"}
+	}
+
+	return active, 200, types.Err{}
+}
@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Tus1688/library-management-api/bookutil"
+	"github.com/Tus1688/library-management-api/pageutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/lib/pq"
+	"strings"
+)
+
+// authorColumns are the columns scanned by scanAuthor, in order, the authors table must be aliased as a.
+const authorColumns = `a.id, a.pagination_id, a.name, a.sort_name, a.aliases,
+	(SELECT COUNT(DISTINCT ba.book_id) FROM book_authors ba INNER JOIN books b ON ba.book_id = b.id
+	WHERE ba.author_id = a.id AND b.deleted_at IS NULL), a.created_at, a.updated_at, a.version`
+
+// authorFields returns the scan destinations matching authorColumns.
+func authorFields(author *types.ListAuthor) []interface{} {
+	return []interface{}{&author.Id, &author.PaginationId, &author.Name, &author.SortName, pq.Array(&author.Aliases),
+		&author.BookCount, &author.CreatedAt, &author.UpdatedAt, &author.Version}
+}
+
+// scanAuthor scans a row selected with authorColumns.
+func scanAuthor(rows *sql.Rows) (types.ListAuthor, error) {
+	var author types.ListAuthor
+	err := rows.Scan(authorFields(&author)...)
+	return author, err
+}
+
+// GetAuthor retrieves a page of authors, optionally filtered by a search on their name, sort name or aliases.
+// An empty result is not an error, it yields a page with no items.
+// Parameters:
+// - filter: a pointer to the AuthorFilter containing the search query, cursor and page size
+// Returns a page of ListAuthor, status code, and an error if the operation fails.
+func (s *PostgresStore) GetAuthor(filter *types.AuthorFilter) (types.Page[types.ListAuthor], int, types.Err) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Search != "" {
+		search := placeholder(&args, filter.Search)
+		conditions = append(conditions, `(a.name ILIKE '%' || `+search+` || '%'
+		OR a.sort_name ILIKE '%' || `+search+` || '%'
+		OR EXISTS (SELECT 1 FROM unnest(a.aliases) alias WHERE alias ILIKE '%' || `+search+` || '%'))`)
+	}
+
+	total, err := s.countRows("authors a", whereClause(conditions), args)
+	if err != nil {
+		return types.Page[types.ListAuthor]{}, 500, types.Err{Error: "unable to get authors"}
+	}
+
+	if filter.Cursor != 0 {
+		conditions = append(conditions, `a.pagination_id < `+placeholder(&args, filter.Cursor))
+	}
+
+	query := `SELECT ` + authorColumns + ` FROM authors a` + whereClause(conditions) +
+		` ORDER BY a.pagination_id DESC LIMIT ` + placeholder(&args, filter.Limit+1)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return types.Page[types.ListAuthor]{}, 500, types.Err{Error: "unable to get authors"}
+	}
+	defer rows.Close()
+
+	var authors []types.ListAuthor
+	for rows.Next() {
+		author, err := scanAuthor(rows)
+		if err != nil {
+			return types.Page[types.ListAuthor]{}, 500, types.Err{Error: "unable to get authors"}
+		}
+		authors = append(authors, author)
+	}
+
+	return pageutil.NewPage(authors, filter.Limit, total, func(a types.ListAuthor) int64 {
+		return int64(a.PaginationId)
+	}), 200, types.Err{}
+}
+
+// GetAuthorById retrieves a single author together with the number of books it is credited on.
+// Parameters:
+// - id: a pointer to the author ID
+// Returns the ListAuthor, status code, and an error if the operation fails.
+func (s *PostgresStore) GetAuthorById(id *string) (types.ListAuthor, int, types.Err) {
+	var author types.ListAuthor
+	err := s.db.QueryRow(`SELECT `+authorColumns+` FROM authors a WHERE a.id = $1`, *id).
+		Scan(authorFields(&author)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.ListAuthor{}, 404, types.Err{Error: "author not found"}
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return types.ListAuthor{}, 400, types.Err{Error: "invalid id"}
+		}
+
+		return types.ListAuthor{}, 500, types.Err{Error: "unable to get author"}
+	}
+
+	return author, 200, types.Err{}
+}
+
+// CreateAuthor inserts a new author.
+// Names are unique regardless of case, punctuation and spacing, so bylines always find the author they name.
+// People sharing a name are told apart in it, such as "John Smith (1950)".
+// If an author with that name already exists, it returns a 409 status code.
+// Parameters:
+// - req: a pointer to the normalized CreateAuthor request
+// Returns the created author ID, status code, and an error if the operation fails.
+func (s *PostgresStore) CreateAuthor(req *types.CreateAuthor) (types.CreateId, int, types.Err) {
+	var id types.CreateId
+	err := s.db.QueryRow(`INSERT INTO authors(name, sort_name, aliases) VALUES ($1, $2, $3) RETURNING id`,
+		req.Name, req.SortName, pq.Array(req.Aliases)).Scan(&id.Id)
+	if err != nil {
+		if violates(err, "uq_authors_name_key") {
+			return types.CreateId{}, 409, types.Err{Error: "author with that name already exists"}
+		}
+
+		return types.CreateId{}, 500, types.Err{Error: "unable to create author"}
+	}
+
+	return id, 201, types.Err{}
+}
+
+// UpdateAuthor replaces the name, sort name and aliases of an author.
+// If another author already has the new name, it returns a 409 status code.
+// If version is not 0 the update only succeeds when it matches the stored version.
+// Parameters:
+// - req: a pointer to the normalized UpdateAuthor request
+// - version: the version the client based its changes on, 0 to skip the check
+// Returns the new version, status code, and an error if the operation fails.
+func (s *PostgresStore) UpdateAuthor(req *types.UpdateAuthor, version int) (int, int, types.Err) {
+	args := []interface{}{req.Name, req.SortName, pq.Array(req.Aliases), req.Id}
+	query := `UPDATE authors SET name = $1, sort_name = $2, aliases = $3 WHERE id = $4`
+	if version != 0 {
+		query += ` AND version = ` + placeholder(&args, version)
+	}
+	query += ` RETURNING version`
+
+	var newVersion int
+	err := s.db.QueryRow(query, args...).Scan(&newVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			var exists bool
+			err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM authors WHERE id = $1)`, req.Id).Scan(&exists)
+			if err == nil && exists {
+				return 0, 412, types.Err{Error: "author has been modified"}
+			}
+
+			return 0, 404, types.Err{Error: "author not found"}
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return 0, 400, types.Err{Error: "invalid id"}
+		}
+
+		if violates(err, "uq_authors_name_key") {
+			return 0, 409, types.Err{Error: "author with that name already exists"}
+		}
+
+		return 0, 500, types.Err{Error: "unable to update author"}
+	}
+
+	return newVersion, 200, types.Err{}
+}
+
+// DeleteAuthor deletes an author that is no longer credited on any book.
+// If the author is still linked to a book, including soft-deleted ones, it returns a 409 status code.
+// Parameters:
+// - id: a pointer to the author ID to be deleted
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) DeleteAuthor(id *string) (int, types.Err) {
+	res, err := s.db.Exec(`DELETE FROM authors WHERE id = $1`, *id)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return 400, types.Err{Error: "invalid id"}
+		}
+
+		if strings.Contains(err.Error(), "foreign key") {
+			return 409, types.Err{Error: "author is still credited on books"}
+		}
+
+		return 500, types.Err{Error: "unable to delete author"}
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 500, types.Err{Error: "unable to delete author"}
+	}
+
+	if rowsAffected == 0 {
+		return 404, types.Err{Error: "author not found"}
+	}
+
+	return 200, types.Err{}
+}
+
+// getBookAuthors retrieves the authors credited on a book, in credit order.
+// Parameters:
+// - bookId: the book ID
+// Returns the authors, never nil, and an error if the query fails.
+func (s *PostgresStore) getBookAuthors(bookId string) ([]types.BookAuthor, error) {
+	rows, err := s.db.Query(`SELECT a.id, a.name, a.sort_name, ba.role FROM book_authors ba
+	INNER JOIN authors a ON ba.author_id = a.id WHERE ba.book_id = $1 ORDER BY ba.position, a.sort_name`, bookId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	authors := []types.BookAuthor{}
+	for rows.Next() {
+		var author types.BookAuthor
+		if err := rows.Scan(&author.Id, &author.Name, &author.SortName, &author.Role); err != nil {
+			return nil, err
+		}
+		authors = append(authors, author)
+	}
+
+	return authors, rows.Err()
+}
+
+// linkBookAuthors sets the authors credited on a book within the transaction that writes the book.
+// With explicit links every existing link is replaced. Without them only the links with the author role are
+// replaced by the names of the byline, matched against the name keys of the authors (ignoring case, punctuation and
+// spacing) and case-insensitively against their aliases, and created when unknown, so editors and translators survive
+// a byline change.
+// Parameters:
+// - tx: the transaction writing the book
+// - bookId: the book ID
+// - byline: the author column of the book
+// - authors: the normalized explicit links, empty to derive them from the byline
+// Returns an error if a link cannot be written, see linkFailure.
+func linkBookAuthors(tx *sql.Tx, bookId, byline string, authors []types.BookAuthorRef) error {
+	if len(authors) > 0 {
+		if _, err := tx.Exec(`DELETE FROM book_authors WHERE book_id = $1`, bookId); err != nil {
+			return err
+		}
+
+		for i, author := range authors {
+			_, err := tx.Exec(`INSERT INTO book_authors(book_id, author_id, role, position) VALUES ($1, $2, $3, $4)`,
+				bookId, author.Id, author.Role, i)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM book_authors WHERE book_id = $1 AND role = $2`,
+		bookId, types.RoleAuthor); err != nil {
+		return err
+	}
+
+	for i, raw := range bookutil.SplitAuthors(byline) {
+		name, sortName := bookutil.ParseAuthorName(raw)
+
+		var authorId string
+		err := tx.QueryRow(`WITH found AS (
+			SELECT id FROM authors WHERE name_key = trim(regexp_replace(lower($1), '[^[:alnum:]]+', ' ', 'g'))
+			OR EXISTS (SELECT 1 FROM unnest(aliases) alias WHERE lower(alias) = lower($1))
+			ORDER BY pagination_id LIMIT 1
+		), created AS (
+			INSERT INTO authors(name, sort_name) SELECT $1, $2 WHERE NOT EXISTS (SELECT 1 FROM found)
+			ON CONFLICT (name_key) DO NOTHING RETURNING id
+		)
+		SELECT id FROM found UNION ALL SELECT id FROM created`, name, sortName).Scan(&authorId)
+		if errors.Is(err, sql.ErrNoRows) {
+			// another byline created the author after this statement started, it is committed by now
+			err = tx.QueryRow(`SELECT id FROM authors
+			WHERE name_key = trim(regexp_replace(lower($1), '[^[:alnum:]]+', ' ', 'g'))`, name).Scan(&authorId)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT INTO book_authors(book_id, author_id, role, position) VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING`, bookId, authorId, types.RoleAuthor, i)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// linkFailure maps an error returned by linkBookAuthors or classifyBook to a response.
+// Parameters:
+// - err: the error returned while linking
+// - message: the message used for unexpected errors
+// Returns the status code and the error response.
+func linkFailure(err error, message string) (int, types.Err) {
+	if strings.Contains(err.Error(), "uuid") {
+		return 400, types.Err{Error: "invalid id"}
+	}
+
+	if strings.Contains(err.Error(), "foreign key") {
+		if strings.Contains(err.Error(), "book_categories") {
+			return 404, types.Err{Error: "category not found"}
+		}
+
+		return 404, types.Err{Error: "author not found"}
+	}
+
+	return 500, types.Err{Error: message}
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"testing"
+)
+
+func TestLinkBookAuthorsMatchesNameVariants(t *testing.T) {
+	store := newTestStore(t)
+
+	bookIds := make([]string, 2)
+	for i := range bookIds {
+		err := store.db.QueryRow(`INSERT INTO books(title, author, description) VALUES ('Author Test', '', '')
+		RETURNING id`).Scan(&bookIds[i])
+		if err != nil {
+			t.Fatalf("unable to create book: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		store.db.Exec(`DELETE FROM book_authors WHERE book_id = $1 OR book_id = $2`, bookIds[0], bookIds[1])
+		store.db.Exec(`DELETE FROM books WHERE id = $1 OR id = $2`, bookIds[0], bookIds[1])
+		store.db.Exec(`DELETE FROM authors WHERE name_key = 'q x tester'`)
+	})
+
+	for i, byline := range []string{"Q.X. Tester", "Tester, Q. X."} {
+		tx, err := store.db.Begin()
+		if err != nil {
+			t.Fatalf("unable to begin: %v", err)
+		}
+		if err := linkBookAuthors(tx, bookIds[i], byline, nil); err != nil {
+			tx.Rollback()
+			t.Fatalf("linkBookAuthors(%q) returned an error: %v", byline, err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("unable to commit: %v", err)
+		}
+	}
+
+	var authors int
+	err := store.db.QueryRow(`SELECT COUNT(DISTINCT author_id) FROM book_authors WHERE book_id = $1 OR book_id = $2`,
+		bookIds[0], bookIds[1]).Scan(&authors)
+	if err != nil {
+		t.Fatalf("unable to read book_authors: %v", err)
+	}
+	if authors != 1 {
+		t.Errorf("both bylines linked %d authors, want 1", authors)
+	}
+}
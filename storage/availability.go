@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"database/sql"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/lib/pq"
+	"strings"
+)
+
+// GetAvailability retrieves whether books are on loan or kept for a patron, and how many patrons wait for them.
+// Deleted and unknown books are left out.
+// Parameters:
+// - ids: the book IDs
+// Returns the Availability of every book found, without the estimate, status code, and an error if the operation fails.
+func (s *PostgresStore) GetAvailability(ids []string) ([]types.Availability, int, types.Err) {
+	rows, err := s.db.Query(`SELECT b.id, COALESCE(b.is_booked, FALSE), b.booked_until,
+	EXISTS (SELECT 1 FROM holds h WHERE h.book_id = b.id AND h.status = 'ready'),
+	(SELECT COUNT(*) FROM holds h WHERE h.book_id = b.id AND h.status = 'waiting')
+	FROM books b WHERE b.id = ANY($1::UUID[]) AND b.deleted_at IS NULL`, pq.Array(ids))
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return nil, 400, types.Err{Error: "invalid id"}
+		}
+
+		return nil, 500, types.Err{Error: "unable to get availability"}
+	}
+	defer rows.Close()
+
+	var availability []types.Availability
+	for rows.Next() {
+		var isBooked, isHeld bool
+		var dueAt sql.NullString
+		a := types.Availability{Copies: 1}
+		if err := rows.Scan(&a.BookId, &isBooked, &dueAt, &isHeld, &a.QueueLength); err != nil {
+			return nil, 500, types.Err{Error: "unable to get availability"}
+		}
+
+		switch {
+		case isBooked:
+			a.Status, a.DueAt = types.AvailabilityOnLoan, dueAt.String
+		case isHeld:
+			a.Status = types.AvailabilityOnHold
+		default:
+			a.Status, a.AvailableCopies = types.AvailabilityAvailable, 1
+		}
+		availability = append(availability, a)
+	}
+
+	if rows.Err() != nil {
+		return nil, 500, types.Err{Error: "unable to get availability"}
+	}
+
+	return availability, 200, types.Err{}
+}
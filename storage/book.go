@@ -1,106 +1,291 @@
 package storage
 
 import (
+	"database/sql"
+	"errors"
+	"github.com/Tus1688/library-management-api/bookutil"
+	"github.com/Tus1688/library-management-api/pageutil"
 	"github.com/Tus1688/library-management-api/types"
-	"strconv"
+	"github.com/lib/pq"
 	"strings"
 )
 
-// GetBook retrieves a list of books based on the search query, last ID, and limit.
-// It constructs a SQL query to fetch books from the database and returns the list of books.
+// bookColumns are the columns filled by bookFields, in order, the books table must be aliased as b.
+const bookColumns = `b.id, b.pagination_id, b.title, b.author, b.description, COALESCE(b.isbn, ''), b.publisher,
+	COALESCE(b.publication_year, 0), b.edition, b.language, COALESCE(b.page_count, 0), b.subjects,
+	ARRAY(SELECT t.name FROM book_tags bt INNER JOIN tags t ON bt.tag_id = t.id WHERE bt.book_id = b.id ORDER BY t.name),
+	b.barcode, COALESCE(b.cover_key, ''), b.is_booked,
+	COALESCE(b.booked_until::TEXT, ''), b.created_at, b.updated_at, b.version, COALESCE(b.deleted_at::TEXT, '')`
+
+// bookFields returns the scan destinations matching bookColumns.
+func bookFields(book *types.ListBook) []interface{} {
+	return []interface{}{&book.Id, &book.PaginationId, &book.Title, &book.Author, &book.Description, &book.Isbn,
+		&book.Publisher, &book.PublicationYear, &book.Edition, &book.Language, &book.PageCount,
+		pq.Array(&book.Subjects), pq.Array(&book.Tags), &book.Barcode, &book.CoverKey, &book.IsBooked, &book.BookedUntil, &book.CreatedAt, &book.UpdatedAt,
+		&book.Version, &book.DeletedAt}
+}
+
+// bookConditions translates the filter into WHERE conditions shared by every query that lists books.
+// The cursor is not part of it, as it does not apply to counting or exporting.
 // Parameters:
-// - searchQuery: a pointer to the search query string
-// - lastId: a pointer to the last ID for pagination
-// - limit: a pointer to the limit of books to retrieve
-// Returns a slice of ListBook, status code, and an error if the operation fails.
-func (s *PostgresStore) GetBook(searchQuery *string, lastId, limit *int) ([]types.ListBook, int, types.Err) {
-	query := `SELECT id, pagination_id, title, author, description, is_booked, COALESCE(booked_until::TEXT,''),
-	created_at, updated_at FROM books`
-	var args []interface{}
-	argsCount := 1
+// - filter: a pointer to the BookFilter
+// - args: a pointer to the query arguments the conditions refer to
+// Returns the conditions to pass to whereClause.
+func bookConditions(filter *types.BookFilter, args *[]interface{}) []string {
+	var conditions []string
 
-	if *searchQuery != "" || *lastId != 0 {
-		query += ` WHERE`
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, `b.deleted_at IS NULL`)
 	}
 
-	if *searchQuery != "" {
-		query += ` title ILIKE '%' ||` + ` $` + strconv.Itoa(argsCount) + ` || '%'`
-		args = append(args, *searchQuery)
-		argsCount++
+	if filter.Search != "" {
+		search := placeholder(args, filter.Search)
+		isbn, err := bookutil.NormalizeIsbn(filter.Search)
+		if err != nil {
+			isbn = filter.Search
+		}
+		conditions = append(conditions, `(b.title ILIKE '%' || `+search+` || '%'
+		OR b.author ILIKE '%' || `+search+` || '%'
+		OR b.publisher ILIKE '%' || `+search+` || '%'
+		OR b.isbn = `+placeholder(args, isbn)+`
+		OR b.barcode = upper(`+search+`)
+		OR EXISTS (SELECT 1 FROM unnest(b.subjects) subject WHERE subject ILIKE '%' || `+search+` || '%')
+		OR EXISTS (SELECT 1 FROM book_authors ba INNER JOIN authors a ON ba.author_id = a.id
+			WHERE ba.book_id = b.id AND (a.name ILIKE '%' || `+search+` || '%'
+			OR EXISTS (SELECT 1 FROM unnest(a.aliases) alias WHERE alias ILIKE '%' || `+search+` || '%'))))`)
 	}
 
-	if *lastId != 0 {
-		if argsCount > 1 {
-			query += ` AND`
+	if len(filter.Ids) > 0 {
+		conditions = append(conditions, `b.id = ANY(`+placeholder(args, pq.Array(filter.Ids))+`::UUID[])`)
+	}
+
+	if filter.AuthorId != "" {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM book_authors ba WHERE ba.book_id = b.id
+		AND ba.author_id = `+placeholder(args, filter.AuthorId)+`)`)
+	}
+
+	if filter.CategoryId != "" {
+		category := placeholder(args, filter.CategoryId)
+		if filter.Descendants {
+			conditions = append(conditions, `EXISTS (SELECT 1 FROM book_categories bc WHERE bc.book_id = b.id
+			AND bc.category_id IN (WITH RECURSIVE tree AS (
+				SELECT id FROM categories WHERE id = `+category+`
+				UNION SELECT c.id FROM categories c INNER JOIN tree ON c.parent_id = tree.id
+			) SELECT id FROM tree))`)
+		} else {
+			conditions = append(conditions, `EXISTS (SELECT 1 FROM book_categories bc WHERE bc.book_id = b.id
+			AND bc.category_id = `+category+`)`)
 		}
-		query += ` pagination_id < $` + strconv.Itoa(argsCount)
-		args = append(args, *lastId)
-		argsCount++
 	}
 
-	query += ` ORDER BY pagination_id DESC`
+	for _, tag := range filter.Tags {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM book_tags bt INNER JOIN tags t ON bt.tag_id = t.id
+		WHERE bt.book_id = b.id AND t.name = `+placeholder(args, tag)+`)`)
+	}
+
+	if filter.Isbn != "" {
+		conditions = append(conditions, `b.isbn = `+placeholder(args, filter.Isbn))
+	}
 
-	if *limit != 0 {
-		query += ` LIMIT $` + strconv.Itoa(argsCount)
-		args = append(args, *limit)
-		argsCount++
+	if filter.Publisher != "" {
+		conditions = append(conditions, `b.publisher ILIKE `+placeholder(args, filter.Publisher))
 	}
 
+	if filter.Language != "" {
+		conditions = append(conditions, `b.language = `+placeholder(args, filter.Language))
+	}
+
+	if filter.Subject != "" {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM unnest(b.subjects) subject
+		WHERE lower(subject) = lower(`+placeholder(args, filter.Subject)+`))`)
+	}
+
+	if filter.YearFrom != 0 {
+		conditions = append(conditions, `b.publication_year >= `+placeholder(args, filter.YearFrom))
+	}
+
+	if filter.YearTo != 0 {
+		conditions = append(conditions, `b.publication_year <= `+placeholder(args, filter.YearTo))
+	}
+
+	return conditions
+}
+
+// scanBook scans a row selected with bookColumns.
+func scanBook(rows *sql.Rows) (types.ListBook, error) {
+	var book types.ListBook
+	err := rows.Scan(bookFields(&book)...)
+	return book, err
+}
+
+// GetBook retrieves a page of books based on the search query, cursor, and limit.
+// It constructs a SQL query to fetch books from the database and wraps them in a page envelope.
+// An empty result is not an error, it yields a page with no items.
+// Parameters:
+// - filter: a pointer to the BookFilter containing the search query, cursor and page size
+// Returns a page of ListBook, status code, and an error if the operation fails.
+func (s *PostgresStore) GetBook(filter *types.BookFilter) (types.Page[types.ListBook], int, types.Err) {
+	var args []interface{}
+	conditions := bookConditions(filter, &args)
+
+	total, err := s.countRows("books b", whereClause(conditions), args)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return types.Page[types.ListBook]{}, 400, types.Err{Error: "invalid id"}
+		}
+
+		return types.Page[types.ListBook]{}, 500, types.Err{Error: "unable to get books"}
+	}
+
+	if filter.Cursor != 0 {
+		conditions = append(conditions, `b.pagination_id < `+placeholder(&args, filter.Cursor))
+	}
+
+	query := `SELECT ` + bookColumns + ` FROM books b` + whereClause(conditions) +
+		` ORDER BY b.pagination_id DESC LIMIT ` + placeholder(&args, filter.Limit+1)
+
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
-		return nil, 500, types.Err{Error: "unable to get books"}
+		return types.Page[types.ListBook]{}, 500, types.Err{Error: "unable to get books"}
 	}
 	defer rows.Close()
 
 	var books []types.ListBook
 	for rows.Next() {
-		var book types.ListBook
-		err := rows.Scan(&book.Id, &book.PaginationId, &book.Title, &book.Author, &book.Description,
-			&book.IsBooked, &book.BookedUntil, &book.CreatedAt, &book.UpdatedAt)
+		book, err := scanBook(rows)
 		if err != nil {
-			return nil, 500, types.Err{Error: "unable to get books"}
+			return types.Page[types.ListBook]{}, 500, types.Err{Error: "unable to get books"}
 		}
 		books = append(books, book)
 	}
 
-	if len(books) == 0 {
-		return nil, 404, types.Err{Error: "no books found"}
+	return pageutil.NewPage(books, filter.Limit, total, func(b types.ListBook) int64 {
+		return int64(b.PaginationId)
+	}), 200, types.Err{}
+}
+
+// GetBookById retrieves a single book together with its credited authors, categories and booking history counts.
+// If the book is currently booked, the open booking is embedded as well.
+// Parameters:
+// - id: a pointer to the book ID
+// - includeDeleted: whether a soft-deleted book should be returned instead of a 404
+// Returns the BookDetail, status code, and an error if the operation fails.
+func (s *PostgresStore) GetBookById(id *string, includeDeleted bool) (types.BookDetail, int, types.Err) {
+	var book types.BookDetail
+	err := s.db.QueryRow(`SELECT `+bookColumns+`,
+	(SELECT COUNT(*) FROM bookings bo WHERE bo.book_id = b.id),
+	(SELECT COUNT(*) FROM bookings bo WHERE bo.book_id = b.id AND bo.is_returned = TRUE)
+	FROM books b WHERE b.id = $1 AND ($2 OR b.deleted_at IS NULL)`, *id, includeDeleted).
+		Scan(append(bookFields(&book.ListBook), &book.BookingCount, &book.ReturnedCount)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.BookDetail{}, 404, types.Err{Error: "book not found"}
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return types.BookDetail{}, 400, types.Err{Error: "invalid id"}
+		}
+
+		return types.BookDetail{}, 500, types.Err{Error: "unable to get book"}
+	}
+
+	book.Authors, err = s.getBookAuthors(book.Id)
+	if err != nil {
+		return types.BookDetail{}, 500, types.Err{Error: "unable to get book"}
 	}
 
-	return books, 200, types.Err{}
+	book.Categories, err = s.getBookCategories(book.Id)
+	if err != nil {
+		return types.BookDetail{}, 500, types.Err{Error: "unable to get book"}
+	}
+
+	if !book.IsBooked {
+		return book, 200, types.Err{}
+	}
+
+	var current types.BookCurrentBooking
+	err = s.db.QueryRow(`SELECT id, customer_name, customer_phone, due_at, created_at
+	FROM bookings WHERE book_id = $1 AND is_returned = FALSE ORDER BY pagination_id DESC LIMIT 1`, *id).
+		Scan(&current.Id, &current.CustomerName, &current.CustomerPhone, &current.BookedUntil, &current.CreatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return types.BookDetail{}, 500, types.Err{Error: "unable to get book"}
+	}
+
+	if err == nil {
+		book.CurrentBooking = &current
+	}
+
+	return book, 200, types.Err{}
 }
 
 // CreateBook inserts a new book into the database based on the provided request.
+// The credited authors and the classification are written in the same transaction,
+// see linkBookAuthors and classifyBook.
 // It returns the ID of the created book, status code, and an error if the operation fails.
 // Parameters:
 // - req: a pointer to the CreateBook request containing the book details
 // Returns the created book ID, status code, and an error if the operation fails.
 func (s *PostgresStore) CreateBook(req *types.CreateBook) (types.CreateId, int, types.Err) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.CreateId{}, 500, types.Err{Error: "unable to create book"}
+	}
+
 	var id types.CreateId
-	err := s.db.QueryRow(`INSERT INTO books(title, author, description) VALUES ($1, $2, $3) RETURNING id`,
-		req.Title, req.Author, req.Description).Scan(&id.Id)
+	err = tx.QueryRow(`INSERT INTO books(title, author, description, isbn, publisher, publication_year, edition,
+	language, page_count, subjects) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+		req.Title, req.Author, req.Description, nullString(req.Isbn), req.Publisher, nullInt(req.PublicationYear),
+		req.Edition, req.Language, nullInt(req.PageCount), pq.Array(req.Subjects)).Scan(&id.Id)
 	if err != nil {
+		tx.Rollback()
 		if strings.Contains(err.Error(), "duplicate") {
-			return types.CreateId{}, 409, types.Err{Error: "book already exists"}
+			return types.CreateId{}, 409, types.Err{Error: "book with that isbn already exists"}
 		}
 
 		return types.CreateId{}, 500, types.Err{Error: "unable to create book"}
 	}
 
+	if err := linkBookAuthors(tx, id.Id, req.Author, req.Authors); err != nil {
+		tx.Rollback()
+		statusCode, errResp := linkFailure(err, "unable to create book")
+		return types.CreateId{}, statusCode, errResp
+	}
+
+	if err := classifyBook(tx, id.Id, req.CategoryIds, req.Tags); err != nil {
+		tx.Rollback()
+		statusCode, errResp := linkFailure(err, "unable to create book")
+		return types.CreateId{}, statusCode, errResp
+	}
+
+	if err := writeEvent(tx, types.EventBookCreated, types.EventRef{Id: id.Id}); err != nil {
+		tx.Rollback()
+		return types.CreateId{}, 500, types.Err{Error: "unable to create book"}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return types.CreateId{}, 500, types.Err{Error: "unable to create book"}
+	}
+
 	return id, 201, types.Err{}
 }
 
-// DeleteBook removes a book from the database based on the provided ID.
-// It returns the status code and an error if the operation fails.
+// DeleteBook soft-deletes a book by setting its deleted_at timestamp.
+// The row is kept so historical bookings stay intact, but it is hidden from lists and cannot be booked.
+// A book that is currently booked cannot be deleted until it is returned.
 // Parameters:
 // - id: a pointer to the book ID to be deleted
 // Returns the status code and an error if the operation fails.
 func (s *PostgresStore) DeleteBook(id *string) (int, types.Err) {
-	res, err := s.db.Exec(`DELETE FROM books WHERE id = $1`, *id)
+	tx, err := s.db.Begin()
 	if err != nil {
-		if strings.Contains(err.Error(), "foreign key constraint") {
-			return 409, types.Err{Error: "book is being used"}
-		}
+		return 500, types.Err{Error: "unable to delete book"}
+	}
+
+	res, err := tx.Exec(`UPDATE books SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL
+	AND is_booked = FALSE`, *id)
+	if err != nil {
+		tx.Rollback()
 		if strings.Contains(err.Error(), "uuid") {
 			return 400, types.Err{Error: "invalid id"}
 		}
@@ -110,44 +295,308 @@ func (s *PostgresStore) DeleteBook(id *string) (int, types.Err) {
 
 	rowsAffected, err := res.RowsAffected()
 	if err != nil {
+		tx.Rollback()
 		return 500, types.Err{Error: "unable to delete book"}
 	}
 
 	if rowsAffected == 0 {
+		tx.Rollback()
+		var isBooked bool
+		err := s.db.QueryRow(`SELECT is_booked FROM books WHERE id = $1 AND deleted_at IS NULL`, *id).Scan(&isBooked)
+		if err == nil && isBooked {
+			return 409, types.Err{Error: "book is currently booked"}
+		}
+
 		return 404, types.Err{Error: "book not found"}
 	}
 
+	if err := writeEvent(tx, types.EventBookDeleted, types.EventRef{Id: *id}); err != nil {
+		tx.Rollback()
+		return 500, types.Err{Error: "unable to delete book"}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return 500, types.Err{Error: "unable to delete book"}
+	}
+
 	return 200, types.Err{}
 }
 
-// UpdateBook updates the details of an existing book in the database based on the provided request.
-// It returns the status code and an error if the operation fails.
+// RestoreBook undoes a soft delete of a book.
+// If another active book has taken its ISBN in the meantime, it returns a 409 status code.
 // Parameters:
-// - req: a pointer to the UpdateBook request containing the updated book details
+// - id: a pointer to the book ID to be restored
 // Returns the status code and an error if the operation fails.
-func (s *PostgresStore) UpdateBook(req *types.UpdateBook) (int, types.Err) {
-	res, err := s.db.Exec(`UPDATE books SET title = $1, author = $2, description = $3 WHERE id = $4`,
-		req.Title, req.Author, req.Description, req.Id)
+func (s *PostgresStore) RestoreBook(id *string) (int, types.Err) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 500, types.Err{Error: "unable to restore book"}
+	}
+
+	res, err := tx.Exec(`UPDATE books SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, *id)
 	if err != nil {
+		tx.Rollback()
 		if strings.Contains(err.Error(), "uuid") {
 			return 400, types.Err{Error: "invalid id"}
 		}
 
 		if strings.Contains(err.Error(), "duplicate") {
-			return 409, types.Err{Error: "book with that name already exists"}
+			return 409, types.Err{Error: "book with that isbn already exists"}
 		}
 
-		return 500, types.Err{Error: "unable to update book"}
+		return 500, types.Err{Error: "unable to restore book"}
 	}
 
 	rowsAffected, err := res.RowsAffected()
 	if err != nil {
-		return 500, types.Err{Error: "unable to update book"}
+		tx.Rollback()
+		return 500, types.Err{Error: "unable to restore book"}
 	}
 
 	if rowsAffected == 0 {
-		return 404, types.Err{Error: "book not found"}
+		tx.Rollback()
+		return 404, types.Err{Error: "deleted book not found"}
+	}
+
+	// a restored book reappears in the catalog, receivers treat it like any other change
+	if err := writeEvent(tx, types.EventBookUpdated, types.EventRef{Id: *id}); err != nil {
+		tx.Rollback()
+		return 500, types.Err{Error: "unable to restore book"}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return 500, types.Err{Error: "unable to restore book"}
 	}
 
 	return 200, types.Err{}
 }
+
+// UpdateBook updates the details of an existing book in the database based on the provided request.
+// If version is not 0 the update only succeeds when it matches the stored version.
+// The credited authors and the classification are rewritten in the same transaction,
+// see linkBookAuthors and classifyBook.
+// It returns the new version, status code and an error if the operation fails.
+// Parameters:
+// - req: a pointer to the UpdateBook request containing the updated book details
+// - version: the version the client based its changes on, 0 to skip the check
+// Returns the new version, status code, and an error if the operation fails.
+func (s *PostgresStore) UpdateBook(req *types.UpdateBook, version int) (int, int, types.Err) {
+	args := []interface{}{req.Title, req.Author, req.Description, nullString(req.Isbn), req.Publisher,
+		nullInt(req.PublicationYear), req.Edition, req.Language, nullInt(req.PageCount), pq.Array(req.Subjects), req.Id}
+	query := `UPDATE books SET title = $1, author = $2, description = $3, isbn = $4, publisher = $5,
+	publication_year = $6, edition = $7, language = $8, page_count = $9, subjects = $10
+	WHERE id = $11 AND deleted_at IS NULL`
+	if version != 0 {
+		query += ` AND version = ` + placeholder(&args, version)
+	}
+	query += ` RETURNING version`
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 500, types.Err{Error: "unable to update book"}
+	}
+
+	var newVersion int
+	err = tx.QueryRow(query, args...).Scan(&newVersion)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			statusCode, errResp := s.preconditionFailure("books", "book", &req.Id)
+			return 0, statusCode, errResp
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return 0, 400, types.Err{Error: "invalid id"}
+		}
+
+		if strings.Contains(err.Error(), "duplicate") {
+			return 0, 409, types.Err{Error: "book with that isbn already exists"}
+		}
+
+		return 0, 500, types.Err{Error: "unable to update book"}
+	}
+
+	if err := linkBookAuthors(tx, req.Id, req.Author, req.Authors); err != nil {
+		tx.Rollback()
+		statusCode, errResp := linkFailure(err, "unable to update book")
+		return 0, statusCode, errResp
+	}
+
+	if err := classifyBook(tx, req.Id, req.CategoryIds, req.Tags); err != nil {
+		tx.Rollback()
+		statusCode, errResp := linkFailure(err, "unable to update book")
+		return 0, statusCode, errResp
+	}
+
+	if err := writeEvent(tx, types.EventBookUpdated, types.EventRef{Id: req.Id}); err != nil {
+		tx.Rollback()
+		return 0, 500, types.Err{Error: "unable to update book"}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return 0, 500, types.Err{Error: "unable to update book"}
+	}
+
+	return newVersion, 200, types.Err{}
+}
+
+// PatchBook applies a JSON merge patch to an existing book, only the fields present in the patch are updated.
+// Optional metadata set to null is cleared.
+// The credited authors are relinked when the patch contains the byline or the authors, setting the authors
+// to null derives them from the byline again. Categories or tags set to null are cleared.
+// If version is not 0 the update only succeeds when it matches the stored version,
+// otherwise a 412 status code is returned so the client can refetch and retry.
+// Parameters:
+// - id: a pointer to the book ID to be patched
+// - version: the version the client based its changes on, 0 to skip the check
+// - req: a pointer to the PatchBook request containing the fields to change
+// Returns the new version, status code, and an error if the operation fails.
+func (s *PostgresStore) PatchBook(id *string, version int, req *types.PatchBook) (int, int, types.Err) {
+	var sets []string
+	var args []interface{}
+
+	if req.Title.Set {
+		sets = append(sets, `title = `+placeholder(&args, req.Title.Value))
+	}
+
+	if req.Author.Set {
+		sets = append(sets, `author = `+placeholder(&args, req.Author.Value))
+	}
+
+	if req.Description.Set {
+		sets = append(sets, `description = `+placeholder(&args, req.Description.Value))
+	}
+
+	if req.Barcode.Set {
+		sets = append(sets, `barcode = `+placeholder(&args, req.Barcode.Value))
+	}
+
+	if req.Isbn.Set {
+		sets = append(sets, `isbn = `+placeholder(&args, nullString(req.Isbn.Value)))
+	}
+
+	if req.Publisher.Set {
+		sets = append(sets, `publisher = `+placeholder(&args, req.Publisher.Value))
+	}
+
+	if req.PublicationYear.Set {
+		sets = append(sets, `publication_year = `+placeholder(&args, nullInt(req.PublicationYear.Value)))
+	}
+
+	if req.Edition.Set {
+		sets = append(sets, `edition = `+placeholder(&args, req.Edition.Value))
+	}
+
+	if req.Language.Set {
+		sets = append(sets, `language = `+placeholder(&args, req.Language.Value))
+	}
+
+	if req.PageCount.Set {
+		sets = append(sets, `page_count = `+placeholder(&args, nullInt(req.PageCount.Value)))
+	}
+
+	if req.Subjects.Set {
+		sets = append(sets, `subjects = `+placeholder(&args, pq.Array(req.Subjects.Value)))
+	}
+
+	// a patch of the links alone still bumps the version, as it changes the book representation
+	if len(sets) == 0 && (req.Authors.Set || req.CategoryIds.Set || req.Tags.Set) {
+		sets = append(sets, `author = author`)
+	}
+
+	if len(sets) == 0 {
+		return 0, 400, types.Err{Error: "nothing to update"}
+	}
+
+	query := `UPDATE books SET ` + strings.Join(sets, `, `) + ` WHERE deleted_at IS NULL AND id = ` +
+		placeholder(&args, *id)
+	if version != 0 {
+		query += ` AND version = ` + placeholder(&args, version)
+	}
+	query += ` RETURNING version, author`
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 500, types.Err{Error: "unable to update book"}
+	}
+
+	var newVersion int
+	var byline string
+	err = tx.QueryRow(query, args...).Scan(&newVersion, &byline)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			statusCode, errResp := s.preconditionFailure("books", "book", id)
+			return 0, statusCode, errResp
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return 0, 400, types.Err{Error: "invalid id"}
+		}
+
+		if strings.Contains(err.Error(), "uq_books_barcode") {
+			return 0, 409, types.Err{Error: "book with that barcode already exists"}
+		}
+
+		if strings.Contains(err.Error(), "duplicate") {
+			return 0, 409, types.Err{Error: "book with that isbn already exists"}
+		}
+
+		return 0, 500, types.Err{Error: "unable to update book"}
+	}
+
+	if req.Author.Set || req.Authors.Set {
+		if err := linkBookAuthors(tx, *id, byline, req.Authors.Value); err != nil {
+			tx.Rollback()
+			statusCode, errResp := linkFailure(err, "unable to update book")
+			return 0, statusCode, errResp
+		}
+	}
+
+	if err := classifyBook(tx, *id, req.CategoryIds.Value, req.Tags.Value); err != nil {
+		tx.Rollback()
+		statusCode, errResp := linkFailure(err, "unable to update book")
+		return 0, statusCode, errResp
+	}
+
+	if err := writeEvent(tx, types.EventBookUpdated, types.EventRef{Id: *id}); err != nil {
+		tx.Rollback()
+		return 0, 500, types.Err{Error: "unable to update book"}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return 0, 500, types.Err{Error: "unable to update book"}
+	}
+
+	return newVersion, 200, types.Err{}
+}
+
+// SetBookCover stores the key of the cover images of a book, an empty key removes the cover.
+// The previous key is returned so the caller can delete the images it replaced.
+// Parameters:
+// - id: a pointer to the book ID
+// - coverKey: the key the cover images are stored under, see coverutil.Key
+// Returns the previous cover key, status code, and an error if the operation fails.
+func (s *PostgresStore) SetBookCover(id *string, coverKey string) (string, int, types.Err) {
+	var previous string
+	err := s.db.QueryRow(`UPDATE books b SET cover_key = $1 FROM books old
+	WHERE b.id = $2 AND old.id = b.id AND b.deleted_at IS NULL RETURNING COALESCE(old.cover_key, '')`,
+		nullString(coverKey), *id).Scan(&previous)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", 404, types.Err{Error: "book not found"}
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return "", 400, types.Err{Error: "invalid id"}
+		}
+
+		return "", 500, types.Err{Error: "unable to update book cover"}
+	}
+
+	return previous, 200, types.Err{}
+}
@@ -3,13 +3,13 @@ package storage
 import (
 	"database/sql"
 	"errors"
+	"github.com/Tus1688/library-management-api/pageutil"
 	"github.com/Tus1688/library-management-api/types"
-	"strconv"
 	"strings"
 )
 
 // CreateBooking creates a new booking for a book.
-// It checks if the book is already booked and inserts a new booking record if available.
+// It checks if the book is already booked or kept for a patron who held it, and inserts a new booking record if available.
 // Parameters:
 // - uid: a pointer to the user ID creating the booking
 // - req: a pointer to the CreateBooking request containing the booking details
@@ -20,7 +20,7 @@ func (s *PostgresStore) CreateBooking(uid *string, req *types.CreateBooking) (ty
 		return types.CreateId{}, 500, types.Err{Error: "unable to create booking"}
 	}
 	var isBooked bool
-	err = tx.QueryRow(`SELECT is_booked FROM books WHERE id = $1`, req.BookId).
+	err = tx.QueryRow(`SELECT is_booked FROM books WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, req.BookId).
 		Scan(&isBooked)
 	if err != nil {
 		tx.Rollback()
@@ -40,16 +40,34 @@ func (s *PostgresStore) CreateBooking(uid *string, req *types.CreateBooking) (ty
 		return types.CreateId{}, 409, types.Err{Error: "book is already booked"}
 	}
 
+	available, err := claimHold(tx, req.BookId, "")
+	if err != nil {
+		tx.Rollback()
+		return types.CreateId{}, 500, types.Err{Error: "unable to create booking"}
+	}
+
+	if !available {
+		tx.Rollback()
+		return types.CreateId{}, 409, types.Err{Error: "book is held for a patron"}
+	}
+
 	var bookingId types.CreateId
-	err = tx.QueryRow(`INSERT INTO bookings(book_id, customer_name, customer_phone, updated_by) VALUES ($1, $2, $3, $4) RETURNING id`,
-		req.BookId, req.CustomerName, req.CustomerPhone, uid).Scan(&bookingId.Id)
+	err = tx.QueryRow(`INSERT INTO bookings(book_id, customer_name, customer_phone, customer_email, updated_by, due_at)
+	VALUES ($1, $2, $3, $4, $5, NOW() + $6::INTERVAL) RETURNING id`,
+		req.BookId, req.CustomerName, req.CustomerPhone, req.CustomerEmail, uid, loanPeriod).Scan(&bookingId.Id)
 	if err != nil {
 		tx.Rollback()
 		return types.CreateId{}, 500, types.Err{Error: "unable to create booking"}
 	}
 
-	_, err = tx.Exec(`UPDATE books SET is_booked = TRUE, booked_until = NOW() + INTERVAL '7 days' WHERE id = $1`,
-		req.BookId)
+	_, err = tx.Exec(`UPDATE books SET is_booked = TRUE, booked_until = (SELECT due_at FROM bookings WHERE id = $2)
+	WHERE id = $1`, req.BookId, bookingId.Id)
+	if err != nil {
+		tx.Rollback()
+		return types.CreateId{}, 500, types.Err{Error: "unable to create booking"}
+	}
+
+	err = writeEvent(tx, types.EventBookingCreated, types.EventRef{Id: bookingId.Id, BookId: req.BookId})
 	if err != nil {
 		tx.Rollback()
 		return types.CreateId{}, 500, types.Err{Error: "unable to create booking"}
@@ -65,7 +83,7 @@ func (s *PostgresStore) CreateBooking(uid *string, req *types.CreateBooking) (ty
 }
 
 // ReturnBook returns a booked book to the library.
-// It updates the booking and book records to mark the book as returned.
+// It updates the booking and book records to mark the book as returned, and makes it ready for the first patron holding it.
 // Parameters:
 // - id: a pointer to the booking ID to be returned
 // Returns the status code and an error if the operation fails.
@@ -105,6 +123,15 @@ func (s *PostgresStore) ReturnBook(id *string) (int, types.Err) {
 	}
 
 	_, err = tx.Exec(`UPDATE books SET is_booked = FALSE, booked_until = NULL WHERE id = $1`, bookId)
+	if err == nil {
+		err = promoteHold(tx, bookId)
+	}
+	if err != nil {
+		tx.Rollback()
+		return 500, types.Err{Error: "unable to return book"}
+	}
+
+	err = writeEvent(tx, types.EventBookingReturned, types.EventRef{Id: *id, BookId: bookId})
 	if err != nil {
 		tx.Rollback()
 		return 500, types.Err{Error: "unable to return book"}
@@ -119,57 +146,116 @@ func (s *PostgresStore) ReturnBook(id *string) (int, types.Err) {
 	return 200, types.Err{}
 }
 
-// GetBooking retrieves a list of bookings based on the last ID and limit for pagination.
-// It constructs a SQL query to fetch bookings from the database and returns the list of bookings.
-// Parameters:
-// - lastId: a pointer to the last ID for pagination
-// - limit: a pointer to the limit of bookings to retrieve
-// Returns a slice of GetBooking, status code, and an error if the operation fails.
-func (s *PostgresStore) GetBooking(lastId, limit *int) ([]types.GetBooking, int, types.Err) {
-	query := `SELECT bo.id, bo.pagination_id, b.id, b.title, b.author, bo.customer_name, bo.customer_phone,
-	bo.created_at + INTERVAL '7 days', bo.created_at, bo.updated_at, e.username, COALESCE(bo.returned_at::TEXT, ''), bo.is_returned
-	FROM bookings bo
+// bookingColumns are the columns scanned by scanBooking, in order.
+const bookingColumns = `bo.id, bo.pagination_id, b.id, b.title, b.author, bo.customer_name, bo.customer_phone,
+	bo.customer_email, bo.due_at, bo.created_at, bo.updated_at,
+	COALESCE(e.username, 'kiosk: ' || k.name, ''), COALESCE(bo.returned_at::TEXT, ''), bo.is_returned`
+
+// bookingFrom joins every table bookingColumns refers to.
+// A booking is handled either by an employee or, for self-checkout, by a kiosk device.
+const bookingFrom = ` FROM bookings bo
 	INNER JOIN books b ON bo.book_id = b.id
-	INNER JOIN employees e ON bo.updated_by = e.id
-	`
+	LEFT JOIN employees e ON bo.updated_by = e.id
+	LEFT JOIN kiosk_devices k ON bo.updated_by = k.id`
+
+// scanBooking scans a row selected with bookingColumns.
+func scanBooking(rows *sql.Rows) (types.GetBooking, error) {
+	var booking types.GetBooking
+	err := rows.Scan(&booking.Id, &booking.PaginationId, &booking.BookId, &booking.BookTitle, &booking.BookAuthor,
+		&booking.CustomerName, &booking.CustomerPhone, &booking.CustomerEmail, &booking.BookedUntil, &booking.CreatedAt, &booking.UpdatedAt,
+		&booking.UpdatedBy, &booking.ReturnedAt, &booking.IsReturned)
+	return booking, err
+}
+
+// GetBooking retrieves a page of bookings based on the cursor and limit for pagination.
+// It constructs a SQL query to fetch bookings from the database and wraps them in a page envelope.
+// An empty result is not an error, it yields a page with no items.
+// Parameters:
+// - filter: a pointer to the BookingFilter containing the cursor and page size
+// Returns a page of GetBooking, status code, and an error if the operation fails.
+func (s *PostgresStore) GetBooking(filter *types.BookingFilter) (types.Page[types.GetBooking], int, types.Err) {
+	var conditions []string
 	var args []interface{}
-	argsCount := 1
 
-	if *lastId != 0 {
-		query += `WHERE bo.pagination_id < $1 `
-		args = append(args, lastId)
-		argsCount++
+	total, err := s.countRows("bookings bo", whereClause(conditions), args)
+	if err != nil {
+		return types.Page[types.GetBooking]{}, 500, types.Err{Error: "unable to get bookings"}
 	}
 
-	query += `ORDER BY bo.pagination_id DESC `
-
-	if *limit != 0 {
-		query += `LIMIT $` + strconv.Itoa(argsCount)
-		args = append(args, limit)
-		argsCount++
+	if filter.Cursor != 0 {
+		conditions = append(conditions, `bo.pagination_id < `+placeholder(&args, filter.Cursor))
 	}
 
+	query := `SELECT ` + bookingColumns + bookingFrom + whereClause(conditions) +
+		` ORDER BY bo.pagination_id DESC LIMIT ` + placeholder(&args, filter.Limit+1)
+
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
-		return nil, 500, types.Err{Error: "unable to get bookings"}
+		return types.Page[types.GetBooking]{}, 500, types.Err{Error: "unable to get bookings"}
 	}
 	defer rows.Close()
 
 	var bookings []types.GetBooking
 	for rows.Next() {
-		var booking types.GetBooking
-		err := rows.Scan(&booking.Id, &booking.PaginationId, &booking.BookId, &booking.BookTitle, &booking.BookAuthor,
-			&booking.CustomerName, &booking.CustomerPhone, &booking.BookedUntil, &booking.CreatedAt, &booking.UpdatedAt,
-			&booking.UpdatedBy, &booking.ReturnedAt, &booking.IsReturned)
+		booking, err := scanBooking(rows)
 		if err != nil {
-			return nil, 500, types.Err{Error: "unable to get bookings"}
+			return types.Page[types.GetBooking]{}, 500, types.Err{Error: "unable to get bookings"}
 		}
 		bookings = append(bookings, booking)
 	}
 
-	if len(bookings) == 0 {
-		return nil, 404, types.Err{Error: "no bookings found"}
+	return pageutil.NewPage(bookings, filter.Limit, total, func(b types.GetBooking) int64 {
+		return int64(b.PaginationId)
+	}), 200, types.Err{}
+}
+
+// GetBookingById retrieves a single booking together with the booked book and the employee or kiosk that handled it.
+// Parameters:
+// - id: a pointer to the booking ID
+// Returns the BookingDetail, status code, and an error if the operation fails.
+func (s *PostgresStore) GetBookingById(id *string) (types.BookingDetail, int, types.Err) {
+	var booking types.BookingDetail
+	var employee types.ListEmployee
+	var kiosk types.ListKiosk
+	var employeeId, employeeCreatedAt, employeeUpdatedAt, kioskId, kioskCreatedAt sql.NullString
+	fields := []interface{}{&booking.Id, &booking.PaginationId, &booking.CustomerName, &booking.CustomerPhone,
+		&booking.CustomerEmail, &booking.BookedUntil, &booking.IsReturned, &booking.CreatedAt, &booking.UpdatedAt, &booking.ReturnedAt}
+	fields = append(fields, bookFields(&booking.Book)...)
+	fields = append(fields, &employeeId, &employee.PaginationId, &employee.Username, &employeeCreatedAt,
+		&employeeUpdatedAt, &employee.Version)
+	fields = append(fields, &kioskId, &kiosk.PaginationId, &kiosk.Name, &kiosk.IsPaired, &kioskCreatedAt)
+
+	err := s.db.QueryRow(`SELECT bo.id, bo.pagination_id, bo.customer_name, bo.customer_phone, bo.customer_email,
+	bo.due_at, bo.is_returned, bo.created_at, bo.updated_at, COALESCE(bo.returned_at::TEXT, ''),
+	`+bookColumns+`,
+	e.id, COALESCE(e.pagination_id, 0), COALESCE(e.username, ''), e.created_at, e.updated_at, COALESCE(e.version, 0),
+	k.id, COALESCE(k.pagination_id, 0), COALESCE(k.name, ''), k.token_hash IS NOT NULL, k.created_at
+	FROM bookings bo
+	INNER JOIN books b ON bo.book_id = b.id
+	LEFT JOIN employees e ON bo.updated_by = e.id
+	LEFT JOIN kiosk_devices k ON bo.updated_by = k.id
+	WHERE bo.id = $1`, *id).Scan(fields...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.BookingDetail{}, 404, types.Err{Error: "booking not found"}
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return types.BookingDetail{}, 400, types.Err{Error: "invalid id"}
+		}
+
+		return types.BookingDetail{}, 500, types.Err{Error: "unable to get booking"}
+	}
+
+	if employeeId.Valid {
+		employee.Id, employee.CreatedAt, employee.UpdatedAt = employeeId.String, employeeCreatedAt.String,
+			employeeUpdatedAt.String
+		booking.Employee = &employee
+	}
+	if kioskId.Valid {
+		kiosk.Id, kiosk.CreatedAt = kioskId.String, kioskCreatedAt.String
+		booking.Kiosk = &kiosk
 	}
 
-	return bookings, 200, types.Err{}
+	return booking, 200, types.Err{}
 }
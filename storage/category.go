@@ -0,0 +1,404 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Tus1688/library-management-api/bookutil"
+	"github.com/Tus1688/library-management-api/pageutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/lib/pq"
+	"strings"
+)
+
+// categoryLock is the advisory lock held while a category is moved below another, so two moves never form a cycle.
+const categoryLock = 7_001_002
+
+// GetCategoryTree retrieves every category nested under its parent, siblings ordered by code and name.
+// Book counts only include the books classified directly under a category.
+// Returns the root categories, status code, and an error if the operation fails.
+func (s *PostgresStore) GetCategoryTree() ([]types.CategoryNode, int, types.Err) {
+	rows, err := s.db.Query(`SELECT c.id, c.pagination_id, COALESCE(c.parent_id::TEXT, ''), c.code, c.name,
+	(SELECT COUNT(*) FROM book_categories bc INNER JOIN books b ON bc.book_id = b.id
+	WHERE bc.category_id = c.id AND b.deleted_at IS NULL), c.created_at, c.updated_at, c.version
+	FROM categories c ORDER BY c.code, c.name`)
+	if err != nil {
+		return nil, 500, types.Err{Error: "unable to get categories"}
+	}
+	defer rows.Close()
+
+	var categories []types.Category
+	for rows.Next() {
+		var category types.Category
+		err := rows.Scan(&category.Id, &category.PaginationId, &category.ParentId, &category.Code, &category.Name,
+			&category.BookCount, &category.CreatedAt, &category.UpdatedAt, &category.Version)
+		if err != nil {
+			return nil, 500, types.Err{Error: "unable to get categories"}
+		}
+		categories = append(categories, category)
+	}
+
+	return bookutil.CategoryTree(categories), 200, types.Err{}
+}
+
+// CreateCategory inserts a new category, at the root of the tree when no parent is given.
+// If the parent already has a category with that name, it returns a 409 status code.
+// Parameters:
+// - req: a pointer to the CreateCategory request containing the category details
+// Returns the created category ID, status code, and an error if the operation fails.
+func (s *PostgresStore) CreateCategory(req *types.CreateCategory) (types.CreateId, int, types.Err) {
+	var id types.CreateId
+	err := s.db.QueryRow(`INSERT INTO categories(parent_id, code, name) VALUES ($1, $2, $3) RETURNING id`,
+		nullString(req.ParentId), req.Code, req.Name).Scan(&id.Id)
+	if err != nil {
+		statusCode, errResp := categoryFailure(err, "unable to create category")
+		return types.CreateId{}, statusCode, errResp
+	}
+
+	return id, 201, types.Err{}
+}
+
+// UpdateCategory renames or moves a category, together with its subcategories.
+// A category cannot be moved below itself or one of its descendants.
+// If version is not 0 the update only succeeds when it matches the stored version.
+// Parameters:
+// - req: a pointer to the UpdateCategory request containing the updated category details
+// - version: the version the client based its changes on, 0 to skip the check
+// Returns the new version, status code, and an error if the operation fails.
+func (s *PostgresStore) UpdateCategory(req *types.UpdateCategory, version int) (int, int, types.Err) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 500, types.Err{Error: "unable to update category"}
+	}
+
+	if req.ParentId != "" {
+		// two moves checked at once could each pass and together form a cycle, so they are checked one at a time
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, categoryLock); err != nil {
+			tx.Rollback()
+			return 0, 500, types.Err{Error: "unable to update category"}
+		}
+
+		var cycle bool
+		err := tx.QueryRow(`WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION SELECT c.id FROM categories c INNER JOIN tree ON c.parent_id = tree.id
+		) SELECT EXISTS (SELECT 1 FROM tree WHERE id = $2)`, req.Id, req.ParentId).Scan(&cycle)
+		if err != nil {
+			tx.Rollback()
+			statusCode, errResp := categoryFailure(err, "unable to update category")
+			return 0, statusCode, errResp
+		}
+
+		if cycle {
+			tx.Rollback()
+			return 0, 409, types.Err{Error: "category cannot be moved below itself"}
+		}
+	}
+
+	args := []interface{}{nullString(req.ParentId), req.Code, req.Name, req.Id}
+	query := `UPDATE categories SET parent_id = $1, code = $2, name = $3 WHERE id = $4`
+	if version != 0 {
+		query += ` AND version = ` + placeholder(&args, version)
+	}
+	query += ` RETURNING version`
+
+	var newVersion int
+	err = tx.QueryRow(query, args...).Scan(&newVersion)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			var exists bool
+			err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1)`, req.Id).Scan(&exists)
+			if err == nil && exists {
+				return 0, 412, types.Err{Error: "category has been modified"}
+			}
+
+			return 0, 404, types.Err{Error: "category not found"}
+		}
+
+		statusCode, errResp := categoryFailure(err, "unable to update category")
+		return 0, statusCode, errResp
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return 0, 500, types.Err{Error: "unable to update category"}
+	}
+
+	return newVersion, 200, types.Err{}
+}
+
+// DeleteCategory deletes a category and unassigns it from its books.
+// A category that still has subcategories cannot be deleted, they have to be moved or deleted first.
+// Parameters:
+// - id: a pointer to the category ID to be deleted
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) DeleteCategory(id *string) (int, types.Err) {
+	res, err := s.db.Exec(`DELETE FROM categories WHERE id = $1`, *id)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return 400, types.Err{Error: "invalid id"}
+		}
+
+		if strings.Contains(err.Error(), "foreign key") {
+			return 409, types.Err{Error: "category has subcategories"}
+		}
+
+		return 500, types.Err{Error: "unable to delete category"}
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 500, types.Err{Error: "unable to delete category"}
+	}
+
+	if rowsAffected == 0 {
+		return 404, types.Err{Error: "category not found"}
+	}
+
+	return 200, types.Err{}
+}
+
+// categoryFailure maps an error of a category write to a response.
+// Parameters:
+// - err: the error returned by the query
+// - message: the message used for unexpected errors
+// Returns the status code and the error response.
+func categoryFailure(err error, message string) (int, types.Err) {
+	if strings.Contains(err.Error(), "uuid") {
+		return 400, types.Err{Error: "invalid id"}
+	}
+
+	if strings.Contains(err.Error(), "foreign key") {
+		return 404, types.Err{Error: "parent category not found"}
+	}
+
+	if strings.Contains(err.Error(), "duplicate") {
+		return 409, types.Err{Error: "category with that name already exists"}
+	}
+
+	return 500, types.Err{Error: message}
+}
+
+// GetTag retrieves a page of tags, optionally filtered by a search on their name.
+// Parameters:
+// - filter: a pointer to the TagFilter containing the search query, cursor and page size
+// Returns a page of ListTag, status code, and an error if the operation fails.
+func (s *PostgresStore) GetTag(filter *types.TagFilter) (types.Page[types.ListTag], int, types.Err) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Search != "" {
+		conditions = append(conditions, `t.name ILIKE '%' || `+placeholder(&args, filter.Search)+` || '%'`)
+	}
+
+	total, err := s.countRows("tags t", whereClause(conditions), args)
+	if err != nil {
+		return types.Page[types.ListTag]{}, 500, types.Err{Error: "unable to get tags"}
+	}
+
+	if filter.Cursor != 0 {
+		conditions = append(conditions, `t.pagination_id < `+placeholder(&args, filter.Cursor))
+	}
+
+	query := `SELECT t.id, t.pagination_id, t.name, (SELECT COUNT(*) FROM book_tags bt INNER JOIN books b
+	ON bt.book_id = b.id WHERE bt.tag_id = t.id AND b.deleted_at IS NULL) FROM tags t` + whereClause(conditions) +
+		` ORDER BY t.pagination_id DESC LIMIT ` + placeholder(&args, filter.Limit+1)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return types.Page[types.ListTag]{}, 500, types.Err{Error: "unable to get tags"}
+	}
+	defer rows.Close()
+
+	var tags []types.ListTag
+	for rows.Next() {
+		var tag types.ListTag
+		if err := rows.Scan(&tag.Id, &tag.PaginationId, &tag.Name, &tag.BookCount); err != nil {
+			return types.Page[types.ListTag]{}, 500, types.Err{Error: "unable to get tags"}
+		}
+		tags = append(tags, tag)
+	}
+
+	return pageutil.NewPage(tags, filter.Limit, total, func(t types.ListTag) int64 {
+		return int64(t.PaginationId)
+	}), 200, types.Err{}
+}
+
+// UpdateTag renames a tag.
+// If another tag already has the new name, it returns a 409 status code, the tags should be merged instead.
+// Parameters:
+// - req: a pointer to the UpdateTag request with the normalized name
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) UpdateTag(req *types.UpdateTag) (int, types.Err) {
+	res, err := s.db.Exec(`UPDATE tags SET name = $1 WHERE id = $2`, req.Name, req.Id)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return 400, types.Err{Error: "invalid id"}
+		}
+
+		if strings.Contains(err.Error(), "duplicate") {
+			return 409, types.Err{Error: "tag with that name already exists"}
+		}
+
+		return 500, types.Err{Error: "unable to update tag"}
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 500, types.Err{Error: "unable to update tag"}
+	}
+
+	if rowsAffected == 0 {
+		return 404, types.Err{Error: "tag not found"}
+	}
+
+	return 200, types.Err{}
+}
+
+// MergeTags retags every book tagged with one of the source tags with the target tag and deletes the sources.
+// It runs in a single transaction, so nothing is merged if one of the tags does not exist.
+// Parameters:
+// - req: a pointer to the MergeTags request
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) MergeTags(req *types.MergeTags) (int, types.Err) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 500, types.Err{Error: "unable to merge tags"}
+	}
+
+	var sources int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM tags WHERE id = ANY($1::uuid[]) AND id <> $2`,
+		pq.Array(req.SourceIds), req.TargetId).Scan(&sources)
+	if err != nil {
+		tx.Rollback()
+		if strings.Contains(err.Error(), "uuid") {
+			return 400, types.Err{Error: "invalid id"}
+		}
+
+		return 500, types.Err{Error: "unable to merge tags"}
+	}
+
+	var targetExists bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM tags WHERE id = $1)`, req.TargetId).Scan(&targetExists)
+	if err != nil {
+		tx.Rollback()
+		return 500, types.Err{Error: "unable to merge tags"}
+	}
+
+	if !targetExists || sources != len(req.SourceIds) {
+		tx.Rollback()
+		return 404, types.Err{Error: "tag not found"}
+	}
+
+	_, err = tx.Exec(`INSERT INTO book_tags(book_id, tag_id) SELECT book_id, $1 FROM book_tags
+	WHERE tag_id = ANY($2::uuid[]) ON CONFLICT DO NOTHING`, req.TargetId, pq.Array(req.SourceIds))
+	if err != nil {
+		tx.Rollback()
+		return 500, types.Err{Error: "unable to merge tags"}
+	}
+
+	_, err = tx.Exec(`DELETE FROM tags WHERE id = ANY($1::uuid[])`, pq.Array(req.SourceIds))
+	if err != nil {
+		tx.Rollback()
+		return 500, types.Err{Error: "unable to merge tags"}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return 500, types.Err{Error: "unable to merge tags"}
+	}
+
+	return 200, types.Err{}
+}
+
+// DeleteTag deletes a tag and removes it from every book.
+// Parameters:
+// - id: a pointer to the tag ID to be deleted
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) DeleteTag(id *string) (int, types.Err) {
+	res, err := s.db.Exec(`DELETE FROM tags WHERE id = $1`, *id)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return 400, types.Err{Error: "invalid id"}
+		}
+
+		return 500, types.Err{Error: "unable to delete tag"}
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 500, types.Err{Error: "unable to delete tag"}
+	}
+
+	if rowsAffected == 0 {
+		return 404, types.Err{Error: "tag not found"}
+	}
+
+	return 200, types.Err{}
+}
+
+// getBookCategories retrieves the categories a book is classified under.
+// Parameters:
+// - bookId: the book ID
+// Returns the categories, never nil, and an error if the query fails.
+func (s *PostgresStore) getBookCategories(bookId string) ([]types.BookCategory, error) {
+	rows, err := s.db.Query(`SELECT c.id, c.code, c.name FROM book_categories bc
+	INNER JOIN categories c ON bc.category_id = c.id WHERE bc.book_id = $1 ORDER BY c.code, c.name`, bookId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []types.BookCategory{}
+	for rows.Next() {
+		var category types.BookCategory
+		if err := rows.Scan(&category.Id, &category.Code, &category.Name); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// classifyBook replaces the categories and tags of a book within the transaction that writes the book.
+// A nil slice leaves that part of the classification untouched. Unknown tags are created on the fly.
+// Parameters:
+// - tx: the transaction writing the book
+// - bookId: the book ID
+// - categoryIds: the normalized category IDs
+// - tags: the normalized tag names
+// Returns an error if the classification cannot be written, see linkFailure.
+func classifyBook(tx *sql.Tx, bookId string, categoryIds, tags []string) error {
+	if categoryIds != nil {
+		if _, err := tx.Exec(`DELETE FROM book_categories WHERE book_id = $1`, bookId); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`INSERT INTO book_categories(book_id, category_id) SELECT $1, unnest($2::uuid[])`,
+			bookId, pq.Array(categoryIds))
+		if err != nil {
+			return err
+		}
+	}
+
+	if tags != nil {
+		_, err := tx.Exec(`INSERT INTO tags(name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`,
+			pq.Array(tags))
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`DELETE FROM book_tags WHERE book_id = $1`, bookId); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT INTO book_tags(book_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2)`,
+			bookId, pq.Array(tags))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
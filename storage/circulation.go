@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+)
+
+// ScanItems checks out or returns every scanned item for the patron holding the card, in a single transaction.
+// An item that is on loan is returned, whoever borrowed it, any other item is checked out to the patron
+// unless it is kept for another patron who held it.
+// Unknown and deleted barcodes are reported in the result rather than failing the batch.
+// Parameters:
+// - uid: a pointer to the ID of the employee at the desk
+// - req: a pointer to the normalized ScanRequest
+// Returns the outcome of every barcode in scan order, status code, and an error if the operation fails.
+func (s *PostgresStore) ScanItems(uid *string, req *types.ScanRequest) (types.ScanResult, int, types.Err) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.ScanResult{}, 500, types.Err{Error: "unable to process scan"}
+	}
+
+	var patron struct{ id, name, phone, email string }
+	result := types.ScanResult{CardNumber: req.CardNumber, Items: make([]types.ScanItem, 0, len(req.Barcodes))}
+	err = tx.QueryRow(`SELECT id, name, phone, email FROM patrons WHERE card_number = $1 AND deleted_at IS NULL`,
+		req.CardNumber).Scan(&patron.id, &patron.name, &patron.phone, &patron.email)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.ScanResult{}, 404, types.Err{Error: "patron not found"}
+		}
+
+		return types.ScanResult{}, 500, types.Err{Error: "unable to process scan"}
+	}
+	result.PatronName = patron.name
+
+	for _, barcode := range req.Barcodes {
+		item := types.ScanItem{Barcode: barcode, Status: types.ScanUnknown}
+
+		var isBooked bool
+		err := tx.QueryRow(`SELECT id, title, is_booked FROM books WHERE barcode = $1 AND deleted_at IS NULL FOR UPDATE`,
+			barcode).Scan(&item.BookId, &item.Title, &isBooked)
+		if errors.Is(err, sql.ErrNoRows) {
+			result.Items = append(result.Items, item)
+			continue
+		}
+		if err != nil {
+			tx.Rollback()
+			return types.ScanResult{}, 500, types.Err{Error: "unable to process scan"}
+		}
+
+		if isBooked {
+			err = returnItem(tx, &item)
+		} else {
+			err = checkOutItem(tx, uid, patron.id, patron.name, patron.phone, patron.email, &item)
+		}
+		switch item.Status {
+		case types.ScanReturned:
+			result.Returned++
+		case types.ScanCheckedOut:
+			result.CheckedOut++
+		}
+		if err == nil {
+			err = writeScanEvent(tx, &item)
+		}
+		if err != nil {
+			tx.Rollback()
+			return types.ScanResult{}, 500, types.Err{Error: "unable to process scan"}
+		}
+
+		result.Items = append(result.Items, item)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return types.ScanResult{}, 500, types.Err{Error: "unable to process scan"}
+	}
+
+	return result, 200, types.Err{}
+}
+
+// checkOutItem books a locked item for a patron within the scan transaction.
+// The customer columns are copied from the patron so the booking list reads the same as for manual bookings.
+// An item kept for another patron who held it is not checked out, its status is set to types.ScanHeld.
+// Parameters:
+// - tx: the scan transaction
+// - uid: a pointer to the ID of the employee at the desk
+// - patronId, name, phone, email: the patron borrowing the item
+// - item: the scanned item, its booking ID, due date and status are set on success
+// Returns an error if a statement fails.
+func checkOutItem(tx *sql.Tx, uid *string, patronId, name, phone, email string, item *types.ScanItem) error {
+	available, err := claimHold(tx, item.BookId, patronId)
+	if err != nil {
+		return err
+	}
+	if !available {
+		item.Status = types.ScanHeld
+		return nil
+	}
+
+	err = tx.QueryRow(`INSERT INTO bookings(book_id, patron_id, customer_name, customer_phone, customer_email,
+	updated_by, due_at) VALUES ($1, $2, $3, $4, $5, $6, NOW() + $7::INTERVAL) RETURNING id, due_at`,
+		item.BookId, patronId, name, phone, email, uid, loanPeriod).Scan(&item.BookingId, &item.DueAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE books SET is_booked = TRUE, booked_until = (SELECT due_at FROM bookings WHERE id = $2)
+	WHERE id = $1`, item.BookId, item.BookingId)
+	if err != nil {
+		return err
+	}
+
+	item.Status = types.ScanCheckedOut
+	return nil
+}
+
+// returnItem closes the open booking of a locked item within the scan transaction.
+// A booked item without an open booking is still made available again, and ready for the first patron holding it.
+// Parameters:
+// - tx: the scan transaction
+// - item: the scanned item, its booking ID and status are set on success
+// Returns an error if a statement fails.
+func returnItem(tx *sql.Tx, item *types.ScanItem) error {
+	err := tx.QueryRow(`UPDATE bookings SET is_returned = TRUE, returned_at = NOW()
+	WHERE id = (SELECT id FROM bookings WHERE book_id = $1 AND is_returned = FALSE ORDER BY created_at DESC LIMIT 1)
+	RETURNING id`, item.BookId).Scan(&item.BookingId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE books SET is_booked = FALSE, booked_until = NULL WHERE id = $1`, item.BookId)
+	if err != nil {
+		return err
+	}
+
+	if err := promoteHold(tx, item.BookId); err != nil {
+		return err
+	}
+
+	item.Status = types.ScanReturned
+	return nil
+}
+
+// writeScanEvent records the event of a checked out or returned item within the scan transaction.
+// A returned item without an open booking has nothing to report.
+func writeScanEvent(tx *sql.Tx, item *types.ScanItem) error {
+	if item.BookingId == "" {
+		return nil
+	}
+
+	event := types.EventBookingCreated
+	if item.Status == types.ScanReturned {
+		event = types.EventBookingReturned
+	}
+
+	return writeEvent(tx, event, types.EventRef{Id: item.BookingId, BookId: item.BookId})
+}
@@ -1,6 +1,9 @@
 package storage
 
 import (
+	"database/sql"
+	"errors"
+	"github.com/Tus1688/library-management-api/pageutil"
 	"github.com/Tus1688/library-management-api/types"
 	"golang.org/x/crypto/bcrypt"
 	"strings"
@@ -31,32 +34,159 @@ func (s *PostgresStore) CreateEmployee(req *types.CreateEmployee) (types.CreateI
 	return userId, 0, types.Err{}
 }
 
-// GetEmployee retrieves a list of all employees from the database.
-// It constructs a SQL query to fetch employees and returns the list of employees.
-// Returns a slice of ListEmployee, status code, and an error if the operation fails.
-func (s *PostgresStore) GetEmployee() ([]types.ListEmployee, int, types.Err) {
-	rows, err := s.db.Query(`SELECT id, username, created_at, updated_at FROM employees`)
+// employeeColumns are the columns scanned by scanEmployee, in order.
+const employeeColumns = `id, pagination_id, username, created_at, updated_at, version, COALESCE(deleted_at::TEXT, '')`
+
+// employeeConditions translates the filter into WHERE conditions shared by every query that lists employees.
+// Parameters:
+// - filter: a pointer to the EmployeeFilter
+// - args: a pointer to the query arguments the conditions refer to
+// Returns the conditions to pass to whereClause.
+func employeeConditions(filter *types.EmployeeFilter, _ *[]interface{}) []string {
+	var conditions []string
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, `deleted_at IS NULL`)
+	}
+
+	return conditions
+}
+
+// scanEmployee scans a row selected with employeeColumns.
+func scanEmployee(rows *sql.Rows) (types.ListEmployee, error) {
+	var employee types.ListEmployee
+	err := rows.Scan(&employee.Id, &employee.PaginationId, &employee.Username, &employee.CreatedAt,
+		&employee.UpdatedAt, &employee.Version, &employee.DeletedAt)
+	return employee, err
+}
+
+// GetEmployee retrieves a page of employees based on the cursor and limit for pagination.
+// It constructs a SQL query to fetch employees and wraps them in a page envelope.
+// Parameters:
+// - filter: a pointer to the EmployeeFilter containing the cursor and page size
+// Returns a page of ListEmployee, status code, and an error if the operation fails.
+func (s *PostgresStore) GetEmployee(filter *types.EmployeeFilter) (types.Page[types.ListEmployee], int, types.Err) {
+	var args []interface{}
+	conditions := employeeConditions(filter, &args)
+
+	total, err := s.countRows("employees", whereClause(conditions), args)
 	if err != nil {
-		return nil, 500, types.Err{Error: "unable to get employees"}
+		return types.Page[types.ListEmployee]{}, 500, types.Err{Error: "unable to get employees"}
+	}
+
+	if filter.Cursor != 0 {
+		conditions = append(conditions, `pagination_id < `+placeholder(&args, filter.Cursor))
+	}
+
+	query := `SELECT ` + employeeColumns + ` FROM employees` + whereClause(conditions) +
+		` ORDER BY pagination_id DESC LIMIT ` + placeholder(&args, filter.Limit+1)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return types.Page[types.ListEmployee]{}, 500, types.Err{Error: "unable to get employees"}
 	}
 	defer rows.Close()
 
 	var employees []types.ListEmployee
 	for rows.Next() {
-		var employee types.ListEmployee
-		err := rows.Scan(&employee.Id, &employee.Username, &employee.CreatedAt, &employee.UpdatedAt)
+		employee, err := scanEmployee(rows)
 		if err != nil {
-			return nil, 500, types.Err{Error: "unable to get employees"}
+			return types.Page[types.ListEmployee]{}, 500, types.Err{Error: "unable to get employees"}
 		}
 		employees = append(employees, employee)
 	}
 
-	return employees, 0, types.Err{}
+	return pageutil.NewPage(employees, filter.Limit, total, func(e types.ListEmployee) int64 {
+		return int64(e.PaginationId)
+	}), 200, types.Err{}
+}
+
+// GetEmployeeById retrieves a single employee based on the provided ID, including soft-deleted ones.
+// Parameters:
+// - id: a pointer to the employee ID
+// Returns the ListEmployee, status code, and an error if the operation fails.
+func (s *PostgresStore) GetEmployeeById(id *string) (types.ListEmployee, int, types.Err) {
+	var employee types.ListEmployee
+	err := s.db.QueryRow(`SELECT id, pagination_id, username, created_at, updated_at, version,
+	COALESCE(deleted_at::TEXT, '') FROM employees WHERE id = $1`, *id).
+		Scan(&employee.Id, &employee.PaginationId, &employee.Username, &employee.CreatedAt, &employee.UpdatedAt,
+			&employee.Version, &employee.DeletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.ListEmployee{}, 404, types.Err{Error: "employee not found"}
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return types.ListEmployee{}, 400, types.Err{Error: "invalid id"}
+		}
+
+		return types.ListEmployee{}, 500, types.Err{Error: "unable to get employee"}
+	}
+
+	return employee, 200, types.Err{}
+}
+
+// PatchEmployee applies a JSON merge patch to an existing employee.
+// Only the fields present in the patch are updated, a new password is hashed using bcrypt.
+// If version is not 0 the update only succeeds when it matches the stored version,
+// otherwise a 412 status code is returned so the client can refetch and retry.
+// Parameters:
+// - id: a pointer to the employee ID to be patched
+// - version: the version the client based its changes on, 0 to skip the check
+// - req: a pointer to the PatchEmployee request containing the fields to change
+// Returns the new version, status code, and an error if the operation fails.
+func (s *PostgresStore) PatchEmployee(id *string, version int, req *types.PatchEmployee) (int, int, types.Err) {
+	var sets []string
+	var args []interface{}
+
+	if req.Username.Set {
+		sets = append(sets, `username = `+placeholder(&args, req.Username.Value))
+	}
+
+	if req.Password.Set {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password.Value), bcrypt.DefaultCost)
+		if err != nil {
+			return 0, 500, types.Err{Error: "unable to update employee"}
+		}
+		sets = append(sets, `password = `+placeholder(&args, string(hashedPassword)))
+	}
+
+	if len(sets) == 0 {
+		return 0, 400, types.Err{Error: "nothing to update"}
+	}
+
+	query := `UPDATE employees SET ` + strings.Join(sets, `, `) + ` WHERE deleted_at IS NULL AND id = ` +
+		placeholder(&args, *id)
+	if version != 0 {
+		query += ` AND version = ` + placeholder(&args, version)
+	}
+	query += ` RETURNING version`
+
+	var newVersion int
+	err := s.db.QueryRow(query, args...).Scan(&newVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			statusCode, errResp := s.preconditionFailure("employees", "employee", id)
+			return 0, statusCode, errResp
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return 0, 400, types.Err{Error: "invalid id"}
+		}
+
+		if strings.Contains(err.Error(), "duplicate") {
+			return 0, 409, types.Err{Error: "username already exists"}
+		}
+
+		return 0, 500, types.Err{Error: "unable to update employee"}
+	}
+
+	return newVersion, 200, types.Err{}
 }
 
-// DeleteEmployee deletes an employee from the database based on the provided ID.
+// DeleteEmployee soft-deletes an employee by setting its deleted_at timestamp.
+// The row is kept so the bookings they handled stay intact, but they can no longer log in.
 // It checks if the current user is trying to delete themselves and returns a 403 status code if so.
-// If the employee is being used, it returns a 409 status code.
 // Parameters:
 // - currentUserId: a pointer to the current user's ID
 // - id: a pointer to the employee ID to be deleted
@@ -66,11 +196,8 @@ func (s *PostgresStore) DeleteEmployee(currentUserId, id *string) (int, types.Er
 		return 403, types.Err{Error: "cannot delete yourself"}
 	}
 
-	res, err := s.db.Exec(`DELETE FROM employees WHERE id = $1`, *id)
+	res, err := s.db.Exec(`UPDATE employees SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, *id)
 	if err != nil {
-		if strings.Contains(err.Error(), "foreign key constraint") {
-			return 409, types.Err{Error: "employee is being used"}
-		}
 		if strings.Contains(err.Error(), "uuid") {
 			return 400, types.Err{Error: "invalid id"}
 		}
@@ -89,3 +216,34 @@ func (s *PostgresStore) DeleteEmployee(currentUserId, id *string) (int, types.Er
 
 	return 200, types.Err{}
 }
+
+// RestoreEmployee undoes a soft delete of an employee.
+// If another active employee has taken the username in the meantime, it returns a 409 status code.
+// Parameters:
+// - id: a pointer to the employee ID to be restored
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) RestoreEmployee(id *string) (int, types.Err) {
+	res, err := s.db.Exec(`UPDATE employees SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, *id)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return 400, types.Err{Error: "invalid id"}
+		}
+
+		if strings.Contains(err.Error(), "duplicate") {
+			return 409, types.Err{Error: "username already exists"}
+		}
+
+		return 500, types.Err{Error: "unable to restore employee"}
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 500, types.Err{Error: "unable to restore employee"}
+	}
+
+	if rowsAffected == 0 {
+		return 404, types.Err{Error: "deleted employee not found"}
+	}
+
+	return 200, types.Err{}
+}
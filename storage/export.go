@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"github.com/Tus1688/library-management-api/types"
+	"strings"
+)
+
+// StreamBooks calls fn for every book matching the filter, newest first.
+// Rows are read one at a time so memory use does not grow with the table, cursor and limit are ignored.
+// Parameters:
+// - filter: a pointer to the BookFilter, the same one the list endpoint uses
+// - fn: called for every row, returning an error stops the stream
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) StreamBooks(filter *types.BookFilter, fn func(book *types.ListBook) error) (int, types.Err) {
+	var args []interface{}
+	conditions := bookConditions(filter, &args)
+
+	rows, err := s.db.Query(`SELECT `+bookColumns+` FROM books b`+whereClause(conditions)+
+		` ORDER BY b.pagination_id DESC`, args...)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return 400, types.Err{Error: "invalid id"}
+		}
+
+		return 500, types.Err{Error: "unable to export books"}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		book, err := scanBook(rows)
+		if err != nil {
+			return 500, types.Err{Error: "unable to export books"}
+		}
+		if err := fn(&book); err != nil {
+			return 500, types.Err{Error: "unable to export books"}
+		}
+	}
+
+	if rows.Err() != nil {
+		return 500, types.Err{Error: "unable to export books"}
+	}
+
+	return 200, types.Err{}
+}
+
+// StreamBookings calls fn for every booking matching the filter, newest first.
+// Rows are read one at a time so memory use does not grow with the table, cursor and limit are ignored.
+// Parameters:
+// - filter: a pointer to the BookingFilter, the same one the list endpoint uses
+// - fn: called for every row, returning an error stops the stream
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) StreamBookings(filter *types.BookingFilter,
+	fn func(booking *types.GetBooking) error) (int, types.Err) {
+	var conditions []string
+	var args []interface{}
+
+	rows, err := s.db.Query(`SELECT `+bookingColumns+bookingFrom+whereClause(conditions)+
+		` ORDER BY bo.pagination_id DESC`, args...)
+	if err != nil {
+		return 500, types.Err{Error: "unable to export bookings"}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		booking, err := scanBooking(rows)
+		if err != nil {
+			return 500, types.Err{Error: "unable to export bookings"}
+		}
+		if err := fn(&booking); err != nil {
+			return 500, types.Err{Error: "unable to export bookings"}
+		}
+	}
+
+	if rows.Err() != nil {
+		return 500, types.Err{Error: "unable to export bookings"}
+	}
+
+	return 200, types.Err{}
+}
+
+// StreamEmployees calls fn for every employee matching the filter, newest first.
+// Password hashes are never selected, cursor and limit are ignored.
+// Parameters:
+// - filter: a pointer to the EmployeeFilter, the same one the list endpoint uses
+// - fn: called for every row, returning an error stops the stream
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) StreamEmployees(filter *types.EmployeeFilter,
+	fn func(employee *types.ListEmployee) error) (int, types.Err) {
+	var args []interface{}
+	conditions := employeeConditions(filter, &args)
+
+	rows, err := s.db.Query(`SELECT `+employeeColumns+` FROM employees`+whereClause(conditions)+
+		` ORDER BY pagination_id DESC`, args...)
+	if err != nil {
+		return 500, types.Err{Error: "unable to export employees"}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		employee, err := scanEmployee(rows)
+		if err != nil {
+			return 500, types.Err{Error: "unable to export employees"}
+		}
+		if err := fn(&employee); err != nil {
+			return 500, types.Err{Error: "unable to export employees"}
+		}
+	}
+
+	if rows.Err() != nil {
+		return 500, types.Err{Error: "unable to export employees"}
+	}
+
+	return 200, types.Err{}
+}
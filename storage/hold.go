@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxHolds is the number of books a patron may hold at the same time.
+	maxHolds = 5
+	// holdPickup is how long a book that came back is kept for the patron who held it, as a Postgres interval.
+	holdPickup = "3 days"
+)
+
+// PlaceHold queues a patron for a book.
+// A book that is available and held by nobody else is ready for pickup straight away.
+// Parameters:
+// - patronId: a pointer to the ID of the signed in patron
+// - req: a pointer to the CreateHold request
+// Returns the created hold ID, status code, and an error if the book is on loan to the patron or the patron holds too many books.
+func (s *PostgresStore) PlaceHold(patronId *string, req *types.CreateHold) (types.CreateId, int, types.Err) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.CreateId{}, 500, types.Err{Error: "unable to place hold"}
+	}
+
+	var isBooked bool
+	err = tx.QueryRow(`SELECT is_booked FROM books WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, req.BookId).
+		Scan(&isBooked)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.CreateId{}, 404, types.Err{Error: "book not found"}
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return types.CreateId{}, 400, types.Err{Error: "invalid id"}
+		}
+
+		return types.CreateId{}, 500, types.Err{Error: "unable to place hold"}
+	}
+
+	var onLoan, queued bool
+	var holds int
+	err = tx.QueryRow(`SELECT
+	EXISTS (SELECT 1 FROM bookings WHERE book_id = $1 AND patron_id = $2 AND is_returned = FALSE),
+	EXISTS (SELECT 1 FROM holds WHERE book_id = $1 AND status IN ('waiting', 'ready')),
+	(SELECT COUNT(*) FROM holds WHERE patron_id = $2 AND status IN ('waiting', 'ready'))`, req.BookId, *patronId).
+		Scan(&onLoan, &queued, &holds)
+	if err != nil {
+		tx.Rollback()
+		return types.CreateId{}, 500, types.Err{Error: "unable to place hold"}
+	}
+
+	if onLoan {
+		tx.Rollback()
+		return types.CreateId{}, 409, types.Err{Error: "you already have this book on loan"}
+	}
+
+	if holds >= maxHolds {
+		tx.Rollback()
+		return types.CreateId{}, 409, types.Err{Error: "you may hold at most " + strconv.Itoa(maxHolds) + " books at a time"}
+	}
+
+	status := types.HoldWaiting
+	if !isBooked && !queued {
+		status = types.HoldReady
+	}
+
+	var id types.CreateId
+	err = tx.QueryRow(`INSERT INTO holds(book_id, patron_id, status, ready_at, expires_at)
+	VALUES ($1, $2, $3, CASE WHEN $3 = 'ready' THEN NOW() END, CASE WHEN $3 = 'ready' THEN NOW() + $4::INTERVAL END)
+	RETURNING id`, req.BookId, *patronId, status, holdPickup).Scan(&id.Id)
+	if err != nil {
+		tx.Rollback()
+		if strings.Contains(err.Error(), "duplicate") {
+			return types.CreateId{}, 409, types.Err{Error: "you already hold this book"}
+		}
+
+		return types.CreateId{}, 500, types.Err{Error: "unable to place hold"}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return types.CreateId{}, 500, types.Err{Error: "unable to place hold"}
+	}
+
+	return id, 201, types.Err{}
+}
+
+// CancelHold cancels an active hold of a patron, the next patron in the queue gets a book that was ready for pickup.
+// Parameters:
+// - patronId: a pointer to the ID of the signed in patron
+// - id: a pointer to the hold ID
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) CancelHold(patronId, id *string) (int, types.Err) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 500, types.Err{Error: "unable to cancel hold"}
+	}
+
+	var bookId, status string
+	err = tx.QueryRow(`SELECT h.book_id, h.status FROM holds h INNER JOIN books b ON h.book_id = b.id
+	WHERE h.id = $1 AND h.patron_id = $2 AND h.status IN ('waiting', 'ready') FOR UPDATE`, *id, *patronId).
+		Scan(&bookId, &status)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return 404, types.Err{Error: "hold not found"}
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return 400, types.Err{Error: "invalid id"}
+		}
+
+		return 500, types.Err{Error: "unable to cancel hold"}
+	}
+
+	_, err = tx.Exec(`UPDATE holds SET status = 'cancelled' WHERE id = $1`, *id)
+	if err == nil && status == types.HoldReady {
+		err = promoteHold(tx, bookId)
+	}
+	if err != nil {
+		tx.Rollback()
+		return 500, types.Err{Error: "unable to cancel hold"}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return 500, types.Err{Error: "unable to cancel hold"}
+	}
+
+	return 200, types.Err{}
+}
+
+// GetPatronHolds retrieves the active holds of a patron, oldest first.
+// Parameters:
+// - patronId: a pointer to the ID of the signed in patron
+// Returns the holds, status code, and an error if the operation fails.
+func (s *PostgresStore) GetPatronHolds(patronId *string) ([]types.ListHold, int, types.Err) {
+	rows, err := s.db.Query(`SELECT h.id, b.id, b.title, b.author, h.status,
+	CASE WHEN h.status = 'waiting' THEN (SELECT COUNT(*) FROM holds q WHERE q.book_id = h.book_id
+		AND q.status = 'waiting' AND (q.created_at, q.pagination_id) <= (h.created_at, h.pagination_id)) ELSE 0 END,
+	COALESCE(h.expires_at::TEXT, ''), h.created_at
+	FROM holds h INNER JOIN books b ON h.book_id = b.id
+	WHERE h.patron_id = $1 AND h.status IN ('waiting', 'ready')
+	ORDER BY h.created_at`, *patronId)
+	if err != nil {
+		return nil, 500, types.Err{Error: "unable to get holds"}
+	}
+	defer rows.Close()
+
+	holds := make([]types.ListHold, 0)
+	for rows.Next() {
+		var hold types.ListHold
+		err := rows.Scan(&hold.Id, &hold.BookId, &hold.BookTitle, &hold.BookAuthor, &hold.Status, &hold.Position,
+			&hold.ExpiresAt, &hold.CreatedAt)
+		if err != nil {
+			return nil, 500, types.Err{Error: "unable to get holds"}
+		}
+		holds = append(holds, hold)
+	}
+
+	return holds, 200, types.Err{}
+}
+
+// ExpireHolds expires the holds whose book was not picked up in time and passes the books on to the next patron in the queue.
+// Returns the number of expired holds, status code, and an error if the operation fails.
+func (s *PostgresStore) ExpireHolds() (int64, int, types.Err) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 500, types.Err{Error: "unable to expire holds"}
+	}
+
+	rows, err := tx.Query(`UPDATE holds SET status = 'expired' WHERE status = 'ready' AND expires_at < NOW()
+	RETURNING book_id`)
+	if err != nil {
+		tx.Rollback()
+		return 0, 500, types.Err{Error: "unable to expire holds"}
+	}
+
+	var bookIds []string
+	for rows.Next() {
+		var bookId string
+		if err := rows.Scan(&bookId); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return 0, 500, types.Err{Error: "unable to expire holds"}
+		}
+		bookIds = append(bookIds, bookId)
+	}
+	rows.Close()
+
+	for _, bookId := range bookIds {
+		if err := promoteHold(tx, bookId); err != nil {
+			tx.Rollback()
+			return 0, 500, types.Err{Error: "unable to expire holds"}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return 0, 500, types.Err{Error: "unable to expire holds"}
+	}
+
+	return int64(len(bookIds)), 200, types.Err{}
+}
+
+// promoteHold makes the first waiting hold of an available book ready for pickup within a transaction.
+// Nothing happens while the book is on loan or another hold of it is ready.
+func promoteHold(tx *sql.Tx, bookId string) error {
+	_, err := tx.Exec(`UPDATE holds SET status = 'ready', ready_at = NOW(), expires_at = NOW() + $2::INTERVAL
+	WHERE id = (SELECT id FROM holds WHERE book_id = $1 AND status = 'waiting'
+		ORDER BY created_at, pagination_id LIMIT 1)
+	AND NOT EXISTS (SELECT 1 FROM holds WHERE book_id = $1 AND status = 'ready')
+	AND NOT EXISTS (SELECT 1 FROM books WHERE id = $1 AND is_booked)`, bookId, holdPickup)
+	return err
+}
+
+// claimHold decides within a transaction whether a locked, available book may be checked out to a patron.
+// A hold that is ready for another patron keeps the book for them, a hold of the patron is fulfilled by the checkout.
+// Parameters:
+// - tx: the checkout transaction
+// - bookId: the book being checked out
+// - patronId: the patron borrowing it, empty for a booking that is not made for a patron
+// Returns whether the book may be checked out, and an error if a statement fails.
+func claimHold(tx *sql.Tx, bookId, patronId string) (bool, error) {
+	var holder string
+	err := tx.QueryRow(`SELECT patron_id FROM holds WHERE book_id = $1 AND status = 'ready' FOR UPDATE`, bookId).
+		Scan(&holder)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+	if err == nil && holder != patronId {
+		return false, nil
+	}
+	if patronId == "" {
+		return true, nil
+	}
+
+	_, err = tx.Exec(`UPDATE holds SET status = 'fulfilled' WHERE book_id = $1 AND patron_id = $2
+	AND status IN ('waiting', 'ready')`, bookId, patronId)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"database/sql"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/lib/pq"
+	"slices"
+	"strings"
+)
+
+// ImportBooks upserts a batch of books inside a single transaction, matching existing books by ISBN or title.
+// Every row runs under its own savepoint so a failing row is reported without aborting the batch.
+// Rows identical to the stored book are reported as skipped.
+// Parameters:
+// - rows: the validated rows of the batch
+// - dryRun: whether the transaction is rolled back instead of committed
+// Returns a result per row, status code, and an error if the batch as a whole fails.
+func (s *PostgresStore) ImportBooks(rows []types.ImportBookRow, dryRun bool) ([]types.ImportResult, int, types.Err) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, 500, types.Err{Error: "unable to import books"}
+	}
+
+	results := make([]types.ImportResult, 0, len(rows))
+	for _, row := range rows {
+		if _, err := tx.Exec(`SAVEPOINT import_row`); err != nil {
+			tx.Rollback()
+			return nil, 500, types.Err{Error: "unable to import books"}
+		}
+
+		result := importBook(tx, &row)
+		if err := writeImportEvent(tx, &result); err != nil {
+			result = failedImport(result, err)
+		}
+		if result.Status == types.ImportFailed {
+			_, err = tx.Exec(`ROLLBACK TO SAVEPOINT import_row`)
+		} else {
+			_, err = tx.Exec(`RELEASE SAVEPOINT import_row`)
+		}
+		if err != nil {
+			tx.Rollback()
+			return nil, 500, types.Err{Error: "unable to import books"}
+		}
+
+		results = append(results, result)
+	}
+
+	if dryRun {
+		tx.Rollback()
+		return results, 200, types.Err{}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return nil, 500, types.Err{Error: "unable to import books"}
+	}
+
+	return results, 200, types.Err{}
+}
+
+// importBook creates or updates a single book within the import transaction.
+// Books are matched by ISBN when the row has one, otherwise by title.
+// The credited authors are linked for new books and relinked when the byline or the authors change,
+// categories and tags are only replaced when the row has them.
+// Parameters:
+// - tx: the transaction of the current batch
+// - row: a pointer to the row to import
+// Returns the outcome of the row.
+func importBook(tx *sql.Tx, row *types.ImportBookRow) types.ImportResult {
+	result := types.ImportResult{Line: row.Line}
+	book := &row.Book
+
+	query := `SELECT ` + bookColumns + ` FROM books b WHERE b.deleted_at IS NULL AND b.title = $1 LIMIT 2 FOR UPDATE`
+	match := book.Title
+	if book.Isbn != "" {
+		query = `SELECT ` + bookColumns + ` FROM books b WHERE b.deleted_at IS NULL AND b.isbn = $1 FOR UPDATE`
+		match = book.Isbn
+	}
+
+	rows, err := tx.Query(query, match)
+	if err != nil {
+		return failedImport(result, err)
+	}
+
+	var existing []types.ListBook
+	for rows.Next() {
+		current, err := scanBook(rows)
+		if err != nil {
+			rows.Close()
+			return failedImport(result, err)
+		}
+		existing = append(existing, current)
+	}
+	rows.Close()
+
+	// a row matched by title without an isbn must not wipe the one already catalogued
+	if book.Isbn == "" && len(existing) == 1 {
+		book.Isbn = existing[0].Isbn
+	}
+
+	switch {
+	case len(existing) > 1:
+		result.Status = types.ImportFailed
+		result.Error = "title matches several books, provide an isbn"
+		return result
+	case len(existing) == 0:
+		err = tx.QueryRow(`INSERT INTO books(title, author, description, isbn, publisher, publication_year, edition,
+		language, page_count, subjects) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+			book.Title, book.Author, book.Description, nullString(book.Isbn), book.Publisher,
+			nullInt(book.PublicationYear), book.Edition, book.Language, nullInt(book.PageCount),
+			pq.Array(book.Subjects)).Scan(&result.Id)
+		result.Status = types.ImportCreated
+	case len(book.Authors) == 0 && book.CategoryIds == nil && sameBook(&existing[0], book):
+		result.Id = existing[0].Id
+		result.Status = types.ImportSkipped
+		return result
+	default:
+		_, err = tx.Exec(`UPDATE books SET title = $1, author = $2, description = $3, isbn = $4, publisher = $5,
+		publication_year = $6, edition = $7, language = $8, page_count = $9, subjects = $10 WHERE id = $11`,
+			book.Title, book.Author, book.Description, nullString(book.Isbn), book.Publisher,
+			nullInt(book.PublicationYear), book.Edition, book.Language, nullInt(book.PageCount),
+			pq.Array(book.Subjects), existing[0].Id)
+		result.Id = existing[0].Id
+		result.Status = types.ImportUpdated
+	}
+
+	if err != nil {
+		return failedImport(result, err)
+	}
+
+	if result.Status == types.ImportCreated || len(book.Authors) > 0 || existing[0].Author != book.Author {
+		if err := linkBookAuthors(tx, result.Id, book.Author, book.Authors); err != nil {
+			return failedImport(result, err)
+		}
+	}
+
+	if err := classifyBook(tx, result.Id, book.CategoryIds, book.Tags); err != nil {
+		return failedImport(result, err)
+	}
+
+	return result
+}
+
+// sameBook reports whether importing the row would leave the stored book unchanged.
+func sameBook(current *types.ListBook, book *types.CreateBook) bool {
+	return current.Title == book.Title && current.Author == book.Author && current.Description == book.Description &&
+		current.Isbn == book.Isbn && current.Publisher == book.Publisher &&
+		current.PublicationYear == book.PublicationYear && current.Edition == book.Edition &&
+		current.Language == book.Language && current.PageCount == book.PageCount &&
+		slices.Equal(current.Subjects, book.Subjects) && (book.Tags == nil || sameTags(current.Tags, book.Tags))
+}
+
+// sameTags reports whether both lists hold the same tags, current is sorted by name as selected by bookColumns.
+func sameTags(current, tags []string) bool {
+	sorted := slices.Clone(tags)
+	slices.Sort(sorted)
+	return slices.Equal(current, sorted)
+}
+
+// failedImport marks the row as failed with a message suitable for the import report.
+func failedImport(result types.ImportResult, err error) types.ImportResult {
+	result.Id = ""
+	result.Status = types.ImportFailed
+	result.Error = "unable to import book"
+	if violates(err, "uq_books_isbn") {
+		result.Error = "book with that isbn already exists"
+	}
+
+	if strings.Contains(err.Error(), "foreign key") {
+		_, errResp := linkFailure(err, result.Error)
+		result.Error = errResp.Error
+	}
+
+	return result
+}
+
+// writeImportEvent records the event of a created or updated book within the import transaction,
+// a dry run writes them as well as they are rolled back with it.
+func writeImportEvent(tx *sql.Tx, result *types.ImportResult) error {
+	switch result.Status {
+	case types.ImportCreated:
+		return writeEvent(tx, types.EventBookCreated, types.EventRef{Id: result.Id})
+	case types.ImportUpdated:
+		return writeEvent(tx, types.EventBookUpdated, types.EventRef{Id: result.Id})
+	}
+
+	return nil
+}
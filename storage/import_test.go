@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/lib/pq"
+	"testing"
+)
+
+func TestFailedImport(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{&pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "uq_books_isbn"`,
+			Constraint: "uq_books_isbn"}, "book with that isbn already exists"},
+		{fmt.Errorf("unable to link tags: %w", &pq.Error{Code: "23505",
+			Message: `duplicate key value violates unique constraint "uq_tags_name"`, Constraint: "uq_tags_name"}),
+			"unable to import book"},
+		{&pq.Error{Code: "23503", Message: `insert or update on table "book_categories" violates foreign key constraint`},
+			"category not found"},
+	}
+
+	for _, c := range cases {
+		result := failedImport(types.ImportResult{Id: "1", Status: types.ImportCreated}, c.err)
+		if result.Status != types.ImportFailed || result.Id != "" || result.Error != c.want {
+			t.Errorf("failedImport(%v) = %+v, want %q", c.err, result, c.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"github.com/Tus1688/library-management-api/pageutil"
+	"github.com/Tus1688/library-management-api/types"
+)
+
+// StartJobRun records that a background job started running.
+// Parameters:
+// - job: a pointer to the name of the job
+// - instance: a pointer to the name of the instance running it
+// Returns the ID of the run, status code, and an error if the operation fails.
+func (s *PostgresStore) StartJobRun(job, instance *string) (string, int, types.Err) {
+	var id string
+	err := s.db.QueryRow(`INSERT INTO job_runs(job, instance) VALUES ($1, $2) RETURNING id`, *job, *instance).Scan(&id)
+	if err != nil {
+		return "", 500, types.Err{Error: "unable to record job run"}
+	}
+
+	return id, 201, types.Err{}
+}
+
+// FinishJobRun records the outcome of a job run.
+// Parameters:
+// - id: a pointer to the ID of the run
+// - failure: why the job failed, empty if it succeeded
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) FinishJobRun(id *string, failure string) (int, types.Err) {
+	_, err := s.db.Exec(`UPDATE job_runs SET status = CASE WHEN $2 = '' THEN 'succeeded' ELSE 'failed' END,
+	error = $2, finished_at = NOW() WHERE id = $1`, *id, failure)
+	if err != nil {
+		return 500, types.Err{Error: "unable to record job run"}
+	}
+
+	return 200, types.Err{}
+}
+
+// DiscardJobRun deletes the record of a job run that found nothing to do,
+// so jobs running every few seconds only leave the runs that did work or failed.
+// Parameters:
+// - id: a pointer to the ID of the run
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) DiscardJobRun(id *string) (int, types.Err) {
+	_, err := s.db.Exec(`DELETE FROM job_runs WHERE id = $1`, *id)
+	if err != nil {
+		return 500, types.Err{Error: "unable to discard job run"}
+	}
+
+	return 200, types.Err{}
+}
+
+// PurgeJobRuns deletes the finished job runs that started more than the given number of days ago.
+// Parameters:
+// - days: how many days of history are kept
+// Returns the number of deleted runs, status code, and an error if the operation fails.
+func (s *PostgresStore) PurgeJobRuns(days int) (int64, int, types.Err) {
+	result, err := s.db.Exec(`DELETE FROM job_runs WHERE status <> 'running'
+	AND started_at < NOW() - MAKE_INTERVAL(days => $1)`, days)
+	if err != nil {
+		return 0, 500, types.Err{Error: "unable to purge job runs"}
+	}
+
+	deleted, _ := result.RowsAffected()
+	return deleted, 200, types.Err{}
+}
+
+// GetJobRun retrieves a page of job runs, newest first, optionally filtered by job or status.
+// An empty result is not an error, it yields a page with no items.
+// Parameters:
+// - filter: a pointer to the JobRunFilter containing the job, status, cursor and page size
+// Returns a page of ListJobRun, status code, and an error if the operation fails.
+func (s *PostgresStore) GetJobRun(filter *types.JobRunFilter) (types.Page[types.ListJobRun], int, types.Err) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Job != "" {
+		conditions = append(conditions, `j.job = `+placeholder(&args, filter.Job))
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, `j.status = `+placeholder(&args, filter.Status))
+	}
+
+	total, err := s.countRows("job_runs j", whereClause(conditions), args)
+	if err != nil {
+		return types.Page[types.ListJobRun]{}, 500, types.Err{Error: "unable to get job runs"}
+	}
+
+	if filter.Cursor != 0 {
+		conditions = append(conditions, `j.pagination_id < `+placeholder(&args, filter.Cursor))
+	}
+
+	rows, err := s.db.Query(`SELECT j.id, j.pagination_id, j.job, j.instance, j.status, j.error, j.started_at,
+	COALESCE(j.finished_at::TEXT, '') FROM job_runs j`+whereClause(conditions)+
+		` ORDER BY j.pagination_id DESC LIMIT `+placeholder(&args, filter.Limit+1), args...)
+	if err != nil {
+		return types.Page[types.ListJobRun]{}, 500, types.Err{Error: "unable to get job runs"}
+	}
+	defer rows.Close()
+
+	var runs []types.ListJobRun
+	for rows.Next() {
+		var run types.ListJobRun
+		err := rows.Scan(&run.Id, &run.PaginationId, &run.Job, &run.Instance, &run.Status, &run.Error,
+			&run.StartedAt, &run.FinishedAt)
+		if err != nil {
+			return types.Page[types.ListJobRun]{}, 500, types.Err{Error: "unable to get job runs"}
+		}
+		runs = append(runs, run)
+	}
+
+	return pageutil.NewPage(runs, filter.Limit, total, func(run types.ListJobRun) int64 {
+		return int64(run.PaginationId)
+	}), 200, types.Err{}
+}
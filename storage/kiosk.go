@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Tus1688/library-management-api/pageutil"
+	"github.com/Tus1688/library-management-api/types"
+	"strings"
+)
+
+// kioskPairingTTL is how long a pairing code can be used, as a Postgres interval.
+const kioskPairingTTL = "15 minutes"
+
+// GetKiosk retrieves a page of the kiosk devices that have not been revoked.
+// Parameters:
+// - filter: a pointer to the KioskFilter containing the cursor and page size
+// Returns a page of ListKiosk, status code, and an error if the operation fails.
+func (s *PostgresStore) GetKiosk(filter *types.KioskFilter) (types.Page[types.ListKiosk], int, types.Err) {
+	conditions := []string{`k.deleted_at IS NULL`}
+	var args []interface{}
+
+	total, err := s.countRows("kiosk_devices k", whereClause(conditions), args)
+	if err != nil {
+		return types.Page[types.ListKiosk]{}, 500, types.Err{Error: "unable to get kiosks"}
+	}
+
+	if filter.Cursor != 0 {
+		conditions = append(conditions, `k.pagination_id < `+placeholder(&args, filter.Cursor))
+	}
+
+	rows, err := s.db.Query(`SELECT k.id, k.pagination_id, k.name, k.token_hash IS NOT NULL,
+	COALESCE(k.last_seen_at::TEXT, ''), k.created_at FROM kiosk_devices k`+whereClause(conditions)+
+		` ORDER BY k.pagination_id DESC LIMIT `+placeholder(&args, filter.Limit+1), args...)
+	if err != nil {
+		return types.Page[types.ListKiosk]{}, 500, types.Err{Error: "unable to get kiosks"}
+	}
+	defer rows.Close()
+
+	var kiosks []types.ListKiosk
+	for rows.Next() {
+		var kiosk types.ListKiosk
+		err := rows.Scan(&kiosk.Id, &kiosk.PaginationId, &kiosk.Name, &kiosk.IsPaired, &kiosk.LastSeenAt,
+			&kiosk.CreatedAt)
+		if err != nil {
+			return types.Page[types.ListKiosk]{}, 500, types.Err{Error: "unable to get kiosks"}
+		}
+		kiosks = append(kiosks, kiosk)
+	}
+
+	return pageutil.NewPage(kiosks, filter.Limit, total, func(k types.ListKiosk) int64 {
+		return int64(k.PaginationId)
+	}), 200, types.Err{}
+}
+
+// CreateKiosk registers a kiosk device waiting to be paired with the given code.
+// Parameters:
+// - uid: a pointer to the ID of the employee registering the device
+// - req: a pointer to the CreateKiosk request
+// - pairingCodeHash: the hash of the pairing code shown to the employee
+// Returns the device ID and the expiry of the code, status code, and an error if the operation fails.
+func (s *PostgresStore) CreateKiosk(uid *string, req *types.CreateKiosk, pairingCodeHash []byte) (types.KioskPairing,
+	int, types.Err) {
+	var pairing types.KioskPairing
+	err := s.db.QueryRow(`INSERT INTO kiosk_devices(name, pairing_code_hash, pairing_expires_at, created_by)
+	VALUES ($1, $2, NOW() + $3::INTERVAL, $4) RETURNING id, pairing_expires_at`,
+		req.Name, pairingCodeHash, kioskPairingTTL, *uid).Scan(&pairing.Id, &pairing.ExpiresAt)
+	if err != nil {
+		return types.KioskPairing{}, 500, types.Err{Error: "unable to create kiosk"}
+	}
+
+	return pairing, 201, types.Err{}
+}
+
+// PairKiosk issues a new pairing code for a kiosk device, for instance when its hardware is replaced.
+// The current token of the device stops working right away.
+// Parameters:
+// - id: a pointer to the device ID
+// - pairingCodeHash: the hash of the new pairing code
+// Returns the expiry of the code, status code, and an error if the operation fails.
+func (s *PostgresStore) PairKiosk(id *string, pairingCodeHash []byte) (types.KioskPairing, int, types.Err) {
+	pairing := types.KioskPairing{Id: *id}
+	err := s.db.QueryRow(`UPDATE kiosk_devices SET token_hash = NULL, pairing_code_hash = $1,
+	pairing_expires_at = NOW() + $2::INTERVAL WHERE id = $3 AND deleted_at IS NULL RETURNING pairing_expires_at`,
+		pairingCodeHash, kioskPairingTTL, *id).Scan(&pairing.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.KioskPairing{}, 404, types.Err{Error: "kiosk not found"}
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return types.KioskPairing{}, 400, types.Err{Error: "invalid id"}
+		}
+
+		return types.KioskPairing{}, 500, types.Err{Error: "unable to pair kiosk"}
+	}
+
+	return pairing, 200, types.Err{}
+}
+
+// RegisterKiosk exchanges a pairing code for the long-lived token of the device, the code can only be used once.
+// Parameters:
+// - pairingCodeHash: the hash of the code typed into the kiosk
+// - tokenHash: the hash of the token handed to the kiosk
+// Returns the device ID and name, status code, and an error if the code is unknown or expired.
+func (s *PostgresStore) RegisterKiosk(pairingCodeHash, tokenHash []byte) (types.KioskCredential, int, types.Err) {
+	var credential types.KioskCredential
+	err := s.db.QueryRow(`UPDATE kiosk_devices SET token_hash = $1, pairing_code_hash = NULL, pairing_expires_at = NULL
+	WHERE pairing_code_hash = $2 AND pairing_expires_at > NOW() AND deleted_at IS NULL RETURNING id, name`,
+		tokenHash, pairingCodeHash).Scan(&credential.Id, &credential.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.KioskCredential{}, 401, types.Err{Error: "invalid or expired pairing code"}
+		}
+
+		return types.KioskCredential{}, 500, types.Err{Error: "unable to register kiosk"}
+	}
+
+	return credential, 200, types.Err{}
+}
+
+// ExpireKioskPairings forgets the pairing codes that expired without being used.
+// Returns the number of kiosks whose code was removed, status code, and an error if the operation fails.
+func (s *PostgresStore) ExpireKioskPairings() (int64, int, types.Err) {
+	result, err := s.db.Exec(`UPDATE kiosk_devices SET pairing_code_hash = NULL, pairing_expires_at = NULL
+	WHERE pairing_expires_at < NOW()`)
+	if err != nil {
+		return 0, 500, types.Err{Error: "unable to expire kiosk pairings"}
+	}
+
+	expired, _ := result.RowsAffected()
+	return expired, 200, types.Err{}
+}
+
+// AuthenticateKiosk resolves a device token to its kiosk and records when the kiosk was last seen.
+// Parameters:
+// - tokenHash: the hash of the bearer token sent by the kiosk
+// Returns the device ID, status code, and an error if the token is unknown or the device has been revoked.
+func (s *PostgresStore) AuthenticateKiosk(tokenHash []byte) (string, int, types.Err) {
+	var id string
+	err := s.db.QueryRow(`UPDATE kiosk_devices SET last_seen_at = NOW()
+	WHERE token_hash = $1 AND deleted_at IS NULL RETURNING id`, tokenHash).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", 401, types.Err{Error: "invalid kiosk token"}
+		}
+
+		return "", 500, types.Err{Error: "unable to authenticate kiosk"}
+	}
+
+	return id, 200, types.Err{}
+}
+
+// DeleteKiosk revokes a kiosk device, its token stops working right away.
+// The row is kept so bookings made at the kiosk can still name it.
+// Parameters:
+// - id: a pointer to the device ID
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) DeleteKiosk(id *string) (int, types.Err) {
+	res, err := s.db.Exec(`UPDATE kiosk_devices SET deleted_at = NOW(), token_hash = NULL, pairing_code_hash = NULL
+	WHERE id = $1 AND deleted_at IS NULL`, *id)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return 400, types.Err{Error: "invalid id"}
+		}
+
+		return 500, types.Err{Error: "unable to delete kiosk"}
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 500, types.Err{Error: "unable to delete kiosk"}
+	}
+
+	if rowsAffected == 0 {
+		return 404, types.Err{Error: "kiosk not found"}
+	}
+
+	return 200, types.Err{}
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Tus1688/library-management-api/pageutil"
+	"github.com/Tus1688/library-management-api/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRenewals is how often a patron may renew the same loan.
+const maxRenewals = 2
+
+// loanPeriod is types.LoanTerm as a Postgres interval, for the due dates of new and renewed loans.
+var loanPeriod = strconv.FormatInt(int64(types.LoanTerm/time.Second), 10) + " seconds"
+
+// GetPatronLoans retrieves the books a patron currently has on loan, the one due first on top.
+// Parameters:
+// - patronId: a pointer to the ID of the signed in patron
+// Returns the loans, status code, and an error if the operation fails.
+func (s *PostgresStore) GetPatronLoans(patronId *string) ([]types.PatronLoan, int, types.Err) {
+	rows, err := s.db.Query(`SELECT bo.id, b.id, b.title, b.author, bo.created_at, bo.due_at, bo.renewals,
+	bo.due_at < NOW(),
+	bo.due_at >= NOW() AND bo.renewals < $2
+		AND NOT EXISTS (SELECT 1 FROM holds h WHERE h.book_id = bo.book_id AND h.status = 'waiting')
+	FROM bookings bo INNER JOIN books b ON bo.book_id = b.id
+	WHERE bo.patron_id = $1 AND bo.is_returned = FALSE
+	ORDER BY bo.due_at`, *patronId, maxRenewals)
+	if err != nil {
+		return nil, 500, types.Err{Error: "unable to get loans"}
+	}
+	defer rows.Close()
+
+	loans := make([]types.PatronLoan, 0)
+	for rows.Next() {
+		var loan types.PatronLoan
+		err := rows.Scan(&loan.Id, &loan.BookId, &loan.BookTitle, &loan.BookAuthor, &loan.LoanedAt, &loan.DueAt,
+			&loan.Renewals, &loan.IsOverdue, &loan.CanRenew)
+		if err != nil {
+			return nil, 500, types.Err{Error: "unable to get loans"}
+		}
+		loans = append(loans, loan)
+	}
+
+	return loans, 200, types.Err{}
+}
+
+// RenewLoan moves the due date of a loan of the patron by another loanPeriod, at most maxRenewals times.
+// Overdue loans and books other patrons are waiting for cannot be renewed.
+// Parameters:
+// - patronId: a pointer to the ID of the signed in patron
+// - id: a pointer to the booking ID
+// Returns the new due date, status code, and an error if the loan cannot be renewed.
+func (s *PostgresStore) RenewLoan(patronId, id *string) (types.RenewedLoan, int, types.Err) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.RenewedLoan{}, 500, types.Err{Error: "unable to renew loan"}
+	}
+
+	var bookId string
+	var renewals int
+	var overdue, held bool
+	err = tx.QueryRow(`SELECT bo.book_id, bo.renewals, bo.due_at < NOW(),
+	EXISTS (SELECT 1 FROM holds h WHERE h.book_id = bo.book_id AND h.status = 'waiting')
+	FROM bookings bo INNER JOIN books b ON bo.book_id = b.id
+	WHERE bo.id = $1 AND bo.patron_id = $2 AND bo.is_returned = FALSE FOR UPDATE OF bo, b`, *id, *patronId).
+		Scan(&bookId, &renewals, &overdue, &held)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.RenewedLoan{}, 404, types.Err{Error: "loan not found"}
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return types.RenewedLoan{}, 400, types.Err{Error: "invalid id"}
+		}
+
+		return types.RenewedLoan{}, 500, types.Err{Error: "unable to renew loan"}
+	}
+
+	switch {
+	case overdue:
+		tx.Rollback()
+		return types.RenewedLoan{}, 409, types.Err{Error: "loan is overdue, please return the book"}
+	case renewals >= maxRenewals:
+		tx.Rollback()
+		return types.RenewedLoan{}, 409, types.Err{Error: "loan cannot be renewed again"}
+	case held:
+		tx.Rollback()
+		return types.RenewedLoan{}, 409, types.Err{Error: "another patron is waiting for this book"}
+	}
+
+	var renewed types.RenewedLoan
+	err = tx.QueryRow(`UPDATE bookings SET due_at = due_at + $2::INTERVAL, renewals = renewals + 1 WHERE id = $1
+	RETURNING due_at, renewals`, *id, loanPeriod).Scan(&renewed.DueAt, &renewed.Renewals)
+	if err != nil {
+		tx.Rollback()
+		return types.RenewedLoan{}, 500, types.Err{Error: "unable to renew loan"}
+	}
+
+	_, err = tx.Exec(`UPDATE books SET booked_until = (SELECT due_at FROM bookings WHERE id = $2) WHERE id = $1`,
+		bookId, *id)
+	if err == nil {
+		err = writeEvent(tx, types.EventBookingRenewed, types.EventRef{Id: *id, BookId: bookId})
+	}
+	if err != nil {
+		tx.Rollback()
+		return types.RenewedLoan{}, 500, types.Err{Error: "unable to renew loan"}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return types.RenewedLoan{}, 500, types.Err{Error: "unable to renew loan"}
+	}
+
+	return renewed, 200, types.Err{}
+}
+
+// GetPatronHistory retrieves a page of the returned loans of a patron, the most recent first.
+// An empty result is not an error, it yields a page with no items.
+// Parameters:
+// - filter: a pointer to the PatronHistoryFilter containing the patron ID, cursor and page size
+// Returns a page of PatronHistory, status code, and an error if the operation fails.
+func (s *PostgresStore) GetPatronHistory(filter *types.PatronHistoryFilter) (types.Page[types.PatronHistory], int,
+	types.Err) {
+	var args []interface{}
+	conditions := []string{`bo.patron_id = ` + placeholder(&args, filter.PatronId), `bo.is_returned = TRUE`}
+
+	total, err := s.countRows("bookings bo", whereClause(conditions), args)
+	if err != nil {
+		return types.Page[types.PatronHistory]{}, 500, types.Err{Error: "unable to get history"}
+	}
+
+	if filter.Cursor != 0 {
+		conditions = append(conditions, `bo.pagination_id < `+placeholder(&args, filter.Cursor))
+	}
+
+	rows, err := s.db.Query(`SELECT bo.id, bo.pagination_id, b.id, b.title, b.author, bo.created_at, bo.due_at,
+	COALESCE(bo.returned_at::TEXT, '') FROM bookings bo INNER JOIN books b ON bo.book_id = b.id`+
+		whereClause(conditions)+` ORDER BY bo.pagination_id DESC LIMIT `+placeholder(&args, filter.Limit+1), args...)
+	if err != nil {
+		return types.Page[types.PatronHistory]{}, 500, types.Err{Error: "unable to get history"}
+	}
+	defer rows.Close()
+
+	var history []types.PatronHistory
+	for rows.Next() {
+		var h types.PatronHistory
+		err := rows.Scan(&h.Id, &h.PaginationId, &h.BookId, &h.BookTitle, &h.BookAuthor, &h.LoanedAt, &h.DueAt,
+			&h.ReturnedAt)
+		if err != nil {
+			return types.Page[types.PatronHistory]{}, 500, types.Err{Error: "unable to get history"}
+		}
+		history = append(history, h)
+	}
+
+	return pageutil.NewPage(history, filter.Limit, total, func(h types.PatronHistory) int64 {
+		return int64(h.PaginationId)
+	}), 200, types.Err{}
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"github.com/Tus1688/library-management-api/types"
+	"testing"
+)
+
+// dueReminders lists the email reminders due for a booking within 30 days.
+func dueReminders(t *testing.T, store *PostgresStore, bookingId string) []types.DueNotification {
+	t.Helper()
+	due, statusCode, errResp := store.GetDueNotifications(types.ChannelEmail, []string{types.NotifyReminder}, 30, 3)
+	if statusCode != 200 {
+		t.Fatalf("GetDueNotifications = %d %q, want 200", statusCode, errResp.Error)
+	}
+
+	var reminders []types.DueNotification
+	for _, n := range due {
+		if n.BookingId == bookingId {
+			reminders = append(reminders, n)
+		}
+	}
+	return reminders
+}
+
+func TestRenewLoanRemindsAgain(t *testing.T) {
+	store := newTestStore(t)
+
+	var patronId, cardNumber, bookId, barcode string
+	err := store.db.QueryRow(`INSERT INTO patrons(name, phone, email) VALUES ('Renew Test', '+10000000000',
+	'renew@example.com') RETURNING id, card_number`).Scan(&patronId, &cardNumber)
+	if err != nil {
+		t.Fatalf("unable to create patron: %v", err)
+	}
+	err = store.db.QueryRow(`INSERT INTO books(title, author, description) VALUES ('Renew Test', 'Tester', '')
+	RETURNING id, barcode`).Scan(&bookId, &barcode)
+	if err != nil {
+		t.Fatalf("unable to create book: %v", err)
+	}
+	t.Cleanup(func() {
+		store.db.Exec(`DELETE FROM outbox WHERE data->>'book_id' = $1`, bookId)
+		store.db.Exec(`DELETE FROM notifications WHERE booking_id IN (SELECT id FROM bookings WHERE book_id = $1)`, bookId)
+		store.db.Exec(`DELETE FROM bookings WHERE book_id = $1`, bookId)
+		store.db.Exec(`DELETE FROM books WHERE id = $1`, bookId)
+		store.db.Exec(`DELETE FROM patrons WHERE id = $1`, patronId)
+	})
+
+	scan, statusCode, errResp := store.ScanItems(nil, &types.ScanRequest{CardNumber: cardNumber, Barcodes: []string{barcode}})
+	if statusCode != 200 || scan.CheckedOut != 1 {
+		t.Fatalf("ScanItems = %d %q, want the book checked out", statusCode, errResp.Error)
+	}
+	bookingId := scan.Items[0].BookingId
+
+	reminders := dueReminders(t, store, bookingId)
+	if len(reminders) != 1 {
+		t.Fatalf("GetDueNotifications listed %d reminders, want 1", len(reminders))
+	}
+	claimed, statusCode, errResp := store.ClaimNotification(&reminders[0], 3)
+	if !claimed || statusCode != 200 {
+		t.Fatalf("ClaimNotification = %t %d %q, want claimed", claimed, statusCode, errResp.Error)
+	}
+	if statusCode, errResp := store.FinishNotification(&reminders[0].Id, ""); statusCode != 200 {
+		t.Fatalf("FinishNotification = %d %q, want 200", statusCode, errResp.Error)
+	}
+
+	if reminders := dueReminders(t, store, bookingId); len(reminders) != 0 {
+		t.Fatalf("GetDueNotifications listed a reminder that was sent")
+	}
+
+	if _, statusCode, errResp := store.RenewLoan(&patronId, &bookingId); statusCode != 200 {
+		t.Fatalf("RenewLoan = %d %q, want 200", statusCode, errResp.Error)
+	}
+
+	// the reminder was sent for the old due date, the new one gets its own
+	if reminders := dueReminders(t, store, bookingId); len(reminders) != 1 {
+		t.Errorf("GetDueNotifications listed %d reminders after the renewal, want 1", len(reminders))
+	}
+}
@@ -2,26 +2,115 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"github.com/Tus1688/library-management-api/types"
+	"github.com/lib/pq"
 	"os"
-
-	_ "github.com/lib/pq"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Storage interface {
 	Shutdown() error
 	InitAdmin(username, password *string) error
 	Login(req *types.LoginRequest) (string, int, types.Err)
+	IsEmployeeActive(id *string) (bool, int, types.Err)
 	CreateEmployee(req *types.CreateEmployee) (types.CreateId, int, types.Err)
-	GetEmployee() ([]types.ListEmployee, int, types.Err)
+	GetEmployee(filter *types.EmployeeFilter) (types.Page[types.ListEmployee], int, types.Err)
+	GetEmployeeById(id *string) (types.ListEmployee, int, types.Err)
+	PatchEmployee(id *string, version int, req *types.PatchEmployee) (int, int, types.Err)
 	DeleteEmployee(currentUserId, id *string) (int, types.Err)
-	GetBook(searchQuery *string, lastId, limit *int) ([]types.ListBook, int, types.Err)
+	RestoreEmployee(id *string) (int, types.Err)
+	GetBook(filter *types.BookFilter) (types.Page[types.ListBook], int, types.Err)
+	GetBookById(id *string, includeDeleted bool) (types.BookDetail, int, types.Err)
 	CreateBook(req *types.CreateBook) (types.CreateId, int, types.Err)
 	DeleteBook(id *string) (int, types.Err)
-	UpdateBook(req *types.UpdateBook) (int, types.Err)
+	RestoreBook(id *string) (int, types.Err)
+	ImportBooks(rows []types.ImportBookRow, dryRun bool) ([]types.ImportResult, int, types.Err)
+	StreamBooks(filter *types.BookFilter, fn func(book *types.ListBook) error) (int, types.Err)
+	StreamBookings(filter *types.BookingFilter, fn func(booking *types.GetBooking) error) (int, types.Err)
+	StreamEmployees(filter *types.EmployeeFilter, fn func(employee *types.ListEmployee) error) (int, types.Err)
+	UpdateBook(req *types.UpdateBook, version int) (int, int, types.Err)
+	PatchBook(id *string, version int, req *types.PatchBook) (int, int, types.Err)
+	SetBookCover(id *string, coverKey string) (string, int, types.Err)
+	GetAuthor(filter *types.AuthorFilter) (types.Page[types.ListAuthor], int, types.Err)
+	GetAuthorById(id *string) (types.ListAuthor, int, types.Err)
+	CreateAuthor(req *types.CreateAuthor) (types.CreateId, int, types.Err)
+	UpdateAuthor(req *types.UpdateAuthor, version int) (int, int, types.Err)
+	DeleteAuthor(id *string) (int, types.Err)
+	GetCategoryTree() ([]types.CategoryNode, int, types.Err)
+	CreateCategory(req *types.CreateCategory) (types.CreateId, int, types.Err)
+	UpdateCategory(req *types.UpdateCategory, version int) (int, int, types.Err)
+	DeleteCategory(id *string) (int, types.Err)
+	GetTag(filter *types.TagFilter) (types.Page[types.ListTag], int, types.Err)
+	UpdateTag(req *types.UpdateTag) (int, types.Err)
+	MergeTags(req *types.MergeTags) (int, types.Err)
+	DeleteTag(id *string) (int, types.Err)
 	CreateBooking(uid *string, req *types.CreateBooking) (types.CreateId, int, types.Err)
 	ReturnBook(id *string) (int, types.Err)
-	GetBooking(lastId, limit *int) ([]types.GetBooking, int, types.Err)
+	GetBooking(filter *types.BookingFilter) (types.Page[types.GetBooking], int, types.Err)
+	GetBookingById(id *string) (types.BookingDetail, int, types.Err)
+	GetPatron(filter *types.PatronFilter) (types.Page[types.ListPatron], int, types.Err)
+	GetPatronById(id *string) (types.ListPatron, int, types.Err)
+	CreatePatron(req *types.CreatePatron) (types.CreateId, int, types.Err)
+	UpdatePatron(req *types.UpdatePatron, version int) (int, int, types.Err)
+	DeletePatron(id *string) (int, types.Err)
+	VerifyPatronPin(cardNumber, pin *string) (string, int, types.Err)
+	GetPatronByCard(cardNumber *string) (types.ListPatron, int, types.Err)
+	IsPatronActive(id *string) (bool, int, types.Err)
+	UpdatePatronContact(id *string, req *types.UpdatePatronContact) (int, int, types.Err)
+	GetPatronLoans(patronId *string) ([]types.PatronLoan, int, types.Err)
+	RenewLoan(patronId, id *string) (types.RenewedLoan, int, types.Err)
+	GetPatronHistory(filter *types.PatronHistoryFilter) (types.Page[types.PatronHistory], int, types.Err)
+	PlaceHold(patronId *string, req *types.CreateHold) (types.CreateId, int, types.Err)
+	CancelHold(patronId, id *string) (int, types.Err)
+	GetPatronHolds(patronId *string) ([]types.ListHold, int, types.Err)
+	ExpireHolds() (int64, int, types.Err)
+	GetAvailability(ids []string) ([]types.Availability, int, types.Err)
+	ScanItems(uid *string, req *types.ScanRequest) (types.ScanResult, int, types.Err)
+	GetKiosk(filter *types.KioskFilter) (types.Page[types.ListKiosk], int, types.Err)
+	CreateKiosk(uid *string, req *types.CreateKiosk, pairingCodeHash []byte) (types.KioskPairing, int, types.Err)
+	PairKiosk(id *string, pairingCodeHash []byte) (types.KioskPairing, int, types.Err)
+	RegisterKiosk(pairingCodeHash, tokenHash []byte) (types.KioskCredential, int, types.Err)
+	AuthenticateKiosk(tokenHash []byte) (string, int, types.Err)
+	DeleteKiosk(id *string) (int, types.Err)
+	GetDueNotifications(channel string, kinds []string, reminderDays, maxAttempts int) ([]types.DueNotification, int, types.Err)
+	ClaimNotification(notification *types.DueNotification, maxAttempts int) (bool, int, types.Err)
+	FinishNotification(id *string, failure string) (int, types.Err)
+	ExpireNotificationClaims(lease time.Duration) (int64, int, types.Err)
+	GetNotification(filter *types.NotificationFilter) (types.Page[types.ListNotification], int, types.Err)
+	ExpireKioskPairings() (int64, int, types.Err)
+	StartJobRun(job, instance *string) (string, int, types.Err)
+	FinishJobRun(id *string, failure string) (int, types.Err)
+	DiscardJobRun(id *string) (int, types.Err)
+	PurgeJobRuns(days int) (int64, int, types.Err)
+	GetJobRun(filter *types.JobRunFilter) (types.Page[types.ListJobRun], int, types.Err)
+	GetWebhook(filter *types.WebhookFilter) (types.Page[types.ListWebhook], int, types.Err)
+	CreateWebhook(uid *string, req *types.CreateWebhook, secret string) (types.CreateId, int, types.Err)
+	UpdateWebhook(req *types.UpdateWebhook, version int) (int, int, types.Err)
+	RotateWebhookSecret(id *string, secret string) (int, types.Err)
+	DeleteWebhook(id *string) (int, types.Err)
+	EnqueueEvent(event *types.Event) (int64, int, types.Err)
+	ClaimDeliveries(limit int, lease time.Duration) ([]types.PendingDelivery, int, types.Err)
+	FinishDelivery(id *string, responseStatus int, failure string, retryIn time.Duration) (int, types.Err)
+	GetDelivery(filter *types.DeliveryFilter) (types.Page[types.ListDelivery], int, types.Err)
+	RedeliverWebhook(id *string) (int, types.Err)
+	GetOutboxCursor(sink string) (int64, int, types.Err)
+	UpdateOutboxCursor(sink string, position int64, failure string) (int, types.Err)
+	GetOutboxEvents(after int64, limit int) ([]types.Event, int, types.Err)
+	SequenceOutbox() (int64, int, types.Err)
+	GetOutbox(filter *types.EventFilter) (types.Page[types.Event], int, types.Err)
+	GetOutboxSinks() ([]types.OutboxSink, int, types.Err)
+	ReplayOutbox(sink string, fromPosition int64, since time.Time) (int64, int, types.Err)
+	PurgeOutbox(days int) (int64, int, types.Err)
+	ReportLoans(params *types.ReportParams) ([]types.LoanPeriod, int, types.Err)
+	ReportTopTitles(params *types.ReportParams) ([]types.TitleLoans, int, types.Err)
+	ReportTopAuthors(params *types.ReportParams) ([]types.AuthorLoans, int, types.Err)
+	ReportLoanDuration(params *types.ReportParams) (types.LoanDuration, int, types.Err)
+	ReportOnTime(params *types.ReportParams) (types.ReturnRate, int, types.Err)
+	ReportEmployees(params *types.ReportParams) ([]types.HandlerLoans, int, types.Err)
+	ReportIdleBooks(params *types.ReportParams) ([]types.IdleBook, int, types.Err)
 }
 
 type PostgresStore struct {
@@ -53,3 +142,81 @@ func NewPostgresStore() (*PostgresStore, error) {
 func (s *PostgresStore) Shutdown() error {
 	return s.db.Close()
 }
+
+// whereClause joins the given conditions into a WHERE clause.
+// Returns an empty string if there are no conditions.
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return ` WHERE ` + strings.Join(conditions, ` AND `)
+}
+
+// placeholder appends the value to args and returns its positional placeholder (e.g. "$3").
+func placeholder(args *[]interface{}, value interface{}) string {
+	*args = append(*args, value)
+	return `$` + strconv.Itoa(len(*args))
+}
+
+// countRows returns the number of rows matching the where clause.
+// When there is no filter the planner's estimate from pg_class is used instead of scanning the table,
+// falling back to an exact count if the table has never been analyzed.
+// Parameters:
+// - from: the FROM expression, its first word must be the table name (e.g. "bookings bo")
+// - where: the WHERE clause built by whereClause
+// - args: the arguments referenced by the where clause
+// Returns the row count and an error if the operation fails.
+func (s *PostgresStore) countRows(from, where string, args []interface{}) (int64, error) {
+	if where == "" {
+		var estimate int64
+		err := s.db.QueryRow(`SELECT reltuples::BIGINT FROM pg_class WHERE relname = $1`, strings.Fields(from)[0]).
+			Scan(&estimate)
+		if err == nil && estimate >= 0 {
+			return estimate, nil
+		}
+	}
+
+	var count int64
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM `+from+where, args...).Scan(&count)
+	return count, err
+}
+
+// preconditionFailure tells apart the two reasons a versioned UPDATE can match no rows.
+// Soft-deleted rows count as missing.
+// Parameters:
+// - table: the table that was updated
+// - noun: how the row is called in error messages (e.g. "book")
+// - id: a pointer to the ID of the row that was updated
+// Returns 412 if the row exists (so its version did not match), 404 otherwise, and the matching error.
+func (s *PostgresStore) preconditionFailure(table, noun string, id *string) (int, types.Err) {
+	var exists bool
+	err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM `+table+` WHERE id = $1 AND deleted_at IS NULL)`, *id).Scan(&exists)
+	if err != nil || !exists {
+		return 404, types.Err{Error: noun + " not found"}
+	}
+
+	return 412, types.Err{Error: noun + " has been modified"}
+}
+
+// violates reports whether err is the violation of the named constraint or unique index.
+func violates(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Constraint == constraint
+}
+
+// nullString maps an empty string to NULL, for optional columns with a uniqueness constraint or a type of their own.
+func nullString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// nullInt maps 0 to NULL, for optional numeric columns where 0 means unknown.
+func nullInt(value int) interface{} {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
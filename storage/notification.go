@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Tus1688/library-management-api/pageutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/lib/pq"
+	"strings"
+	"time"
+)
+
+// GetDueNotifications lists the messages of a channel that are due today for open bookings:
+// a reminder from reminderDays before the due date, a notice on the due date and a notice every week while overdue,
+// and for holds that are ready for pickup.
+// Emails go to the customer email, SMS to the customer phone unless the patron opted out of them.
+// Messages already sent or being sent are left out, failed ones are listed again until maxAttempts is reached.
+// The messages of a booking are told apart by its due date, so a renewed loan is reminded again.
+// Parameters:
+// - channel: types.ChannelEmail or types.ChannelSMS
+// - kinds: the kinds of notification sent on this channel
+// - reminderDays: how many days before the due date the reminder is sent, 0 to send no reminder
+// - maxAttempts: how often a message is tried before it is given up
+// Returns the due notifications, oldest due date first, status code, and an error if the operation fails.
+func (s *PostgresStore) GetDueNotifications(channel string, kinds []string, reminderDays, maxAttempts int) (
+	[]types.DueNotification, int, types.Err) {
+	rows, err := s.db.Query(`WITH due AS (
+		SELECT bo.id AS booking_id, NULL::UUID AS hold_id, bo.customer_name, b.title, b.booked_until,
+		CASE WHEN $2 = 'sms' THEN bo.customer_phone ELSE bo.customer_email END AS recipient,
+		CASE WHEN CURRENT_DATE < b.booked_until::DATE THEN 'reminder'
+			WHEN CURRENT_DATE = b.booked_until::DATE THEN 'due' ELSE 'overdue' END AS kind,
+		CASE WHEN CURRENT_DATE > b.booked_until::DATE THEN (CURRENT_DATE - b.booked_until::DATE - 1) / 7
+			ELSE 0 END AS sequence
+		FROM bookings bo INNER JOIN books b ON bo.book_id = b.id
+		LEFT JOIN patrons p ON bo.patron_id = p.id
+		WHERE bo.is_returned = FALSE AND b.booked_until IS NOT NULL
+		AND CURRENT_DATE >= b.booked_until::DATE - $1::INTEGER
+		AND ($2 <> 'sms' OR NOT COALESCE(p.sms_opt_out, FALSE))
+		UNION ALL
+		SELECT NULL, h.id, p.name, b.title, h.expires_at,
+		CASE WHEN $2 = 'sms' THEN p.phone ELSE p.email END, 'hold_available', 0
+		FROM holds h INNER JOIN books b ON h.book_id = b.id
+		INNER JOIN patrons p ON h.patron_id = p.id
+		WHERE h.status = 'ready' AND h.expires_at > NOW() AND ($2 <> 'sms' OR NOT p.sms_opt_out)
+	)
+	SELECT COALESCE(due.booking_id::TEXT, ''), COALESCE(due.hold_id::TEXT, ''), due.kind, due.sequence,
+	due.recipient, due.customer_name, due.title, due.booked_until
+	FROM due LEFT JOIN notifications n
+		ON ((n.booking_id = due.booking_id AND n.due_date = due.booked_until::DATE) OR n.hold_id = due.hold_id)
+		AND n.kind = due.kind AND n.sequence = due.sequence AND n.channel = $2
+	WHERE due.recipient <> '' AND due.kind = ANY($4)
+	AND (n.id IS NULL OR (n.status = 'failed' AND n.attempts < $3))
+	ORDER BY due.booked_until`, reminderDays, channel, maxAttempts, pq.Array(kinds))
+	if err != nil {
+		return nil, 500, types.Err{Error: "unable to get due notifications"}
+	}
+	defer rows.Close()
+
+	var notifications []types.DueNotification
+	for rows.Next() {
+		notification := types.DueNotification{Channel: channel}
+		err := rows.Scan(&notification.BookingId, &notification.HoldId, &notification.Kind, &notification.Sequence, &notification.Recipient,
+			&notification.CustomerName, &notification.BookTitle, &notification.BookedUntil)
+		if err != nil {
+			return nil, 500, types.Err{Error: "unable to get due notifications"}
+		}
+		notifications = append(notifications, notification)
+	}
+
+	if rows.Err() != nil {
+		return nil, 500, types.Err{Error: "unable to get due notifications"}
+	}
+
+	return notifications, 200, types.Err{}
+}
+
+// ClaimNotification records that a notification is about to be sent, so no other run sends it as well.
+// A failed notification can be claimed again until maxAttempts is reached, a claim left pending is released by
+// ExpireNotificationClaims.
+// Parameters:
+// - notification: a pointer to the due notification, its ID is set when the claim succeeds
+// - maxAttempts: how often a message is tried before it is given up
+// Returns whether the notification was claimed, status code, and an error if the operation fails.
+func (s *PostgresStore) ClaimNotification(notification *types.DueNotification, maxAttempts int) (bool, int, types.Err) {
+	query := `INSERT INTO notifications(booking_id, kind, sequence, channel, recipient, attempts, claimed_at, due_date)
+	VALUES ($1, $2, $3, $4, $5, 1, NOW(), $7::TIMESTAMP::DATE)
+	ON CONFLICT (booking_id, due_date, kind, sequence, channel) WHERE booking_id IS NOT NULL DO UPDATE`
+	args := []interface{}{notification.BookingId, notification.Kind, notification.Sequence, notification.Channel,
+		notification.Recipient, maxAttempts, notification.BookedUntil}
+	if notification.HoldId != "" {
+		query = `INSERT INTO notifications(hold_id, kind, sequence, channel, recipient, attempts, claimed_at)
+		VALUES ($1, $2, $3, $4, $5, 1, NOW())
+		ON CONFLICT (hold_id, kind, sequence, channel) WHERE hold_id IS NOT NULL DO UPDATE`
+		args = []interface{}{notification.HoldId, notification.Kind, notification.Sequence, notification.Channel,
+			notification.Recipient, maxAttempts}
+	}
+
+	err := s.db.QueryRow(query+`
+	SET status = 'pending', attempts = notifications.attempts + 1, recipient = EXCLUDED.recipient, claimed_at = NOW()
+	WHERE notifications.status = 'failed' AND notifications.attempts < $6
+	RETURNING id`, args...).Scan(&notification.Id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, 200, types.Err{}
+		}
+
+		return false, 500, types.Err{Error: "unable to claim notification"}
+	}
+
+	return true, 200, types.Err{}
+}
+
+// FinishNotification records the outcome of sending a claimed notification.
+// Parameters:
+// - id: a pointer to the notification ID
+// - failure: why sending failed, empty if the notification was sent
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) FinishNotification(id *string, failure string) (int, types.Err) {
+	_, err := s.db.Exec(`UPDATE notifications SET
+	status = CASE WHEN $2 = '' THEN 'sent' ELSE 'failed' END, last_error = $2,
+	sent_at = CASE WHEN $2 = '' THEN NOW() END
+	WHERE id = $1`, *id, failure)
+	if err != nil {
+		return 500, types.Err{Error: "unable to update notification"}
+	}
+
+	return 200, types.Err{}
+}
+
+// ExpireNotificationClaims marks the notifications claimed longer than the lease ago and still pending as failed.
+// Their sending was interrupted before it was finished, they are claimed again like any failed notification.
+// Parameters:
+// - lease: how long a claimed notification may take to be sent
+// Returns the number of expired claims, status code, and an error if the operation fails.
+func (s *PostgresStore) ExpireNotificationClaims(lease time.Duration) (int64, int, types.Err) {
+	result, err := s.db.Exec(`UPDATE notifications SET status = 'failed', last_error = 'sending was interrupted'
+	WHERE status = 'pending' AND claimed_at < NOW() - MAKE_INTERVAL(secs => $1::FLOAT8)`, lease.Seconds())
+	if err != nil {
+		return 0, 500, types.Err{Error: "unable to expire notification claims"}
+	}
+
+	expired, _ := result.RowsAffected()
+	return expired, 200, types.Err{}
+}
+
+// GetNotification retrieves a page of notifications, optionally filtered by status or booking.
+// An empty result is not an error, it yields a page with no items.
+// Parameters:
+// - filter: a pointer to the NotificationFilter containing the status, booking ID, cursor and page size
+// Returns a page of ListNotification, status code, and an error if the operation fails.
+func (s *PostgresStore) GetNotification(filter *types.NotificationFilter) (types.Page[types.ListNotification], int,
+	types.Err) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Status != "" {
+		conditions = append(conditions, `n.status = `+placeholder(&args, filter.Status))
+	}
+	if filter.BookingId != "" {
+		conditions = append(conditions, `n.booking_id = `+placeholder(&args, filter.BookingId))
+	}
+
+	total, err := s.countRows("notifications n", whereClause(conditions), args)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return types.Page[types.ListNotification]{}, 400, types.Err{Error: "invalid booking_id"}
+		}
+
+		return types.Page[types.ListNotification]{}, 500, types.Err{Error: "unable to get notifications"}
+	}
+
+	if filter.Cursor != 0 {
+		conditions = append(conditions, `n.pagination_id < `+placeholder(&args, filter.Cursor))
+	}
+
+	rows, err := s.db.Query(`SELECT n.id, n.pagination_id, COALESCE(n.booking_id::TEXT, ''),
+	COALESCE(n.hold_id::TEXT, ''), n.kind, n.sequence, n.channel, n.recipient,
+	n.status, n.attempts, n.last_error, COALESCE(n.sent_at::TEXT, ''), n.created_at, n.updated_at
+	FROM notifications n`+whereClause(conditions)+
+		` ORDER BY n.pagination_id DESC LIMIT `+placeholder(&args, filter.Limit+1), args...)
+	if err != nil {
+		return types.Page[types.ListNotification]{}, 500, types.Err{Error: "unable to get notifications"}
+	}
+	defer rows.Close()
+
+	var notifications []types.ListNotification
+	for rows.Next() {
+		var n types.ListNotification
+		err := rows.Scan(&n.Id, &n.PaginationId, &n.BookingId, &n.HoldId, &n.Kind, &n.Sequence, &n.Channel, &n.Recipient,
+			&n.Status, &n.Attempts, &n.LastError, &n.SentAt, &n.CreatedAt, &n.UpdatedAt)
+		if err != nil {
+			return types.Page[types.ListNotification]{}, 500, types.Err{Error: "unable to get notifications"}
+		}
+		notifications = append(notifications, n)
+	}
+
+	return pageutil.NewPage(notifications, filter.Limit, total, func(n types.ListNotification) int64 {
+		return int64(n.PaginationId)
+	}), 200, types.Err{}
+}
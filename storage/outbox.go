@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Tus1688/library-management-api/pageutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/goccy/go-json"
+	"time"
+)
+
+// outboxLock is the advisory lock held while events are numbered, so two relays never hand out positions at once.
+// Writers do not take it, they insert their events without a position.
+const outboxLock = 7_001_001
+
+// writeEvent records an event in the outbox within the transaction of the change it reports.
+// The event gets its position from SequenceOutbox once the transaction has committed.
+// Parameters:
+// - tx: the transaction of the change
+// - event: the event type, such as types.EventBookCreated
+// - data: the resources the event is about
+// Returns an error if a statement fails.
+func writeEvent(tx *sql.Tx, event string, data types.EventRef) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO outbox(type, data) VALUES ($1, $2)`, event, string(payload))
+	return err
+}
+
+// SequenceOutbox gives a position to the events written by the transactions older than every running one.
+// Such a transaction has either committed or rolled back, so no event can show up later with a lower position
+// than one a sink has already seen. Events are numbered in the order of their transactions, and in the order
+// they were written within one.
+// Returns the number of events numbered, status code, and an error if the operation fails.
+func (s *PostgresStore) SequenceOutbox() (int64, int, types.Err) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 500, types.Err{Error: "unable to sequence outbox"}
+	}
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, outboxLock); err != nil {
+		tx.Rollback()
+		return 0, 500, types.Err{Error: "unable to sequence outbox"}
+	}
+
+	result, err := tx.Exec(`UPDATE outbox o SET position = n.position FROM (
+		SELECT p.id, nextval('outbox_position_seq') AS position FROM (
+			SELECT id FROM outbox WHERE position IS NULL AND xid < pg_snapshot_xmin(pg_current_snapshot())
+			ORDER BY xid, seq
+		) p
+	) n WHERE o.id = n.id`)
+	if err != nil {
+		tx.Rollback()
+		return 0, 500, types.Err{Error: "unable to sequence outbox"}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return 0, 500, types.Err{Error: "unable to sequence outbox"}
+	}
+
+	sequenced, _ := result.RowsAffected()
+	return sequenced, 200, types.Err{}
+}
+
+// GetOutboxCursor returns the position of the last event published to a sink.
+// A sink seen for the first time starts after the newest event, events before it are only sent by a replay.
+// Parameters:
+// - sink: the name of the sink
+// Returns the position, status code, and an error if the operation fails.
+func (s *PostgresStore) GetOutboxCursor(sink string) (int64, int, types.Err) {
+	_, err := s.db.Exec(`INSERT INTO outbox_cursors(sink, position)
+	SELECT $1, COALESCE(MAX(position), 0) FROM outbox ON CONFLICT (sink) DO NOTHING`, sink)
+	if err != nil {
+		return 0, 500, types.Err{Error: "unable to get outbox cursor"}
+	}
+
+	var position int64
+	err = s.db.QueryRow(`SELECT position FROM outbox_cursors WHERE sink = $1`, sink).Scan(&position)
+	if err != nil {
+		return 0, 500, types.Err{Error: "unable to get outbox cursor"}
+	}
+
+	return position, 200, types.Err{}
+}
+
+// UpdateOutboxCursor records the progress of a sink.
+// Parameters:
+// - sink: the name of the sink
+// - position: the position of the last event published
+// - failure: why publishing the next event failed, empty if it did not
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) UpdateOutboxCursor(sink string, position int64, failure string) (int, types.Err) {
+	_, err := s.db.Exec(`UPDATE outbox_cursors SET position = $2, last_error = $3 WHERE sink = $1`,
+		sink, position, failure)
+	if err != nil {
+		return 500, types.Err{Error: "unable to update outbox cursor"}
+	}
+
+	return 200, types.Err{}
+}
+
+// GetOutboxEvents lists the events after a position, in order.
+// Parameters:
+// - after: the position of the last event already seen
+// - limit: the maximum number of events
+// Returns the events, status code, and an error if the operation fails.
+func (s *PostgresStore) GetOutboxEvents(after int64, limit int) ([]types.Event, int, types.Err) {
+	rows, err := s.db.Query(`SELECT position, id, type, data::TEXT, created_at FROM outbox
+	WHERE position > $1 ORDER BY position LIMIT $2`, after, limit)
+	if err != nil {
+		return nil, 500, types.Err{Error: "unable to get outbox events"}
+	}
+	defer rows.Close()
+
+	var events []types.Event
+	for rows.Next() {
+		var event types.Event
+		var data string
+		if err := rows.Scan(&event.Position, &event.Id, &event.Type, &data, &event.CreatedAt); err != nil {
+			return nil, 500, types.Err{Error: "unable to get outbox events"}
+		}
+		event.Data = json.RawMessage(data)
+		events = append(events, event)
+	}
+
+	if rows.Err() != nil {
+		return nil, 500, types.Err{Error: "unable to get outbox events"}
+	}
+
+	return events, 200, types.Err{}
+}
+
+// GetOutbox retrieves a page of events, newest first, optionally filtered by type.
+// Events not yet numbered by SequenceOutbox are left out.
+// An empty result is not an error, it yields a page with no items.
+// Parameters:
+// - filter: a pointer to the EventFilter containing the type, cursor and page size
+// Returns a page of Event, status code, and an error if the operation fails.
+func (s *PostgresStore) GetOutbox(filter *types.EventFilter) (types.Page[types.Event], int, types.Err) {
+	conditions := []string{`o.position IS NOT NULL`}
+	var args []interface{}
+
+	if filter.Type != "" {
+		conditions = append(conditions, `o.type = `+placeholder(&args, filter.Type))
+	}
+
+	total, err := s.countRows("outbox o", whereClause(conditions), args)
+	if err != nil {
+		return types.Page[types.Event]{}, 500, types.Err{Error: "unable to get outbox events"}
+	}
+
+	if filter.Cursor != 0 {
+		conditions = append(conditions, `o.position < `+placeholder(&args, filter.Cursor))
+	}
+
+	rows, err := s.db.Query(`SELECT o.position, o.id, o.type, o.data::TEXT, o.created_at FROM outbox o`+
+		whereClause(conditions)+` ORDER BY o.position DESC LIMIT `+placeholder(&args, filter.Limit+1), args...)
+	if err != nil {
+		return types.Page[types.Event]{}, 500, types.Err{Error: "unable to get outbox events"}
+	}
+	defer rows.Close()
+
+	var events []types.Event
+	for rows.Next() {
+		var event types.Event
+		var data string
+		if err := rows.Scan(&event.Position, &event.Id, &event.Type, &data, &event.CreatedAt); err != nil {
+			return types.Page[types.Event]{}, 500, types.Err{Error: "unable to get outbox events"}
+		}
+		event.Data = json.RawMessage(data)
+		events = append(events, event)
+	}
+
+	return pageutil.NewPage(events, filter.Limit, total, func(event types.Event) int64 {
+		return event.Position
+	}), 200, types.Err{}
+}
+
+// GetOutboxSinks lists every sink with its position and the number of events it has yet to publish.
+// Returns the sinks, status code, and an error if the operation fails.
+func (s *PostgresStore) GetOutboxSinks() ([]types.OutboxSink, int, types.Err) {
+	rows, err := s.db.Query(`SELECT c.sink, c.position,
+	GREATEST((SELECT COALESCE(MAX(position), 0) FROM outbox) - c.position, 0), c.last_error, c.updated_at
+	FROM outbox_cursors c ORDER BY c.sink`)
+	if err != nil {
+		return nil, 500, types.Err{Error: "unable to get outbox sinks"}
+	}
+	defer rows.Close()
+
+	sinks := []types.OutboxSink{}
+	for rows.Next() {
+		var sink types.OutboxSink
+		if err := rows.Scan(&sink.Sink, &sink.Position, &sink.Lag, &sink.LastError, &sink.UpdatedAt); err != nil {
+			return nil, 500, types.Err{Error: "unable to get outbox sinks"}
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, 200, types.Err{}
+}
+
+// ReplayOutbox moves the cursor of a sink back, the relay publishes the events after it again.
+// Parameters:
+// - sink: the name of the sink
+// - fromPosition: the position of the first event to publish again, used when since is zero
+// - since: the creation time of the first event to publish again
+// Returns the new position of the cursor, status code, and an error if the sink is unknown.
+func (s *PostgresStore) ReplayOutbox(sink string, fromPosition int64, since time.Time) (int64, int, types.Err) {
+	query := `UPDATE outbox_cursors SET position = LEAST(position, GREATEST($2::BIGINT - 1, 0)), last_error = ''
+	WHERE sink = $1 RETURNING position`
+	args := []interface{}{sink, fromPosition}
+	if !since.IsZero() {
+		query = `UPDATE outbox_cursors SET position = LEAST(position,
+		COALESCE((SELECT MAX(o.position) FROM outbox o WHERE o.created_at < $2::TIMESTAMPTZ), 0)), last_error = ''
+		WHERE sink = $1 RETURNING position`
+		args = []interface{}{sink, since}
+	}
+
+	var position int64
+	err := s.db.QueryRow(query, args...).Scan(&position)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 404, types.Err{Error: "sink not found"}
+		}
+
+		return 0, 500, types.Err{Error: "unable to replay outbox"}
+	}
+
+	return position, 200, types.Err{}
+}
+
+// PurgeOutbox deletes the events older than the given number of days that every sink has published.
+// Parameters:
+// - days: how many days of events are kept
+// Returns the number of deleted events, status code, and an error if the operation fails.
+func (s *PostgresStore) PurgeOutbox(days int) (int64, int, types.Err) {
+	result, err := s.db.Exec(`DELETE FROM outbox WHERE created_at < NOW() - MAKE_INTERVAL(days => $1)
+	AND position <= (SELECT COALESCE(MIN(position), 0) FROM outbox_cursors)`, days)
+	if err != nil {
+		return 0, 500, types.Err{Error: "unable to purge outbox"}
+	}
+
+	deleted, _ := result.RowsAffected()
+	return deleted, 200, types.Err{}
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"github.com/Tus1688/library-management-api/types"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newTestStore connects to the database of the POSTGRES_* variables, the test is skipped without one.
+// The database is expected to have the schema applied.
+func newTestStore(t *testing.T) *PostgresStore {
+	t.Helper()
+	if os.Getenv("POSTGRES_HOST") == "" {
+		t.Skip("POSTGRES_HOST is not set")
+	}
+
+	store, err := NewPostgresStore()
+	if err != nil {
+		t.Fatalf("NewPostgresStore returned an error: %v", err)
+	}
+	t.Cleanup(func() { store.Shutdown() })
+	return store
+}
+
+// waitForLockWait waits until a statement of the database is waiting for a row lock.
+func waitForLockWait(t *testing.T, store *PostgresStore) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var waiting int
+		err := store.db.QueryRow(`SELECT COUNT(*) FROM pg_stat_activity
+		WHERE datname = current_database() AND wait_event_type = 'Lock' AND query LIKE '%FOR UPDATE%'`).Scan(&waiting)
+		if err != nil {
+			t.Fatalf("unable to read pg_stat_activity: %v", err)
+		}
+		if waiting > 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("the scan never waited for the booked item")
+}
+
+func TestScanWhileBooking(t *testing.T) {
+	store := newTestStore(t)
+
+	var patronId, cardNumber string
+	err := store.db.QueryRow(`INSERT INTO patrons(name, phone) VALUES ('Outbox Test', '+10000000000')
+	RETURNING id, card_number`).Scan(&patronId, &cardNumber)
+	if err != nil {
+		t.Fatalf("unable to create patron: %v", err)
+	}
+
+	bookIds := make([]string, 2)
+	barcodes := make([]string, 2)
+	for i := range bookIds {
+		err := store.db.QueryRow(`INSERT INTO books(title, author, description) VALUES ('Outbox Test', 'Tester', '')
+		RETURNING id, barcode`).Scan(&bookIds[i], &barcodes[i])
+		if err != nil {
+			t.Fatalf("unable to create book: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		store.db.Exec(`DELETE FROM outbox WHERE data->>'book_id' = ANY($1::TEXT[])`, "{"+bookIds[0]+","+bookIds[1]+"}")
+		store.db.Exec(`DELETE FROM bookings WHERE book_id = $1 OR book_id = $2`, bookIds[0], bookIds[1])
+		store.db.Exec(`DELETE FROM books WHERE id = $1 OR id = $2`, bookIds[0], bookIds[1])
+		store.db.Exec(`DELETE FROM patrons WHERE id = $1`, patronId)
+	})
+
+	// a booking of the second book holds its row until it commits, as CreateBooking does
+	booking, err := store.db.Begin()
+	if err != nil {
+		t.Fatalf("unable to begin booking: %v", err)
+	}
+	defer booking.Rollback()
+	if _, err := booking.Exec(`SELECT id FROM books WHERE id = $1 FOR UPDATE`, bookIds[1]); err != nil {
+		t.Fatalf("unable to lock book: %v", err)
+	}
+	var bookingId string
+	err = booking.QueryRow(`INSERT INTO bookings(book_id, customer_name, customer_phone, due_at)
+	VALUES ($1, 'Desk', '+1', NOW() + $2::INTERVAL) RETURNING id`, bookIds[1], loanPeriod).Scan(&bookingId)
+	if err != nil {
+		t.Fatalf("unable to create booking: %v", err)
+	}
+	if _, err := booking.Exec(`UPDATE books SET is_booked = TRUE WHERE id = $1`, bookIds[1]); err != nil {
+		t.Fatalf("unable to book book: %v", err)
+	}
+
+	type scanned struct {
+		result     types.ScanResult
+		statusCode int
+		err        types.Err
+	}
+	done := make(chan scanned, 1)
+	go func() {
+		result, statusCode, errResp := store.ScanItems(nil, &types.ScanRequest{CardNumber: cardNumber, Barcodes: barcodes})
+		done <- scanned{result, statusCode, errResp}
+	}()
+
+	// the scan has checked out the first book and written its event, and now waits for the second
+	waitForLockWait(t, store)
+	err = writeEvent(booking, types.EventBookingCreated, types.EventRef{Id: bookingId, BookId: bookIds[1]})
+	if err != nil {
+		t.Fatalf("writeEvent of the booking returned an error: %v", err)
+	}
+	if err := booking.Commit(); err != nil {
+		t.Fatalf("unable to commit booking: %v", err)
+	}
+
+	var scan scanned
+	select {
+	case scan = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("ScanItems did not finish")
+	}
+	if scan.statusCode != 200 || scan.err.Error != "" {
+		t.Fatalf("ScanItems = %d %q, want 200", scan.statusCode, scan.err.Error)
+	}
+	if scan.result.CheckedOut != 1 || scan.result.Returned != 1 {
+		t.Errorf("ScanItems checked out %d and returned %d, want 1 each", scan.result.CheckedOut, scan.result.Returned)
+	}
+
+	if _, statusCode, errResp := store.SequenceOutbox(); statusCode != 200 {
+		t.Fatalf("SequenceOutbox = %d %q, want 200", statusCode, errResp.Error)
+	}
+
+	// the booking locked a row before the scan did, so its event is numbered before the events of the scan
+	rows, err := store.db.Query(`SELECT type FROM outbox WHERE data->>'book_id' = ANY($1::TEXT[]) ORDER BY position`,
+		"{"+bookIds[0]+","+bookIds[1]+"}")
+	if err != nil {
+		t.Fatalf("unable to read outbox: %v", err)
+	}
+	defer rows.Close()
+	var events []string
+	for rows.Next() {
+		var event string
+		if err := rows.Scan(&event); err != nil {
+			t.Fatalf("unable to read outbox: %v", err)
+		}
+		events = append(events, event)
+	}
+	want := []string{types.EventBookingCreated, types.EventBookingCreated, types.EventBookingReturned}
+	if !slices.Equal(events, want) {
+		t.Errorf("outbox events = %v, want %v", events, want)
+	}
+}
@@ -0,0 +1,357 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Tus1688/library-management-api/pageutil"
+	"github.com/Tus1688/library-management-api/types"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxPinFailures is the number of wrong PINs in a row that locks a card at the kiosks.
+	maxPinFailures = 5
+	// pinLockout is how long a card stays locked, as a Postgres interval.
+	pinLockout = "15 minutes"
+)
+
+// patronColumns are the columns scanned by scanPatron, in order, the patrons table must be aliased as p.
+const patronColumns = `p.id, p.pagination_id, p.card_number, p.name, p.phone, p.email, p.sms_opt_out,
+	(SELECT COUNT(*) FROM bookings bo WHERE bo.patron_id = p.id AND bo.is_returned = FALSE), p.pin_hash IS NOT NULL,
+	p.created_at, p.updated_at, p.version`
+
+// patronFields returns the scan destinations matching patronColumns.
+func patronFields(patron *types.ListPatron) []interface{} {
+	return []interface{}{&patron.Id, &patron.PaginationId, &patron.CardNumber, &patron.Name, &patron.Phone,
+		&patron.Email, &patron.SmsOptOut, &patron.ActiveLoans, &patron.HasPin, &patron.CreatedAt, &patron.UpdatedAt, &patron.Version}
+}
+
+// scanPatron scans a row selected with patronColumns.
+func scanPatron(rows *sql.Rows) (types.ListPatron, error) {
+	var patron types.ListPatron
+	err := rows.Scan(patronFields(&patron)...)
+	return patron, err
+}
+
+// GetPatron retrieves a page of patrons, optionally filtered by a search on their name, phone or card number.
+// An empty result is not an error, it yields a page with no items.
+// Parameters:
+// - filter: a pointer to the PatronFilter containing the search query, cursor and page size
+// Returns a page of ListPatron, status code, and an error if the operation fails.
+func (s *PostgresStore) GetPatron(filter *types.PatronFilter) (types.Page[types.ListPatron], int, types.Err) {
+	conditions := []string{`p.deleted_at IS NULL`}
+	var args []interface{}
+
+	if filter.Search != "" {
+		search := placeholder(&args, filter.Search)
+		conditions = append(conditions, `(p.name ILIKE '%' || `+search+` || '%'
+		OR p.phone ILIKE '%' || `+search+` || '%' OR p.card_number = upper(`+search+`))`)
+	}
+
+	total, err := s.countRows("patrons p", whereClause(conditions), args)
+	if err != nil {
+		return types.Page[types.ListPatron]{}, 500, types.Err{Error: "unable to get patrons"}
+	}
+
+	if filter.Cursor != 0 {
+		conditions = append(conditions, `p.pagination_id < `+placeholder(&args, filter.Cursor))
+	}
+
+	query := `SELECT ` + patronColumns + ` FROM patrons p` + whereClause(conditions) +
+		` ORDER BY p.pagination_id DESC LIMIT ` + placeholder(&args, filter.Limit+1)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return types.Page[types.ListPatron]{}, 500, types.Err{Error: "unable to get patrons"}
+	}
+	defer rows.Close()
+
+	var patrons []types.ListPatron
+	for rows.Next() {
+		patron, err := scanPatron(rows)
+		if err != nil {
+			return types.Page[types.ListPatron]{}, 500, types.Err{Error: "unable to get patrons"}
+		}
+		patrons = append(patrons, patron)
+	}
+
+	return pageutil.NewPage(patrons, filter.Limit, total, func(p types.ListPatron) int64 {
+		return int64(p.PaginationId)
+	}), 200, types.Err{}
+}
+
+// GetPatronById retrieves a single patron together with the number of books it currently has on loan.
+// Parameters:
+// - id: a pointer to the patron ID
+// Returns the ListPatron, status code, and an error if the operation fails.
+func (s *PostgresStore) GetPatronById(id *string) (types.ListPatron, int, types.Err) {
+	var patron types.ListPatron
+	err := s.db.QueryRow(`SELECT `+patronColumns+` FROM patrons p WHERE p.id = $1 AND p.deleted_at IS NULL`, *id).
+		Scan(patronFields(&patron)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.ListPatron{}, 404, types.Err{Error: "patron not found"}
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return types.ListPatron{}, 400, types.Err{Error: "invalid id"}
+		}
+
+		return types.ListPatron{}, 500, types.Err{Error: "unable to get patron"}
+	}
+
+	return patron, 200, types.Err{}
+}
+
+// hashPin hashes a patron PIN using bcrypt, an empty PIN yields NULL.
+func hashPin(pin string) (interface{}, error) {
+	if pin == "" {
+		return nil, nil
+	}
+
+	hashedPin, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(hashedPin), nil
+}
+
+// CreatePatron inserts a new patron, taking the next card number from the sequence when none is given.
+// The PIN, if any, is hashed using bcrypt.
+// Parameters:
+// - req: a pointer to the normalized CreatePatron request
+// Returns the created patron ID, status code, and an error if the operation fails.
+func (s *PostgresStore) CreatePatron(req *types.CreatePatron) (types.CreateId, int, types.Err) {
+	hashedPin, err := hashPin(req.Pin)
+	if err != nil {
+		return types.CreateId{}, 500, types.Err{Error: "unable to create patron"}
+	}
+
+	query := `INSERT INTO patrons(name, phone, email, sms_opt_out, pin_hash) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	args := []interface{}{req.Name, req.Phone, req.Email, req.SmsOptOut, hashedPin}
+	if req.CardNumber != "" {
+		query = `INSERT INTO patrons(name, phone, email, sms_opt_out, pin_hash, card_number)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+		args = append(args, req.CardNumber)
+	}
+
+	var id types.CreateId
+	err = s.db.QueryRow(query, args...).Scan(&id.Id)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") {
+			return types.CreateId{}, 409, types.Err{Error: "patron with that card number already exists"}
+		}
+
+		return types.CreateId{}, 500, types.Err{Error: "unable to create patron"}
+	}
+
+	return id, 201, types.Err{}
+}
+
+// UpdatePatron replaces the name, phone, email and SMS opt-out of a patron, and its card number or PIN when a new one is given.
+// Setting a PIN also lifts a lockout caused by wrong PINs.
+// If version is not 0 the update only succeeds when it matches the stored version.
+// Parameters:
+// - req: a pointer to the normalized UpdatePatron request
+// - version: the version the client based its changes on, 0 to skip the check
+// Returns the new version, status code, and an error if the operation fails.
+func (s *PostgresStore) UpdatePatron(req *types.UpdatePatron, version int) (int, int, types.Err) {
+	hashedPin, err := hashPin(req.Pin)
+	if err != nil {
+		return 0, 500, types.Err{Error: "unable to update patron"}
+	}
+
+	args := []interface{}{req.Name, req.Phone, req.Email, req.SmsOptOut, nullString(req.CardNumber), hashedPin, req.Id}
+	query := `UPDATE patrons SET name = $1, phone = $2, email = $3, sms_opt_out = $4,
+	card_number = COALESCE($5, card_number), pin_hash = COALESCE($6, pin_hash),
+	pin_failures = CASE WHEN $6 IS NULL THEN pin_failures ELSE 0 END,
+	pin_locked_until = CASE WHEN $6 IS NULL THEN pin_locked_until END
+	WHERE id = $7 AND deleted_at IS NULL`
+	if version != 0 {
+		query += ` AND version = ` + placeholder(&args, version)
+	}
+	query += ` RETURNING version`
+
+	var newVersion int
+	err = s.db.QueryRow(query, args...).Scan(&newVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			statusCode, err := s.preconditionFailure("patrons", "patron", &req.Id)
+			return 0, statusCode, err
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return 0, 400, types.Err{Error: "invalid id"}
+		}
+
+		if strings.Contains(err.Error(), "duplicate") {
+			return 0, 409, types.Err{Error: "patron with that card number already exists"}
+		}
+
+		return 0, 500, types.Err{Error: "unable to update patron"}
+	}
+
+	return newVersion, 200, types.Err{}
+}
+
+// DeletePatron soft-deletes a patron so its past bookings stay intact.
+// If the patron still has books on loan, it returns a 409 status code.
+// Parameters:
+// - id: a pointer to the patron ID to be deleted
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) DeletePatron(id *string) (int, types.Err) {
+	res, err := s.db.Exec(`UPDATE patrons p SET deleted_at = NOW() WHERE p.id = $1 AND p.deleted_at IS NULL
+	AND NOT EXISTS (SELECT 1 FROM bookings bo WHERE bo.patron_id = p.id AND bo.is_returned = FALSE)`, *id)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return 400, types.Err{Error: "invalid id"}
+		}
+
+		return 500, types.Err{Error: "unable to delete patron"}
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 500, types.Err{Error: "unable to delete patron"}
+	}
+
+	if rowsAffected == 0 {
+		var exists bool
+		err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM patrons WHERE id = $1 AND deleted_at IS NULL)`, *id).
+			Scan(&exists)
+		if err == nil && exists {
+			return 409, types.Err{Error: "patron still has books on loan"}
+		}
+
+		return 404, types.Err{Error: "patron not found"}
+	}
+
+	return 200, types.Err{}
+}
+
+// dummyPinHash is compared against when there is no PIN to check, so an unknown, locked or PIN-less card
+// takes as long to answer as a wrong PIN.
+var dummyPinHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("0000"), bcrypt.DefaultCost)
+	return hash
+})
+
+// VerifyPatronPin checks the PIN a patron entered at a kiosk or in the portal together with the card number.
+// After maxPinFailures wrong PINs in a row the card is locked for pinLockout, so PINs cannot be guessed.
+// The row of the patron is locked while the PIN is checked, so concurrent attempts are counted one after another.
+// An unknown card, a locked card and a wrong PIN get the same answer in the same time.
+// Parameters:
+// - cardNumber: a pointer to the normalized card number
+// - pin: a pointer to the PIN as entered
+// Returns the patron ID, status code, and an error if the card and PIN do not match or the card is locked.
+func (s *PostgresStore) VerifyPatronPin(cardNumber, pin *string) (string, int, types.Err) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", 500, types.Err{Error: "unable to verify pin"}
+	}
+
+	var patronId string
+	var hashedPin []byte
+	var locked bool
+	err = tx.QueryRow(`SELECT id, pin_hash, COALESCE(pin_locked_until > NOW(), FALSE) FROM patrons
+	WHERE card_number = $1 AND deleted_at IS NULL FOR UPDATE`, *cardNumber).Scan(&patronId, &hashedPin, &locked)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return "", 500, types.Err{Error: "unable to verify pin"}
+	}
+
+	if err != nil || hashedPin == nil || locked {
+		tx.Rollback()
+		_ = bcrypt.CompareHashAndPassword(dummyPinHash(), []byte(*pin))
+		return "", 401, types.Err{Error: "invalid card number or pin"}
+	}
+
+	if bcrypt.CompareHashAndPassword(hashedPin, []byte(*pin)) != nil {
+		_, err := tx.Exec(`UPDATE patrons SET
+		pin_failures = CASE WHEN pin_failures + 1 >= $2 THEN 0 ELSE pin_failures + 1 END,
+		pin_locked_until = CASE WHEN pin_failures + 1 >= $2 THEN NOW() + $3::INTERVAL ELSE pin_locked_until END
+		WHERE id = $1`, patronId, maxPinFailures, pinLockout)
+		if err != nil {
+			tx.Rollback()
+			return "", 500, types.Err{Error: "unable to verify pin"}
+		}
+
+		if err := tx.Commit(); err != nil {
+			tx.Rollback()
+			return "", 500, types.Err{Error: "unable to verify pin"}
+		}
+
+		return "", 401, types.Err{Error: "invalid card number or pin"}
+	}
+
+	_, err = tx.Exec(`UPDATE patrons SET pin_failures = 0, pin_locked_until = NULL
+	WHERE id = $1 AND (pin_failures > 0 OR pin_locked_until IS NOT NULL)`, patronId)
+	if err != nil {
+		tx.Rollback()
+		return "", 500, types.Err{Error: "unable to verify pin"}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return "", 500, types.Err{Error: "unable to verify pin"}
+	}
+
+	return patronId, 200, types.Err{}
+}
+
+// GetPatronByCard retrieves the patron holding a card.
+// Parameters:
+// - cardNumber: a pointer to the normalized card number
+// Returns the ListPatron, status code, and an error if the operation fails.
+func (s *PostgresStore) GetPatronByCard(cardNumber *string) (types.ListPatron, int, types.Err) {
+	var patron types.ListPatron
+	err := s.db.QueryRow(`SELECT `+patronColumns+` FROM patrons p WHERE p.card_number = $1 AND p.deleted_at IS NULL`,
+		*cardNumber).Scan(patronFields(&patron)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.ListPatron{}, 404, types.Err{Error: "patron not found"}
+		}
+
+		return types.ListPatron{}, 500, types.Err{Error: "unable to get patron"}
+	}
+
+	return patron, 200, types.Err{}
+}
+
+// IsPatronActive reports whether a patron still exists, so a deleted patron cannot keep using the portal.
+// Parameters:
+// - id: a pointer to the patron ID
+// Returns whether the patron is active, status code, and an error if the operation fails.
+func (s *PostgresStore) IsPatronActive(id *string) (bool, int, types.Err) {
+	var active bool
+	err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM patrons WHERE id = $1 AND deleted_at IS NULL)`, *id).
+		Scan(&active)
+	if err != nil {
+		return false, 500, types.Err{Error: "unable to check patron"}
+	}
+
+	return active, 200, types.Err{}
+}
+
+// UpdatePatronContact replaces the phone, email and SMS opt-out of a patron, as changed by the patron in the portal.
+// Parameters:
+// - id: a pointer to the ID of the signed in patron
+// - req: a pointer to the normalized UpdatePatronContact request
+// Returns the new version, status code, and an error if the operation fails.
+func (s *PostgresStore) UpdatePatronContact(id *string, req *types.UpdatePatronContact) (int, int, types.Err) {
+	var version int
+	err := s.db.QueryRow(`UPDATE patrons SET phone = $1, email = $2, sms_opt_out = $3
+	WHERE id = $4 AND deleted_at IS NULL RETURNING version`, req.Phone, req.Email, req.SmsOptOut, *id).Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 404, types.Err{Error: "patron not found"}
+		}
+
+		return 0, 500, types.Err{Error: "unable to update patron"}
+	}
+
+	return version, 200, types.Err{}
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"sync"
+	"testing"
+)
+
+func TestVerifyPatronPinLockout(t *testing.T) {
+	store := newTestStore(t)
+
+	hashedPin, err := bcrypt.GenerateFromPassword([]byte("1234"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("unable to hash pin: %v", err)
+	}
+	var patronId, cardNumber string
+	err = store.db.QueryRow(`INSERT INTO patrons(name, phone, pin_hash) VALUES ('Pin Test', '+10000000000', $1)
+	RETURNING id, card_number`, hashedPin).Scan(&patronId, &cardNumber)
+	if err != nil {
+		t.Fatalf("unable to create patron: %v", err)
+	}
+	t.Cleanup(func() { store.db.Exec(`DELETE FROM patrons WHERE id = $1`, patronId) })
+
+	// a burst of wrong PINs larger than maxPinFailures, the failures after the lock must keep it
+	var wg sync.WaitGroup
+	for i := 0; i < maxPinFailures+2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wrong := "0000"
+			if _, statusCode, _ := store.VerifyPatronPin(&cardNumber, &wrong); statusCode != 401 {
+				t.Errorf("VerifyPatronPin with a wrong pin = %d, want 401", statusCode)
+			}
+		}()
+	}
+	wg.Wait()
+
+	pin := "1234"
+	if _, statusCode, errResp := store.VerifyPatronPin(&cardNumber, &pin); statusCode != 401 ||
+		errResp.Error != "invalid card number or pin" {
+		t.Errorf("VerifyPatronPin of a locked card = %d %q, want the answer of a wrong pin", statusCode, errResp.Error)
+	}
+
+	unknown := "P999999999"
+	if _, statusCode, _ := store.VerifyPatronPin(&unknown, &pin); statusCode != 401 {
+		t.Errorf("VerifyPatronPin of an unknown card = %d, want 401", statusCode)
+	}
+}
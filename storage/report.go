@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"github.com/Tus1688/library-management-api/types"
+)
+
+// loanDue is when a booking aliased bo is due, renewals included, the same as the booked_until of bookingColumns.
+const loanDue = `bo.due_at`
+
+// createdWithin limits the bookings aliased bo to those made within the inclusive dates $1 and $2.
+const createdWithin = `bo.created_at >= $1::DATE AND bo.created_at < $2::DATE + 1`
+
+// ReportLoans counts the loans made per day, week or month, periods without loans are included.
+// Parameters:
+// - params: a pointer to the ReportParams with the range and the interval
+// Returns the loans per period in order, status code, and an error if the operation fails.
+func (s *PostgresStore) ReportLoans(params *types.ReportParams) ([]types.LoanPeriod, int, types.Err) {
+	rows, err := s.db.Query(`SELECT p.period::DATE::TEXT, COUNT(bo.id)
+	FROM generate_series(date_trunc($3::TEXT, $1::DATE::TIMESTAMP), $2::DATE::TIMESTAMP, ('1 ' || $3::TEXT)::INTERVAL)
+		AS p(period)
+	LEFT JOIN bookings bo ON date_trunc($3::TEXT, bo.created_at) = p.period AND `+createdWithin+`
+	GROUP BY p.period ORDER BY p.period`, params.From, params.To, params.Interval)
+	if err != nil {
+		return nil, 500, types.Err{Error: "unable to get report"}
+	}
+	defer rows.Close()
+
+	var periods []types.LoanPeriod
+	for rows.Next() {
+		var period types.LoanPeriod
+		if err := rows.Scan(&period.Period, &period.Loans); err != nil {
+			return nil, 500, types.Err{Error: "unable to get report"}
+		}
+		periods = append(periods, period)
+	}
+
+	if rows.Err() != nil {
+		return nil, 500, types.Err{Error: "unable to get report"}
+	}
+
+	return periods, 200, types.Err{}
+}
+
+// ReportTopTitles lists the most borrowed books.
+// Parameters:
+// - params: a pointer to the ReportParams with the range and the limit
+// Returns the books with their number of loans, most borrowed first, status code, and an error if the operation fails.
+func (s *PostgresStore) ReportTopTitles(params *types.ReportParams) ([]types.TitleLoans, int, types.Err) {
+	rows, err := s.db.Query(`SELECT b.id, b.title, b.author, COUNT(*) FROM bookings bo
+	INNER JOIN books b ON bo.book_id = b.id
+	WHERE `+createdWithin+`
+	GROUP BY b.id ORDER BY COUNT(*) DESC, b.title LIMIT $3`, params.From, params.To, params.Limit)
+	if err != nil {
+		return nil, 500, types.Err{Error: "unable to get report"}
+	}
+	defer rows.Close()
+
+	var titles []types.TitleLoans
+	for rows.Next() {
+		var title types.TitleLoans
+		if err := rows.Scan(&title.BookId, &title.Title, &title.Author, &title.Loans); err != nil {
+			return nil, 500, types.Err{Error: "unable to get report"}
+		}
+		titles = append(titles, title)
+	}
+
+	if rows.Err() != nil {
+		return nil, 500, types.Err{Error: "unable to get report"}
+	}
+
+	return titles, 200, types.Err{}
+}
+
+// ReportTopAuthors lists the authors whose books were borrowed the most, editors and translators are not counted.
+// Parameters:
+// - params: a pointer to the ReportParams with the range and the limit
+// Returns the authors with their number of loans, most borrowed first, status code, and an error if the operation fails.
+func (s *PostgresStore) ReportTopAuthors(params *types.ReportParams) ([]types.AuthorLoans, int, types.Err) {
+	rows, err := s.db.Query(`SELECT a.id, a.name, COUNT(*) FROM bookings bo
+	INNER JOIN book_authors ba ON ba.book_id = bo.book_id AND ba.role = 'author'
+	INNER JOIN authors a ON ba.author_id = a.id
+	WHERE `+createdWithin+`
+	GROUP BY a.id ORDER BY COUNT(*) DESC, a.sort_name LIMIT $3`, params.From, params.To, params.Limit)
+	if err != nil {
+		return nil, 500, types.Err{Error: "unable to get report"}
+	}
+	defer rows.Close()
+
+	var authors []types.AuthorLoans
+	for rows.Next() {
+		var author types.AuthorLoans
+		if err := rows.Scan(&author.AuthorId, &author.Name, &author.Loans); err != nil {
+			return nil, 500, types.Err{Error: "unable to get report"}
+		}
+		authors = append(authors, author)
+	}
+
+	if rows.Err() != nil {
+		return nil, 500, types.Err{Error: "unable to get report"}
+	}
+
+	return authors, 200, types.Err{}
+}
+
+// ReportLoanDuration computes how long the loans returned within the range were out.
+// Parameters:
+// - params: a pointer to the ReportParams with the range
+// Returns the LoanDuration, status code, and an error if the operation fails.
+func (s *PostgresStore) ReportLoanDuration(params *types.ReportParams) (types.LoanDuration, int, types.Err) {
+	var duration types.LoanDuration
+	err := s.db.QueryRow(`WITH returned AS (
+		SELECT EXTRACT(EPOCH FROM bo.returned_at - bo.created_at)::NUMERIC / 86400 AS days FROM bookings bo
+		WHERE bo.is_returned AND bo.returned_at >= $1::DATE AND bo.returned_at < $2::DATE + 1
+	)
+	SELECT COUNT(*), COALESCE(ROUND(AVG(days), 2), 0)::FLOAT8,
+		COALESCE(ROUND((PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY days))::NUMERIC, 2), 0)::FLOAT8
+	FROM returned`, params.From, params.To).Scan(&duration.Returned, &duration.AverageDays, &duration.MedianDays)
+	if err != nil {
+		return types.LoanDuration{}, 500, types.Err{Error: "unable to get report"}
+	}
+
+	return duration, 200, types.Err{}
+}
+
+// ReportOnTime counts how the loans due within the range were returned.
+// Parameters:
+// - params: a pointer to the ReportParams with the range
+// Returns the ReturnRate, status code, and an error if the operation fails.
+func (s *PostgresStore) ReportOnTime(params *types.ReportParams) (types.ReturnRate, int, types.Err) {
+	var rate types.ReturnRate
+	err := s.db.QueryRow(`SELECT
+		COUNT(*) FILTER (WHERE bo.is_returned AND bo.returned_at <= `+loanDue+`),
+		COUNT(*) FILTER (WHERE bo.is_returned AND bo.returned_at > `+loanDue+`),
+		COUNT(*) FILTER (WHERE NOT bo.is_returned)
+	FROM bookings bo
+	WHERE `+loanDue+` >= $1::DATE AND `+loanDue+` < LEAST($2::DATE + 1, NOW())`, params.From, params.To).
+		Scan(&rate.OnTime, &rate.ReturnedLate, &rate.Overdue)
+	if err != nil {
+		return types.ReturnRate{}, 500, types.Err{Error: "unable to get report"}
+	}
+	rate.Due = rate.OnTime + rate.ReturnedLate + rate.Overdue
+
+	return rate, 200, types.Err{}
+}
+
+// ReportEmployees counts the loans made by every employee and self-checkout kiosk.
+// Parameters:
+// - params: a pointer to the ReportParams with the range
+// Returns the loans per employee or kiosk, most loans first, status code, and an error if the operation fails.
+func (s *PostgresStore) ReportEmployees(params *types.ReportParams) ([]types.HandlerLoans, int, types.Err) {
+	rows, err := s.db.Query(`SELECT COALESCE(bo.updated_by::TEXT, ''), COALESCE(e.username, 'kiosk: ' || k.name, ''),
+		COUNT(*)
+	FROM bookings bo
+	LEFT JOIN employees e ON bo.updated_by = e.id
+	LEFT JOIN kiosk_devices k ON bo.updated_by = k.id
+	WHERE `+createdWithin+`
+	GROUP BY bo.updated_by, e.username, k.name ORDER BY COUNT(*) DESC, 2`, params.From, params.To)
+	if err != nil {
+		return nil, 500, types.Err{Error: "unable to get report"}
+	}
+	defer rows.Close()
+
+	var handlers []types.HandlerLoans
+	for rows.Next() {
+		var handler types.HandlerLoans
+		if err := rows.Scan(&handler.Id, &handler.Name, &handler.Loans); err != nil {
+			return nil, 500, types.Err{Error: "unable to get report"}
+		}
+		handlers = append(handlers, handler)
+	}
+
+	if rows.Err() != nil {
+		return nil, 500, types.Err{Error: "unable to get report"}
+	}
+
+	return handlers, 200, types.Err{}
+}
+
+// ReportIdleBooks lists the books that were in the collection but not borrowed within the range.
+// Books that were never borrowed come first, then the ones whose last loan is the oldest.
+// Parameters:
+// - params: a pointer to the ReportParams with the range and the limit
+// Returns the idle books, status code, and an error if the operation fails.
+func (s *PostgresStore) ReportIdleBooks(params *types.ReportParams) ([]types.IdleBook, int, types.Err) {
+	rows, err := s.db.Query(`SELECT b.id, b.title, b.author, b.barcode, b.created_at, COALESCE(MAX(bo.created_at)::TEXT, '')
+	FROM books b
+	LEFT JOIN bookings bo ON bo.book_id = b.id
+	WHERE b.deleted_at IS NULL AND b.created_at < $2::DATE + 1
+	GROUP BY b.id
+	HAVING COUNT(bo.id) FILTER (WHERE `+createdWithin+`) = 0
+	ORDER BY MAX(bo.created_at) NULLS FIRST, b.created_at LIMIT $3`, params.From, params.To, params.Limit)
+	if err != nil {
+		return nil, 500, types.Err{Error: "unable to get report"}
+	}
+	defer rows.Close()
+
+	var books []types.IdleBook
+	for rows.Next() {
+		var book types.IdleBook
+		if err := rows.Scan(&book.BookId, &book.Title, &book.Author, &book.Barcode, &book.CreatedAt,
+			&book.LastLoanedAt); err != nil {
+			return nil, 500, types.Err{Error: "unable to get report"}
+		}
+		books = append(books, book)
+	}
+
+	if rows.Err() != nil {
+		return nil, 500, types.Err{Error: "unable to get report"}
+	}
+
+	return books, 200, types.Err{}
+}
@@ -0,0 +1,343 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Tus1688/library-management-api/pageutil"
+	"github.com/Tus1688/library-management-api/types"
+	"github.com/lib/pq"
+	"strings"
+	"time"
+)
+
+// GetWebhook retrieves a page of webhook subscriptions, without their secrets.
+// An empty result is not an error, it yields a page with no items.
+// Parameters:
+// - filter: a pointer to the WebhookFilter containing the cursor and page size
+// Returns a page of ListWebhook, status code, and an error if the operation fails.
+func (s *PostgresStore) GetWebhook(filter *types.WebhookFilter) (types.Page[types.ListWebhook], int, types.Err) {
+	conditions := []string{`w.deleted_at IS NULL`}
+	var args []interface{}
+
+	total, err := s.countRows("webhooks w", whereClause(conditions), args)
+	if err != nil {
+		return types.Page[types.ListWebhook]{}, 500, types.Err{Error: "unable to get webhooks"}
+	}
+
+	if filter.Cursor != 0 {
+		conditions = append(conditions, `w.pagination_id < `+placeholder(&args, filter.Cursor))
+	}
+
+	rows, err := s.db.Query(`SELECT w.id, w.pagination_id, w.url, w.events, w.is_active, w.created_at, w.updated_at,
+	w.version FROM webhooks w`+whereClause(conditions)+
+		` ORDER BY w.pagination_id DESC LIMIT `+placeholder(&args, filter.Limit+1), args...)
+	if err != nil {
+		return types.Page[types.ListWebhook]{}, 500, types.Err{Error: "unable to get webhooks"}
+	}
+	defer rows.Close()
+
+	var webhooks []types.ListWebhook
+	for rows.Next() {
+		var w types.ListWebhook
+		err := rows.Scan(&w.Id, &w.PaginationId, &w.Url, pq.Array(&w.Events), &w.IsActive, &w.CreatedAt,
+			&w.UpdatedAt, &w.Version)
+		if err != nil {
+			return types.Page[types.ListWebhook]{}, 500, types.Err{Error: "unable to get webhooks"}
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	return pageutil.NewPage(webhooks, filter.Limit, total, func(w types.ListWebhook) int64 {
+		return int64(w.PaginationId)
+	}), 200, types.Err{}
+}
+
+// CreateWebhook subscribes a URL to events.
+// Parameters:
+// - uid: a pointer to the ID of the employee creating the subscription
+// - req: a pointer to the normalized CreateWebhook request
+// - secret: the secret the payloads are signed with
+// Returns the ID of the new subscription, status code, and an error if the operation fails.
+func (s *PostgresStore) CreateWebhook(uid *string, req *types.CreateWebhook, secret string) (types.CreateId, int,
+	types.Err) {
+	isActive := req.IsActive == nil || *req.IsActive
+
+	var id types.CreateId
+	err := s.db.QueryRow(`INSERT INTO webhooks(url, secret, events, is_active, created_by) VALUES ($1, $2, $3, $4, $5)
+	RETURNING id`, req.Url, secret, pq.Array(req.Events), isActive, *uid).Scan(&id.Id)
+	if err != nil {
+		return types.CreateId{}, 500, types.Err{Error: "unable to create webhook"}
+	}
+
+	return id, 201, types.Err{}
+}
+
+// UpdateWebhook replaces the URL and events of a subscription, and pauses or resumes it when IsActive is given.
+// Deliveries of a paused subscription wait until it is resumed.
+// If version is not 0 the update only succeeds when it matches the stored version.
+// Parameters:
+// - req: a pointer to the normalized UpdateWebhook request
+// - version: the expected version, 0 to skip the check
+// Returns the new version, status code, and an error if the operation fails.
+func (s *PostgresStore) UpdateWebhook(req *types.UpdateWebhook, version int) (int, int, types.Err) {
+	var isActive interface{}
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	args := []interface{}{req.Url, pq.Array(req.Events), isActive, req.Id}
+	query := `UPDATE webhooks SET url = $1, events = $2, is_active = COALESCE($3, is_active)
+	WHERE id = $4 AND deleted_at IS NULL`
+	if version != 0 {
+		query += ` AND version = ` + placeholder(&args, version)
+	}
+	query += ` RETURNING version`
+
+	var newVersion int
+	err := s.db.QueryRow(query, args...).Scan(&newVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			statusCode, err := s.preconditionFailure("webhooks", "webhook", &req.Id)
+			return 0, statusCode, err
+		}
+
+		if strings.Contains(err.Error(), "uuid") {
+			return 0, 400, types.Err{Error: "invalid id"}
+		}
+
+		return 0, 500, types.Err{Error: "unable to update webhook"}
+	}
+
+	return newVersion, 200, types.Err{}
+}
+
+// RotateWebhookSecret replaces the secret of a subscription, deliveries sent from now on are signed with it.
+// Parameters:
+// - id: a pointer to the webhook ID
+// - secret: the new secret
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) RotateWebhookSecret(id *string, secret string) (int, types.Err) {
+	res, err := s.db.Exec(`UPDATE webhooks SET secret = $1 WHERE id = $2 AND deleted_at IS NULL`, secret, *id)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return 400, types.Err{Error: "invalid id"}
+		}
+
+		return 500, types.Err{Error: "unable to rotate webhook secret"}
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 500, types.Err{Error: "unable to rotate webhook secret"}
+	}
+
+	if rowsAffected == 0 {
+		return 404, types.Err{Error: "webhook not found"}
+	}
+
+	return 200, types.Err{}
+}
+
+// DeleteWebhook removes a subscription, its pending deliveries move to the dead letters.
+// Parameters:
+// - id: a pointer to the webhook ID
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) DeleteWebhook(id *string) (int, types.Err) {
+	var deleted bool
+	err := s.db.QueryRow(`WITH deleted AS (
+		UPDATE webhooks SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING id
+	), dead AS (
+		UPDATE webhook_deliveries SET status = 'dead', last_error = 'webhook deleted'
+		WHERE webhook_id IN (SELECT id FROM deleted) AND status = 'pending'
+	)
+	SELECT EXISTS (SELECT 1 FROM deleted)`, *id).Scan(&deleted)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return 400, types.Err{Error: "invalid id"}
+		}
+
+		return 500, types.Err{Error: "unable to delete webhook"}
+	}
+
+	if !deleted {
+		return 404, types.Err{Error: "webhook not found"}
+	}
+
+	return 200, types.Err{}
+}
+
+// EnqueueEvent records a delivery of an event for every active subscription to its type.
+// The payload is a JSON object with the event ID, type, creation time and data.
+// An event is only delivered once per subscription, enqueueing it again does nothing.
+// Parameters:
+// - event: a pointer to the event from the outbox
+// Returns the number of new deliveries, status code, and an error if the operation fails.
+func (s *PostgresStore) EnqueueEvent(event *types.Event) (int64, int, types.Err) {
+	res, err := s.db.Exec(`INSERT INTO webhook_deliveries(webhook_id, event_id, event, payload)
+	SELECT w.id, $1, $2, JSONB_BUILD_OBJECT('id', $1::UUID, 'type', $2::TEXT, 'created_at', $3::TIMESTAMP,
+		'data', $4::JSONB)
+	FROM webhooks w WHERE w.deleted_at IS NULL AND w.is_active AND $2 = ANY(w.events)
+	ON CONFLICT (webhook_id, event_id) DO NOTHING`, event.Id, event.Type, event.CreatedAt, string(event.Data))
+	if err != nil {
+		return 0, 500, types.Err{Error: "unable to enqueue event"}
+	}
+
+	deliveries, _ := res.RowsAffected()
+	return deliveries, 200, types.Err{}
+}
+
+// ClaimDeliveries leases the oldest due deliveries of active subscriptions, counting an attempt for each.
+// A leased delivery is not claimed again until the lease ends, unless it is finished first.
+// Parameters:
+// - limit: the maximum number of deliveries to claim
+// - lease: how long the deliveries are leased
+// Returns the claimed deliveries, oldest first, status code, and an error if the operation fails.
+func (s *PostgresStore) ClaimDeliveries(limit int, lease time.Duration) ([]types.PendingDelivery, int, types.Err) {
+	rows, err := s.db.Query(`WITH due AS (
+		SELECT d.id FROM webhook_deliveries d INNER JOIN webhooks w ON d.webhook_id = w.id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND w.is_active AND w.deleted_at IS NULL
+		ORDER BY d.pagination_id LIMIT $1 FOR UPDATE OF d SKIP LOCKED
+	), claimed AS (
+		UPDATE webhook_deliveries d SET attempts = d.attempts + 1,
+		next_attempt_at = NOW() + MAKE_INTERVAL(secs => $2::FLOAT8)
+		FROM due WHERE d.id = due.id
+		RETURNING d.id, d.pagination_id, d.webhook_id, d.event_id, d.event, d.payload, d.attempts
+	)
+	SELECT c.id, c.webhook_id, w.url, w.secret, c.event_id, c.event, c.payload::TEXT, c.attempts
+	FROM claimed c INNER JOIN webhooks w ON c.webhook_id = w.id ORDER BY c.pagination_id`, limit, lease.Seconds())
+	if err != nil {
+		return nil, 500, types.Err{Error: "unable to claim webhook deliveries"}
+	}
+	defer rows.Close()
+
+	var deliveries []types.PendingDelivery
+	for rows.Next() {
+		var d types.PendingDelivery
+		var payload string
+		err := rows.Scan(&d.Id, &d.WebhookId, &d.Url, &d.Secret, &d.EventId, &d.Event, &payload, &d.Attempts)
+		if err != nil {
+			return nil, 500, types.Err{Error: "unable to claim webhook deliveries"}
+		}
+		d.Payload = []byte(payload)
+		deliveries = append(deliveries, d)
+	}
+
+	if rows.Err() != nil {
+		return nil, 500, types.Err{Error: "unable to claim webhook deliveries"}
+	}
+
+	return deliveries, 200, types.Err{}
+}
+
+// FinishDelivery records the outcome of a delivery attempt.
+// Parameters:
+// - id: a pointer to the delivery ID
+// - responseStatus: the HTTP status the webhook responded with, 0 if it did not respond
+// - failure: why the attempt failed, empty if the delivery succeeded
+// - retryIn: when to try a failed delivery again, 0 to move it to the dead letters
+// Returns the status code and an error if the operation fails.
+func (s *PostgresStore) FinishDelivery(id *string, responseStatus int, failure string, retryIn time.Duration) (int,
+	types.Err) {
+	_, err := s.db.Exec(`UPDATE webhook_deliveries SET response_status = $2, last_error = $3,
+	status = CASE WHEN $3 = '' THEN 'delivered' WHEN $4::FLOAT8 = 0 THEN 'dead' ELSE 'pending' END,
+	delivered_at = CASE WHEN $3 = '' THEN NOW() END,
+	next_attempt_at = NOW() + MAKE_INTERVAL(secs => $4::FLOAT8)
+	WHERE id = $1`, *id, responseStatus, failure, retryIn.Seconds())
+	if err != nil {
+		return 500, types.Err{Error: "unable to update webhook delivery"}
+	}
+
+	return 200, types.Err{}
+}
+
+// GetDelivery retrieves a page of webhook deliveries, optionally filtered by webhook or status.
+// Filtering by types.DeliveryDead lists the dead letters.
+// An empty result is not an error, it yields a page with no items.
+// Parameters:
+// - filter: a pointer to the DeliveryFilter containing the webhook ID, status, cursor and page size
+// Returns a page of ListDelivery, status code, and an error if the operation fails.
+func (s *PostgresStore) GetDelivery(filter *types.DeliveryFilter) (types.Page[types.ListDelivery], int, types.Err) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.WebhookId != "" {
+		conditions = append(conditions, `d.webhook_id = `+placeholder(&args, filter.WebhookId))
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, `d.status = `+placeholder(&args, filter.Status))
+	}
+
+	total, err := s.countRows("webhook_deliveries d", whereClause(conditions), args)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return types.Page[types.ListDelivery]{}, 400, types.Err{Error: "invalid webhook_id"}
+		}
+
+		return types.Page[types.ListDelivery]{}, 500, types.Err{Error: "unable to get webhook deliveries"}
+	}
+
+	if filter.Cursor != 0 {
+		conditions = append(conditions, `d.pagination_id < `+placeholder(&args, filter.Cursor))
+	}
+
+	rows, err := s.db.Query(`SELECT d.id, d.pagination_id, d.webhook_id, d.event_id, d.event, d.status, d.attempts,
+	d.response_status, d.last_error,
+	CASE WHEN d.status = 'pending' THEN d.next_attempt_at::TEXT ELSE '' END, COALESCE(d.delivered_at::TEXT, ''),
+	d.created_at FROM webhook_deliveries d`+whereClause(conditions)+
+		` ORDER BY d.pagination_id DESC LIMIT `+placeholder(&args, filter.Limit+1), args...)
+	if err != nil {
+		return types.Page[types.ListDelivery]{}, 500, types.Err{Error: "unable to get webhook deliveries"}
+	}
+	defer rows.Close()
+
+	var deliveries []types.ListDelivery
+	for rows.Next() {
+		var d types.ListDelivery
+		err := rows.Scan(&d.Id, &d.PaginationId, &d.WebhookId, &d.EventId, &d.Event, &d.Status, &d.Attempts,
+			&d.ResponseStatus, &d.LastError, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt)
+		if err != nil {
+			return types.Page[types.ListDelivery]{}, 500, types.Err{Error: "unable to get webhook deliveries"}
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return pageutil.NewPage(deliveries, filter.Limit, total, func(d types.ListDelivery) int64 {
+		return int64(d.PaginationId)
+	}), 200, types.Err{}
+}
+
+// RedeliverWebhook queues a dead or delivered delivery again, with a fresh set of attempts.
+// Parameters:
+// - id: a pointer to the delivery ID
+// Returns the status code and an error if the delivery does not exist or is still pending.
+func (s *PostgresStore) RedeliverWebhook(id *string) (int, types.Err) {
+	res, err := s.db.Exec(`UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = NOW(),
+	response_status = 0, last_error = '', delivered_at = NULL
+	WHERE id = $1 AND status <> 'pending'
+	AND EXISTS (SELECT 1 FROM webhooks w WHERE w.id = webhook_id AND w.deleted_at IS NULL)`, *id)
+	if err != nil {
+		if strings.Contains(err.Error(), "uuid") {
+			return 400, types.Err{Error: "invalid id"}
+		}
+
+		return 500, types.Err{Error: "unable to redeliver webhook"}
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 500, types.Err{Error: "unable to redeliver webhook"}
+	}
+
+	if rowsAffected == 0 {
+		var status string
+		err := s.db.QueryRow(`SELECT d.status FROM webhook_deliveries d INNER JOIN webhooks w ON d.webhook_id = w.id
+		WHERE d.id = $1 AND w.deleted_at IS NULL`, *id).Scan(&status)
+		if err == nil && status == types.DeliveryPending {
+			return 409, types.Err{Error: "delivery is still pending"}
+		}
+
+		return 404, types.Err{Error: "delivery not found"}
+	}
+
+	return 200, types.Err{}
+}
@@ -0,0 +1,50 @@
+package types
+
+const (
+	RoleAuthor     = "author"
+	RoleEditor     = "editor"
+	RoleTranslator = "translator"
+)
+
+type ListAuthor struct {
+	Id           string   `json:"id"`
+	PaginationId int      `json:"pagination_id"`
+	Name         string   `json:"name"`
+	SortName     string   `json:"sort_name"`
+	Aliases      []string `json:"aliases"`
+	BookCount    int      `json:"book_count"`
+	CreatedAt    string   `json:"created_at"`
+	UpdatedAt    string   `json:"updated_at"`
+	Version      int      `json:"version"`
+}
+
+type CreateAuthor struct {
+	Name     string   `json:"name" binding:"required"`
+	SortName string   `json:"sort_name"`
+	Aliases  []string `json:"aliases"`
+}
+
+type UpdateAuthor struct {
+	Id string `json:"id" binding:"required"`
+	CreateAuthor
+}
+
+type AuthorFilter struct {
+	Search string
+	Cursor int64
+	Limit  int
+}
+
+// BookAuthor is an author credited on a book, in credit order.
+type BookAuthor struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	SortName string `json:"sort_name"`
+	Role     string `json:"role"`
+}
+
+// BookAuthorRef links a book to an existing author, Role defaults to RoleAuthor.
+type BookAuthorRef struct {
+	Id   string `json:"id"`
+	Role string `json:"role"`
+}
@@ -0,0 +1,21 @@
+package types
+
+const (
+	AvailabilityAvailable = "available"
+	AvailabilityOnLoan    = "on_loan"
+	// AvailabilityOnHold is a book that was returned and is kept for the patron who held it.
+	AvailabilityOnHold = "on_hold"
+)
+
+// Availability tells the public catalog whether a book can be borrowed now, and when it likely can otherwise.
+// Every book record is a single item, so Copies is 1 and AvailableCopies is 0 or 1.
+// EstimatedAvailableAt is empty while the book is available, otherwise it counts a loan period for every patron in the queue.
+type Availability struct {
+	BookId               string `json:"book_id"`
+	Status               string `json:"status"`
+	Copies               int    `json:"copies"`
+	AvailableCopies      int    `json:"available_copies"`
+	QueueLength          int    `json:"queue_length"`
+	DueAt                string `json:"due_at,omitempty"`
+	EstimatedAvailableAt string `json:"estimated_available_at,omitempty"`
+}
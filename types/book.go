@@ -6,16 +6,49 @@ type ListBook struct {
 	Title        string `json:"title"`
 	Author       string `json:"author"`
 	Description  string `json:"description"`
-	IsBooked     bool   `json:"is_booked"`
-	BookedUntil  string `json:"booked_until,omitempty"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
+	BookMetadata
+	Tags        []string   `json:"tags"`
+	Barcode     string     `json:"barcode"`
+	CoverKey    string     `json:"-"`
+	Cover       *BookCover `json:"cover,omitempty"`
+	IsBooked    bool       `json:"is_booked"`
+	BookedUntil string     `json:"booked_until,omitempty"`
+	CreatedAt   string     `json:"created_at"`
+	UpdatedAt   string     `json:"updated_at"`
+	Version     int        `json:"version"`
+	DeletedAt   string     `json:"deleted_at,omitempty"`
 }
 
+// BookMetadata holds the optional bibliographic details of a book.
+// Isbn is always stored as a normalized ISBN-13.
+type BookMetadata struct {
+	Isbn            string   `json:"isbn,omitempty"`
+	Publisher       string   `json:"publisher,omitempty"`
+	PublicationYear int      `json:"publication_year,omitempty"`
+	Edition         string   `json:"edition,omitempty"`
+	Language        string   `json:"language,omitempty"`
+	PageCount       int      `json:"page_count,omitempty"`
+	Subjects        []string `json:"subjects"`
+}
+
+// BookCover holds the URLs of the generated cover sizes.
+type BookCover struct {
+	Small  string `json:"small"`
+	Medium string `json:"medium"`
+	Large  string `json:"large"`
+}
+
+// CreateBook creates a book, Author is the byline shown in lists.
+// Without Authors the credited authors are derived from the byline.
+// CategoryIds and Tags are left untouched on update when absent, an empty list clears them.
 type CreateBook struct {
 	Title       string `json:"title" binding:"required"`
 	Author      string `json:"author" binding:"required"`
 	Description string `json:"description" binding:"required"`
+	BookMetadata
+	Authors     []BookAuthorRef `json:"authors,omitempty"`
+	CategoryIds []string        `json:"category_ids"`
+	Tags        []string        `json:"tags"`
 }
 
 type UpdateBook struct {
@@ -23,4 +56,60 @@ type UpdateBook struct {
 	Title       string `json:"title" binding:"required"`
 	Author      string `json:"author" binding:"required"`
 	Description string `json:"description" binding:"required"`
+	BookMetadata
+	Authors     []BookAuthorRef `json:"authors,omitempty"`
+	CategoryIds []string        `json:"category_ids"`
+	Tags        []string        `json:"tags"`
+}
+
+type BookFilter struct {
+	Ids            []string
+	Search         string
+	Isbn           string
+	Publisher      string
+	Language       string
+	Subject        string
+	YearFrom       int
+	YearTo         int
+	AuthorId       string
+	CategoryId     string
+	Descendants    bool
+	Tags           []string
+	IncludeDeleted bool
+	Cursor         int64
+	Limit          int
+}
+
+type BookDetail struct {
+	ListBook
+	Authors        []BookAuthor        `json:"authors"`
+	Categories     []BookCategory      `json:"categories"`
+	BookingCount   int                 `json:"booking_count"`
+	ReturnedCount  int                 `json:"returned_count"`
+	CurrentBooking *BookCurrentBooking `json:"current_booking,omitempty"`
+}
+
+type BookCurrentBooking struct {
+	Id            string `json:"id"`
+	CustomerName  string `json:"customer_name"`
+	CustomerPhone string `json:"customer_phone"`
+	BookedUntil   string `json:"booked_until"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type PatchBook struct {
+	Title           Optional[string]          `json:"title" binding:"nonempty"`
+	Author          Optional[string]          `json:"author" binding:"nonempty"`
+	Description     Optional[string]          `json:"description" binding:"nonempty"`
+	Barcode         Optional[string]          `json:"barcode" binding:"nonempty"`
+	Isbn            Optional[string]          `json:"isbn"`
+	Publisher       Optional[string]          `json:"publisher"`
+	PublicationYear Optional[int]             `json:"publication_year"`
+	Edition         Optional[string]          `json:"edition"`
+	Language        Optional[string]          `json:"language"`
+	PageCount       Optional[int]             `json:"page_count"`
+	Subjects        Optional[[]string]        `json:"subjects"`
+	Authors         Optional[[]BookAuthorRef] `json:"authors"`
+	CategoryIds     Optional[[]string]        `json:"category_ids"`
+	Tags            Optional[[]string]        `json:"tags"`
 }
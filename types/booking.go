@@ -1,9 +1,16 @@
 package types
 
+import "time"
+
+// LoanTerm is how long a book is lent, and how much a renewal adds to the due date.
+// The due dates of the storage and the estimates of the public catalog are both derived from it.
+const LoanTerm = 7 * 24 * time.Hour
+
 type CreateBooking struct {
 	BookId        string `json:"book_id" binding:"required"`
 	CustomerName  string `json:"customer_name" binding:"required"`
 	CustomerPhone string `json:"customer_phone" binding:"required"`
+	CustomerEmail string `json:"customer_email"`
 }
 
 type GetBooking struct {
@@ -14,6 +21,7 @@ type GetBooking struct {
 	BookAuthor    string `json:"book_author"`
 	CustomerName  string `json:"customer_name"`
 	CustomerPhone string `json:"customer_phone"`
+	CustomerEmail string `json:"customer_email"`
 	BookedUntil   string `json:"booked_until"`
 	IsReturned    bool   `json:"is_returned"`
 	CreatedAt     string `json:"created_at"`
@@ -21,3 +29,24 @@ type GetBooking struct {
 	UpdatedBy     string `json:"updated_by"`
 	ReturnedAt    string `json:"returned_at,omitempty"`
 }
+
+type BookingFilter struct {
+	Cursor int64
+	Limit  int
+}
+
+type BookingDetail struct {
+	Id            string        `json:"id"`
+	PaginationId  int           `json:"pagination_id"`
+	CustomerName  string        `json:"customer_name"`
+	CustomerPhone string        `json:"customer_phone"`
+	CustomerEmail string        `json:"customer_email"`
+	BookedUntil   string        `json:"booked_until"`
+	IsReturned    bool          `json:"is_returned"`
+	CreatedAt     string        `json:"created_at"`
+	UpdatedAt     string        `json:"updated_at"`
+	ReturnedAt    string        `json:"returned_at,omitempty"`
+	Book          ListBook      `json:"book"`
+	Employee      *ListEmployee `json:"employee,omitempty"`
+	Kiosk         *ListKiosk    `json:"kiosk,omitempty"`
+}
@@ -0,0 +1,63 @@
+package types
+
+type Category struct {
+	Id           string `json:"id"`
+	PaginationId int    `json:"pagination_id"`
+	ParentId     string `json:"parent_id,omitempty"`
+	Code         string `json:"code,omitempty"`
+	Name         string `json:"name"`
+	BookCount    int    `json:"book_count"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+	Version      int    `json:"version"`
+}
+
+// CategoryNode is a category with its subcategories, as returned by the category tree.
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
+}
+
+type CreateCategory struct {
+	ParentId string `json:"parent_id"`
+	Code     string `json:"code"`
+	Name     string `json:"name" binding:"required"`
+}
+
+type UpdateCategory struct {
+	Id       string `json:"id" binding:"required"`
+	ParentId string `json:"parent_id"`
+	Code     string `json:"code"`
+	Name     string `json:"name" binding:"required"`
+}
+
+// BookCategory is a category a book is classified under.
+type BookCategory struct {
+	Id   string `json:"id"`
+	Code string `json:"code,omitempty"`
+	Name string `json:"name"`
+}
+
+type ListTag struct {
+	Id           string `json:"id"`
+	PaginationId int    `json:"pagination_id"`
+	Name         string `json:"name"`
+	BookCount    int    `json:"book_count"`
+}
+
+type TagFilter struct {
+	Search string
+	Cursor int64
+	Limit  int
+}
+
+type UpdateTag struct {
+	Id   string `json:"id" binding:"required"`
+	Name string `json:"name" binding:"required"`
+}
+
+// MergeTags moves every book tagged with one of the sources to the target and deletes the sources.
+type MergeTags struct {
+	TargetId  string   `json:"target_id" binding:"required"`
+	SourceIds []string `json:"source_ids" binding:"required"`
+}
@@ -0,0 +1,32 @@
+package types
+
+const (
+	ScanCheckedOut = "checked_out"
+	ScanReturned   = "returned"
+	ScanHeld       = "held"
+	ScanUnknown    = "unknown"
+)
+
+// ScanRequest is a batch of item barcodes scanned at the desk for the patron holding the card.
+type ScanRequest struct {
+	CardNumber string   `json:"card_number" binding:"required"`
+	Barcodes   []string `json:"barcodes" binding:"required"`
+}
+
+// ScanItem is the outcome of a single scanned barcode, kept short enough for a kiosk display.
+type ScanItem struct {
+	Barcode   string `json:"barcode"`
+	Status    string `json:"status"`
+	BookId    string `json:"book_id,omitempty"`
+	Title     string `json:"title,omitempty"`
+	BookingId string `json:"booking_id,omitempty"`
+	DueAt     string `json:"due_at,omitempty"`
+}
+
+type ScanResult struct {
+	CardNumber string     `json:"card_number"`
+	PatronName string     `json:"patron_name"`
+	CheckedOut int        `json:"checked_out"`
+	Returned   int        `json:"returned"`
+	Items      []ScanItem `json:"items"`
+}
@@ -6,8 +6,22 @@ type CreateEmployee struct {
 }
 
 type ListEmployee struct {
-	Id        string `json:"id"`
-	Username  string `json:"username"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	Id           string `json:"id"`
+	PaginationId int    `json:"pagination_id"`
+	Username     string `json:"username"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+	Version      int    `json:"version"`
+	DeletedAt    string `json:"deleted_at,omitempty"`
+}
+
+type EmployeeFilter struct {
+	IncludeDeleted bool
+	Cursor         int64
+	Limit          int
+}
+
+type PatchEmployee struct {
+	Username Optional[string] `json:"username" binding:"nonempty"`
+	Password Optional[string] `json:"password" binding:"nonempty"`
 }
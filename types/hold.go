@@ -0,0 +1,26 @@
+package types
+
+const (
+	HoldWaiting   = "waiting"
+	HoldReady     = "ready"
+	HoldFulfilled = "fulfilled"
+	HoldCancelled = "cancelled"
+	HoldExpired   = "expired"
+)
+
+// ListHold is an active hold of a patron.
+// Position is the place in the queue of a waiting hold, 0 once the book is ready for pickup until ExpiresAt.
+type ListHold struct {
+	Id         string `json:"id"`
+	BookId     string `json:"book_id"`
+	BookTitle  string `json:"book_title"`
+	BookAuthor string `json:"book_author"`
+	Status     string `json:"status"`
+	Position   int    `json:"position"`
+	ExpiresAt  string `json:"expires_at,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type CreateHold struct {
+	BookId string `json:"book_id" binding:"required"`
+}
@@ -0,0 +1,31 @@
+package types
+
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+type ImportBookRow struct {
+	Line int
+	Book CreateBook
+}
+
+type ImportResult struct {
+	Line   int    `json:"line"`
+	Status string `json:"status"`
+	Id     string `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun  bool           `json:"dry_run"`
+	Created int            `json:"created"`
+	Updated int            `json:"updated"`
+	Skipped int            `json:"skipped"`
+	Failed  int            `json:"failed"`
+	Rows    []ImportResult `json:"rows"`
+	// Error tells why the import stopped early, the rows reported before the failing line are in place.
+	Error string `json:"error,omitempty"`
+}
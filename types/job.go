@@ -0,0 +1,33 @@
+package types
+
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// ListJob is a job registered with the runner of this instance.
+type ListJob struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	NextRun  string `json:"next_run"`
+	Running  bool   `json:"running"`
+}
+
+type ListJobRun struct {
+	Id           string `json:"id"`
+	PaginationId int    `json:"pagination_id"`
+	Job          string `json:"job"`
+	Instance     string `json:"instance"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+	StartedAt    string `json:"started_at"`
+	FinishedAt   string `json:"finished_at,omitempty"`
+}
+
+type JobRunFilter struct {
+	Job    string
+	Status string
+	Cursor int64
+	Limit  int
+}
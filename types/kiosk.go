@@ -0,0 +1,43 @@
+package types
+
+type ListKiosk struct {
+	Id           string `json:"id"`
+	PaginationId int    `json:"pagination_id"`
+	Name         string `json:"name"`
+	IsPaired     bool   `json:"is_paired"`
+	LastSeenAt   string `json:"last_seen_at,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type KioskFilter struct {
+	Cursor int64
+	Limit  int
+}
+
+type CreateKiosk struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// KioskPairing is shown once to the employee setting up a kiosk, the code is typed into the device.
+type KioskPairing struct {
+	Id          string `json:"id"`
+	PairingCode string `json:"pairing_code"`
+	ExpiresAt   string `json:"expires_at"`
+}
+
+type RegisterKiosk struct {
+	PairingCode string `json:"pairing_code" binding:"required"`
+}
+
+// KioskCredential is returned once to a kiosk when it is paired, the token is sent as a bearer token.
+type KioskCredential struct {
+	Id    string `json:"id"`
+	Name  string `json:"name"`
+	Token string `json:"token"`
+}
+
+// KioskScanRequest is a ScanRequest made by a patron at a kiosk, who proves holding the card with a PIN.
+type KioskScanRequest struct {
+	Pin string `json:"pin" binding:"required"`
+	ScanRequest
+}
@@ -0,0 +1,62 @@
+package types
+
+import "time"
+
+const (
+	NotifyReminder = "reminder"
+	NotifyDue      = "due"
+	NotifyOverdue  = "overdue"
+	// NotifyHoldAvailable tells a patron that a held book is ready for pickup.
+	NotifyHoldAvailable = "hold_available"
+)
+
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+)
+
+type ListNotification struct {
+	Id           string `json:"id"`
+	PaginationId int    `json:"pagination_id"`
+	BookingId    string `json:"booking_id,omitempty"`
+	HoldId       string `json:"hold_id,omitempty"`
+	Kind         string `json:"kind"`
+	Sequence     int    `json:"sequence"`
+	Channel      string `json:"channel"`
+	Recipient    string `json:"recipient"`
+	Status       string `json:"status"`
+	Attempts     int    `json:"attempts"`
+	LastError    string `json:"last_error,omitempty"`
+	SentAt       string `json:"sent_at,omitempty"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+}
+
+type NotificationFilter struct {
+	Status    string
+	BookingId string
+	Cursor    int64
+	Limit     int
+}
+
+// DueNotification is a message the scheduler has to send for an open booking, or for a hold that is ready for pickup.
+// Sequence tells apart the weekly overdue notices of the same booking, it is 0 for the other kinds.
+// BookedUntil is the due date of the booking, or the pickup deadline of the hold.
+type DueNotification struct {
+	Id           string
+	BookingId    string
+	HoldId       string
+	Kind         string
+	Sequence     int
+	Channel      string
+	Recipient    string
+	CustomerName string
+	BookTitle    string
+	BookedUntil  time.Time
+}
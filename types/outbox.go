@@ -0,0 +1,36 @@
+package types
+
+import "github.com/goccy/go-json"
+
+// Event is a domain event, written to the outbox in the transaction of the change it reports.
+// Position orders the events, Data is an EventRef.
+type Event struct {
+	Position  int64           `json:"position"`
+	Id        string          `json:"id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt string          `json:"created_at"`
+}
+
+type EventFilter struct {
+	Type   string
+	Cursor int64
+	Limit  int
+}
+
+// OutboxSink is how far a sink has got, Lag is the number of events it has yet to publish.
+type OutboxSink struct {
+	Sink      string `json:"sink"`
+	Position  int64  `json:"position"`
+	Lag       int64  `json:"lag"`
+	LastError string `json:"last_error,omitempty"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// ReplayOutbox moves the cursor of a sink back so it publishes the events again,
+// starting with the event at FromPosition or with the first event created at or after Since (RFC 3339).
+type ReplayOutbox struct {
+	Sink         string `json:"sink" binding:"required"`
+	FromPosition int64  `json:"from_position"`
+	Since        string `json:"since"`
+}
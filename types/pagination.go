@@ -0,0 +1,9 @@
+package types
+
+// Page is the envelope returned by every list endpoint.
+type Page[T any] struct {
+	Items         []T    `json:"items"`
+	NextCursor    string `json:"next_cursor,omitempty"`
+	HasMore       bool   `json:"has_more"`
+	TotalEstimate int64  `json:"total_estimate"`
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"github.com/goccy/go-json"
+	"reflect"
+)
+
+// Optional is a single member of a JSON merge patch (RFC 7396).
+// Set reports whether the member was present in the document at all and
+// Null whether it was explicitly set to null, which means "remove".
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Null = true
+		return nil
+	}
+
+	return json.Unmarshal(data, &o.Value)
+}
+
+// IsEmpty reports whether the member was sent as null or as the zero value of T.
+// Members that were not sent at all are never empty.
+func (o Optional[T]) IsEmpty() bool {
+	if !o.Set {
+		return false
+	}
+
+	return o.Null || reflect.ValueOf(&o.Value).Elem().IsZero()
+}
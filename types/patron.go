@@ -0,0 +1,99 @@
+package types
+
+type ListPatron struct {
+	Id           string `json:"id"`
+	PaginationId int    `json:"pagination_id"`
+	CardNumber   string `json:"card_number"`
+	Name         string `json:"name"`
+	Phone        string `json:"phone"`
+	Email        string `json:"email"`
+	SmsOptOut    bool   `json:"sms_opt_out"`
+	ActiveLoans  int    `json:"active_loans"`
+	HasPin       bool   `json:"has_pin"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+	Version      int    `json:"version"`
+}
+
+// CreatePatron registers a patron, a card number is assigned from a sequence when CardNumber is empty.
+// Without a Pin the patron cannot use the self-checkout kiosks, without an Email it is not sent due date reminders.
+type CreatePatron struct {
+	CardNumber string `json:"card_number"`
+	Name       string `json:"name" binding:"required"`
+	Phone      string `json:"phone" binding:"required"`
+	Email      string `json:"email"`
+	SmsOptOut  bool   `json:"sms_opt_out"`
+	Pin        string `json:"pin"`
+}
+
+// UpdatePatron replaces the details of a patron, an empty CardNumber or Pin keeps the current one.
+type UpdatePatron struct {
+	Id string `json:"id" binding:"required"`
+	CreatePatron
+}
+
+type PatronFilter struct {
+	Search string
+	Cursor int64
+	Limit  int
+}
+
+// PatronLoginRequest signs a patron in to the portal with the same card number and PIN as at the kiosks.
+type PatronLoginRequest struct {
+	CardNumber string `json:"card_number" binding:"required"`
+	Pin        string `json:"pin" binding:"required"`
+}
+
+// PatronLinkRequest asks for a sign-in link, sent to the email address on file for the card.
+type PatronLinkRequest struct {
+	CardNumber string `json:"card_number" binding:"required"`
+}
+
+// PatronLinkLogin signs a patron in with the token of a sign-in link, a token can be used once.
+type PatronLinkLogin struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// UpdatePatronContact is what patrons may change about themselves, the rest is kept by the library.
+type UpdatePatronContact struct {
+	Phone     string `json:"phone" binding:"required"`
+	Email     string `json:"email"`
+	SmsOptOut bool   `json:"sms_opt_out"`
+}
+
+// PatronLoan is a book a patron has on loan.
+// CanRenew tells whether RenewLoan would succeed now: the loan is not overdue, has renewals left and nobody holds the book.
+type PatronLoan struct {
+	Id         string `json:"id"`
+	BookId     string `json:"book_id"`
+	BookTitle  string `json:"book_title"`
+	BookAuthor string `json:"book_author"`
+	LoanedAt   string `json:"loaned_at"`
+	DueAt      string `json:"due_at"`
+	Renewals   int    `json:"renewals"`
+	IsOverdue  bool   `json:"is_overdue"`
+	CanRenew   bool   `json:"can_renew"`
+}
+
+// PatronHistory is a returned loan of a patron.
+type PatronHistory struct {
+	Id           string `json:"id"`
+	PaginationId int    `json:"pagination_id"`
+	BookId       string `json:"book_id"`
+	BookTitle    string `json:"book_title"`
+	BookAuthor   string `json:"book_author"`
+	LoanedAt     string `json:"loaned_at"`
+	DueAt        string `json:"due_at"`
+	ReturnedAt   string `json:"returned_at"`
+}
+
+type PatronHistoryFilter struct {
+	PatronId string
+	Cursor   int64
+	Limit    int
+}
+
+type RenewedLoan struct {
+	DueAt    string `json:"due_at"`
+	Renewals int    `json:"renewals"`
+}
@@ -0,0 +1,82 @@
+package types
+
+const (
+	ReportLoans        = "loans"
+	ReportTopTitles    = "top-titles"
+	ReportTopAuthors   = "top-authors"
+	ReportLoanDuration = "loan-duration"
+	ReportOnTime       = "on-time"
+	ReportEmployees    = "employees"
+	ReportIdleBooks    = "idle-books"
+)
+
+// ReportParams selects what a report covers, From and To are inclusive dates (YYYY-MM-DD).
+// Interval is only set for the loans report and Limit only for the reports listing books or authors.
+type ReportParams struct {
+	Name     string
+	From     string
+	To       string
+	Interval string
+	Limit    int
+}
+
+// Report is the result of a report as a table, the rows hold the values in the order of the columns.
+type Report struct {
+	Name        string          `json:"name"`
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Columns     []string        `json:"columns"`
+	Rows        [][]interface{} `json:"rows"`
+	GeneratedAt string          `json:"generated_at"`
+}
+
+// LoanPeriod is the number of loans made in the day, week (starting Monday) or month starting at Period.
+type LoanPeriod struct {
+	Period string
+	Loans  int
+}
+
+type TitleLoans struct {
+	BookId string
+	Title  string
+	Author string
+	Loans  int
+}
+
+type AuthorLoans struct {
+	AuthorId string
+	Name     string
+	Loans    int
+}
+
+// LoanDuration covers the loans returned within the range, the durations are in days.
+type LoanDuration struct {
+	Returned    int
+	AverageDays float64
+	MedianDays  float64
+}
+
+// ReturnRate covers the loans due within the range, loans that are not due yet are left out.
+type ReturnRate struct {
+	Due          int
+	OnTime       int
+	ReturnedLate int
+	Overdue      int
+}
+
+// HandlerLoans is the number of loans made by an employee or a self-checkout kiosk.
+type HandlerLoans struct {
+	Id    string
+	Name  string
+	Loans int
+}
+
+// IdleBook is a book that was not borrowed within the range, LastLoanedAt is empty if it was never borrowed.
+type IdleBook struct {
+	BookId       string
+	Title        string
+	Author       string
+	Barcode      string
+	CreatedAt    string
+	LastLoanedAt string
+}
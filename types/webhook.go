@@ -0,0 +1,90 @@
+package types
+
+const (
+	EventBookCreated     = "book.created"
+	EventBookUpdated     = "book.updated"
+	EventBookDeleted     = "book.deleted"
+	EventBookingCreated  = "booking.created"
+	EventBookingReturned = "booking.returned"
+	EventBookingRenewed  = "booking.renewed"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// EventRef is the data of an event, receivers fetch the current state of the resource through the API.
+type EventRef struct {
+	Id     string `json:"id"`
+	BookId string `json:"book_id,omitempty"`
+}
+
+type ListWebhook struct {
+	Id           string   `json:"id"`
+	PaginationId int      `json:"pagination_id"`
+	Url          string   `json:"url"`
+	Events       []string `json:"events"`
+	IsActive     bool     `json:"is_active"`
+	CreatedAt    string   `json:"created_at"`
+	UpdatedAt    string   `json:"updated_at"`
+	Version      int      `json:"version"`
+}
+
+type WebhookFilter struct {
+	Cursor int64
+	Limit  int
+}
+
+// CreateWebhook subscribes a URL to the given event types, a new subscription is active unless IsActive is false.
+type CreateWebhook struct {
+	Url      string   `json:"url" binding:"required"`
+	Events   []string `json:"events" binding:"required"`
+	IsActive *bool    `json:"is_active"`
+}
+
+type UpdateWebhook struct {
+	Id string `json:"id" binding:"required"`
+	CreateWebhook
+}
+
+// WebhookSecret is returned once when a subscription is created or its secret is rotated.
+type WebhookSecret struct {
+	Id     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+type ListDelivery struct {
+	Id             string `json:"id"`
+	PaginationId   int    `json:"pagination_id"`
+	WebhookId      string `json:"webhook_id"`
+	EventId        string `json:"event_id"`
+	Event          string `json:"event"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	ResponseStatus int    `json:"response_status,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"`
+	DeliveredAt    string `json:"delivered_at,omitempty"`
+	CreatedAt      string `json:"created_at"`
+}
+
+type DeliveryFilter struct {
+	WebhookId string
+	Status    string
+	Cursor    int64
+	Limit     int
+}
+
+// PendingDelivery is a delivery claimed by the deliverer, Attempts includes the current one.
+type PendingDelivery struct {
+	Id        string
+	WebhookId string
+	Url       string
+	Secret    string
+	EventId   string
+	Event     string
+	Payload   []byte
+	Attempts  int
+}
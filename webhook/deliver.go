@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// batchSize is how many deliveries are claimed at once.
+	batchSize = 50
+	// baseDelay is the wait before the second attempt, it doubles with every attempt up to maxDelay.
+	baseDelay = 30 * time.Second
+	maxDelay  = 6 * time.Hour
+)
+
+// Store is the part of the storage the deliverer needs.
+type Store interface {
+	ClaimDeliveries(limit int, lease time.Duration) ([]types.PendingDelivery, int, types.Err)
+	FinishDelivery(id *string, responseStatus int, failure string, retryIn time.Duration) (int, types.Err)
+}
+
+// Deliverer posts pending deliveries to their webhooks.
+// A claimed delivery is leased, so if the instance stops halfway it is tried again once the lease ends.
+type Deliverer struct {
+	store       Store
+	client      *http.Client
+	maxAttempts int
+}
+
+// NewDeliverer creates a deliverer, WEBHOOK_MAX_ATTEMPTS sets how often a delivery is tried
+// before it moves to the dead letters (8 by default, the last one about an hour after the first).
+// Returns the deliverer and an error if WEBHOOK_MAX_ATTEMPTS is invalid.
+func NewDeliverer(store Store) (*Deliverer, error) {
+	d := &Deliverer{store: store, client: &http.Client{Timeout: 15 * time.Second}, maxAttempts: 8}
+	if raw := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); raw != "" {
+		var err error
+		if d.maxAttempts, err = strconv.Atoi(raw); err != nil || d.maxAttempts < 1 {
+			return nil, errors.New("invalid WEBHOOK_MAX_ATTEMPTS")
+		}
+	}
+
+	return d, nil
+}
+
+// RunOnce sends every delivery that is due, batch by batch, until none is left or ctx is cancelled.
+// Returns the number of deliveries delivered and failed, and an error if the store cannot be queried.
+func (d *Deliverer) RunOnce(ctx context.Context) (int, int, error) {
+	delivered, failed := 0, 0
+	for ctx.Err() == nil {
+		// the lease outlasts the timeouts of a whole batch
+		deliveries, _, errResp := d.store.ClaimDeliveries(batchSize, time.Duration(batchSize)*d.client.Timeout)
+		if errResp.Error != "" {
+			return delivered, failed, errors.New(errResp.Error)
+		}
+		if len(deliveries) == 0 {
+			break
+		}
+
+		for i := range deliveries {
+			delivery := &deliveries[i]
+			if ctx.Err() != nil {
+				// left to the lease, it is claimed again once the lease ends
+				break
+			}
+
+			responseStatus, err := d.post(ctx, delivery)
+			failure, retryIn := "", time.Duration(0)
+			if err != nil {
+				failed++
+				failure = err.Error()
+				if delivery.Attempts < d.maxAttempts {
+					retryIn = Backoff(delivery.Attempts)
+				}
+			} else {
+				delivered++
+			}
+
+			_, errResp := d.store.FinishDelivery(&delivery.Id, responseStatus, failure, retryIn)
+			if errResp.Error != "" {
+				return delivered, failed, errors.New(errResp.Error)
+			}
+		}
+	}
+
+	return delivered, failed, nil
+}
+
+// post sends a delivery, any 2xx response counts as delivered.
+// Returns the response status, 0 if there was none, and an error if the delivery failed.
+func (d *Deliverer) post(ctx context.Context, delivery *types.PendingDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "library-management-webhooks")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderId, delivery.EventId)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.New("webhook responded with " + strconv.Itoa(resp.StatusCode))
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Backoff returns how long to wait after a failed attempt before the next one.
+// Parameters:
+// - attempt: the number of the attempt that failed, starting at 1.
+func Backoff(attempt int) time.Duration {
+	delay := baseDelay
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxDelay)
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/Tus1688/library-management-api/types"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidURL    = errors.New("url must be an absolute http or https url")
+	ErrMissingEvents = errors.New("at least one event type is required")
+	ErrUnknownEvent  = errors.New("unknown event type")
+)
+
+// Events are the event types a webhook can subscribe to.
+var Events = []string{types.EventBookCreated, types.EventBookUpdated, types.EventBookDeleted,
+	types.EventBookingCreated, types.EventBookingReturned, types.EventBookingRenewed}
+
+// Headers sent with every delivery. The signature is "sha256=" followed by the hex HMAC-SHA256 of
+// the timestamp, a dot and the body, keyed with the secret of the subscription.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderId        = "X-Webhook-Id"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// NormalizeSubscription validates a subscription, and sorts and deduplicates its event types.
+// Parameters:
+// - req: the subscription to normalize in place.
+// Returns ErrInvalidURL, ErrMissingEvents or ErrUnknownEvent if the subscription is invalid.
+func NormalizeSubscription(req *types.CreateWebhook) error {
+	req.Url = strings.TrimSpace(req.Url)
+	parsed, err := url.Parse(req.Url)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrInvalidURL
+	}
+
+	var events []string
+	for _, event := range req.Events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if !slices.Contains(Events, event) {
+			return ErrUnknownEvent
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		return ErrMissingEvents
+	}
+
+	slices.Sort(events)
+	req.Events = events
+	return nil
+}
+
+// NewSecret generates the signing secret of a subscription.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Sign computes the value of the signature header of a delivery.
+// Parameters:
+// - secret: the secret of the subscription.
+// - timestamp: the Unix time sent in the timestamp header.
+// - body: the payload.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether a signature header matches a delivery, receivers written in Go can use it as is.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
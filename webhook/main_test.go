@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"context"
+	"github.com/Tus1688/library-management-api/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNormalizeSubscription(t *testing.T) {
+	req := types.CreateWebhook{Url: " https://example.com/hooks ",
+		Events: []string{"booking.returned", " Book.Created", "booking.returned"}}
+	if err := NormalizeSubscription(&req); err != nil {
+		t.Fatalf("NormalizeSubscription returned an error: %v", err)
+	}
+	if req.Url != "https://example.com/hooks" ||
+		!slices.Equal(req.Events, []string{types.EventBookCreated, types.EventBookingReturned}) {
+		t.Errorf("NormalizeSubscription = %+v", req)
+	}
+
+	tests := []struct {
+		req  types.CreateWebhook
+		want error
+	}{
+		{types.CreateWebhook{Url: "ftp://example.com", Events: []string{types.EventBookCreated}}, ErrInvalidURL},
+		{types.CreateWebhook{Url: "/hooks", Events: []string{types.EventBookCreated}}, ErrInvalidURL},
+		{types.CreateWebhook{Url: "https://example.com"}, ErrMissingEvents},
+		{types.CreateWebhook{Url: "https://example.com", Events: []string{"book.burned"}}, ErrUnknownEvent},
+	}
+	for _, test := range tests {
+		if err := NormalizeSubscription(&test.req); err != test.want {
+			t.Errorf("NormalizeSubscription(%+v) = %v, want %v", test.req, err, test.want)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"book.created"}`)
+	signature := Sign("whsec_test", 1714730400, body)
+	// echo -n '1714730400.{"type":"book.created"}' | openssl dgst -sha256 -hmac whsec_test
+	if signature != "sha256=0c1bb0ac9e725345ada054df70d30932c8a22f4f201964ed531ddf22dc1acd3f" {
+		t.Errorf("Sign = %s", signature)
+	}
+
+	if Verify("whsec_other", 1714730400, body, signature) || Verify("whsec_test", 1714730401, body, signature) {
+		t.Error("Verify accepted a signature made with another secret or timestamp")
+	}
+
+	first, _ := NewSecret()
+	second, _ := NewSecret()
+	if first == second || len(first) < 40 {
+		t.Errorf("NewSecret = %q, %q", first, second)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 4: 4 * time.Minute,
+		20: 6 * time.Hour} {
+		if got := Backoff(attempt); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+type fakeStore struct {
+	pending  []types.PendingDelivery
+	finished map[string]finish
+}
+
+type finish struct {
+	status  int
+	failure string
+	retryIn time.Duration
+}
+
+func (f *fakeStore) ClaimDeliveries(limit int, _ time.Duration) ([]types.PendingDelivery, int, types.Err) {
+	n := min(limit, len(f.pending))
+	claimed := f.pending[:n]
+	f.pending = f.pending[n:]
+	return claimed, 200, types.Err{}
+}
+
+func (f *fakeStore) FinishDelivery(id *string, responseStatus int, failure string, retryIn time.Duration) (int,
+	types.Err) {
+	f.finished[*id] = finish{status: responseStatus, failure: failure, retryIn: retryIn}
+	return 200, types.Err{}
+}
+
+func TestDelivererRunOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if !Verify("whsec_test", timestamp, body, r.Header.Get(HeaderSignature)) ||
+			r.Header.Get(HeaderEvent) != types.EventBookCreated {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	delivery := func(id, path, secret string, attempts int) types.PendingDelivery {
+		return types.PendingDelivery{Id: id, Url: server.URL + path, Secret: secret, EventId: "e" + id,
+			Event: types.EventBookCreated, Payload: []byte(`{"type":"book.created"}`), Attempts: attempts}
+	}
+	store := &fakeStore{finished: map[string]finish{}, pending: []types.PendingDelivery{
+		delivery("ok", "/", "whsec_test", 1),
+		delivery("retry", "/down", "whsec_test", 2),
+		delivery("dead", "/down", "whsec_test", 3),
+		delivery("forged", "/", "whsec_other", 1),
+	}}
+	deliverer := &Deliverer{store: store, client: server.Client(), maxAttempts: 3}
+
+	delivered, failed, err := deliverer.RunOnce(context.Background())
+	if err != nil || delivered != 1 || failed != 3 {
+		t.Fatalf("RunOnce = %d delivered, %d failed, %v", delivered, failed, err)
+	}
+
+	want := map[string]finish{
+		"ok":     {status: 200},
+		"retry":  {status: 503, failure: "webhook responded with 503", retryIn: time.Minute},
+		"dead":   {status: 503, failure: "webhook responded with 503"},
+		"forged": {status: 401, failure: "webhook responded with 401", retryIn: 30 * time.Second},
+	}
+	for id, w := range want {
+		if got := store.finished[id]; got != w {
+			t.Errorf("delivery %s finished with %+v, want %+v", id, got, w)
+		}
+	}
+}